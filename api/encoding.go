@@ -0,0 +1,452 @@
+package api
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/YaleSpinup/apierror"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	listFormatJSON   = "json"
+	listFormatCSV    = "csv"
+	listFormatNDJSON = "ndjson"
+)
+
+// listOptions are the output options of a list request
+type listOptions struct {
+	// format is the encoding of the list, json, csv or ndjson
+	format string
+	// fields are the fields (or CSV columns) of each item to return, all fields if empty
+	fields []string
+	// all follows the next token through every page of the list
+	all bool
+	// full returns the full details of each item instead of the summary
+	full bool
+	// sort are the fields to sort the items by, descending for fields with a leading -
+	sort []string
+}
+
+// wantsYAML returns true if the request asks for a YAML response with the format parameter or the Accept header
+func wantsYAML(r *http.Request) bool {
+	if f := r.URL.Query().Get("format"); f != "" {
+		return strings.EqualFold(f, "yaml") || strings.EqualFold(f, "yml")
+	}
+
+	return strings.Contains(r.Header.Get("Accept"), "yaml")
+}
+
+// wantsCSV returns true if the request asks for a CSV response with the format parameter or the Accept header
+func wantsCSV(r *http.Request) bool {
+	if f := r.URL.Query().Get("format"); f != "" {
+		return strings.EqualFold(f, "csv")
+	}
+
+	return strings.Contains(r.Header.Get("Accept"), "text/csv")
+}
+
+// parseListOptions parses the format, fields, sort, all and detail parameters of a list request.  Without a format parameter
+// the format is negotiated with the Accept header.
+func parseListOptions(r *http.Request) (*listOptions, error) {
+	q := r.URL.Query()
+	opts := &listOptions{format: listFormatJSON}
+
+	switch f := strings.ToLower(q.Get("format")); f {
+	case "":
+		accept := r.Header.Get("Accept")
+		if strings.Contains(accept, "text/csv") {
+			opts.format = listFormatCSV
+		} else if strings.Contains(accept, "ndjson") {
+			opts.format = listFormatNDJSON
+		}
+	case listFormatJSON, listFormatCSV, listFormatNDJSON:
+		opts.format = f
+	default:
+		return nil, apierror.New(apierror.ErrBadRequest, fmt.Sprintf("invalid format %s, must be json, csv or ndjson", f), nil)
+	}
+
+	opts.fields = splitQueryList(q.Get("fields"))
+	opts.sort = splitQueryList(q.Get("sort"))
+
+	if a := q.Get("all"); a != "" {
+		all, err := strconv.ParseBool(a)
+		if err != nil {
+			return nil, apierror.New(apierror.ErrBadRequest, fmt.Sprintf("invalid all parameter %s", a), nil)
+		}
+		opts.all = all
+	}
+
+	switch d := strings.ToLower(q.Get("detail")); d {
+	case "", "summary":
+	case "full":
+		opts.full = true
+	default:
+		return nil, apierror.New(apierror.ErrBadRequest, fmt.Sprintf("invalid detail %s, must be summary or full", d), nil)
+	}
+
+	return opts, nil
+}
+
+// contentType returns the content type of a list in the format
+func (o *listOptions) contentType() string {
+	switch o.format {
+	case listFormatCSV:
+		return "text/csv"
+	case listFormatNDJSON:
+		return "application/x-ndjson"
+	default:
+		return "application/json"
+	}
+}
+
+// listItems returns the elements of a slice of list items
+func listItems(items interface{}) []interface{} {
+	v := reflect.ValueOf(items)
+	if v.Kind() != reflect.Slice {
+		return nil
+	}
+
+	out := make([]interface{}, v.Len())
+	for i := range out {
+		out[i] = v.Index(i).Interface()
+	}
+
+	return out
+}
+
+// listRecord flattens a list item into a map of its top level JSON fields
+func listRecord(item interface{}) (map[string]interface{}, error) {
+	j, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(j))
+	dec.UseNumber()
+
+	record := map[string]interface{}{}
+	if err := dec.Decode(&record); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+// listColumns returns the CSV columns of list items, the selected fields or else the JSON fields of the item type in
+// the order they're declared.  Items that are maps have their keys as columns, sorted with the id first.
+func listColumns(items interface{}, fields []string) []string {
+	if len(fields) > 0 {
+		return fields
+	}
+
+	t := reflect.TypeOf(items)
+	if t == nil || t.Kind() != reflect.Slice {
+		return nil
+	}
+
+	t = t.Elem()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() == reflect.Struct {
+		return jsonFields(t)
+	}
+
+	keys := map[string]bool{}
+	for _, item := range listItems(items) {
+		v := reflect.ValueOf(item)
+		if v.Kind() != reflect.Map {
+			continue
+		}
+
+		for _, k := range v.MapKeys() {
+			keys[fmt.Sprint(k.Interface())] = true
+		}
+	}
+
+	columns := make([]string, 0, len(keys))
+	for k := range keys {
+		columns = append(columns, k)
+	}
+
+	sort.Slice(columns, func(i, j int) bool {
+		if columns[i] == "id" || columns[j] == "id" {
+			return columns[i] == "id"
+		}
+		return columns[i] < columns[j]
+	})
+
+	return columns
+}
+
+// jsonFields returns the JSON field names of a struct type, including the fields of embedded structs
+func jsonFields(t reflect.Type) []string {
+	fields := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+
+			if ft.Kind() == reflect.Struct {
+				fields = append(fields, jsonFields(ft)...)
+				continue
+			}
+		}
+
+		if !f.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, name)
+	}
+
+	return fields
+}
+
+// csvValue formats a field of a list record as a CSV value, nested objects and lists are written as compact JSON
+func csvValue(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case json.Number:
+		return value.String()
+	case bool:
+		return strconv.FormatBool(value)
+	}
+
+	j, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(j)
+}
+
+// selectedFields is a list record with only the selected fields, encoded as JSON in the order they're selected
+type selectedFields struct {
+	record map[string]interface{}
+	fields []string
+}
+
+func (s selectedFields) MarshalJSON() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteByte('{')
+	n := 0
+	for _, f := range s.fields {
+		v, ok := s.record[f]
+		if !ok {
+			continue
+		}
+
+		k, err := json.Marshal(f)
+		if err != nil {
+			return nil, err
+		}
+
+		j, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+
+		if n > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(j)
+		n++
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// encodeList encodes a list of items in the format of the list options
+func encodeList(w io.Writer, opts *listOptions, items interface{}) error {
+	enc := newListEncoder(w, opts)
+	if err := enc.begin(); err != nil {
+		return err
+	}
+
+	if err := enc.encode(items); err != nil {
+		return err
+	}
+
+	return enc.end()
+}
+
+// listEncoder encodes the items of a list, one page at a time, as a JSON array, newline delimited JSON or CSV
+type listEncoder struct {
+	w       io.Writer
+	opts    *listOptions
+	csv     *csv.Writer
+	columns []string
+	items   int
+}
+
+func newListEncoder(w io.Writer, opts *listOptions) *listEncoder {
+	e := &listEncoder{w: w, opts: opts}
+	if opts.format == listFormatCSV {
+		e.csv = csv.NewWriter(w)
+	}
+	return e
+}
+
+// begin starts the list
+func (e *listEncoder) begin() error {
+	if e.opts.format == listFormatJSON {
+		_, err := io.WriteString(e.w, "[")
+		return err
+	}
+	return nil
+}
+
+// encode writes a page of list items, a CSV header is written with the columns of the first page
+func (e *listEncoder) encode(items interface{}) error {
+	if e.csv != nil && e.columns == nil {
+		e.columns = listColumns(items, e.opts.fields)
+		if len(e.columns) > 0 {
+			if err := e.csv.Write(e.columns); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, item := range listItems(items) {
+		if err := e.encodeItem(item); err != nil {
+			return err
+		}
+		e.items++
+	}
+
+	if e.csv != nil {
+		e.csv.Flush()
+		return e.csv.Error()
+	}
+
+	return nil
+}
+
+func (e *listEncoder) encodeItem(item interface{}) error {
+	if e.csv == nil && len(e.opts.fields) == 0 {
+		return e.writeJSON(item)
+	}
+
+	record, err := listRecord(item)
+	if err != nil {
+		return err
+	}
+
+	if e.csv == nil {
+		return e.writeJSON(selectedFields{record: record, fields: e.opts.fields})
+	}
+
+	row := make([]string, len(e.columns))
+	for i, c := range e.columns {
+		row[i] = csvValue(record[c])
+	}
+
+	return e.csv.Write(row)
+}
+
+func (e *listEncoder) writeJSON(item interface{}) error {
+	j, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case e.opts.format == listFormatNDJSON:
+		j = append(j, '\n')
+	case e.items > 0:
+		j = append([]byte{','}, j...)
+	}
+
+	_, err = e.w.Write(j)
+	return err
+}
+
+// end finishes the list
+func (e *listEncoder) end() error {
+	if e.opts.format == listFormatJSON {
+		_, err := io.WriteString(e.w, "]")
+		return err
+	}
+	return nil
+}
+
+// decodeBody decodes a JSON or, based on the Content-Type, YAML request body
+func decodeBody(r *http.Request, v interface{}) error {
+	if !strings.Contains(r.Header.Get("Content-Type"), "yaml") {
+		return json.NewDecoder(r.Body).Decode(v)
+	}
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+
+	return unmarshalYAML(data, v)
+}
+
+// marshalYAML converts a value to YAML with the field names and order of its JSON representation
+func marshalYAML(v interface{}) ([]byte, error) {
+	j, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	// JSON is YAML, parse it into a node to keep the field order and reset the flow style it's parsed with
+	var node yaml.Node
+	if err := yaml.Unmarshal(j, &node); err != nil {
+		return nil, err
+	}
+	resetYAMLStyle(&node)
+
+	return yaml.Marshal(&node)
+}
+
+// unmarshalYAML decodes YAML into a value using the field names of its JSON representation
+func unmarshalYAML(data []byte, v interface{}) error {
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	j, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(j, v)
+}
+
+func resetYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, n := range node.Content {
+		resetYAMLStyle(n)
+	}
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func Test_parseListOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		accept  string
+		want    *listOptions
+		wantErr bool
+	}{
+		{name: "defaults", url: "/instances", want: &listOptions{format: listFormatJSON}},
+		{name: "csv accept header", url: "/instances", accept: "text/csv", want: &listOptions{format: listFormatCSV}},
+		{name: "ndjson accept header", url: "/instances", accept: "application/x-ndjson", want: &listOptions{format: listFormatNDJSON}},
+		{name: "format overrides accept header", url: "/instances?format=JSON", accept: "text/csv", want: &listOptions{format: listFormatJSON}},
+		{
+			name: "all options",
+			url:  "/instances?format=csv&fields=id,%20state,,type&all=true&detail=full",
+			want: &listOptions{format: listFormatCSV, fields: []string{"id", "state", "type"}, all: true, full: true},
+		},
+		{name: "summary detail", url: "/instances?detail=summary&all=0", want: &listOptions{format: listFormatJSON}},
+		{name: "invalid format", url: "/instances?format=xml", wantErr: true},
+		{name: "invalid all", url: "/instances?all=maybe", wantErr: true},
+		{name: "invalid detail", url: "/instances?detail=some", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", tt.url, nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+
+			got, err := parseListOptions(r)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseListOptions() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseListOptions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_listColumns(t *testing.T) {
+	type embedded struct {
+		Count int `json:"count"`
+	}
+
+	type item struct {
+		embedded
+		Id       string `json:"id"`
+		Name     string `json:"name,omitempty"`
+		Internal string `json:"-"`
+		Other    string
+		private  string
+	}
+
+	tests := []struct {
+		name   string
+		items  interface{}
+		fields []string
+		want   []string
+	}{
+		{name: "struct fields in order", items: []*item{}, want: []string{"count", "id", "name", "Other"}},
+		{
+			name:  "map keys with id first",
+			items: []map[string]*string{{"state": aws.String("running"), "id": aws.String("i-1")}, {"name": aws.String("web")}},
+			want:  []string{"id", "name", "state"},
+		},
+		{name: "selected fields", items: []*item{}, fields: []string{"name", "id"}, want: []string{"name", "id"}},
+		{name: "not a list", items: "foo", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := listColumns(tt.items, tt.fields); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("listColumns() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_encodeList(t *testing.T) {
+	type item struct {
+		Id    string            `json:"id"`
+		Size  int64             `json:"size"`
+		Tags  map[string]string `json:"tags,omitempty"`
+		Ready *bool             `json:"ready,omitempty"`
+	}
+
+	items := []*item{
+		{Id: "vol-1", Size: 100, Tags: map[string]string{"Name": "data"}, Ready: aws.Bool(true)},
+		{Id: "vol-2", Size: 8},
+	}
+
+	tests := []struct {
+		name string
+		opts *listOptions
+		want string
+	}{
+		{
+			name: "json",
+			opts: &listOptions{format: listFormatJSON},
+			want: `[{"id":"vol-1","size":100,"tags":{"Name":"data"},"ready":true},{"id":"vol-2","size":8}]`,
+		},
+		{
+			name: "json fields",
+			opts: &listOptions{format: listFormatJSON, fields: []string{"size", "id", "ready"}},
+			want: `[{"size":100,"id":"vol-1","ready":true},{"size":8,"id":"vol-2"}]`,
+		},
+		{
+			name: "ndjson",
+			opts: &listOptions{format: listFormatNDJSON, fields: []string{"id"}},
+			want: "{\"id\":\"vol-1\"}\n{\"id\":\"vol-2\"}\n",
+		},
+		{
+			name: "csv",
+			opts: &listOptions{format: listFormatCSV},
+			want: "id,size,tags,ready\nvol-1,100,\"{\"\"Name\"\":\"\"data\"\"}\",true\nvol-2,8,,\n",
+		},
+		{
+			name: "csv fields",
+			opts: &listOptions{format: listFormatCSV, fields: []string{"id", "missing"}},
+			want: "id,missing\nvol-1,\nvol-2,\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			if err := encodeList(buf, tt.opts, items); err != nil {
+				t.Fatalf("encodeList() error = %v", err)
+			}
+
+			if got := buf.String(); got != tt.want {
+				t.Errorf("encodeList() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -17,10 +17,18 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package api
 
 import (
+	"bytes"
+	"context"
+	"encoding/csv"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"reflect"
+	"strconv"
 
 	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/ec2-api/common"
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
@@ -70,6 +78,174 @@ func handleResponseOk(w http.ResponseWriter, response interface{}) {
 	w.Write(j)
 }
 
+// handleResponseYAML handles a success response encoded as YAML
+func handleResponseYAML(w http.ResponseWriter, response interface{}) {
+	y, err := marshalYAML(response)
+	if err != nil {
+		log.Errorf("cannot marshal response (%v) into YAML: %s", response, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/yaml")
+	w.WriteHeader(http.StatusOK)
+	w.Write(y)
+}
+
+// handleResponseCSV handles a success response of CSV records, downloaded as the given file name
+func handleResponseCSV(w http.ResponseWriter, records [][]string, filename string) {
+	buf := &bytes.Buffer{}
+	if err := csv.NewWriter(buf).WriteAll(records); err != nil {
+		log.Errorf("cannot write response into CSV: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// listPageFunc returns a page of list items starting at the next token, and the token of the following page
+type listPageFunc func(ctx context.Context, next *string) (interface{}, *string, error)
+
+// listErrorTrailer is the trailer set when listing fails after the response has started streaming
+const listErrorTrailer = "X-List-Error"
+
+// handleListResponse handles a success response of list items in the format of the list options
+func handleListResponse(w http.ResponseWriter, opts *listOptions, items interface{}) {
+	items, err := sortListItems(items, opts.sort)
+	if err != nil {
+		log.Errorf("cannot sort list response: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("X-Items", strconv.Itoa(len(listItems(items))))
+
+	if opts.format == listFormatJSON && len(opts.fields) == 0 {
+		handleResponseOk(w, items)
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	if err := encodeList(buf, opts, items); err != nil {
+		log.Errorf("cannot encode list response as %s: %s", opts.format, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", opts.contentType())
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// handlePagedListResponse handles a success response of a paged list.  A single page is returned with the X-Per-Page
+// and X-Next-Token headers unless the list options ask for all items, then every page is fetched and streamed to the
+// client as it's listed.  An error listing a page after the first is reported in the X-List-Error trailer.  All items
+// sorted together are collected before they're returned.
+func handlePagedListResponse(w http.ResponseWriter, r *http.Request, opts *listOptions, perPage int, next *string, page listPageFunc) {
+	items, next, err := page(r.Context(), next)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if opts.all && len(opts.sort) > 0 {
+		// sorting needs every item, so the pages are collected instead of streamed
+		all := reflect.ValueOf(items)
+		for next != nil {
+			items, next, err = page(r.Context(), next)
+			if err != nil {
+				handleError(w, err)
+				return
+			}
+			all = reflect.AppendSlice(all, reflect.ValueOf(items))
+		}
+
+		handleListResponse(w, opts, all.Interface())
+		return
+	}
+
+	if !opts.all {
+		if next != nil {
+			w.Header().Set("X-Per-Page", strconv.Itoa(perPage))
+			w.Header().Set("X-Next-Token", aws.StringValue(next))
+		}
+
+		handleListResponse(w, opts, items)
+		return
+	}
+
+	w.Header().Set("Content-Type", opts.contentType())
+	w.Header().Set("Trailer", listErrorTrailer)
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	enc := newListEncoder(w, opts)
+	if err := enc.begin(); err != nil {
+		log.Errorf("cannot stream list response: %s", err)
+		return
+	}
+
+	for {
+		if err := enc.encode(items); err != nil {
+			log.Errorf("cannot stream list response: %s", err)
+			return
+		}
+
+		if err := rc.Flush(); err != nil {
+			log.Debugf("cannot flush list response: %s", err)
+		}
+
+		if next == nil {
+			break
+		}
+
+		log.Debugf("streamed %d list items, listing the next page", enc.items)
+
+		items, next, err = page(r.Context(), next)
+		if err != nil {
+			log.Errorf("failed listing the next page after %d items: %s", enc.items, err)
+			w.Header().Set(listErrorTrailer, err.Error())
+			break
+		}
+	}
+
+	if err := enc.end(); err != nil {
+		log.Errorf("cannot stream list response: %s", err)
+	}
+}
+
+// handleResponseConflict handles a conflict response with a structured body describing the conflict
+func handleResponseConflict(w http.ResponseWriter, response interface{}) {
+	j, err := json.Marshal(response)
+	if err != nil {
+		log.Errorf("cannot marshal response (%v) into JSON: %s", response, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	w.Write(j)
+}
+
+// handleResponseAccepted handles an accepted response for a change that hasn't finished, with a body describing it
+func handleResponseAccepted(w http.ResponseWriter, response interface{}) {
+	j, err := json.Marshal(response)
+	if err != nil {
+		log.Errorf("cannot marshal response (%v) into JSON: %s", response, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	w.Write(j)
+}
+
 // handleError handles standard apierror return codes
 func handleError(w http.ResponseWriter, err error) {
 	log.Error(err.Error())
@@ -85,6 +261,8 @@ func handleError(w http.ResponseWriter, err error) {
 			w.WriteHeader(http.StatusBadRequest)
 		case apierror.ErrLimitExceeded:
 			w.WriteHeader(http.StatusTooManyRequests)
+		case common.ErrTimeout:
+			w.WriteHeader(http.StatusGatewayTimeout)
 		default:
 			w.WriteHeader(http.StatusInternalServerError)
 		}
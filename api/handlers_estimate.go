@@ -0,0 +1,29 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/YaleSpinup/apierror"
+)
+
+// EstimateHandler estimates the monthly cost of an instance and volumes before creating them
+func (s *server) EstimateHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+
+	req := &Ec2EstimateRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		msg := fmt.Sprintf("cannot decode body into estimate input: %s", err)
+		handleError(w, apierror.New(apierror.ErrBadRequest, msg, err))
+		return
+	}
+
+	out, err := estimateCost(s.prices, s.pricesRegion, req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	handleResponseOk(w, out)
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+// eventStreamKeepAlive is how often a comment is sent to keep idle event streams open
+const eventStreamKeepAlive = 15 * time.Second
+
+// EventStreamHandler streams the changes of the resources in the org as server-sent events
+func (s *server) EventStreamHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	account := s.mapAccountNumber(vars["account"])
+
+	var types map[string]bool
+	if t := r.URL.Query().Get("types"); t != "" {
+		types = map[string]bool{}
+		for _, eventType := range strings.Split(t, ",") {
+			types[strings.TrimSpace(eventType)] = true
+		}
+	}
+
+	// fail before streaming if the role in the account can't be assumed
+	if _, err := s.newEc2Orchestrator(r.Context(), &sessionParams{
+		role: fmt.Sprintf("arn:aws:iam::%s:role/%s", account, s.session.RoleName),
+		policyArns: []string{
+			"arn:aws:iam::aws:policy/AmazonEC2ReadOnlyAccess",
+		},
+	}); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	// the stream stays open until the client goes away, beyond the write timeout of the server
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		log.Warnf("failed to clear the write deadline of the event stream, clients will have to reconnect: %s", err)
+	}
+
+	events, unsubscribe := s.eventBroker.subscribe(account)
+	defer unsubscribe()
+
+	log.Infof("subscribed to the event stream of account %s", account)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, ": subscribed\n\n")
+	rc.Flush()
+
+	keepAlive := time.NewTicker(eventStreamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		var err error
+		select {
+		case <-r.Context().Done():
+			log.Infof("unsubscribed from the event stream of account %s", account)
+			return
+		case e := <-events:
+			if types != nil && !types[e.Type] {
+				continue
+			}
+			err = writeServerSentEvent(w, e)
+		case <-keepAlive.C:
+			_, err = io.WriteString(w, ": keepalive\n\n")
+		}
+
+		if err == nil {
+			err = rc.Flush()
+		}
+
+		if err != nil {
+			log.Warnf("closing the event stream of account %s: %s", account, err)
+			return
+		}
+	}
+}
+
+// writeServerSentEvent writes a resource event in the server-sent events format
+func writeServerSentEvent(w io.Writer, e *Ec2ResourceEvent) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.Id, e.Type, data)
+	return err
+}
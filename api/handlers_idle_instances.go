@@ -0,0 +1,44 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// IdleInstancesHandler reports the running instances with cpu and network use under the idle thresholds
+func (s *server) IdleInstancesHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	account := s.mapAccountNumber(vars["account"])
+	q := r.URL.Query()
+
+	settings, err := parseIdleSettings(s.idleDetector.settings, q.Get("window"), q.Get("cpu"), q.Get("network"))
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	orch, err := s.newMetricsOrchestrator(r.Context(), &sessionParams{
+		role: fmt.Sprintf("arn:aws:iam::%s:role/%s", account, s.session.RoleName),
+		policyArns: []string{
+			"arn:aws:iam::aws:policy/AmazonEC2ReadOnlyAccess",
+			"arn:aws:iam::aws:policy/CloudWatchReadOnlyAccess",
+		},
+	})
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	out, err := orch.idleInstanceReport(r.Context(), s.idleDetector, settings)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	w.Header().Set("X-Items", strconv.Itoa(len(out.Instances)))
+	handleResponseOk(w, out)
+}
@@ -1,10 +1,10 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"strconv"
 
 	"github.com/YaleSpinup/apierror"
 	"github.com/YaleSpinup/ec2-api/ec2"
@@ -18,6 +18,24 @@ func (s *server) ImageListHandler(w http.ResponseWriter, r *http.Request) {
 	account := s.mapAccountNumber(vars["account"])
 	name := vars["name"]
 
+	opts, err := parseListOptions(r)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	filter, filters, err := parseListFilters(r, "image")
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	perPage, pageToken, err := parsePagination(r, 0)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
 	role := fmt.Sprintf("arn:aws:iam::%s:role/%s", account, s.session.RoleName)
 
 	session, err := s.assumeRole(
@@ -39,15 +57,24 @@ func (s *server) ImageListHandler(w http.ResponseWriter, r *http.Request) {
 	)
 
 	// TODO only return images from our org, current EC2-API returns all (needed for managed)
-	out, err := service.ListImages(r.Context(), "", name)
-	if err != nil {
-		handleError(w, err)
-		return
-	}
-
-	w.Header().Set("X-Items", strconv.Itoa(len(out)))
-
-	handleResponseOk(w, out)
+	handlePagedListResponse(w, r, opts, perPage, pageToken, func(ctx context.Context, next *string) (interface{}, *string, error) {
+		out, next, err := service.ListImagesPage(ctx, "", name, int64(perPage), next, filters...)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		list := []map[string]*string{}
+		for _, i := range out {
+			if filter.Created(parseImageTime(i.CreationDate)) {
+				list = append(list, map[string]*string{
+					"id":   i.ImageId,
+					"name": i.Name,
+				})
+			}
+		}
+
+		return list, next, nil
+	})
 }
 
 func (s *server) ImageGetHandler(w http.ResponseWriter, r *http.Request) {
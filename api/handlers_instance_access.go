@@ -0,0 +1,142 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/gorilla/mux"
+)
+
+// InstanceConnectHandler pushes a short-lived ssh public key to an instance with ec2 instance connect
+func (s *server) InstanceConnectHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	account := s.mapAccountNumber(vars["account"])
+	id := vars["id"]
+
+	req := &Ec2InstanceConnectRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		msg := fmt.Sprintf("cannot decode body into instance connect input: %s", err)
+		handleError(w, apierror.New(apierror.ErrBadRequest, msg, err))
+		return
+	}
+
+	policy, err := generatePolicy([]string{"ec2-instance-connect:SendSSHPublicKey"})
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	orch, err := s.newInstanceAccessOrchestrator(r.Context(), &sessionParams{
+		role:         fmt.Sprintf("arn:aws:iam::%s:role/%s", account, s.session.RoleName),
+		inlinePolicy: policy,
+		policyArns: []string{
+			"arn:aws:iam::aws:policy/AmazonEC2ReadOnlyAccess",
+		},
+	})
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	out, err := orch.sendSSHPublicKey(r.Context(), id, req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	handleResponseOk(w, out)
+}
+
+// InstanceSessionStartHandler starts an ssm session manager session on an instance
+func (s *server) InstanceSessionStartHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	account := s.mapAccountNumber(vars["account"])
+	id := vars["id"]
+
+	req := &Ec2InstanceSessionRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		msg := fmt.Sprintf("cannot decode body into start session input: %s", err)
+		handleError(w, apierror.New(apierror.ErrBadRequest, msg, err))
+		return
+	}
+
+	policy, err := instanceSessionPolicy(id)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	orch, err := s.newInstanceAccessOrchestrator(r.Context(), &sessionParams{
+		role:         fmt.Sprintf("arn:aws:iam::%s:role/%s", account, s.session.RoleName),
+		inlinePolicy: policy,
+		policyArns: []string{
+			"arn:aws:iam::aws:policy/AmazonEC2ReadOnlyAccess",
+			"arn:aws:iam::aws:policy/AmazonSSMReadOnlyAccess",
+		},
+	})
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	out, err := orch.startSession(r.Context(), id, req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	handleResponseOk(w, out)
+}
+
+// InstanceSessionTerminateHandler terminates an ssm session manager session on an instance
+func (s *server) InstanceSessionTerminateHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	account := s.mapAccountNumber(vars["account"])
+	id := vars["id"]
+	sessionId := vars["sid"]
+
+	requestedBy := r.URL.Query().Get("requested_by")
+	if requestedBy == "" {
+		handleError(w, apierror.New(apierror.ErrBadRequest, "requested_by is required", nil))
+		return
+	}
+
+	policy, err := generatePolicy([]string{"ssm:DescribeSessions", "ssm:TerminateSession"})
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	orch, err := s.newInstanceAccessOrchestrator(r.Context(), &sessionParams{
+		role:         fmt.Sprintf("arn:aws:iam::%s:role/%s", account, s.session.RoleName),
+		inlinePolicy: policy,
+	})
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if err := orch.terminateSession(r.Context(), id, sessionId, requestedBy, requestSource(r)); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	handleResponseOk(w, nil)
+}
+
+// requestSource returns the address a request came from, the first address in the X-Forwarded-For header when the
+// api is behind a load balancer or else the remote address of the connection
+func requestSource(r *http.Request) string {
+	if f := r.Header.Get("X-Forwarded-For"); f != "" {
+		addr, _, _ := strings.Cut(f, ",")
+		return strings.TrimSpace(addr)
+	}
+
+	return r.RemoteAddr
+}
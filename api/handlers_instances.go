@@ -1,12 +1,14 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	log "github.com/sirupsen/logrus"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/YaleSpinup/apierror"
 	"github.com/YaleSpinup/ec2-api/ec2"
@@ -52,6 +54,13 @@ func (s *server) InstanceCreateHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	start := time.Now()
+	wait, timeout, err := parseInstanceWait(r.URL.Query())
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
 	policy, err := instanceCreatePolicy()
 	if err != nil {
 		handleError(w, err)
@@ -76,6 +85,25 @@ func (s *server) InstanceCreateHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if wait {
+		instance, err := orch.waitForInstanceState(r.Context(), out, "running", remainingInstanceWaitTimeout(start, timeout))
+		if err != nil {
+			// the instance was created, so the id is returned for the client to wait again instead of creating another
+			log.Warnf("created instance %s but failed waiting for it to be running: %s", out, err)
+
+			msg := err.Error()
+			if aerr, ok := err.(apierror.Error); ok {
+				msg = aerr.Message
+			}
+
+			handleResponseAccepted(w, &Ec2InstanceCreatePendingResponse{ID: out, Message: msg})
+			return
+		}
+
+		handleResponseOk(w, instance)
+		return
+	}
+
 	handleResponseOk(w, out)
 }
 
@@ -85,6 +113,13 @@ func (s *server) InstanceDeleteHandler(w http.ResponseWriter, r *http.Request) {
 	account := s.mapAccountNumber(vars["account"])
 	id := vars["id"]
 
+	start := time.Now()
+	wait, timeout, err := parseInstanceWait(r.URL.Query())
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
 	policy, err := instanceDeletePolicy(id)
 	if err != nil {
 		handleError(w, apierror.New(apierror.ErrInternalError, "failed to generate policy", err))
@@ -108,6 +143,17 @@ func (s *server) InstanceDeleteHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if wait {
+		instance, err := orch.waitForInstanceState(r.Context(), id, "terminated", remainingInstanceWaitTimeout(start, timeout))
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+
+		handleResponseOk(w, instance)
+		return
+	}
+
 	handleResponseOk(w, nil)
 }
 
@@ -116,6 +162,29 @@ func (s *server) InstanceListHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	account := s.mapAccountNumber(vars["account"])
 
+	opts, err := parseListOptions(r)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if err := opts.useDetail(instanceListFields, &Ec2InstanceResponse{}); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	filter, filters, err := parseListFilters(r, "instance")
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	perPage, pageToken, err := parsePagination(r, 500)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
 	session, err := s.assumeRole(
 		r.Context(),
 		s.session.ExternalID,
@@ -129,43 +198,37 @@ func (s *server) InstanceListHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	perPage := 500
-	var pageToken *string
-	for name, values := range r.URL.Query() {
-		if name == "next" {
-			pageToken = aws.String(values[0])
-		}
-
-		if name == "limit" {
-			limit, err := strconv.Atoi(values[0])
-			if err != nil {
-				handleError(w, fmt.Errorf("failed to parse limit parameter: %s", err))
-				return
-			}
-			perPage = limit
-		}
-	}
-
 	service := ec2.New(
 		ec2.WithSession(session.Session),
 		ec2.WithOrg(s.org),
 	)
 
 	// TODO an api should be for one org, currently we need to support the entire account
-	out, next, err := service.ListInstances(r.Context(), "", int64(perPage), pageToken)
-	// out, next, err := service.ListInstances(r.Context(), s.org, int64(perPage), pageToken)
-	if err != nil {
-		handleError(w, err)
-		return
-	}
+	handlePagedListResponse(w, r, opts, perPage, pageToken, func(ctx context.Context, next *string) (interface{}, *string, error) {
+		out, next, err := service.ListInstancesPage(ctx, "", int64(perPage), next, filters...)
+		if err != nil {
+			return nil, nil, err
+		}
 
-	w.Header().Set("X-Items", strconv.Itoa(len(out)))
-	if next != nil {
-		w.Header().Set("X-Per-Page", strconv.Itoa(perPage))
-		w.Header().Set("X-Next-Token", aws.StringValue(next))
-	}
+		if opts.full {
+			list := []*Ec2InstanceResponse{}
+			for _, i := range out {
+				if filter.Created(instanceCreateTime(i)) {
+					list = append(list, toEc2InstanceResponse(i))
+				}
+			}
+			return list, next, nil
+		}
 
-	handleResponseOk(w, out)
+		list := []map[string]*string{}
+		for _, i := range out {
+			if filter.Created(instanceCreateTime(i)) {
+				list = append(list, toEc2InstanceListItem(i))
+			}
+		}
+
+		return list, next, nil
+	})
 }
 
 func GetQueryArrayValues(r *http.Request, key string) ([]string, error) {
@@ -449,6 +512,21 @@ func (s *server) InstanceStateHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	start := time.Now()
+	wait, timeout, err := parseInstanceWait(r.URL.Query())
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	var target string
+	if wait {
+		if target, err = powerStateTarget(req.State); err != nil {
+			handleError(w, err)
+			return
+		}
+	}
+
 	policy, err := changeInstanceStatePolicy()
 	if err != nil {
 		handleError(w, err)
@@ -472,6 +550,17 @@ func (s *server) InstanceStateHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if wait {
+		instance, err := orch.waitForInstanceState(r.Context(), id, target, remainingInstanceWaitTimeout(start, timeout))
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+
+		handleResponseOk(w, instance)
+		return
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -846,3 +935,128 @@ func (s *server) InstanceProfileGetHandler(w http.ResponseWriter, r *http.Reques
 
 	handleResponseOk(w, ip)
 }
+
+// InstanceConsoleHandler gets the decoded system console output of an instance
+func (s *server) InstanceConsoleHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	account := s.mapAccountNumber(vars["account"])
+	id := vars["id"]
+
+	latest := false
+	if l := r.URL.Query().Get("latest"); l != "" {
+		b, err := strconv.ParseBool(l)
+		if err != nil {
+			handleError(w, apierror.New(apierror.ErrBadRequest, "invalid value for latest", err))
+			return
+		}
+		latest = b
+	}
+
+	policy, err := generatePolicy([]string{"ec2:GetConsoleOutput"})
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	orch, err := s.newEc2Orchestrator(r.Context(), &sessionParams{
+		role:         fmt.Sprintf("arn:aws:iam::%s:role/%s", account, s.session.RoleName),
+		inlinePolicy: policy,
+	})
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	out, err := orch.instanceConsoleOutput(r.Context(), id, latest)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	handleResponseOk(w, out)
+}
+
+// InstanceScreenshotHandler returns a jpeg screenshot of the console of an instance
+func (s *server) InstanceScreenshotHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	account := s.mapAccountNumber(vars["account"])
+	id := vars["id"]
+
+	wakeUp := false
+	if wu := r.URL.Query().Get("wakeup"); wu != "" {
+		b, err := strconv.ParseBool(wu)
+		if err != nil {
+			handleError(w, apierror.New(apierror.ErrBadRequest, "invalid value for wakeup", err))
+			return
+		}
+		wakeUp = b
+	}
+
+	policy, err := generatePolicy([]string{"ec2:GetConsoleScreenshot"})
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	orch, err := s.newEc2Orchestrator(r.Context(), &sessionParams{
+		role:         fmt.Sprintf("arn:aws:iam::%s:role/%s", account, s.session.RoleName),
+		inlinePolicy: policy,
+	})
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	out, err := orch.instanceConsoleScreenshot(r.Context(), id, wakeUp)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/jpeg")
+	w.Header().Set("Content-Length", strconv.Itoa(len(out)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
+
+// InstanceWaitHandler waits until an instance is running, stopped or terminated and returns the instance
+func (s *server) InstanceWaitHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	account := s.mapAccountNumber(vars["account"])
+	id := vars["id"]
+
+	start := time.Now()
+	state := r.URL.Query().Get("state")
+	if state == "" {
+		handleError(w, apierror.New(apierror.ErrBadRequest, "missing required parameter: state", nil))
+		return
+	}
+
+	timeout, err := parseInstanceWaitTimeout(r.URL.Query().Get("timeout"))
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	orch, err := s.newEc2Orchestrator(r.Context(), &sessionParams{
+		role: fmt.Sprintf("arn:aws:iam::%s:role/%s", account, s.session.RoleName),
+		policyArns: []string{
+			"arn:aws:iam::aws:policy/AmazonEC2ReadOnlyAccess",
+		},
+	})
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	out, err := orch.waitForInstanceState(r.Context(), id, state, remainingInstanceWaitTimeout(start, timeout))
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	handleResponseOk(w, out)
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// InstanceMetricsHandler gets the cloudwatch metrics of an instance
+func (s *server) InstanceMetricsHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	account := s.mapAccountNumber(vars["account"])
+	id := vars["id"]
+	q := r.URL.Query()
+
+	req, err := parseMetricsRequest(instanceMetricResource, q["metric"], q.Get("stat"), q.Get("period"), q.Get("start"), q.Get("end"), time.Now())
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	orch, err := s.newMetricsOrchestrator(r.Context(), &sessionParams{
+		role: fmt.Sprintf("arn:aws:iam::%s:role/%s", account, s.session.RoleName),
+		policyArns: []string{
+			"arn:aws:iam::aws:policy/AmazonEC2ReadOnlyAccess",
+			"arn:aws:iam::aws:policy/CloudWatchReadOnlyAccess",
+		},
+	})
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	out, err := orch.instanceMetrics(r.Context(), id, req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	handleResponseOk(w, out)
+}
+
+// VolumeMetricsHandler gets the cloudwatch metrics of a volume
+func (s *server) VolumeMetricsHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	account := s.mapAccountNumber(vars["account"])
+	id := vars["id"]
+	q := r.URL.Query()
+
+	req, err := parseMetricsRequest(volumeMetricResource, q["metric"], q.Get("stat"), q.Get("period"), q.Get("start"), q.Get("end"), time.Now())
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	orch, err := s.newMetricsOrchestrator(r.Context(), &sessionParams{
+		role: fmt.Sprintf("arn:aws:iam::%s:role/%s", account, s.session.RoleName),
+		policyArns: []string{
+			"arn:aws:iam::aws:policy/AmazonEC2ReadOnlyAccess",
+			"arn:aws:iam::aws:policy/CloudWatchReadOnlyAccess",
+		},
+	})
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	out, err := orch.volumeMetrics(r.Context(), id, req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	handleResponseOk(w, out)
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/gorilla/mux"
+)
+
+// OrphanListHandler lists the orphaned resources in an account
+func (s *server) OrphanListHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	account := s.mapAccountNumber(vars["account"])
+
+	var types []string
+	if t := r.URL.Query().Get("type"); t != "" {
+		types = strings.Split(t, ",")
+	}
+
+	minAge := 0
+	if m := r.URL.Query().Get("min_age"); m != "" {
+		var err error
+		if minAge, err = strconv.Atoi(m); err != nil {
+			handleError(w, apierror.New(apierror.ErrBadRequest, "failed to parse min_age parameter", err))
+			return
+		}
+	}
+
+	allOrgs := false
+	if a := r.URL.Query().Get("all_orgs"); a != "" {
+		var err error
+		if allOrgs, err = strconv.ParseBool(a); err != nil {
+			handleError(w, apierror.New(apierror.ErrBadRequest, "failed to parse all_orgs parameter", err))
+			return
+		}
+	}
+
+	org, err := orphanScanOrg(s.org, r.URL.Query().Get("org"), allOrgs)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	scan, err := newOrphanScan(types, org, minAge)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	ec2Orch, iamOrch, err := s.newOrphanOrchestrators(r.Context(), account, nil, []string{
+		"iam:ListInstanceProfiles",
+		"iam:ListInstanceProfileTags",
+	})
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	orphans, err := findOrphans(r.Context(), ec2Orch, iamOrch, scan)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	w.Header().Set("X-Items", strconv.Itoa(len(orphans)))
+
+	handleResponseOk(w, &OrphanListResponse{
+		Summary:   summarizeOrphans(orphans),
+		Resources: orphans,
+	})
+}
+
+// OrphanDeleteHandler deletes the selected orphaned resources in an account, by default it's a dry run
+func (s *server) OrphanDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	account := s.mapAccountNumber(vars["account"])
+
+	req := &OrphanDeleteRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		msg := fmt.Sprintf("cannot decode body into orphan delete input: %s", err)
+		handleError(w, apierror.New(apierror.ErrBadRequest, msg, err))
+		return
+	}
+
+	ec2Orch, iamOrch, err := s.newOrphanOrchestrators(r.Context(), account, []string{
+		"ec2:DeleteVolume",
+		"ec2:DeleteSnapshot",
+		"ec2:DeregisterImage",
+		"ec2:DeleteSecurityGroup",
+	}, []string{
+		"iam:ListInstanceProfiles",
+		"iam:ListInstanceProfileTags",
+		"iam:GetInstanceProfile",
+		"iam:RemoveRoleFromInstanceProfile",
+		"iam:DeleteInstanceProfile",
+	})
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	out, err := deleteOrphans(r.Context(), ec2Orch, iamOrch, req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	handleResponseOk(w, out)
+}
+
+// newOrphanOrchestrators returns the ec2 and iam orchestrators used to find and delete orphans with the given actions allowed
+func (s *server) newOrphanOrchestrators(ctx context.Context, account string, ec2Actions, iamActions []string) (*ec2Orchestrator, *iamOrchestrator, error) {
+	role := fmt.Sprintf("arn:aws:iam::%s:role/%s", account, s.session.RoleName)
+
+	ec2Params := &sessionParams{
+		role: role,
+		policyArns: []string{
+			"arn:aws:iam::aws:policy/AmazonEC2ReadOnlyAccess",
+		},
+	}
+
+	if len(ec2Actions) > 0 {
+		policy, err := generatePolicy(ec2Actions)
+		if err != nil {
+			return nil, nil, err
+		}
+		ec2Params.inlinePolicy = policy
+	}
+
+	ec2Orch, err := s.newEc2Orchestrator(ctx, ec2Params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	iamPolicy, err := generatePolicy(iamActions)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	iamOrch, err := s.newIAMOrchestrator(ctx, &sessionParams{
+		role:         role,
+		inlinePolicy: iamPolicy,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ec2Orch, iamOrch, nil
+}
@@ -25,6 +25,11 @@ func (s *server) InstanceSelectorHandler(w http.ResponseWriter, r *http.Request)
 	account := vars["account"]
 
 	queries := r.URL.Query()
+
+	// prices isn't a selector filter, it annotates the instance types with their price
+	prices, _ := strconv.ParseBool(queries.Get("prices"))
+	queries.Del("prices")
+
 	if len(queries) == 0 {
 		handleError(w, apierror.New(apierror.ErrBadRequest, "filter is required", nil))
 		return
@@ -58,6 +63,11 @@ func (s *server) InstanceSelectorHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if prices {
+		handleResponseOk(w, instanceTypePrices(s.prices, s.pricesRegion, out))
+		return
+	}
+
 	handleResponseOk(w, out)
 }
 
@@ -1,14 +1,17 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/YaleSpinup/apierror"
 	"github.com/YaleSpinup/ec2-api/ec2"
 	"github.com/YaleSpinup/ec2-api/ssm"
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/gorilla/mux"
 )
 
@@ -30,6 +33,12 @@ func (s *server) SecurityGroupCreateHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	incoming, outgoing := sgRequestPermissions(req.InitRules...)
+	if err := s.sgLinter.enforce(account, "", incoming, outgoing); err != nil {
+		handleError(w, err)
+		return
+	}
+
 	orch, err := s.newEc2Orchestrator(r.Context(), &sessionParams{
 		inlinePolicy: policy,
 		role:         role,
@@ -68,6 +77,14 @@ func (s *server) SecurityGroupUpdateHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	if req.RuleType != nil && aws.StringValue(req.Action) == "add" {
+		incoming, outgoing := sgRequestPermissions(req)
+		if err := s.sgLinter.enforce(account, id, incoming, outgoing); err != nil {
+			handleError(w, err)
+			return
+		}
+	}
+
 	var policy string
 	var err error
 
@@ -110,11 +127,307 @@ func (s *server) SecurityGroupUpdateHandler(w http.ResponseWriter, r *http.Reque
 	}
 }
 
+// SecurityGroupRulesSyncHandler makes the rules of a security group match the given rule set
+func (s *server) SecurityGroupRulesSyncHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	account := s.mapAccountNumber(vars["account"])
+	id := vars["id"]
+
+	req := &Ec2SecurityGroupRulesSyncRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		msg := fmt.Sprintf("cannot decode body into security group rules input: %s", err)
+		handleError(w, apierror.New(apierror.ErrBadRequest, msg, err))
+		return
+	}
+
+	policy, err := sgUpdatePolicy(id)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	orch, err := s.newEc2Orchestrator(r.Context(), &sessionParams{
+		role:         fmt.Sprintf("arn:aws:iam::%s:role/%s", account, s.session.RoleName),
+		inlinePolicy: policy,
+		policyArns: []string{
+			"arn:aws:iam::aws:policy/AmazonEC2ReadOnlyAccess",
+		},
+	})
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	out, err := orch.syncSecurityGroupRules(r.Context(), id, req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	handleResponseOk(w, out)
+}
+
+// SecurityGroupFindingsHandler reports risky rules in a security group
+func (s *server) SecurityGroupFindingsHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	account := s.mapAccountNumber(vars["account"])
+	id := vars["id"]
+
+	orch, err := s.newEc2Orchestrator(r.Context(), &sessionParams{
+		role: fmt.Sprintf("arn:aws:iam::%s:role/%s", account, s.session.RoleName),
+		policyArns: []string{
+			"arn:aws:iam::aws:policy/AmazonEC2ReadOnlyAccess",
+		},
+	})
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	out, err := orch.securityGroupFindings(r.Context(), id, strings.ToLower(r.URL.Query().Get("severity")))
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	w.Header().Set("X-Items", strconv.Itoa(len(out.Findings)))
+
+	handleResponseOk(w, out)
+}
+
+// SecurityGroupFindingsListHandler reports risky rules in all of the security groups in an account
+func (s *server) SecurityGroupFindingsListHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	account := s.mapAccountNumber(vars["account"])
+
+	orch, err := s.newEc2Orchestrator(r.Context(), &sessionParams{
+		role: fmt.Sprintf("arn:aws:iam::%s:role/%s", account, s.session.RoleName),
+		policyArns: []string{
+			"arn:aws:iam::aws:policy/AmazonEC2ReadOnlyAccess",
+		},
+	})
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	org := s.org
+	if o := r.URL.Query().Get("org"); o != "" {
+		org = o
+	}
+
+	out, err := orch.listSecurityGroupFindings(r.Context(), org, strings.ToLower(r.URL.Query().Get("severity")))
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	w.Header().Set("X-Items", strconv.Itoa(len(out.Findings)))
+
+	handleResponseOk(w, out)
+}
+
+// SecurityGroupExportHandler exports a security group, or the set of security groups in the ids parameter, as
+// JSON or YAML
+func (s *server) SecurityGroupExportHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	account := s.mapAccountNumber(vars["account"])
+
+	var ids []string
+	if id, ok := vars["id"]; ok {
+		ids = []string{id}
+	} else if i := r.URL.Query().Get("ids"); i != "" {
+		ids = strings.Split(i, ",")
+	}
+
+	if len(ids) == 0 {
+		handleError(w, apierror.New(apierror.ErrBadRequest, "ids parameter is required", nil))
+		return
+	}
+
+	orch, err := s.newEc2Orchestrator(r.Context(), &sessionParams{
+		role: fmt.Sprintf("arn:aws:iam::%s:role/%s", account, s.session.RoleName),
+		policyArns: []string{
+			"arn:aws:iam::aws:policy/AmazonEC2ReadOnlyAccess",
+		},
+	})
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	out, err := orch.exportSecurityGroups(r.Context(), ids...)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if wantsYAML(r) {
+		handleResponseYAML(w, out)
+		return
+	}
+
+	handleResponseOk(w, out)
+}
+
+// SecurityGroupImportHandler creates a set of security groups from an export in the given VPC
+func (s *server) SecurityGroupImportHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	account := s.mapAccountNumber(vars["account"])
+
+	req := &Ec2SecurityGroupImportRequest{}
+	if err := decodeBody(r, req); err != nil {
+		msg := fmt.Sprintf("cannot decode body into security group import input: %s", err)
+		handleError(w, apierror.New(apierror.ErrBadRequest, msg, err))
+		return
+	}
+
+	if err := validateSecurityGroupImport(req); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if err := s.sgLinter.enforceImport(account, req); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	orch, err := s.newSecurityGroupImportOrchestrator(r.Context(), account)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	out, err := orch.importSecurityGroups(r.Context(), req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	handleResponseOk(w, out)
+}
+
+// SecurityGroupCloneHandler copies a set of security groups to a VPC, optionally in another account, translating
+// the references between them
+func (s *server) SecurityGroupCloneHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	account := s.mapAccountNumber(vars["account"])
+
+	req := &Ec2SecurityGroupCloneRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		msg := fmt.Sprintf("cannot decode body into security group clone input: %s", err)
+		handleError(w, apierror.New(apierror.ErrBadRequest, msg, err))
+		return
+	}
+
+	if len(req.GroupIds) == 0 || req.VpcId == "" {
+		handleError(w, apierror.New(apierror.ErrBadRequest, "group_ids and vpc_id are required", nil))
+		return
+	}
+
+	targetAccount := account
+	if req.TargetAccount != "" {
+		targetAccount = s.mapAccountNumber(req.TargetAccount)
+	}
+
+	sourceOrch, err := s.newEc2Orchestrator(r.Context(), &sessionParams{
+		role: fmt.Sprintf("arn:aws:iam::%s:role/%s", account, s.session.RoleName),
+		policyArns: []string{
+			"arn:aws:iam::aws:policy/AmazonEC2ReadOnlyAccess",
+		},
+	})
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	export, err := sourceOrch.exportSecurityGroups(r.Context(), req.GroupIds...)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	importReq := &Ec2SecurityGroupImportRequest{
+		VpcId:  req.VpcId,
+		Groups: export.Groups,
+	}
+
+	if err := s.sgLinter.enforceImport(targetAccount, importReq); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	targetOrch, err := s.newSecurityGroupImportOrchestrator(r.Context(), targetAccount)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	out, err := targetOrch.importSecurityGroups(r.Context(), importReq)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	handleResponseOk(w, out)
+}
+
+// newSecurityGroupImportOrchestrator returns an orchestrator allowed to create security groups and manage their rules
+func (s *server) newSecurityGroupImportOrchestrator(ctx context.Context, account string) (*ec2Orchestrator, error) {
+	policy, err := generatePolicy([]string{
+		"ec2:CreateSecurityGroup",
+		"ec2:CreateTags",
+		"ec2:DeleteSecurityGroup",
+		"ec2:ModifySecurityGroupRules",
+		"ec2:AuthorizeSecurityGroupEgress",
+		"ec2:AuthorizeSecurityGroupIngress",
+		"ec2:RevokeSecurityGroupEgress",
+		"ec2:RevokeSecurityGroupIngress",
+		"ec2:UpdateSecurityGroupRuleDescriptionsEgress",
+		"ec2:UpdateSecurityGroupRuleDescriptionsIngress",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.newEc2Orchestrator(ctx, &sessionParams{
+		role:         fmt.Sprintf("arn:aws:iam::%s:role/%s", account, s.session.RoleName),
+		inlinePolicy: policy,
+		policyArns: []string{
+			"arn:aws:iam::aws:policy/AmazonEC2ReadOnlyAccess",
+		},
+	})
+}
+
 func (s *server) SecurityGroupListHandler(w http.ResponseWriter, r *http.Request) {
 	w = LogWriter{w}
 	vars := mux.Vars(r)
 	account := s.mapAccountNumber(vars["account"])
 
+	opts, err := parseListOptions(r)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	_, filters, err := parseListFilters(r, "security-group")
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	perPage, pageToken, err := parsePagination(r, 0)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
 	role := fmt.Sprintf("arn:aws:iam::%s:role/%s", account, s.session.RoleName)
 
 	session, err := s.assumeRole(
@@ -135,15 +448,19 @@ func (s *server) SecurityGroupListHandler(w http.ResponseWriter, r *http.Request
 		ec2.WithOrg(s.org),
 	)
 
-	out, err := service.ListSecurityGroups(r.Context(), "")
-	if err != nil {
-		handleError(w, err)
-		return
-	}
+	handlePagedListResponse(w, r, opts, perPage, pageToken, func(ctx context.Context, next *string) (interface{}, *string, error) {
+		out, next, err := service.ListSecurityGroupsPage(ctx, "", int64(perPage), next, filters...)
+		if err != nil {
+			return nil, nil, err
+		}
 
-	w.Header().Set("X-Items", strconv.Itoa(len(out)))
+		list := make([]map[string]*string, len(out))
+		for i, sg := range out {
+			list[i] = toEc2SecurityGroupListItem(sg)
+		}
 
-	handleResponseOk(w, out)
+		return list, next, nil
+	})
 }
 
 func (s *server) SecurityGroupGetHandler(w http.ResponseWriter, r *http.Request) {
@@ -191,42 +508,92 @@ func (s *server) SecurityGroupGetHandler(w http.ResponseWriter, r *http.Request)
 	handleResponseOk(w, toEc2SecurityGroupResponse(out[0]))
 }
 
+// SecurityGroupUsageHandler lists the network interfaces, instances and security groups using a security group
+func (s *server) SecurityGroupUsageHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	account := s.mapAccountNumber(vars["account"])
+	id := vars["id"]
+
+	orch, err := s.newEc2Orchestrator(r.Context(), &sessionParams{
+		role: fmt.Sprintf("arn:aws:iam::%s:role/%s", account, s.session.RoleName),
+		policyArns: []string{
+			"arn:aws:iam::aws:policy/AmazonEC2ReadOnlyAccess",
+		},
+	})
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	out, err := orch.securityGroupUsage(r.Context(), id)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	handleResponseOk(w, out)
+}
+
+// SecurityGroupDeleteHandler deletes a security group.  If the security group is in use, a 409 is returned listing
+// the blockers unless cascade is set, in which case the security group is detached and references are revoked first.
 func (s *server) SecurityGroupDeleteHandler(w http.ResponseWriter, r *http.Request) {
 	w = LogWriter{w}
 	vars := mux.Vars(r)
 	account := s.mapAccountNumber(vars["account"])
 	id := vars["id"]
 
-	role := fmt.Sprintf("arn:aws:iam::%s:role/%s", account, s.session.RoleName)
-	policy, err := sgDeletePolicy(id)
+	var cascade bool
+	if r.URL.Query().Has("cascade") {
+		var err error
+		cascade, err = strconv.ParseBool(r.URL.Query().Get("cascade"))
+		if err != nil {
+			handleError(w, apierror.New(apierror.ErrBadRequest, "invalid value for cascade parameter", nil))
+			return
+		}
+	}
+
+	var policy string
+	var err error
+	if cascade {
+		policy, err = sgCascadeDeletePolicy(id)
+	} else {
+		policy, err = sgDeletePolicy(id)
+	}
 	if err != nil {
 		handleError(w, apierror.New(apierror.ErrInternalError, "failed to generate policy", err))
 		return
 	}
 
-	session, err := s.assumeRole(
-		r.Context(),
-		s.session.ExternalID,
-		role,
-		policy,
-	)
+	orch, err := s.newEc2Orchestrator(r.Context(), &sessionParams{
+		role:         fmt.Sprintf("arn:aws:iam::%s:role/%s", account, s.session.RoleName),
+		inlinePolicy: policy,
+		policyArns: []string{
+			"arn:aws:iam::aws:policy/AmazonEC2ReadOnlyAccess",
+		},
+	})
 	if err != nil {
-		msg := fmt.Sprintf("failed to assume role in account: %s", account)
-		handleError(w, apierror.New(apierror.ErrForbidden, msg, err))
+		handleError(w, err)
 		return
 	}
 
-	service := ec2.New(
-		ec2.WithSession(session.Session),
-		ec2.WithOrg(s.org),
-	)
-
-	if err := service.DeleteSecurityGroup(r.Context(), id); err != nil {
+	out, err := orch.deleteSecurityGroup(r.Context(), id, cascade)
+	if err != nil {
 		handleError(w, err)
 		return
 	}
 
-	handleResponseOk(w, "OK")
+	if !out.Deleted {
+		handleResponseConflict(w, out)
+		return
+	}
+
+	if !cascade {
+		handleResponseOk(w, "OK")
+		return
+	}
+
+	handleResponseOk(w, out)
 }
 
 // SSMAssociationByTagHandler handler function for creating an ssm association in aws by tag targets
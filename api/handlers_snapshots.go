@@ -1,10 +1,10 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"strconv"
 
 	"github.com/YaleSpinup/apierror"
 	"github.com/YaleSpinup/ec2-api/ec2"
@@ -17,6 +17,29 @@ func (s *server) SnapshotListHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	account := s.mapAccountNumber(vars["account"])
 
+	opts, err := parseListOptions(r)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if err := opts.useDetail(snapshotListFields, &Ec2SnapshotResponse{}); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	filter, filters, err := parseListFilters(r, "snapshot")
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	perPage, pageToken, err := parsePagination(r, 0)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
 	orch, err := s.newEc2Orchestrator(r.Context(), &sessionParams{
 		role: fmt.Sprintf("arn:aws:iam::%s:role/%s", account, s.session.RoleName),
 		policyArns: []string{
@@ -28,42 +51,31 @@ func (s *server) SnapshotListHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	perPage := 0
-	var pageToken *string
-	for name, values := range r.URL.Query() {
-		if name == "next" {
-			pageToken = aws.String(values[0])
+	handlePagedListResponse(w, r, opts, perPage, pageToken, func(ctx context.Context, next *string) (interface{}, *string, error) {
+		out, next, err := orch.listSnapshots(ctx, int64(perPage), next, filters...)
+		if err != nil {
+			return nil, nil, err
 		}
 
-		if name == "limit" {
-			limit, err := strconv.Atoi(values[0])
-			if err != nil {
-				handleError(w, fmt.Errorf("failed to parse limit parameter: %s", err))
-				return
+		if opts.full {
+			list := []*Ec2SnapshotResponse{}
+			for _, s := range out {
+				if filter.Created(s.StartTime) {
+					list = append(list, toEC2SnapshotResponse(s))
+				}
 			}
-			perPage = limit
+			return list, next, nil
 		}
-	}
 
-	out, next, err := orch.listSnapshots(r.Context(), int64(perPage), pageToken)
-	if err != nil {
-		handleError(w, err)
-		return
-	}
-	list := make([]map[string]*string, len(out))
-	for i, s := range out {
-		list[i] = map[string]*string{
-			"id": s.SnapshotId,
+		list := []map[string]*string{}
+		for _, s := range out {
+			if filter.Created(s.StartTime) {
+				list = append(list, map[string]*string{"id": s.SnapshotId})
+			}
 		}
-	}
 
-	w.Header().Set("X-Items", strconv.Itoa(len(list)))
-	if next != nil {
-		w.Header().Set("X-Per-Page", strconv.Itoa(perPage))
-		w.Header().Set("X-Next-Token", aws.StringValue(next))
-	}
-
-	handleResponseOk(w, list)
+		return list, next, nil
+	})
 }
 
 func (s *server) SnapshotSyncTagHandler(w http.ResponseWriter, r *http.Request) {
@@ -3,7 +3,6 @@ package api
 import (
 	"fmt"
 	"net/http"
-	"strconv"
 
 	"github.com/YaleSpinup/apierror"
 	"github.com/YaleSpinup/ec2-api/ec2"
@@ -15,6 +14,12 @@ func (s *server) SubnetsListHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	account := s.mapAccountNumber(vars["account"])
 
+	opts, err := parseListOptions(r)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
 	role := fmt.Sprintf("arn:aws:iam::%s:role/%s", account, s.session.RoleName)
 
 	session, err := s.assumeRole(
@@ -41,9 +46,7 @@ func (s *server) SubnetsListHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("X-Items", strconv.Itoa(len(out)))
-
-	handleResponseOk(w, out)
+	handleListResponse(w, opts, out)
 }
 
 func (s *server) SubnetGetHandler(w http.ResponseWriter, r *http.Request) {
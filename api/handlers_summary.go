@@ -0,0 +1,40 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// SummaryHandler summarizes the inventory and estimated monthly cost of the resources in the org, grouped by a tag
+func (s *server) SummaryHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	account := s.mapAccountNumber(vars["account"])
+	groupBy := r.URL.Query().Get("groupBy")
+
+	orch, err := s.newEc2Orchestrator(r.Context(), &sessionParams{
+		role: fmt.Sprintf("arn:aws:iam::%s:role/%s", account, s.session.RoleName),
+		policyArns: []string{
+			"arn:aws:iam::aws:policy/AmazonEC2ReadOnlyAccess",
+		},
+	})
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	out, err := orch.accountSummary(r.Context(), groupBy)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if wantsCSV(r) {
+		handleResponseCSV(w, summaryCSV(out), fmt.Sprintf("summary-%s.csv", account))
+		return
+	}
+
+	handleResponseOk(w, out)
+}
@@ -1,9 +1,13 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
 )
 
 func TestPingHandler(t *testing.T) {
@@ -57,3 +61,129 @@ func TestVersionHandler(t *testing.T) {
 			rr.Body.String(), expected)
 	}
 }
+
+func Test_handlePagedListResponse(t *testing.T) {
+	pages := map[string][]map[string]string{
+		"":   {{"id": "i-1"}, {"id": "i-2"}},
+		"t2": {{"id": "i-3"}},
+	}
+	nextTokens := map[string]*string{"": aws.String("t2")}
+
+	page := func(ctx context.Context, next *string) (interface{}, *string, error) {
+		items, ok := pages[aws.StringValue(next)]
+		if !ok {
+			return nil, nil, errors.New("boom")
+		}
+		return items, nextTokens[aws.StringValue(next)], nil
+	}
+
+	tests := []struct {
+		name        string
+		url         string
+		page        listPageFunc
+		wantCode    int
+		wantBody    string
+		wantHeaders map[string]string
+		wantTrailer string
+	}{
+		{
+			name:        "single page",
+			url:         "/instances?limit=2",
+			page:        page,
+			wantCode:    http.StatusOK,
+			wantBody:    `[{"id":"i-1"},{"id":"i-2"}]`,
+			wantHeaders: map[string]string{"X-Items": "2", "X-Per-Page": "2", "X-Next-Token": "t2"},
+		},
+		{
+			name:        "all pages as ndjson",
+			url:         "/instances?all=true&format=ndjson",
+			page:        page,
+			wantCode:    http.StatusOK,
+			wantBody:    "{\"id\":\"i-1\"}\n{\"id\":\"i-2\"}\n{\"id\":\"i-3\"}\n",
+			wantHeaders: map[string]string{"Content-Type": "application/x-ndjson", "X-Next-Token": ""},
+		},
+		{
+			name:     "all pages as csv",
+			url:      "/instances?all=true&format=csv",
+			page:     page,
+			wantCode: http.StatusOK,
+			wantBody: "id\ni-1\ni-2\ni-3\n",
+		},
+		{
+			name:        "all pages sorted",
+			url:         "/instances?all=true&sort=-id",
+			page:        page,
+			wantCode:    http.StatusOK,
+			wantBody:    `[{"id":"i-3"},{"id":"i-2"},{"id":"i-1"}]`,
+			wantHeaders: map[string]string{"X-Items": "3", "X-Next-Token": ""},
+		},
+		{
+			name: "error after the first page",
+			url:  "/instances?all=true",
+			page: func(ctx context.Context, next *string) (interface{}, *string, error) {
+				if next != nil {
+					return nil, nil, errors.New("boom")
+				}
+				return pages[""], aws.String("t-bad"), nil
+			},
+			wantCode:    http.StatusOK,
+			wantBody:    `[{"id":"i-1"},{"id":"i-2"}]`,
+			wantTrailer: "boom",
+		},
+		{
+			name: "error on the first page",
+			url:  "/instances?all=true",
+			page: func(ctx context.Context, next *string) (interface{}, *string, error) {
+				return nil, nil, errors.New("boom")
+			},
+			wantCode: http.StatusInternalServerError,
+			wantBody: "boom",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", tt.url, nil)
+			opts, err := parseListOptions(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rr := httptest.NewRecorder()
+			handlePagedListResponse(rr, r, opts, 2, nil, tt.page)
+
+			res := rr.Result()
+			if res.StatusCode != tt.wantCode {
+				t.Errorf("handlePagedListResponse() status = %d, want %d", res.StatusCode, tt.wantCode)
+			}
+
+			if body := rr.Body.String(); body != tt.wantBody {
+				t.Errorf("handlePagedListResponse() body = %q, want %q", body, tt.wantBody)
+			}
+
+			for k, v := range tt.wantHeaders {
+				if got := res.Header.Get(k); got != v {
+					t.Errorf("handlePagedListResponse() header %s = %q, want %q", k, got, v)
+				}
+			}
+
+			if got := res.Trailer.Get(listErrorTrailer); got != tt.wantTrailer {
+				t.Errorf("handlePagedListResponse() trailer = %q, want %q", got, tt.wantTrailer)
+			}
+		})
+	}
+}
+
+func Test_handleResponseAccepted(t *testing.T) {
+	rr := httptest.NewRecorder()
+
+	handleResponseAccepted(rr, &Ec2InstanceCreatePendingResponse{ID: "i-123", Message: "timed out"})
+
+	if status := rr.Code; status != http.StatusAccepted {
+		t.Errorf("handleResponseAccepted() status = %v, want %v", status, http.StatusAccepted)
+	}
+
+	expected := `{"id":"i-123","message":"timed out"}`
+	if rr.Body.String() != expected {
+		t.Errorf("handleResponseAccepted() body = %v, want %v", rr.Body.String(), expected)
+	}
+}
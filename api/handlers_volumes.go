@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -9,7 +10,9 @@ import (
 	"github.com/YaleSpinup/apierror"
 	"github.com/YaleSpinup/ec2-api/ec2"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"github.com/patrickmn/go-cache"
 )
 
 func (s *server) VolumeCreateHandler(w http.ResponseWriter, r *http.Request) {
@@ -111,12 +114,33 @@ func (s *server) VolumeListHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	account := s.mapAccountNumber(vars["account"])
 
-	role := fmt.Sprintf("arn:aws:iam::%s:role/%s", account, s.session.RoleName)
+	opts, err := parseListOptions(r)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if err := opts.useDetail(volumeListFields, &Ec2VolumeResponse{}); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	filter, filters, err := parseListFilters(r, "volume")
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	perPage, pageToken, err := parsePagination(r, 0)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
 
 	session, err := s.assumeRole(
 		r.Context(),
 		s.session.ExternalID,
-		role,
+		fmt.Sprintf("arn:aws:iam::%s:role/%s", account, s.session.RoleName),
 		"",
 		"arn:aws:iam::aws:policy/AmazonEC2ReadOnlyAccess",
 	)
@@ -126,41 +150,36 @@ func (s *server) VolumeListHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	perPage := 0
-	var pageToken *string
-	for name, values := range r.URL.Query() {
-		if name == "next" {
-			pageToken = aws.String(values[0])
-		}
-
-		if name == "limit" {
-			limit, err := strconv.Atoi(values[0])
-			if err != nil {
-				handleError(w, fmt.Errorf("failed to parse limit parameter: %s", err))
-				return
-			}
-			perPage = limit
-		}
-	}
-
 	service := ec2.New(
 		ec2.WithSession(session.Session),
 		ec2.WithOrg(s.org),
 	)
 
-	out, next, err := service.ListVolumes(r.Context(), "", int64(perPage), pageToken)
-	if err != nil {
-		handleError(w, err)
-		return
-	}
+	handlePagedListResponse(w, r, opts, perPage, pageToken, func(ctx context.Context, next *string) (interface{}, *string, error) {
+		out, next, err := service.ListVolumesPage(ctx, "", int64(perPage), next, filters...)
+		if err != nil {
+			return nil, nil, err
+		}
 
-	w.Header().Set("X-Items", strconv.Itoa(len(out)))
-	if next != nil {
-		w.Header().Set("X-Per-Page", strconv.Itoa(perPage))
-		w.Header().Set("X-Next-Token", aws.StringValue(next))
-	}
+		if opts.full {
+			list := []*Ec2VolumeResponse{}
+			for _, v := range out {
+				if filter.Created(v.CreateTime) {
+					list = append(list, toEc2VolumeResponse(v))
+				}
+			}
+			return list, next, nil
+		}
 
-	handleResponseOk(w, out)
+		list := []map[string]*string{}
+		for _, v := range out {
+			if filter.Created(v.CreateTime) {
+				list = append(list, map[string]*string{"id": v.VolumeId})
+			}
+		}
+
+		return list, next, nil
+	})
 }
 
 func (s *server) VolumeGetHandler(w http.ResponseWriter, r *http.Request) {
@@ -346,3 +365,226 @@ func (s *server) VolumeUpdateHandler(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// VolumeMigrationCreateHandler migrates the selected volumes to a new volume type (gp2 to gp3 or io1 to io2)
+func (s *server) VolumeMigrationCreateHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	account := s.mapAccountNumber(vars["account"])
+
+	req := &Ec2VolumeMigrationRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		msg := fmt.Sprintf("cannot decode body into volume migration input: %s", err)
+		handleError(w, apierror.New(apierror.ErrBadRequest, msg, err))
+		return
+	}
+
+	if req.SourceType == nil || req.TargetType == nil {
+		handleError(w, apierror.New(apierror.ErrBadRequest, "missing required fields: source_type and target_type", nil))
+		return
+	}
+
+	concurrency := defaultMigrationConcurrency
+	if req.Concurrency != nil {
+		concurrency = *req.Concurrency
+	}
+
+	if concurrency < 1 || concurrency > maxMigrationConcurrency {
+		msg := fmt.Sprintf("concurrency must be between 1 and %d", maxMigrationConcurrency)
+		handleError(w, apierror.New(apierror.ErrBadRequest, msg, nil))
+		return
+	}
+
+	policy, err := generatePolicy([]string{"ec2:ModifyVolume"})
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	sp := &sessionParams{
+		role:         fmt.Sprintf("arn:aws:iam::%s:role/%s", account, s.session.RoleName),
+		inlinePolicy: policy,
+		policyArns: []string{
+			"arn:aws:iam::aws:policy/AmazonEC2ReadOnlyAccess",
+		},
+	}
+
+	orch, err := s.newEc2Orchestrator(r.Context(), sp)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	plan, err := orch.planVolumeMigration(r.Context(), req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if plan.DryRun {
+		handleResponseOk(w, plan)
+		return
+	}
+
+	m := &volumeMigration{response: plan}
+	s.jobs.Set("migration/"+account+"/"+plan.ID, m, cache.DefaultExpiration)
+
+	if len(plan.Volumes) == 0 {
+		m.update(func(r *Ec2VolumeMigrationResponse) {
+			r.Status = "completed"
+		})
+		handleResponseOk(w, m.snapshot())
+		return
+	}
+
+	m.update(func(r *Ec2VolumeMigrationResponse) {
+		r.Status = "in_progress"
+	})
+
+	go s.trackVolumeMigration(sp, m, concurrency)
+
+	handleResponseOk(w, m.snapshot())
+}
+
+// VolumeMigrationGetHandler returns the current state of a volume migration
+func (s *server) VolumeMigrationGetHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	account := s.mapAccountNumber(vars["account"])
+	id := vars["mid"]
+
+	item, found := s.jobs.Get("migration/" + account + "/" + id)
+	if !found {
+		handleError(w, apierror.New(apierror.ErrNotFound, "volume migration not found", nil))
+		return
+	}
+
+	m, ok := item.(*volumeMigration)
+	if !ok {
+		handleError(w, apierror.New(apierror.ErrInternalError, "unexpected volume migration type", nil))
+		return
+	}
+
+	handleResponseOk(w, m.snapshot())
+}
+
+// VolumeResizeHandler grows a volume and the partition and filesystem on the instance it's attached to
+func (s *server) VolumeResizeHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	account := s.mapAccountNumber(vars["account"])
+	id := vars["id"]
+
+	req := &Ec2VolumeResizeRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		msg := fmt.Sprintf("cannot decode body into volume resize input: %s", err)
+		handleError(w, apierror.New(apierror.ErrBadRequest, msg, err))
+		return
+	}
+
+	if req.Size == nil {
+		handleError(w, apierror.New(apierror.ErrBadRequest, "missing required field: size", nil))
+		return
+	}
+
+	ec2Policy, err := generatePolicy([]string{"ec2:ModifyVolume"})
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	ec2Params := &sessionParams{
+		role:         fmt.Sprintf("arn:aws:iam::%s:role/%s", account, s.session.RoleName),
+		inlinePolicy: ec2Policy,
+		policyArns: []string{
+			"arn:aws:iam::aws:policy/AmazonEC2ReadOnlyAccess",
+		},
+	}
+
+	ssmPolicy, err := sendCommandPolicy()
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	ssmParams := &sessionParams{
+		role:         fmt.Sprintf("arn:aws:iam::%s:role/%s", account, s.session.RoleName),
+		inlinePolicy: ssmPolicy,
+		policyArns: []string{
+			"arn:aws:iam::aws:policy/AmazonSSMReadOnlyAccess",
+		},
+	}
+
+	ec2Orch, err := s.newEc2Orchestrator(r.Context(), ec2Params)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	resize, err := ec2Orch.prepareVolumeResize(r.Context(), id, req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	ssmOrch, err := s.newSSMOrchestrator(r.Context(), ssmParams)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	// make sure the filesystem can be grown before modifying the volume
+	ready, err := checkInstanceSSMStatus(r.Context(), ssmOrch.ssmClient, resize.InstanceId)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if !ready {
+		msg := fmt.Sprintf("instance %s is not managed by SSM or is not online", resize.InstanceId)
+		handleError(w, apierror.New(apierror.ErrBadRequest, msg, nil))
+		return
+	}
+
+	if _, err := ec2Orch.modifyVolume(r.Context(), &Ec2VolumeUpdateRequest{Size: req.Size}, id); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	resize.ID = uuid.New().String()
+	v := &volumeResize{response: resize}
+	s.jobs.Set("resize/"+account+"/"+resize.ID, v, cache.DefaultExpiration)
+
+	go s.trackVolumeResize(ec2Params, ssmParams, v)
+
+	handleResponseOk(w, v.snapshot())
+}
+
+// VolumeResizeGetHandler returns the current state of a volume resize
+func (s *server) VolumeResizeGetHandler(w http.ResponseWriter, r *http.Request) {
+	w = LogWriter{w}
+	vars := mux.Vars(r)
+	account := s.mapAccountNumber(vars["account"])
+	id := vars["id"]
+	rid := vars["rid"]
+
+	item, found := s.jobs.Get("resize/" + account + "/" + rid)
+	if !found {
+		handleError(w, apierror.New(apierror.ErrNotFound, "volume resize not found", nil))
+		return
+	}
+
+	v, ok := item.(*volumeResize)
+	if !ok {
+		handleError(w, apierror.New(apierror.ErrInternalError, "unexpected volume resize type", nil))
+		return
+	}
+
+	resize := v.snapshot()
+	if resize.VolumeId != id {
+		handleError(w, apierror.New(apierror.ErrNotFound, "volume resize not found", nil))
+		return
+	}
+
+	handleResponseOk(w, resize)
+}
@@ -0,0 +1,276 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	pEc2 "github.com/YaleSpinup/ec2-api/ec2"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// splitQueryList splits a comma separated query parameter, dropping empty values
+func splitQueryList(value string) []string {
+	var out []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// parsePagination parses the limit and next parameters of a paged list request
+func parsePagination(r *http.Request, defaultPerPage int) (int, *string, error) {
+	q := r.URL.Query()
+
+	perPage := defaultPerPage
+	if l := q.Get("limit"); l != "" {
+		limit, err := strconv.Atoi(l)
+		if err != nil || limit < 0 {
+			return 0, nil, apierror.New(apierror.ErrBadRequest, fmt.Sprintf("invalid limit parameter %s", l), nil)
+		}
+		perPage = limit
+	}
+
+	var next *string
+	if n := q.Get("next"); n != "" {
+		next = aws.String(n)
+	}
+
+	return perPage, next, nil
+}
+
+// parseListFilters parses the tag:<key>, state, type, az and created_after parameters of a list request and returns
+// the filter along with the EC2 filters for the resource
+func parseListFilters(r *http.Request, resource string) (*pEc2.ListFilter, []*ec2.Filter, error) {
+	q := r.URL.Query()
+	filter := &pEc2.ListFilter{
+		State: splitQueryList(q.Get("state")),
+		Type:  splitQueryList(q.Get("type")),
+		Az:    splitQueryList(q.Get("az")),
+	}
+
+	for k, values := range q {
+		key, ok := strings.CutPrefix(k, "tag:")
+		if !ok {
+			continue
+		}
+
+		if key == "" {
+			return nil, nil, apierror.New(apierror.ErrBadRequest, "tag filter requires a key", nil)
+		}
+
+		if filter.Tags == nil {
+			filter.Tags = map[string][]string{}
+		}
+
+		tagValues := []string{}
+		for _, v := range values {
+			if v != "" {
+				tagValues = append(tagValues, v)
+			}
+		}
+		filter.Tags[key] = tagValues
+	}
+
+	if c := q.Get("created_after"); c != "" {
+		t, err := parseFilterTime(c)
+		if err != nil {
+			return nil, nil, apierror.New(apierror.ErrBadRequest, fmt.Sprintf("invalid created_after %s, must be a date or RFC3339 time", c), nil)
+		}
+		filter.CreatedAfter = &t
+	}
+
+	filters, err := filter.Filters(resource)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return filter, filters, nil
+}
+
+// instanceCreateTime returns when an instance was created, the attach time of its root volume.  The launch time is the
+// last time the instance was started, it's only used for instances without an ebs root volume.
+func instanceCreateTime(instance *ec2.Instance) *time.Time {
+	root := aws.StringValue(instance.RootDeviceName)
+	for _, b := range instance.BlockDeviceMappings {
+		if aws.StringValue(b.DeviceName) == root && b.Ebs != nil && b.Ebs.AttachTime != nil {
+			return b.Ebs.AttachTime
+		}
+	}
+
+	return instance.LaunchTime
+}
+
+// parseFilterTime parses an RFC3339 time or a date
+func parseFilterTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// parseImageTime parses the creation date of an image
+func parseImageTime(value *string) *time.Time {
+	t, err := time.Parse(time.RFC3339, aws.StringValue(value))
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// sortListItems sorts list items by the value of their JSON fields, in descending order for fields with a leading -.
+// Items without a field are sorted last.
+func sortListItems(items interface{}, keys []string) (interface{}, error) {
+	v := reflect.ValueOf(items)
+	if len(keys) == 0 || v.Kind() != reflect.Slice {
+		return items, nil
+	}
+
+	list := listItems(items)
+	records := make([]map[string]interface{}, len(list))
+	for i, item := range list {
+		record, err := listRecord(item)
+		if err != nil {
+			return nil, err
+		}
+		records[i] = record
+	}
+
+	order := make([]int, len(list))
+	for i := range order {
+		order[i] = i
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := records[order[i]], records[order[j]]
+		for _, k := range keys {
+			field, desc := strings.CutPrefix(k, "-")
+
+			av, bv := a[field], b[field]
+			if av == nil || bv == nil {
+				if (av == nil) != (bv == nil) {
+					return bv == nil
+				}
+				continue
+			}
+
+			if c := compareListValues(av, bv); c != 0 {
+				return (c < 0) != desc
+			}
+		}
+		return false
+	})
+
+	sorted := reflect.MakeSlice(v.Type(), 0, len(order))
+	for _, i := range order {
+		sorted = reflect.Append(sorted, v.Index(i))
+	}
+
+	return sorted.Interface(), nil
+}
+
+// compareListValues compares two field values of list records, numbers numerically and everything else by its CSV value
+func compareListValues(a, b interface{}) int {
+	an, aok := a.(json.Number)
+	bn, bok := b.(json.Number)
+	if aok && bok {
+		af, aerr := an.Float64()
+		bf, berr := bn.Float64()
+		if aerr == nil && berr == nil {
+			switch {
+			case af < bf:
+				return -1
+			case af > bf:
+				return 1
+			}
+			return 0
+		}
+	}
+
+	return strings.Compare(csvValue(a), csvValue(b))
+}
+
+// useDetail decides if a list returns the full details of its items, when asked for with detail=full or when the selected
+// or sorted fields aren't all in the summary.  Those fields must be fields of the full details, given as a sample item.
+func (o *listOptions) useDetail(summary []string, detail interface{}) error {
+	fields := jsonFields(reflect.Indirect(reflect.ValueOf(detail)).Type())
+	valid := map[string]bool{}
+	for _, f := range fields {
+		valid[f] = true
+	}
+
+	inSummary := map[string]bool{}
+	for _, f := range summary {
+		inSummary[f] = true
+	}
+
+	selected := append([]string{}, o.fields...)
+	for _, k := range o.sort {
+		selected = append(selected, strings.TrimPrefix(k, "-"))
+	}
+
+	for _, f := range selected {
+		if !valid[f] {
+			return apierror.New(apierror.ErrBadRequest, fmt.Sprintf("invalid field %s, must be one of %s", f, strings.Join(fields, ", ")), nil)
+		}
+
+		if !inSummary[f] {
+			o.full = true
+		}
+	}
+
+	return nil
+}
+
+// instanceListFields, volumeListFields and snapshotListFields are the fields of an item in the summary of a list
+var (
+	instanceListFields = []string{"id", "name", "state"}
+	volumeListFields   = []string{"id"}
+	snapshotListFields = []string{"id"}
+)
+
+// toEc2InstanceListItem returns the id, name and state of an instance in an instance list
+func toEc2InstanceListItem(instance *ec2.Instance) map[string]*string {
+	var name, state *string
+	for _, t := range instance.Tags {
+		if aws.StringValue(t.Key) == "Name" {
+			name = t.Value
+			break
+		}
+	}
+
+	if instance.State != nil {
+		state = instance.State.Name
+	}
+
+	return map[string]*string{
+		"id":    instance.InstanceId,
+		"name":  name,
+		"state": state,
+	}
+}
+
+// toEc2SecurityGroupListItem returns a security group in a security group list, its id mapped to its Name tag or
+// else its group name
+func toEc2SecurityGroupListItem(sg *ec2.SecurityGroup) map[string]*string {
+	name := aws.StringValue(sg.GroupName)
+	for _, t := range sg.Tags {
+		if aws.StringValue(t.Key) == "Name" {
+			name = aws.StringValue(t.Value)
+			break
+		}
+	}
+
+	return map[string]*string{
+		aws.StringValue(sg.GroupId): aws.String(name),
+	}
+}
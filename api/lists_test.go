@@ -0,0 +1,222 @@
+package api
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func Test_parsePagination(t *testing.T) {
+	tests := []struct {
+		name        string
+		url         string
+		wantPerPage int
+		wantNext    *string
+		wantErr     bool
+	}{
+		{name: "defaults", url: "/instances", wantPerPage: 500},
+		{name: "limit and next", url: "/instances?limit=10&next=abc", wantPerPage: 10, wantNext: aws.String("abc")},
+		{name: "invalid limit", url: "/instances?limit=ten", wantErr: true},
+		{name: "negative limit", url: "/instances?limit=-1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			perPage, next, err := parsePagination(httptest.NewRequest("GET", tt.url, nil), 500)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parsePagination() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if perPage != tt.wantPerPage || !reflect.DeepEqual(next, tt.wantNext) {
+				t.Errorf("parsePagination() = %d, %v, want %d, %v", perPage, aws.StringValue(next), tt.wantPerPage, aws.StringValue(tt.wantNext))
+			}
+		})
+	}
+}
+
+func Test_parseListFilters(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		resource string
+		want     []*ec2.Filter
+		wantTime *time.Time
+		wantErr  bool
+	}{
+		{
+			name:     "instance filters",
+			url:      "/instances?state=running,stopped&type=t3.*&az=us-east-1a&tag:Name=web&tag:Name=db&tag:Owner=&created_after=2023-01-01",
+			resource: "instance",
+			want: []*ec2.Filter{
+				{Name: aws.String("instance-state-name"), Values: aws.StringSlice([]string{"running", "stopped"})},
+				{Name: aws.String("instance-type"), Values: aws.StringSlice([]string{"t3.*"})},
+				{Name: aws.String("availability-zone"), Values: aws.StringSlice([]string{"us-east-1a"})},
+				{Name: aws.String("tag:Name"), Values: aws.StringSlice([]string{"web", "db"})},
+				{Name: aws.String("tag-key"), Values: aws.StringSlice([]string{"Owner"})},
+			},
+			wantTime: aws.Time(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)),
+		},
+		{
+			name:     "rfc3339 creation time",
+			url:      "/volumes?created_after=2023-01-01T12:00:00Z",
+			resource: "volume",
+			want:     []*ec2.Filter{},
+			wantTime: aws.Time(time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)),
+		},
+		{name: "invalid creation time", url: "/volumes?created_after=yesterday", resource: "volume", wantErr: true},
+		{name: "missing tag key", url: "/volumes?tag:=foo", resource: "volume", wantErr: true},
+		{name: "unsupported filter", url: "/sgs?state=available", resource: "security-group", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, got, err := parseListFilters(httptest.NewRequest("GET", tt.url, nil), tt.resource)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseListFilters() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseListFilters() = %s, want %s", awsutil.Prettify(got), awsutil.Prettify(tt.want))
+			}
+
+			if !reflect.DeepEqual(filter.CreatedAfter, tt.wantTime) {
+				t.Errorf("parseListFilters() created after = %v, want %v", filter.CreatedAfter, tt.wantTime)
+			}
+		})
+	}
+}
+
+func Test_sortListItems(t *testing.T) {
+	type item struct {
+		Id   string `json:"id"`
+		Size int64  `json:"size"`
+		Type string `json:"type,omitempty"`
+	}
+
+	items := []*item{
+		{Id: "vol-1", Size: 100, Type: "gp3"},
+		{Id: "vol-2", Size: 8},
+		{Id: "vol-3", Size: 20, Type: "gp2"},
+		{Id: "vol-4", Size: 8, Type: "gp3"},
+	}
+
+	tests := []struct {
+		name string
+		keys []string
+		want []string
+	}{
+		{name: "unsorted", want: []string{"vol-1", "vol-2", "vol-3", "vol-4"}},
+		{name: "numeric", keys: []string{"size"}, want: []string{"vol-2", "vol-4", "vol-3", "vol-1"}},
+		{name: "descending", keys: []string{"-size"}, want: []string{"vol-1", "vol-3", "vol-2", "vol-4"}},
+		{name: "missing fields last", keys: []string{"type", "-size"}, want: []string{"vol-3", "vol-1", "vol-4", "vol-2"}},
+		{name: "missing fields last descending", keys: []string{"-type"}, want: []string{"vol-1", "vol-4", "vol-3", "vol-2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sortListItems(items, tt.keys)
+			if err != nil {
+				t.Fatalf("sortListItems() error = %v", err)
+			}
+
+			ids := []string{}
+			for _, i := range got.([]*item) {
+				ids = append(ids, i.Id)
+			}
+
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("sortListItems() = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func Test_toEc2InstanceListItem(t *testing.T) {
+	want := map[string]*string{"id": aws.String("i-1"), "name": aws.String("web"), "state": aws.String("running")}
+	got := toEc2InstanceListItem(&ec2.Instance{
+		InstanceId: aws.String("i-1"),
+		State:      &ec2.InstanceState{Name: aws.String("running")},
+		Tags:       []*ec2.Tag{{Key: aws.String("Owner"), Value: aws.String("me")}, {Key: aws.String("Name"), Value: aws.String("web")}},
+	})
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("toEc2InstanceListItem() = %s, want %s", awsutil.Prettify(got), awsutil.Prettify(want))
+	}
+}
+
+func Test_listOptions_useDetail(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     *listOptions
+		wantFull bool
+		wantErr  bool
+	}{
+		{name: "summary", opts: &listOptions{}},
+		{name: "detail", opts: &listOptions{full: true}, wantFull: true},
+		{name: "summary fields", opts: &listOptions{fields: []string{"id", "state"}, sort: []string{"-name"}}},
+		{name: "detail fields", opts: &listOptions{fields: []string{"id", "state", "type", "tags"}}, wantFull: true},
+		{name: "detail sort", opts: &listOptions{sort: []string{"-created_at"}}, wantFull: true},
+		{name: "invalid field", opts: &listOptions{fields: []string{"id", "flavor"}}, wantErr: true},
+		{name: "invalid sort", opts: &listOptions{sort: []string{"-flavor"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.useDetail(instanceListFields, &Ec2InstanceResponse{})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("listOptions.useDetail() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !tt.wantErr && tt.opts.full != tt.wantFull {
+				t.Errorf("listOptions.useDetail() full = %v, want %v", tt.opts.full, tt.wantFull)
+			}
+		})
+	}
+}
+
+func Test_instanceCreateTime(t *testing.T) {
+	attached := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	launched := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		instance *ec2.Instance
+		want     *time.Time
+	}{
+		{
+			name: "root volume attach time",
+			instance: &ec2.Instance{
+				RootDeviceName: aws.String("/dev/xvda"),
+				LaunchTime:     &launched,
+				BlockDeviceMappings: []*ec2.InstanceBlockDeviceMapping{
+					{DeviceName: aws.String("/dev/xvdb"), Ebs: &ec2.EbsInstanceBlockDevice{AttachTime: &launched}},
+					{DeviceName: aws.String("/dev/xvda"), Ebs: &ec2.EbsInstanceBlockDevice{AttachTime: &attached}},
+				},
+			},
+			want: &attached,
+		},
+		{
+			name: "no ebs root volume",
+			instance: &ec2.Instance{
+				RootDeviceName: aws.String("/dev/sda1"),
+				LaunchTime:     &launched,
+			},
+			want: &launched,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := instanceCreateTime(tt.instance); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("instanceCreateTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSearchLimit bounds the search for the next time matching a cron expression, expressions that can't match
+// within it (like 31 feb) have no next time
+const cronSearchLimit = 5 * 366 * 24 * time.Hour
+
+// cronSchedule is a parsed 5 field cron expression: minute, hour, day of month, month and day of week
+type cronSchedule struct {
+	minutes  uint64
+	hours    uint64
+	days     uint64
+	months   uint64
+	weekdays uint64
+
+	// when both the day of month and the day of week are restricted, a day matching either one matches
+	daysRestricted     bool
+	weekdaysRestricted bool
+}
+
+// cronField describes the allowed values of a cron field
+type cronField struct {
+	name  string
+	min   int
+	max   int
+	names map[string]int
+}
+
+var (
+	cronMinute   = cronField{name: "minute", min: 0, max: 59}
+	cronHour     = cronField{name: "hour", min: 0, max: 23}
+	cronDay      = cronField{name: "day of month", min: 1, max: 31}
+	cronMonth    = cronField{name: "month", min: 1, max: 12, names: map[string]int{"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6, "jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12}}
+	cronWeekday  = cronField{name: "day of week", min: 0, max: 7, names: map[string]int{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6}}
+	cronFields   = []cronField{cronMinute, cronHour, cronDay, cronMonth, cronWeekday}
+	cronShortcut = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+)
+
+// parseCron parses a standard 5 field cron expression.  Fields are lists of values, ranges and steps like
+// '1-5', '*/15' or 'mon-fri', and 7 is also sunday in the day of week.
+func parseCron(expr string) (*cronSchedule, error) {
+	expr = strings.TrimSpace(expr)
+	if e, ok := cronShortcut[strings.ToLower(expr)]; ok {
+		expr = e
+	}
+
+	parts := strings.Fields(expr)
+	if len(parts) != len(cronFields) {
+		return nil, fmt.Errorf("cron expression '%s' must have 5 fields: minute hour day-of-month month day-of-week", expr)
+	}
+
+	values := make([]uint64, len(cronFields))
+	for i, f := range cronFields {
+		v, err := f.parse(parts[i])
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+
+	// 7 is sunday as well
+	if values[4]&(1<<7) != 0 {
+		values[4] = values[4]&^(1<<7) | 1
+	}
+
+	return &cronSchedule{
+		minutes:            values[0],
+		hours:              values[1],
+		days:               values[2],
+		months:             values[3],
+		weekdays:           values[4],
+		daysRestricted:     !strings.HasPrefix(parts[2], "*"),
+		weekdaysRestricted: !strings.HasPrefix(parts[4], "*"),
+	}, nil
+}
+
+// parse returns the values of a field as a bit set
+func (f cronField) parse(field string) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(field, ",") {
+		rng, step := item, 1
+		if i := strings.Index(item, "/"); i >= 0 {
+			s, err := strconv.Atoi(item[i+1:])
+			if err != nil || s < 1 {
+				return 0, fmt.Errorf("invalid step in %s field '%s'", f.name, field)
+			}
+			rng, step = item[:i], s
+		}
+
+		var lo, hi int
+		switch {
+		case rng == "*":
+			lo, hi = f.min, f.max
+		case strings.Contains(rng, "-"):
+			bounds := strings.SplitN(rng, "-", 2)
+			var err error
+			if lo, err = f.value(bounds[0]); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(bounds[1]); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid range in %s field '%s'", f.name, field)
+			}
+		default:
+			v, err := f.value(rng)
+			if err != nil {
+				return 0, err
+			}
+			lo, hi = v, v
+			// a single value with a step runs to the end of the range, like '5/15'
+			if step > 1 {
+				hi = f.max
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return bits, nil
+}
+
+// value parses a single value of a field, numbers or names
+func (f cronField) value(s string) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid %s '%s', it must be between %d and %d", f.name, s, f.min, f.max)
+	}
+
+	return v, nil
+}
+
+// next returns the first time after t matching the schedule in the location of t, and false if there's none
+// within the search limit.  Times skipped by daylight saving changes never match.
+func (c *cronSchedule) next(t time.Time) (time.Time, bool) {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(cronSearchLimit)
+
+	for t.Before(limit) {
+		if c.months&(1<<uint(t.Month())) == 0 {
+			t = cronAdvance(t, time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc))
+			continue
+		}
+
+		if !c.matchDay(t) {
+			t = cronAdvance(t, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc))
+			continue
+		}
+
+		if c.hours&(1<<uint(t.Hour())) == 0 {
+			t = cronAdvance(t, time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc))
+			continue
+		}
+
+		if c.minutes&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t, true
+	}
+
+	return time.Time{}, false
+}
+
+// cronAdvance returns the next time to check after t.  Wall times in a daylight saving gap are normalized to before
+// the gap, so when next isn't after t the search moves on to the start of the next hour instead.
+func cronAdvance(t, next time.Time) time.Time {
+	if next.After(t) {
+		return next
+	}
+
+	return t.Add(time.Hour - time.Duration(t.Minute())*time.Minute)
+}
+
+// matchDay returns true if the day of t matches the day of month and day of week of the schedule
+func (c *cronSchedule) matchDay(t time.Time) bool {
+	day := c.days&(1<<uint(t.Day())) != 0
+	weekday := c.weekdays&(1<<uint(t.Weekday())) != 0
+
+	if c.daysRestricted && c.weekdaysRestricted {
+		return day || weekday
+	}
+
+	return day && weekday
+}
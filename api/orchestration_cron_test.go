@@ -0,0 +1,155 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_parseCron(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		wantErr bool
+	}{
+		{name: "every minute", expr: "* * * * *"},
+		{name: "weekdays", expr: "0 19 * * 1-5"},
+		{name: "names", expr: "30 7 * jan-mar mon-fri"},
+		{name: "lists and steps", expr: "0,30 */2 1-15/3 * *"},
+		{name: "sunday as 7", expr: "0 0 * * 7"},
+		{name: "single value with step", expr: "5/15 * * * *"},
+		{name: "shortcut", expr: "@daily"},
+		{name: "extra spaces", expr: "  0  19 * *  1-5 "},
+		{name: "too few fields", expr: "0 19 * *", wantErr: true},
+		{name: "seconds field", expr: "0 0 19 * * 1-5", wantErr: true},
+		{name: "minute out of range", expr: "60 * * * *", wantErr: true},
+		{name: "hour out of range", expr: "0 24 * * *", wantErr: true},
+		{name: "day zero", expr: "0 0 0 * *", wantErr: true},
+		{name: "invalid name", expr: "0 0 * * funday", wantErr: true},
+		{name: "reversed range", expr: "0 0 * * 5-1", wantErr: true},
+		{name: "zero step", expr: "*/0 * * * *", wantErr: true},
+		{name: "empty", expr: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseCron(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseCron() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_cronSchedule_next(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		expr   string
+		from   time.Time
+		want   time.Time
+		wantOk bool
+	}{
+		{
+			name:   "later today",
+			expr:   "0 19 * * *",
+			from:   time.Date(2023, 1, 2, 10, 0, 0, 0, time.UTC),
+			want:   time.Date(2023, 1, 2, 19, 0, 0, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "strictly after",
+			expr:   "0 19 * * *",
+			from:   time.Date(2023, 1, 2, 19, 0, 0, 0, time.UTC),
+			want:   time.Date(2023, 1, 3, 19, 0, 0, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "friday evening to monday morning",
+			expr:   "0 7 * * mon-fri",
+			from:   time.Date(2023, 1, 6, 20, 0, 0, 0, time.UTC), // friday
+			want:   time.Date(2023, 1, 9, 7, 0, 0, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "every 15 minutes",
+			expr:   "*/15 * * * *",
+			from:   time.Date(2023, 1, 2, 10, 7, 30, 0, time.UTC),
+			want:   time.Date(2023, 1, 2, 10, 15, 0, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "next year",
+			expr:   "0 0 1 jan *",
+			from:   time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC),
+			want:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "leap day",
+			expr:   "0 0 29 2 *",
+			from:   time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC),
+			want:   time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "day of month or day of week",
+			expr:   "0 0 15 * sun",
+			from:   time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), // monday
+			want:   time.Date(2023, 1, 8, 0, 0, 0, 0, time.UTC), // sunday before the 15th
+			wantOk: true,
+		},
+		{
+			name:   "sunday as 7",
+			expr:   "0 0 * * 7",
+			from:   time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC),
+			want:   time.Date(2023, 1, 8, 0, 0, 0, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "in time zone",
+			expr:   "0 19 * * *",
+			from:   time.Date(2023, 1, 2, 10, 0, 0, 0, ny),
+			want:   time.Date(2023, 1, 3, 0, 0, 0, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "skipped by daylight saving",
+			expr:   "30 2 * * *",
+			from:   time.Date(2023, 3, 12, 0, 0, 0, 0, ny),
+			want:   time.Date(2023, 3, 13, 2, 30, 0, 0, ny),
+			wantOk: true,
+		},
+		{
+			name:   "after daylight saving",
+			expr:   "0 7 * * *",
+			from:   time.Date(2023, 3, 11, 8, 0, 0, 0, ny),
+			want:   time.Date(2023, 3, 12, 11, 0, 0, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name: "never",
+			expr: "0 0 31 2 *",
+			from: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := parseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("parseCron() error = %v", err)
+			}
+
+			got, ok := c.next(tt.from)
+			if ok != tt.wantOk {
+				t.Fatalf("cronSchedule.next() ok = %v, want %v", ok, tt.wantOk)
+			}
+
+			if !got.Equal(tt.want) {
+				t.Errorf("cronSchedule.next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	log "github.com/sirupsen/logrus"
+)
+
+// spinupOrgTag is the tag used to scope resources to an org
+const spinupOrgTag = "spinup:org"
+
+// listElasticIps lists the elastic ips in the org
+func (o *ec2Orchestrator) listElasticIps(ctx context.Context) ([]*Ec2ElasticIpResponse, error) {
+	addresses, err := o.ec2Client.ListAddresses(ctx, o.server.org)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*Ec2ElasticIpResponse, 0, len(addresses))
+	for _, a := range addresses {
+		out = append(out, toEc2ElasticIpResponse(a))
+	}
+
+	return out, nil
+}
+
+// getElasticIp gets an elastic ip in the org, elastic ips in other orgs aren't found
+func (o *ec2Orchestrator) getElasticIp(ctx context.Context, id string) (*ec2.Address, error) {
+	if id == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	address, err := o.ec2Client.GetAddress(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !hasSpinupOrgTag(address.Tags, o.server.org) {
+		return nil, apierror.New(apierror.ErrNotFound, "elastic ip not found", nil)
+	}
+
+	return address, nil
+}
+
+// allocateElasticIp allocates an elastic ip tagged with the org
+func (o *ec2Orchestrator) allocateElasticIp(ctx context.Context, req *Ec2ElasticIpAllocateRequest) (*Ec2ElasticIpResponse, error) {
+	if req == nil {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Debugf("got request to allocate elastic ip: %s", awsutil.Prettify(req))
+
+	input := &ec2.AllocateAddressInput{
+		Domain: aws.String("vpc"),
+		TagSpecifications: []*ec2.TagSpecification{
+			{
+				ResourceType: aws.String("elastic-ip"),
+				Tags:         spinupOrgTags(req.Tags, o.server.org),
+			},
+		},
+	}
+
+	if req.NetworkBorderGroup != "" {
+		input.NetworkBorderGroup = aws.String(req.NetworkBorderGroup)
+	}
+
+	if req.PublicIpv4Pool != "" {
+		input.PublicIpv4Pool = aws.String(req.PublicIpv4Pool)
+	}
+
+	out, err := o.ec2Client.AllocateAddress(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return toEc2ElasticIpResponse(&ec2.Address{
+		AllocationId:       out.AllocationId,
+		PublicIp:           out.PublicIp,
+		Domain:             out.Domain,
+		NetworkBorderGroup: out.NetworkBorderGroup,
+		PublicIpv4Pool:     out.PublicIpv4Pool,
+		Tags:               input.TagSpecifications[0].Tags,
+	}), nil
+}
+
+// updateElasticIpTags updates the tags of an elastic ip in the org, the org tag can't be changed
+func (o *ec2Orchestrator) updateElasticIpTags(ctx context.Context, id string, tags map[string]string) error {
+	if len(tags) == 0 {
+		return apierror.New(apierror.ErrBadRequest, "tags are required", nil)
+	}
+
+	if _, ok := tags[spinupOrgTag]; ok {
+		return apierror.New(apierror.ErrBadRequest, spinupOrgTag+" tag can't be changed", nil)
+	}
+
+	if _, err := o.getElasticIp(ctx, id); err != nil {
+		return err
+	}
+
+	return o.ec2Client.UpdateRawTags(ctx, tags, id)
+}
+
+// associateElasticIp associates an elastic ip in the org with an instance or a network interface
+func (o *ec2Orchestrator) associateElasticIp(ctx context.Context, id string, req *Ec2ElasticIpAssociateRequest) (*Ec2ElasticIpResponse, error) {
+	if req == nil {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Debugf("got request to associate elastic ip %s: %s", id, awsutil.Prettify(req))
+
+	if (req.InstanceId == "") == (req.NetworkInterfaceId == "") {
+		return nil, apierror.New(apierror.ErrBadRequest, "either instance_id or network_interface_id is required", nil)
+	}
+
+	address, err := o.getElasticIp(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if address.AssociationId != nil && !req.AllowReassociation {
+		msg := fmt.Sprintf("elastic ip is already associated with %s", elasticIpTarget(address))
+		return nil, apierror.New(apierror.ErrConflict, msg, nil)
+	}
+
+	input := &ec2.AssociateAddressInput{
+		AllocationId:       aws.String(id),
+		AllowReassociation: aws.Bool(req.AllowReassociation),
+	}
+
+	if req.InstanceId != "" {
+		input.InstanceId = aws.String(req.InstanceId)
+	}
+
+	if req.NetworkInterfaceId != "" {
+		input.NetworkInterfaceId = aws.String(req.NetworkInterfaceId)
+	}
+
+	if req.PrivateIp != "" {
+		input.PrivateIpAddress = aws.String(req.PrivateIp)
+	}
+
+	if _, err := o.ec2Client.AssociateAddress(ctx, input); err != nil {
+		return nil, err
+	}
+
+	address, err = o.ec2Client.GetAddress(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return toEc2ElasticIpResponse(address), nil
+}
+
+// disassociateElasticIp removes the association of an elastic ip in the org
+func (o *ec2Orchestrator) disassociateElasticIp(ctx context.Context, id string) error {
+	address, err := o.getElasticIp(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if address.AssociationId == nil {
+		return apierror.New(apierror.ErrBadRequest, "elastic ip isn't associated", nil)
+	}
+
+	return o.ec2Client.DisassociateAddress(ctx, aws.StringValue(address.AssociationId))
+}
+
+// releaseElasticIp releases an elastic ip in the org, it must be disassociated first
+func (o *ec2Orchestrator) releaseElasticIp(ctx context.Context, id string) error {
+	address, err := o.getElasticIp(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if address.AssociationId != nil {
+		msg := fmt.Sprintf("elastic ip is associated with %s, disassociate it first", elasticIpTarget(address))
+		return apierror.New(apierror.ErrConflict, msg, nil)
+	}
+
+	return o.ec2Client.ReleaseAddress(ctx, id)
+}
+
+// spinupOrgTags returns the tags for a new org scoped resource, the org tag is always set to the org
+func spinupOrgTags(tags []map[string]string, org string) []*ec2.Tag {
+	out := []*ec2.Tag{}
+	for _, t := range normalizeTags(tags) {
+		if aws.StringValue(t.Key) != spinupOrgTag {
+			out = append(out, t)
+		}
+	}
+
+	return append(out, &ec2.Tag{
+		Key:   aws.String(spinupOrgTag),
+		Value: aws.String(org),
+	})
+}
+
+// hasSpinupOrgTag returns true if the tags include the org tag for the given org
+func hasSpinupOrgTag(tags []*ec2.Tag, org string) bool {
+	for _, t := range tags {
+		if aws.StringValue(t.Key) == spinupOrgTag {
+			return aws.StringValue(t.Value) == org
+		}
+	}
+	return false
+}
+
+// elasticIpTarget returns the instance or, when it's not attached to an instance, the network interface an elastic ip
+// is associated with
+func elasticIpTarget(address *ec2.Address) string {
+	if id := aws.StringValue(address.InstanceId); id != "" {
+		return id
+	}
+	return aws.StringValue(address.NetworkInterfaceId)
+}
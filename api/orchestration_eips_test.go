@@ -0,0 +1,150 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func Test_spinupOrgTags(t *testing.T) {
+	tests := []struct {
+		name string
+		tags []map[string]string
+		want []*ec2.Tag
+	}{
+		{
+			name: "no tags",
+			want: []*ec2.Tag{
+				{Key: aws.String("spinup:org"), Value: aws.String("foo")},
+			},
+		},
+		{
+			name: "tags",
+			tags: []map[string]string{{"Name": "web"}},
+			want: []*ec2.Tag{
+				{Key: aws.String("Name"), Value: aws.String("web")},
+				{Key: aws.String("spinup:org"), Value: aws.String("foo")},
+			},
+		},
+		{
+			name: "org tag is overridden",
+			tags: []map[string]string{{"spinup:org": "bar"}, {"Name": "web"}},
+			want: []*ec2.Tag{
+				{Key: aws.String("Name"), Value: aws.String("web")},
+				{Key: aws.String("spinup:org"), Value: aws.String("foo")},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := spinupOrgTags(tt.tags, "foo"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("spinupOrgTags() = %s, want %s", awsutil.Prettify(got), awsutil.Prettify(tt.want))
+			}
+		})
+	}
+}
+
+func Test_hasSpinupOrgTag(t *testing.T) {
+	tests := []struct {
+		name string
+		tags []*ec2.Tag
+		want bool
+	}{
+		{
+			name: "in org",
+			tags: []*ec2.Tag{
+				{Key: aws.String("Name"), Value: aws.String("web")},
+				{Key: aws.String("spinup:org"), Value: aws.String("foo")},
+			},
+			want: true,
+		},
+		{
+			name: "other org",
+			tags: []*ec2.Tag{{Key: aws.String("spinup:org"), Value: aws.String("bar")}},
+		},
+		{
+			name: "legacy org tag",
+			tags: []*ec2.Tag{{Key: aws.String("yale:org"), Value: aws.String("foo")}},
+		},
+		{
+			name: "no tags",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hasSpinupOrgTag(tt.tags, "foo"); got != tt.want {
+				t.Errorf("hasSpinupOrgTag() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_elasticIpTarget(t *testing.T) {
+	tests := []struct {
+		name    string
+		address *ec2.Address
+		want    string
+	}{
+		{
+			name: "instance",
+			address: &ec2.Address{
+				InstanceId:         aws.String("i-123"),
+				NetworkInterfaceId: aws.String("eni-123"),
+			},
+			want: "i-123",
+		},
+		{
+			name:    "network interface",
+			address: &ec2.Address{NetworkInterfaceId: aws.String("eni-123")},
+			want:    "eni-123",
+		},
+		{
+			name:    "not associated",
+			address: &ec2.Address{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := elasticIpTarget(tt.address); got != tt.want {
+				t.Errorf("elasticIpTarget() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_toEc2ElasticIpResponse(t *testing.T) {
+	address := &ec2.Address{
+		AllocationId:       aws.String("eipalloc-123"),
+		AssociationId:      aws.String("eipassoc-123"),
+		Domain:             aws.String("vpc"),
+		InstanceId:         aws.String("i-123"),
+		NetworkBorderGroup: aws.String("us-east-1"),
+		NetworkInterfaceId: aws.String("eni-123"),
+		PrivateIpAddress:   aws.String("10.1.2.3"),
+		PublicIp:           aws.String("203.0.113.10"),
+		PublicIpv4Pool:     aws.String("amazon"),
+		Tags: []*ec2.Tag{
+			{Key: aws.String("spinup:org"), Value: aws.String("foo")},
+		},
+	}
+
+	want := &Ec2ElasticIpResponse{
+		AllocationId:       "eipalloc-123",
+		PublicIp:           "203.0.113.10",
+		Domain:             "vpc",
+		NetworkBorderGroup: "us-east-1",
+		PublicIpv4Pool:     "amazon",
+		AssociationId:      "eipassoc-123",
+		InstanceId:         "i-123",
+		NetworkInterfaceId: "eni-123",
+		PrivateIp:          "10.1.2.3",
+		Tags:               []map[string]string{{"spinup:org": "foo"}},
+	}
+
+	if got := toEc2ElasticIpResponse(address); !reflect.DeepEqual(got, want) {
+		t.Errorf("toEc2ElasticIpResponse() = %s, want %s", awsutil.Prettify(got), awsutil.Prettify(want))
+	}
+}
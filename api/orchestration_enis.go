@@ -0,0 +1,343 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	log "github.com/sirupsen/logrus"
+)
+
+// listNetworkInterfaces lists the network interfaces in the org, optionally limited to a subnet, vpc or instance
+func (o *ec2Orchestrator) listNetworkInterfaces(ctx context.Context, subnetId, vpcId, instanceId string) ([]*Ec2NetworkInterface, error) {
+	filters := []*ec2.Filter{}
+	for _, f := range [][2]string{
+		{"subnet-id", subnetId},
+		{"vpc-id", vpcId},
+		{"attachment.instance-id", instanceId},
+	} {
+		if f[1] != "" {
+			filters = append(filters, &ec2.Filter{
+				Name:   aws.String(f[0]),
+				Values: aws.StringSlice([]string{f[1]}),
+			})
+		}
+	}
+
+	enis, err := o.ec2Client.ListNetworkInterfaces(ctx, o.server.org, filters...)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*Ec2NetworkInterface, 0, len(enis))
+	for _, eni := range enis {
+		out = append(out, toEc2NetworkInterfaceResponse(eni))
+	}
+
+	return out, nil
+}
+
+// getNetworkInterface gets a network interface in the org
+func (o *ec2Orchestrator) getNetworkInterface(ctx context.Context, id string) (*Ec2NetworkInterface, error) {
+	eni, err := o.orgNetworkInterface(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return toEc2NetworkInterfaceResponse(eni), nil
+}
+
+// orgNetworkInterface gets a network interface in the org, network interfaces in other orgs aren't found
+func (o *ec2Orchestrator) orgNetworkInterface(ctx context.Context, id string) (*ec2.NetworkInterface, error) {
+	if id == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	eni, err := o.ec2Client.GetNetworkInterface(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !hasSpinupOrgTag(eni.TagSet, o.server.org) {
+		return nil, apierror.New(apierror.ErrNotFound, "network interface not found", nil)
+	}
+
+	return eni, nil
+}
+
+// createNetworkInterface creates a network interface in a subnet tagged with the org
+func (o *ec2Orchestrator) createNetworkInterface(ctx context.Context, req *Ec2NetworkInterfaceCreateRequest) (*Ec2NetworkInterface, error) {
+	if req == nil {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Debugf("got request to create network interface: %s", awsutil.Prettify(req))
+
+	input, err := networkInterfaceCreateInput(req, o.server.org)
+	if err != nil {
+		return nil, err
+	}
+
+	eni, err := o.ec2Client.CreateNetworkInterface(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return toEc2NetworkInterfaceResponse(eni), nil
+}
+
+// updateNetworkInterface modifies the security groups, source/destination check, description and tags of a
+// network interface in the org.  Each attribute is modified separately, since the api only allows one attribute per
+// call.  The org tag can't be changed.
+func (o *ec2Orchestrator) updateNetworkInterface(ctx context.Context, id string, req *Ec2NetworkInterfaceUpdateRequest) error {
+	if id == "" || req == nil {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Debugf("got request to update network interface %s: %s", id, awsutil.Prettify(req))
+
+	inputs := networkInterfaceUpdateInputs(id, req)
+	if len(inputs) == 0 && len(req.Tags) == 0 {
+		return apierror.New(apierror.ErrBadRequest, "missing required fields: sgs, source_dest_check, description or tags", nil)
+	}
+
+	if _, ok := req.Tags[spinupOrgTag]; ok {
+		return apierror.New(apierror.ErrBadRequest, spinupOrgTag+" tag can't be changed", nil)
+	}
+
+	if _, err := o.orgNetworkInterface(ctx, id); err != nil {
+		return err
+	}
+
+	for _, input := range inputs {
+		if err := o.ec2Client.UpdateNetworkInterfaceAttribute(ctx, input); err != nil {
+			return err
+		}
+	}
+
+	if len(req.Tags) > 0 {
+		if err := o.ec2Client.UpdateRawTags(ctx, req.Tags, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// updateNetworkInterfaceTags updates the tags of a network interface in the org, the org tag can't be changed
+func (o *ec2Orchestrator) updateNetworkInterfaceTags(ctx context.Context, id string, tags map[string]string) error {
+	if len(tags) == 0 {
+		return apierror.New(apierror.ErrBadRequest, "tags are required", nil)
+	}
+
+	if _, ok := tags[spinupOrgTag]; ok {
+		return apierror.New(apierror.ErrBadRequest, spinupOrgTag+" tag can't be changed", nil)
+	}
+
+	if _, err := o.orgNetworkInterface(ctx, id); err != nil {
+		return err
+	}
+
+	return o.ec2Client.UpdateRawTags(ctx, tags, id)
+}
+
+// attachNetworkInterface attaches a network interface in the org to an instance.  When the device index isn't given, the
+// next free device index on the instance is used.
+func (o *ec2Orchestrator) attachNetworkInterface(ctx context.Context, id string, req *Ec2NetworkInterfaceAttachRequest) (*Ec2NetworkInterface, error) {
+	if id == "" || req == nil {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	if req.InstanceId == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "instance_id is required", nil)
+	}
+
+	eni, err := o.orgNetworkInterface(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if eni.Attachment != nil {
+		msg := fmt.Sprintf("network interface is already attached to %s", aws.StringValue(eni.Attachment.InstanceId))
+		return nil, apierror.New(apierror.ErrConflict, msg, nil)
+	}
+
+	instance, err := o.ec2Client.GetInstance(ctx, req.InstanceId)
+	if err != nil {
+		return nil, err
+	}
+
+	// network interfaces are only attached to instances in the org
+	if !hasSpinupOrgTag(instance.Tags, o.server.org) {
+		return nil, apierror.New(apierror.ErrNotFound, "instance not found", nil)
+	}
+
+	if aws.StringValue(instance.VpcId) != aws.StringValue(eni.VpcId) {
+		return nil, apierror.New(apierror.ErrBadRequest, "network interface and instance must be in the same vpc", nil)
+	}
+
+	deviceIndex := nextDeviceIndex(instance.NetworkInterfaces)
+	if req.DeviceIndex != nil {
+		deviceIndex = aws.Int64Value(req.DeviceIndex)
+		if deviceIndexInUse(instance.NetworkInterfaces, deviceIndex) {
+			msg := fmt.Sprintf("device index %d is in use on instance %s", deviceIndex, req.InstanceId)
+			return nil, apierror.New(apierror.ErrConflict, msg, nil)
+		}
+	}
+
+	if _, err := o.ec2Client.AttachNetworkInterface(ctx, id, req.InstanceId, deviceIndex); err != nil {
+		return nil, err
+	}
+
+	return o.getNetworkInterface(ctx, id)
+}
+
+// detachNetworkInterface detaches a network interface in the org from its instance, the primary network interface of an
+// instance can't be detached
+func (o *ec2Orchestrator) detachNetworkInterface(ctx context.Context, id string, force bool) error {
+	eni, err := o.orgNetworkInterface(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if eni.Attachment == nil {
+		return apierror.New(apierror.ErrBadRequest, "network interface isn't attached", nil)
+	}
+
+	if aws.Int64Value(eni.Attachment.DeviceIndex) == 0 {
+		return apierror.New(apierror.ErrBadRequest, "the primary network interface of an instance can't be detached", nil)
+	}
+
+	return o.ec2Client.DetachNetworkInterface(ctx, aws.StringValue(eni.Attachment.AttachmentId), force)
+}
+
+// deleteNetworkInterface deletes a network interface in the org, it must be detached first
+func (o *ec2Orchestrator) deleteNetworkInterface(ctx context.Context, id string) error {
+	eni, err := o.orgNetworkInterface(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if eni.Attachment != nil {
+		msg := fmt.Sprintf("network interface is attached to %s, detach it first", aws.StringValue(eni.Attachment.InstanceId))
+		return apierror.New(apierror.ErrConflict, msg, nil)
+	}
+
+	return o.ec2Client.DeleteNetworkInterface(ctx, id)
+}
+
+// networkInterfaceCreateInput validates a create network interface request and builds the input, tagged with the org
+func networkInterfaceCreateInput(req *Ec2NetworkInterfaceCreateRequest, org string) (*ec2.CreateNetworkInterfaceInput, error) {
+	if req.SubnetId == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "subnet_id is required", nil)
+	}
+
+	if len(req.SecondaryPrivateIps) > 0 && req.SecondaryPrivateIpCount > 0 {
+		return nil, apierror.New(apierror.ErrBadRequest, "secondary_private_ips and secondary_private_ip_count can't be combined", nil)
+	}
+
+	if req.SecondaryPrivateIpCount < 0 || req.Ipv6AddressCount < 0 {
+		return nil, apierror.New(apierror.ErrBadRequest, "secondary_private_ip_count and ipv6_address_count can't be negative", nil)
+	}
+
+	input := &ec2.CreateNetworkInterfaceInput{
+		SubnetId: aws.String(req.SubnetId),
+	}
+
+	if req.Description != "" {
+		input.Description = aws.String(req.Description)
+	}
+
+	if len(req.Sgs) > 0 {
+		input.Groups = aws.StringSlice(req.Sgs)
+	}
+
+	if len(req.SecondaryPrivateIps) > 0 {
+		// when specific secondary ips are given the primary ip has to be part of the list
+		if req.PrivateIp == "" {
+			return nil, apierror.New(apierror.ErrBadRequest, "private_ip is required with secondary_private_ips", nil)
+		}
+
+		input.PrivateIpAddresses = []*ec2.PrivateIpAddressSpecification{
+			{
+				PrivateIpAddress: aws.String(req.PrivateIp),
+				Primary:          aws.Bool(true),
+			},
+		}
+		for _, ip := range req.SecondaryPrivateIps {
+			input.PrivateIpAddresses = append(input.PrivateIpAddresses, &ec2.PrivateIpAddressSpecification{
+				PrivateIpAddress: aws.String(ip),
+				Primary:          aws.Bool(false),
+			})
+		}
+	} else if req.PrivateIp != "" {
+		input.PrivateIpAddress = aws.String(req.PrivateIp)
+	}
+
+	if req.SecondaryPrivateIpCount > 0 {
+		input.SecondaryPrivateIpAddressCount = aws.Int64(req.SecondaryPrivateIpCount)
+	}
+
+	if req.Ipv6AddressCount > 0 {
+		input.Ipv6AddressCount = aws.Int64(req.Ipv6AddressCount)
+	}
+
+	input.TagSpecifications = []*ec2.TagSpecification{
+		{
+			ResourceType: aws.String("network-interface"),
+			Tags:         spinupOrgTags(req.Tags, org),
+		},
+	}
+
+	return input, nil
+}
+
+// networkInterfaceUpdateInputs returns an input for each network interface attribute to be modified
+func networkInterfaceUpdateInputs(id string, req *Ec2NetworkInterfaceUpdateRequest) []*ec2.ModifyNetworkInterfaceAttributeInput {
+	inputs := []*ec2.ModifyNetworkInterfaceAttributeInput{}
+
+	if len(req.Sgs) > 0 {
+		inputs = append(inputs, &ec2.ModifyNetworkInterfaceAttributeInput{
+			NetworkInterfaceId: aws.String(id),
+			Groups:             aws.StringSlice(req.Sgs),
+		})
+	}
+
+	if req.SourceDestCheck != nil {
+		inputs = append(inputs, &ec2.ModifyNetworkInterfaceAttributeInput{
+			NetworkInterfaceId: aws.String(id),
+			SourceDestCheck:    &ec2.AttributeBooleanValue{Value: req.SourceDestCheck},
+		})
+	}
+
+	if req.Description != nil {
+		inputs = append(inputs, &ec2.ModifyNetworkInterfaceAttributeInput{
+			NetworkInterfaceId: aws.String(id),
+			Description:        &ec2.AttributeValue{Value: req.Description},
+		})
+	}
+
+	return inputs
+}
+
+// nextDeviceIndex returns the lowest device index not used by the network interfaces attached to an instance
+func nextDeviceIndex(enis []*ec2.InstanceNetworkInterface) int64 {
+	var i int64
+	for deviceIndexInUse(enis, i) {
+		i++
+	}
+	return i
+}
+
+// deviceIndexInUse returns true if a network interface is attached to an instance at the device index
+func deviceIndexInUse(enis []*ec2.InstanceNetworkInterface, deviceIndex int64) bool {
+	for _, eni := range enis {
+		if eni.Attachment != nil && aws.Int64Value(eni.Attachment.DeviceIndex) == deviceIndex {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,328 @@
+package api
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/YaleSpinup/apierror"
+	pEc2 "github.com/YaleSpinup/ec2-api/ec2"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+)
+
+// mockEniEC2Client has a network interface in the org foo and instances in the orgs foo and bar in the same vpc
+type mockEniEC2Client struct {
+	ec2iface.EC2API
+	attached bool
+}
+
+func (m *mockEniEC2Client) DescribeNetworkInterfacesWithContext(ctx context.Context, input *ec2.DescribeNetworkInterfacesInput, opts ...request.Option) (*ec2.DescribeNetworkInterfacesOutput, error) {
+	return &ec2.DescribeNetworkInterfacesOutput{
+		NetworkInterfaces: []*ec2.NetworkInterface{
+			{
+				NetworkInterfaceId: input.NetworkInterfaceIds[0],
+				VpcId:              aws.String("vpc-123"),
+				TagSet:             []*ec2.Tag{{Key: aws.String("spinup:org"), Value: aws.String("foo")}},
+			},
+		},
+	}, nil
+}
+
+func (m *mockEniEC2Client) DescribeInstancesWithContext(ctx context.Context, input *ec2.DescribeInstancesInput, opts ...request.Option) (*ec2.DescribeInstancesOutput, error) {
+	org := "foo"
+	if aws.StringValue(input.InstanceIds[0]) == "i-bar" {
+		org = "bar"
+	}
+
+	return &ec2.DescribeInstancesOutput{
+		Reservations: []*ec2.Reservation{
+			{
+				Instances: []*ec2.Instance{
+					{
+						InstanceId: input.InstanceIds[0],
+						VpcId:      aws.String("vpc-123"),
+						Tags:       []*ec2.Tag{{Key: aws.String("spinup:org"), Value: aws.String(org)}},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+func (m *mockEniEC2Client) AttachNetworkInterfaceWithContext(ctx context.Context, input *ec2.AttachNetworkInterfaceInput, opts ...request.Option) (*ec2.AttachNetworkInterfaceOutput, error) {
+	m.attached = true
+	return &ec2.AttachNetworkInterfaceOutput{AttachmentId: aws.String("eni-attach-123")}, nil
+}
+
+func Test_attachNetworkInterface(t *testing.T) {
+	tests := []struct {
+		name     string
+		instance string
+		wantCode string
+	}{
+		{name: "instance in the org", instance: "i-foo"},
+		{name: "instance in another org", instance: "i-bar", wantCode: apierror.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &mockEniEC2Client{}
+			o := &ec2Orchestrator{
+				ec2Client: &pEc2.Ec2{Service: client},
+				server:    &server{org: "foo"},
+			}
+
+			_, err := o.attachNetworkInterface(context.TODO(), "eni-123", &Ec2NetworkInterfaceAttachRequest{InstanceId: tt.instance})
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("attachNetworkInterface() error = %v", err)
+				}
+				if !client.attached {
+					t.Error("attachNetworkInterface() didn't attach the network interface")
+				}
+				return
+			}
+
+			if aerr, ok := err.(apierror.Error); !ok || aerr.Code != tt.wantCode {
+				t.Errorf("attachNetworkInterface() error = %v, want code %s", err, tt.wantCode)
+			}
+			if client.attached {
+				t.Error("attachNetworkInterface() attached the network interface to an instance in another org")
+			}
+		})
+	}
+}
+
+func Test_networkInterfaceCreateInput(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     *Ec2NetworkInterfaceCreateRequest
+		want    *ec2.CreateNetworkInterfaceInput
+		wantErr bool
+	}{
+		{
+			name: "subnet only",
+			req:  &Ec2NetworkInterfaceCreateRequest{SubnetId: "subnet-123"},
+			want: &ec2.CreateNetworkInterfaceInput{
+				SubnetId: aws.String("subnet-123"),
+				TagSpecifications: []*ec2.TagSpecification{
+					{
+						ResourceType: aws.String("network-interface"),
+						Tags:         []*ec2.Tag{{Key: aws.String("spinup:org"), Value: aws.String("foo")}},
+					},
+				},
+			},
+		},
+		{
+			name: "secondary private ips",
+			req: &Ec2NetworkInterfaceCreateRequest{
+				SubnetId:            "subnet-123",
+				Description:         "web",
+				Sgs:                 []string{"sg-123"},
+				PrivateIp:           "10.1.2.10",
+				SecondaryPrivateIps: []string{"10.1.2.11"},
+				Tags:                []map[string]string{{"Name": "web"}},
+			},
+			want: &ec2.CreateNetworkInterfaceInput{
+				SubnetId:    aws.String("subnet-123"),
+				Description: aws.String("web"),
+				Groups:      aws.StringSlice([]string{"sg-123"}),
+				PrivateIpAddresses: []*ec2.PrivateIpAddressSpecification{
+					{PrivateIpAddress: aws.String("10.1.2.10"), Primary: aws.Bool(true)},
+					{PrivateIpAddress: aws.String("10.1.2.11"), Primary: aws.Bool(false)},
+				},
+				TagSpecifications: []*ec2.TagSpecification{
+					{
+						ResourceType: aws.String("network-interface"),
+						Tags: []*ec2.Tag{
+							{Key: aws.String("Name"), Value: aws.String("web")},
+							{Key: aws.String("spinup:org"), Value: aws.String("foo")},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "secondary private ip count",
+			req: &Ec2NetworkInterfaceCreateRequest{
+				SubnetId:                "subnet-123",
+				PrivateIp:               "10.1.2.10",
+				SecondaryPrivateIpCount: 2,
+				Ipv6AddressCount:        1,
+			},
+			want: &ec2.CreateNetworkInterfaceInput{
+				SubnetId:                       aws.String("subnet-123"),
+				PrivateIpAddress:               aws.String("10.1.2.10"),
+				SecondaryPrivateIpAddressCount: aws.Int64(2),
+				Ipv6AddressCount:               aws.Int64(1),
+				TagSpecifications: []*ec2.TagSpecification{
+					{
+						ResourceType: aws.String("network-interface"),
+						Tags:         []*ec2.Tag{{Key: aws.String("spinup:org"), Value: aws.String("foo")}},
+					},
+				},
+			},
+		},
+		{
+			name:    "missing subnet",
+			req:     &Ec2NetworkInterfaceCreateRequest{},
+			wantErr: true,
+		},
+		{
+			name: "secondary private ips and count",
+			req: &Ec2NetworkInterfaceCreateRequest{
+				SubnetId:                "subnet-123",
+				PrivateIp:               "10.1.2.10",
+				SecondaryPrivateIps:     []string{"10.1.2.11"},
+				SecondaryPrivateIpCount: 1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "secondary private ips without private ip",
+			req: &Ec2NetworkInterfaceCreateRequest{
+				SubnetId:            "subnet-123",
+				SecondaryPrivateIps: []string{"10.1.2.11"},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "negative count",
+			req:     &Ec2NetworkInterfaceCreateRequest{SubnetId: "subnet-123", Ipv6AddressCount: -1},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := networkInterfaceCreateInput(tt.req, "foo")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("networkInterfaceCreateInput() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("networkInterfaceCreateInput() = %s, want %s", awsutil.Prettify(got), awsutil.Prettify(tt.want))
+			}
+		})
+	}
+}
+
+func Test_networkInterfaceUpdateInputs(t *testing.T) {
+	got := networkInterfaceUpdateInputs("eni-123", &Ec2NetworkInterfaceUpdateRequest{
+		Sgs:             []string{"sg-123", "sg-456"},
+		SourceDestCheck: aws.Bool(false),
+		Description:     aws.String("web"),
+	})
+	want := []*ec2.ModifyNetworkInterfaceAttributeInput{
+		{
+			NetworkInterfaceId: aws.String("eni-123"),
+			Groups:             aws.StringSlice([]string{"sg-123", "sg-456"}),
+		},
+		{
+			NetworkInterfaceId: aws.String("eni-123"),
+			SourceDestCheck:    &ec2.AttributeBooleanValue{Value: aws.Bool(false)},
+		},
+		{
+			NetworkInterfaceId: aws.String("eni-123"),
+			Description:        &ec2.AttributeValue{Value: aws.String("web")},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("networkInterfaceUpdateInputs() = %s, want %s", awsutil.Prettify(got), awsutil.Prettify(want))
+	}
+
+	if got := networkInterfaceUpdateInputs("eni-123", &Ec2NetworkInterfaceUpdateRequest{Tags: map[string]string{"Name": "web"}}); len(got) != 0 {
+		t.Errorf("expected no inputs for a tags only update, got %s", awsutil.Prettify(got))
+	}
+}
+
+func Test_nextDeviceIndex(t *testing.T) {
+	attached := func(indexes ...int64) []*ec2.InstanceNetworkInterface {
+		enis := []*ec2.InstanceNetworkInterface{}
+		for _, i := range indexes {
+			enis = append(enis, &ec2.InstanceNetworkInterface{
+				Attachment: &ec2.InstanceNetworkInterfaceAttachment{DeviceIndex: aws.Int64(i)},
+			})
+		}
+		return enis
+	}
+
+	tests := []struct {
+		name string
+		enis []*ec2.InstanceNetworkInterface
+		want int64
+	}{
+		{"no network interfaces", nil, 0},
+		{"primary only", attached(0), 1},
+		{"gap", attached(0, 2), 1},
+		{"unordered", attached(1, 0, 2), 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextDeviceIndex(tt.enis); got != tt.want {
+				t.Errorf("nextDeviceIndex() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_toEc2NetworkInterfaceResponse(t *testing.T) {
+	eni := &ec2.NetworkInterface{
+		NetworkInterfaceId: aws.String("eni-123"),
+		Description:        aws.String("web"),
+		InterfaceType:      aws.String("interface"),
+		Status:             aws.String("in-use"),
+		SubnetId:           aws.String("subnet-123"),
+		VpcId:              aws.String("vpc-123"),
+		AvailabilityZone:   aws.String("us-east-1a"),
+		MacAddress:         aws.String("11:11:11:11:11:11"),
+		PrivateIpAddress:   aws.String("10.1.2.10"),
+		Attachment: &ec2.NetworkInterfaceAttachment{
+			AttachmentId: aws.String("eni-attach-123"),
+			InstanceId:   aws.String("i-123"),
+			DeviceIndex:  aws.Int64(1),
+		},
+		Association: &ec2.NetworkInterfaceAssociation{PublicIp: aws.String("203.0.113.10")},
+		PrivateIpAddresses: []*ec2.NetworkInterfacePrivateIpAddress{
+			{
+				PrivateIpAddress: aws.String("10.1.2.10"),
+				Primary:          aws.Bool(true),
+				Association:      &ec2.NetworkInterfaceAssociation{PublicIp: aws.String("203.0.113.10")},
+			},
+			{PrivateIpAddress: aws.String("10.1.2.11"), Primary: aws.Bool(false)},
+		},
+		Ipv6Addresses:   []*ec2.NetworkInterfaceIpv6Address{{Ipv6Address: aws.String("2001:db8::10")}},
+		Groups:          []*ec2.GroupIdentifier{{GroupId: aws.String("sg-123"), GroupName: aws.String("web")}},
+		SourceDestCheck: aws.Bool(false),
+		TagSet:          []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String("web")}},
+	}
+
+	want := &Ec2NetworkInterface{
+		Id:            "eni-123",
+		Description:   "web",
+		InterfaceType: "interface",
+		Status:        "in-use",
+		SubnetId:      "subnet-123",
+		VpcId:         "vpc-123",
+		Az:            "us-east-1a",
+		MacAddress:    "11:11:11:11:11:11",
+		InstanceId:    "i-123",
+		AttachmentId:  "eni-attach-123",
+		DeviceIndex:   aws.Int64(1),
+		PrivateIp:     "10.1.2.10",
+		PublicIp:      "203.0.113.10",
+		PrivateIps: []*Ec2NetworkInterfacePrivateIp{
+			{PrivateIp: "10.1.2.10", Primary: true, PublicIp: "203.0.113.10"},
+			{PrivateIp: "10.1.2.11"},
+		},
+		Ipv6Addresses: []string{"2001:db8::10"},
+		Sgs:           []map[string]string{{"sg-123": "web"}},
+		Tags:          []map[string]string{{"Name": "web"}},
+	}
+
+	if got := toEc2NetworkInterfaceResponse(eni); !reflect.DeepEqual(got, want) {
+		t.Errorf("toEc2NetworkInterfaceResponse() = %s, want %s", awsutil.Prettify(got), awsutil.Prettify(want))
+	}
+}
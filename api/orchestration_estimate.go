@@ -0,0 +1,186 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/ec2-api/pricing"
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+// defaultEstimateVolumeType is the volume type of volumes created without a type
+const defaultEstimateVolumeType = "gp2"
+
+// estimateCost estimates the monthly on-demand cost of the instance and volumes of a request from the price catalog.
+// Resources that can't be priced, like instance types missing from the catalog or volumes sized from a snapshot, are
+// left out of the totals with a warning.
+func estimateCost(catalog *pricing.Catalog, defaultRegion string, req *Ec2EstimateRequest) (*Ec2EstimateResponse, error) {
+	if catalog == nil {
+		return nil, apierror.New(apierror.ErrServiceUnavailable, "cost estimates aren't available", nil)
+	}
+
+	if req == nil || (req.Instance == nil && len(req.Volumes) == 0) {
+		return nil, apierror.New(apierror.ErrBadRequest, "an instance or volumes are required", nil)
+	}
+
+	region := defaultRegion
+	if r := aws.StringValue(req.Region); r != "" {
+		region = r
+	}
+
+	if _, ok := catalog.Regions[region]; !ok {
+		return nil, apierror.New(apierror.ErrBadRequest, fmt.Sprintf("no prices for region %s", region), nil)
+	}
+
+	out := &Ec2EstimateResponse{
+		Region:     region,
+		Currency:   catalog.Currency,
+		PricesDate: catalog.Updated,
+		Items:      []*Ec2EstimateItem{},
+		Warnings:   []string{},
+	}
+
+	if i := req.Instance; i != nil {
+		instanceType := aws.StringValue(i.Type)
+		if instanceType == "" {
+			return nil, apierror.New(apierror.ErrBadRequest, "instance type is required", nil)
+		}
+
+		if hourly, ok := catalog.InstanceHourly(region, instanceType); ok {
+			out.Items = append(out.Items, &Ec2EstimateItem{
+				Resource:    "instance",
+				Type:        instanceType,
+				HourlyCost:  hourly,
+				MonthlyCost: hourly * pricing.HoursPerMonth,
+			})
+		} else {
+			out.Warnings = append(out.Warnings, fmt.Sprintf("no price for instance type %s", instanceType))
+		}
+
+		if aws.StringValue(i.CpuCredits) == "unlimited" {
+			out.Warnings = append(out.Warnings, "unlimited cpu credits used above the baseline aren't included")
+		}
+
+		for _, bd := range i.BlockDevices {
+			device := aws.StringValue(bd.DeviceName)
+			if bd.Ebs == nil || bd.Ebs.VolumeSize == nil {
+				out.Warnings = append(out.Warnings, fmt.Sprintf("volume %s is sized from the image and isn't included", device))
+				continue
+			}
+
+			item, warning := estimateVolume(catalog, region, bd.Ebs.VolumeType, bd.Ebs.VolumeSize, nil)
+			if item == nil {
+				out.Warnings = append(out.Warnings, warning)
+				continue
+			}
+
+			item.Device = device
+			out.Items = append(out.Items, item)
+		}
+	}
+
+	for _, v := range req.Volumes {
+		if v == nil {
+			continue
+		}
+
+		if v.Size == nil {
+			if aws.StringValue(v.SnapshotId) == "" {
+				return nil, apierror.New(apierror.ErrBadRequest, "volume size is required", nil)
+			}
+
+			out.Warnings = append(out.Warnings, fmt.Sprintf("volume from snapshot %s is sized from the snapshot and isn't included", aws.StringValue(v.SnapshotId)))
+			continue
+		}
+
+		item, warning := estimateVolume(catalog, region, v.Type, v.Size, v.Iops)
+		if item == nil {
+			out.Warnings = append(out.Warnings, warning)
+			continue
+		}
+
+		out.Items = append(out.Items, item)
+	}
+
+	for _, item := range out.Items {
+		out.HourlyCost += item.HourlyCost
+		out.MonthlyCost += item.MonthlyCost
+
+		item.HourlyCost = roundRate(item.HourlyCost)
+		item.MonthlyCost = roundCost(item.MonthlyCost)
+	}
+
+	out.HourlyCost = roundRate(out.HourlyCost)
+	out.MonthlyCost = roundCost(out.MonthlyCost)
+
+	return out, nil
+}
+
+// volumeMonthlyCost returns the monthly price of a volume from the price catalog, or 0 when there's no price for it
+func volumeMonthlyCost(catalog *pricing.Catalog, region, volumeType string, size, iops, throughput int64) float64 {
+	if catalog == nil {
+		return 0
+	}
+
+	cost, _ := catalog.VolumeMonthly(region, volumeType, size, iops, throughput)
+	return cost
+}
+
+// estimateVolume estimates the cost of a volume, or returns why it can't be estimated
+func estimateVolume(catalog *pricing.Catalog, region string, volumeType *string, size, iops *int64) (*Ec2EstimateItem, string) {
+	t := aws.StringValue(volumeType)
+	if t == "" {
+		t = defaultEstimateVolumeType
+	}
+
+	if aws.Int64Value(size) < 1 {
+		return nil, fmt.Sprintf("invalid %s volume size %d", t, aws.Int64Value(size))
+	}
+
+	monthly, ok := catalog.VolumeMonthly(region, t, aws.Int64Value(size), aws.Int64Value(iops), 0)
+	if !ok {
+		return nil, fmt.Sprintf("no price for volume type %s", t)
+	}
+
+	return &Ec2EstimateItem{
+		Resource:    "volume",
+		Type:        t,
+		Size:        aws.Int64Value(size),
+		Iops:        aws.Int64Value(iops),
+		HourlyCost:  monthly / pricing.HoursPerMonth,
+		MonthlyCost: monthly,
+	}, ""
+}
+
+// instanceTypePrices annotates instance types with their on-demand price, cheapest first.  Types missing from the price
+// catalog are listed last.
+func instanceTypePrices(catalog *pricing.Catalog, region string, instanceTypes []string) []*Ec2InstanceTypePrice {
+	out := make([]*Ec2InstanceTypePrice, 0, len(instanceTypes))
+	for _, t := range instanceTypes {
+		p := &Ec2InstanceTypePrice{InstanceType: t}
+		if catalog != nil {
+			if hourly, ok := catalog.InstanceHourly(region, t); ok {
+				p.HourlyCost = aws.Float64(roundRate(hourly))
+				p.MonthlyCost = aws.Float64(roundCost(hourly * pricing.HoursPerMonth))
+			}
+		}
+		out = append(out, p)
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i].HourlyCost, out[j].HourlyCost
+		if a == nil || b == nil {
+			return a != nil && b == nil
+		}
+		return *a < *b
+	})
+
+	return out
+}
+
+// roundRate rounds an hourly rate to a hundredth of a cent
+func roundRate(r float64) float64 {
+	return math.Round(r*10000) / 10000
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/YaleSpinup/ec2-api/pricing"
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+var testPrices = []byte(`{
+  "currency": "USD",
+  "updated": "2023-01-01",
+  "regions": {
+    "us-east-1": {
+      "instances": { "t3.micro": 0.0104, "m5.large": 0.096 },
+      "volumes": {
+        "gp2": { "gb_month": 0.10 },
+        "gp3": { "gb_month": 0.08, "iops_month": 0.005, "included_iops": 3000, "throughput_month": 0.04, "included_throughput": 125 },
+        "io1": { "gb_month": 0.125, "iops_month": 0.065 },
+        "io2": {
+          "gb_month": 0.125,
+          "iops_tiers": [{ "up_to": 32000, "price": 0.065 }, { "up_to": 64000, "price": 0.0455 }, { "price": 0.032 }]
+        }
+      }
+    }
+  }
+}`)
+
+func Test_estimateCost(t *testing.T) {
+	catalog, err := pricing.Parse(testPrices)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		req     *Ec2EstimateRequest
+		want    *Ec2EstimateResponse
+		wantErr bool
+	}{
+		{
+			name: "instance with volumes",
+			req: &Ec2EstimateRequest{
+				Instance: &Ec2InstanceCreateRequest{
+					Type: aws.String("m5.large"),
+					BlockDevices: []Ec2BlockDevice{
+						{DeviceName: aws.String("/dev/sda1"), Ebs: &Ec2EbsVolume{VolumeSize: aws.Int64(100)}},
+						{DeviceName: aws.String("/dev/sdf")},
+					},
+				},
+				Volumes: []*Ec2VolumeCreateRequest{
+					{Type: aws.String("io1"), Size: aws.Int64(100), Iops: aws.Int64(1000)},
+				},
+			},
+			want: &Ec2EstimateResponse{
+				Region:      "us-east-1",
+				Currency:    "USD",
+				PricesDate:  "2023-01-01",
+				HourlyCost:  0.2159,
+				MonthlyCost: 157.58,
+				Items: []*Ec2EstimateItem{
+					{Resource: "instance", Type: "m5.large", HourlyCost: 0.096, MonthlyCost: 70.08},
+					{Resource: "volume", Device: "/dev/sda1", Type: "gp2", Size: 100, HourlyCost: 0.0137, MonthlyCost: 10},
+					{Resource: "volume", Type: "io1", Size: 100, Iops: 1000, HourlyCost: 0.1062, MonthlyCost: 77.5},
+				},
+				Warnings: []string{"volume /dev/sdf is sized from the image and isn't included"},
+			},
+		},
+		{
+			name: "unpriced resources",
+			req: &Ec2EstimateRequest{
+				Region:   aws.String("us-east-1"),
+				Instance: &Ec2InstanceCreateRequest{Type: aws.String("x9.huge"), CpuCredits: aws.String("unlimited")},
+				Volumes: []*Ec2VolumeCreateRequest{
+					{Type: aws.String("gp9"), Size: aws.Int64(10)},
+					{SnapshotId: aws.String("snap-0123456789abcdef0")},
+				},
+			},
+			want: &Ec2EstimateResponse{
+				Region:     "us-east-1",
+				Currency:   "USD",
+				PricesDate: "2023-01-01",
+				Items:      []*Ec2EstimateItem{},
+				Warnings: []string{
+					"no price for instance type x9.huge",
+					"unlimited cpu credits used above the baseline aren't included",
+					"no price for volume type gp9",
+					"volume from snapshot snap-0123456789abcdef0 is sized from the snapshot and isn't included",
+				},
+			},
+		},
+		{name: "empty", req: &Ec2EstimateRequest{}, wantErr: true},
+		{name: "unknown region", req: &Ec2EstimateRequest{Region: aws.String("eu-west-1"), Instance: &Ec2InstanceCreateRequest{Type: aws.String("m5.large")}}, wantErr: true},
+		{name: "missing instance type", req: &Ec2EstimateRequest{Instance: &Ec2InstanceCreateRequest{}}, wantErr: true},
+		{name: "missing volume size", req: &Ec2EstimateRequest{Volumes: []*Ec2VolumeCreateRequest{{Type: aws.String("gp2")}}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := estimateCost(catalog, "us-east-1", tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("estimateCost() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("estimateCost() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_instanceTypePrices(t *testing.T) {
+	catalog, err := pricing.Parse(testPrices)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*Ec2InstanceTypePrice{
+		{InstanceType: "t3.micro", HourlyCost: aws.Float64(0.0104), MonthlyCost: aws.Float64(7.59)},
+		{InstanceType: "m5.large", HourlyCost: aws.Float64(0.096), MonthlyCost: aws.Float64(70.08)},
+		{InstanceType: "x9.huge"},
+		{InstanceType: "x9.giant"},
+	}
+
+	if got := instanceTypePrices(catalog, "us-east-1", []string{"x9.huge", "m5.large", "x9.giant", "t3.micro"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("instanceTypePrices() = %+v, want %+v", got, want)
+	}
+}
+
+func Test_volumeMonthlyCost(t *testing.T) {
+	catalog, err := pricing.Parse(testPrices)
+	if err != nil {
+		t.Fatalf("failed to parse test prices: %s", err)
+	}
+
+	type args struct {
+		volumeType string
+		size       int64
+		iops       int64
+		throughput int64
+	}
+	tests := []struct {
+		name    string
+		catalog *pricing.Catalog
+		args    args
+		want    float64
+	}{
+		{name: "gp2", catalog: catalog, args: args{volumeType: "gp2", size: 100}, want: 10},
+		{name: "gp3 baseline", catalog: catalog, args: args{volumeType: "gp3", size: 100, iops: 3000, throughput: 125}, want: 8},
+		{name: "gp3 provisioned", catalog: catalog, args: args{volumeType: "gp3", size: 100, iops: 4000, throughput: 250}, want: 18},
+		{name: "io1", catalog: catalog, args: args{volumeType: "io1", size: 100, iops: 1000}, want: 77.5},
+		{name: "io2 tiered", catalog: catalog, args: args{volumeType: "io2", size: 100, iops: 40000}, want: 2456.5},
+		{name: "unknown", catalog: catalog, args: args{volumeType: "foo", size: 100}, want: 0},
+		{name: "no catalog", args: args{volumeType: "gp2", size: 100}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := roundCost(volumeMonthlyCost(tt.catalog, "us-east-1", tt.args.volumeType, tt.args.size, tt.args.iops, tt.args.throughput)); got != tt.want {
+				t.Errorf("volumeMonthlyCost() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
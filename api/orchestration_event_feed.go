@@ -0,0 +1,152 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/YaleSpinup/ec2-api/sqs"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// eventFeedRetryDelay is how long to wait before receiving from the event feed queue again after an error
+const eventFeedRetryDelay = 10 * time.Second
+
+// eventFeed consumes the EC2 events delivered to an SQS queue by an EventBridge rule and publishes them to the
+// event broker, so subscribers get changes before the next poll
+type eventFeed struct {
+	sqsClient *sqs.SQS
+	queueUrl  string
+	broker    *eventBroker
+}
+
+// eventBridgeEvent is the envelope of an event delivered by EventBridge
+type eventBridgeEvent struct {
+	DetailType string          `json:"detail-type"`
+	Account    string          `json:"account"`
+	Time       time.Time       `json:"time"`
+	Detail     json.RawMessage `json:"detail"`
+}
+
+// run receives events from the queue until the context is canceled, every message is deleted after it's handled
+// so unknown or malformed events aren't received again
+func (f *eventFeed) run(ctx context.Context) {
+	log.Infof("starting to consume ec2 events from %s", f.queueUrl)
+
+	for {
+		messages, err := f.sqsClient.ReceiveMessages(ctx, f.queueUrl, 10, 20)
+		if err != nil {
+			if ctx.Err() != nil {
+				log.Infof("stopped consuming ec2 events from %s", f.queueUrl)
+				return
+			}
+
+			log.Warnf("failed to receive ec2 events from %s: %s", f.queueUrl, err)
+			if err := aws.SleepWithContext(ctx, eventFeedRetryDelay); err != nil {
+				return
+			}
+			continue
+		}
+
+		for _, m := range messages {
+			event, err := parseEventBridgeEvent([]byte(aws.StringValue(m.Body)))
+			if err != nil {
+				log.Warnf("ignoring malformed ec2 event %s: %s", aws.StringValue(m.MessageId), err)
+			} else if event != nil {
+				f.broker.publish(event)
+			}
+
+			if err := f.sqsClient.DeleteMessage(ctx, f.queueUrl, aws.StringValue(m.ReceiptHandle)); err != nil {
+				log.Warnf("failed to delete ec2 event %s: %s", aws.StringValue(m.MessageId), err)
+			}
+		}
+	}
+}
+
+// parseEventBridgeEvent converts an instance state change, snapshot or image event from EventBridge to a resource
+// event, other events are ignored and return nil
+func parseEventBridgeEvent(body []byte) (*Ec2ResourceEvent, error) {
+	envelope := eventBridgeEvent{}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, errors.Wrap(err, "failed to decode eventbridge event")
+	}
+
+	event := &Ec2ResourceEvent{
+		Account: envelope.Account,
+		Source:  eventSourceEventBridge,
+		Time:    timeFormat(&envelope.Time),
+	}
+
+	switch envelope.DetailType {
+	case "EC2 Instance State-change Notification":
+		detail := struct {
+			InstanceId string `json:"instance-id"`
+			State      string `json:"state"`
+		}{}
+		if err := json.Unmarshal(envelope.Detail, &detail); err != nil {
+			return nil, errors.Wrap(err, "failed to decode instance state change")
+		}
+
+		event.Type = eventInstanceState
+		event.ResourceId = detail.InstanceId
+		event.Current = detail.State
+	case "EBS Snapshot Notification":
+		detail := struct {
+			Event      string `json:"event"`
+			Result     string `json:"result"`
+			SnapshotId string `json:"snapshot_id"`
+		}{}
+		if err := json.Unmarshal(envelope.Detail, &detail); err != nil {
+			return nil, errors.Wrap(err, "failed to decode snapshot notification")
+		}
+
+		if detail.Event != "createSnapshot" && detail.Event != "createSnapshots" && detail.Event != "copySnapshot" {
+			return nil, nil
+		}
+
+		// the snapshot id is an arn, ie. arn:aws:ec2::us-east-1:snapshot/snap-0123456789abcdef0
+		event.ResourceId = detail.SnapshotId[strings.LastIndex(detail.SnapshotId, "/")+1:]
+
+		switch detail.Result {
+		case "succeeded":
+			event.Type = eventSnapshotCompleted
+			event.Current = "completed"
+		case "failed":
+			event.Type = eventSnapshotError
+			event.Current = "error"
+		default:
+			return nil, nil
+		}
+	case "EC2 AMI State Change":
+		detail := struct {
+			ImageId string `json:"ImageId"`
+			State   string `json:"State"`
+		}{}
+		if err := json.Unmarshal(envelope.Detail, &detail); err != nil {
+			return nil, errors.Wrap(err, "failed to decode image state change")
+		}
+
+		event.ResourceId = detail.ImageId
+
+		switch detail.State {
+		case "available":
+			event.Type = eventImageAvailable
+		case "failed":
+			event.Type = eventImageFailed
+		default:
+			return nil, nil
+		}
+		event.Current = detail.State
+	default:
+		return nil, nil
+	}
+
+	if event.Account == "" || event.ResourceId == "" || event.Current == "" {
+		return nil, errors.New("missing account, resource or state")
+	}
+
+	return event, nil
+}
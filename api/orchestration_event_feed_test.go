@@ -0,0 +1,70 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_parseEventBridgeEvent(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    *Ec2ResourceEvent
+		wantErr bool
+	}{
+		{
+			name: "instance state change",
+			body: `{"detail-type":"EC2 Instance State-change Notification","account":"012345678901","time":"2023-01-02T03:04:05Z","detail":{"instance-id":"i-1","state":"stopped"}}`,
+			want: &Ec2ResourceEvent{Type: eventInstanceState, Account: "012345678901", ResourceId: "i-1", Current: "stopped", Source: eventSourceEventBridge, Time: "2023/01/02 03:04:05"},
+		},
+		{
+			name: "snapshot succeeded",
+			body: `{"detail-type":"EBS Snapshot Notification","account":"012345678901","time":"2023-01-02T03:04:05Z","detail":{"event":"createSnapshot","result":"succeeded","snapshot_id":"arn:aws:ec2::us-east-1:snapshot/snap-1"}}`,
+			want: &Ec2ResourceEvent{Type: eventSnapshotCompleted, Account: "012345678901", ResourceId: "snap-1", Current: "completed", Source: eventSourceEventBridge, Time: "2023/01/02 03:04:05"},
+		},
+		{
+			name: "snapshot failed",
+			body: `{"detail-type":"EBS Snapshot Notification","account":"012345678901","time":"2023-01-02T03:04:05Z","detail":{"event":"copySnapshot","result":"failed","snapshot_id":"arn:aws:ec2::us-east-1:snapshot/snap-1"}}`,
+			want: &Ec2ResourceEvent{Type: eventSnapshotError, Account: "012345678901", ResourceId: "snap-1", Current: "error", Source: eventSourceEventBridge, Time: "2023/01/02 03:04:05"},
+		},
+		{
+			name: "snapshot shared",
+			body: `{"detail-type":"EBS Snapshot Notification","account":"012345678901","time":"2023-01-02T03:04:05Z","detail":{"event":"shareSnapshot","result":"succeeded","snapshot_id":"arn:aws:ec2::us-east-1:snapshot/snap-1"}}`,
+		},
+		{
+			name: "image available",
+			body: `{"detail-type":"EC2 AMI State Change","account":"012345678901","time":"2023-01-02T03:04:05Z","detail":{"ImageId":"ami-1","State":"available"}}`,
+			want: &Ec2ResourceEvent{Type: eventImageAvailable, Account: "012345678901", ResourceId: "ami-1", Current: "available", Source: eventSourceEventBridge, Time: "2023/01/02 03:04:05"},
+		},
+		{
+			name: "image deregistered",
+			body: `{"detail-type":"EC2 AMI State Change","account":"012345678901","time":"2023-01-02T03:04:05Z","detail":{"ImageId":"ami-1","State":"deregistered"}}`,
+		},
+		{
+			name: "other event",
+			body: `{"detail-type":"EBS Volume Notification","account":"012345678901","time":"2023-01-02T03:04:05Z","detail":{"event":"attachVolume"}}`,
+		},
+		{
+			name:    "missing instance id",
+			body:    `{"detail-type":"EC2 Instance State-change Notification","account":"012345678901","time":"2023-01-02T03:04:05Z","detail":{"state":"stopped"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "malformed",
+			body:    `{"detail-type":`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseEventBridgeEvent([]byte(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseEventBridgeEvent() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseEventBridgeEvent() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
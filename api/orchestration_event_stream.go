@@ -0,0 +1,410 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/YaleSpinup/ec2-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// types of resource events
+const (
+	eventInstanceState     = "instance.state"
+	eventVolumeAttachment  = "volume.attachment"
+	eventSnapshotCompleted = "snapshot.completed"
+	eventSnapshotError     = "snapshot.error"
+	eventImageAvailable    = "image.available"
+	eventImageFailed       = "image.failed"
+)
+
+// sources of resource events
+const (
+	eventSourcePoller      = "poller"
+	eventSourceEventBridge = "eventbridge"
+)
+
+// kinds of watched resources
+const (
+	watchedInstance = "instance"
+	watchedVolume   = "volume"
+	watchedSnapshot = "snapshot"
+	watchedImage    = "image"
+)
+
+// defaultEventPollInterval is how often the resources of an account are polled when no interval is configured
+const defaultEventPollInterval = 30 * time.Second
+
+// eventSubscriberBuffer is the number of events buffered for a subscriber, events are dropped for subscribers
+// that fall further behind
+const eventSubscriberBuffer = 64
+
+// watchedResource is the last known state of a resource watched for events
+type watchedResource struct {
+	kind       string
+	state      string
+	attachment string // the instance a volume is attached to
+}
+
+// resourcePollFunc lists the watched resources of an account by id, pending are the snapshots and images that
+// were pending in the previous poll
+type resourcePollFunc func(ctx context.Context, account string, pending []string) (map[string]*watchedResource, error)
+
+// eventBroker polls the resources of the accounts with subscribers and sends the changes to the subscribers
+type eventBroker struct {
+	ctx      context.Context
+	interval time.Duration
+	poll     resourcePollFunc
+
+	mu       sync.Mutex
+	accounts map[string]*accountEvents
+}
+
+// accountEvents are the subscribers and the last known resources of an account
+type accountEvents struct {
+	cancel      context.CancelFunc
+	subscribers map[chan *Ec2ResourceEvent]struct{}
+	resources   map[string]*watchedResource
+	seq         uint64
+}
+
+func newEventBroker(ctx context.Context, config *common.EventStream, poll resourcePollFunc) (*eventBroker, error) {
+	interval := defaultEventPollInterval
+	if config != nil && config.PollInterval != "" {
+		i, err := time.ParseDuration(config.PollInterval)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid event stream poll interval")
+		}
+
+		if i < time.Second {
+			return nil, errors.New("event stream poll interval must be at least 1s")
+		}
+
+		interval = i
+	}
+
+	return &eventBroker{
+		ctx:      ctx,
+		interval: interval,
+		poll:     poll,
+		accounts: map[string]*accountEvents{},
+	}, nil
+}
+
+// subscribe returns a channel receiving the events of an account and a function to unsubscribe.  Polling of an
+// account starts with its first subscriber and stops when its last subscriber unsubscribes.
+func (b *eventBroker) subscribe(account string) (<-chan *Ec2ResourceEvent, func()) {
+	ch := make(chan *Ec2ResourceEvent, eventSubscriberBuffer)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ae, ok := b.accounts[account]
+	if !ok {
+		ctx, cancel := context.WithCancel(b.ctx)
+		ae = &accountEvents{
+			cancel:      cancel,
+			subscribers: map[chan *Ec2ResourceEvent]struct{}{},
+		}
+		b.accounts[account] = ae
+
+		go b.run(ctx, account, ae)
+	}
+
+	ae.subscribers[ch] = struct{}{}
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+
+			delete(ae.subscribers, ch)
+			if len(ae.subscribers) == 0 {
+				ae.cancel()
+				if b.accounts[account] == ae {
+					delete(b.accounts, account)
+				}
+			}
+		})
+	}
+
+	return ch, unsubscribe
+}
+
+// publish sends an event from the eventbridge feed to the subscribers of its account.  Events are only sent for
+// resources known from polling, which limits them to the org, and only when they change the known state so the
+// next poll doesn't repeat them.
+func (b *eventBroker) publish(event *Ec2ResourceEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ae, ok := b.accounts[event.Account]
+	if !ok || ae.resources == nil {
+		return
+	}
+
+	r, ok := ae.resources[event.ResourceId]
+	if !ok || r.state == event.Current {
+		return
+	}
+
+	event.Previous = r.state
+	r.state = event.Current
+
+	b.send(ae, []*Ec2ResourceEvent{event})
+}
+
+// run polls the resources of an account until the context is canceled
+func (b *eventBroker) run(ctx context.Context, account string, ae *accountEvents) {
+	log.Infof("starting to poll resources of account %s for events every %s", account, b.interval)
+
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		b.pollAccount(ctx, account, ae)
+
+		select {
+		case <-ctx.Done():
+			log.Infof("stopped polling resources of account %s for events", account)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// pollAccount polls the resources of an account and sends the changes since the previous poll, the first poll
+// only records the resources
+func (b *eventBroker) pollAccount(ctx context.Context, account string, ae *accountEvents) {
+	b.mu.Lock()
+	pending := pendingResources(ae.resources)
+	b.mu.Unlock()
+
+	resources, err := b.poll(ctx, account, pending)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Warnf("failed to poll resources of account %s for events: %s", account, err)
+		}
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if ae.resources != nil {
+		b.send(ae, diffResources(account, ae.resources, resources, time.Now()))
+	}
+	ae.resources = resources
+}
+
+// send numbers events and sends them to the subscribers of an account, it must be called with the lock held
+func (b *eventBroker) send(ae *accountEvents, events []*Ec2ResourceEvent) {
+	for _, e := range events {
+		ae.seq++
+		e.Id = ae.seq
+
+		for ch := range ae.subscribers {
+			select {
+			case ch <- e:
+			default:
+				log.Warnf("dropping %s event %d for %s, subscriber isn't keeping up", e.Type, e.Id, e.ResourceId)
+			}
+		}
+	}
+}
+
+// diffResources returns the events for the changes between two polls of the resources of an account
+func diffResources(account string, prev, cur map[string]*watchedResource, now time.Time) []*Ec2ResourceEvent {
+	ids := make([]string, 0, len(cur))
+	for id := range cur {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	events := []*Ec2ResourceEvent{}
+	newEvent := func(eventType, id, previous, current string) {
+		events = append(events, &Ec2ResourceEvent{
+			Type:       eventType,
+			Account:    account,
+			ResourceId: id,
+			Previous:   previous,
+			Current:    current,
+			Source:     eventSourcePoller,
+			Time:       timeFormat(&now),
+		})
+	}
+
+	for _, id := range ids {
+		r := cur[id]
+		p, seen := prev[id]
+		if !seen {
+			p = &watchedResource{kind: r.kind}
+		}
+
+		switch r.kind {
+		case watchedInstance:
+			if p.state != r.state {
+				newEvent(eventInstanceState, id, p.state, r.state)
+			}
+		case watchedVolume:
+			if p.attachment != r.attachment {
+				newEvent(eventVolumeAttachment, id, p.attachment, r.attachment)
+			}
+		case watchedSnapshot:
+			if p.state == r.state {
+				continue
+			}
+
+			switch r.state {
+			case ec2.SnapshotStateCompleted:
+				newEvent(eventSnapshotCompleted, id, p.state, r.state)
+			case ec2.SnapshotStateError:
+				newEvent(eventSnapshotError, id, p.state, r.state)
+			}
+		case watchedImage:
+			if p.state == r.state {
+				continue
+			}
+
+			switch r.state {
+			case ec2.ImageStateAvailable:
+				newEvent(eventImageAvailable, id, p.state, r.state)
+			case ec2.ImageStateFailed:
+				newEvent(eventImageFailed, id, p.state, r.state)
+			}
+		}
+	}
+
+	return events
+}
+
+// pendingResources returns the ids of the pending snapshots and images
+func pendingResources(resources map[string]*watchedResource) []string {
+	pending := []string{}
+	for id, r := range resources {
+		if (r.kind == watchedSnapshot && r.state == ec2.SnapshotStatePending) || (r.kind == watchedImage && r.state == ec2.ImageStatePending) {
+			pending = append(pending, id)
+		}
+	}
+	sort.Strings(pending)
+
+	return pending
+}
+
+// pollWatchedResources lists the watched resources of the org in an account
+func (s *server) pollWatchedResources(ctx context.Context, account string, pending []string) (map[string]*watchedResource, error) {
+	orch, err := s.newEc2Orchestrator(ctx, &sessionParams{
+		role: fmt.Sprintf("arn:aws:iam::%s:role/%s", account, s.session.RoleName),
+		policyArns: []string{
+			"arn:aws:iam::aws:policy/AmazonEC2ReadOnlyAccess",
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return orch.watchedResources(ctx, pending)
+}
+
+// watchedResources lists the resources in the org watched for events: instances, volumes and the snapshots and
+// images that are pending or were pending in the previous poll
+func (o *ec2Orchestrator) watchedResources(ctx context.Context, pending []string) (map[string]*watchedResource, error) {
+	out := map[string]*watchedResource{}
+
+	instances, err := o.ec2Client.ListInstanceDetails(ctx, o.server.org)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, i := range instances {
+		out[aws.StringValue(i.InstanceId)] = &watchedResource{
+			kind:  watchedInstance,
+			state: instanceStateName(i),
+		}
+	}
+
+	volumes, err := o.ec2Client.ListVolumeDetails(ctx, o.server.org)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range volumes {
+		r := &watchedResource{
+			kind:  watchedVolume,
+			state: aws.StringValue(v.State),
+		}
+
+		for _, a := range v.Attachments {
+			if aws.StringValue(a.State) == ec2.VolumeAttachmentStateAttached {
+				r.attachment = aws.StringValue(a.InstanceId)
+			}
+		}
+
+		out[aws.StringValue(v.VolumeId)] = r
+	}
+
+	var pendingSnapshots, pendingImages []string
+	for _, id := range pending {
+		if strings.HasPrefix(id, "snap-") {
+			pendingSnapshots = append(pendingSnapshots, id)
+		} else if strings.HasPrefix(id, "ami-") {
+			pendingImages = append(pendingImages, id)
+		}
+	}
+
+	snapshotFilters := [][]*ec2.Filter{
+		{{Name: aws.String("status"), Values: aws.StringSlice([]string{ec2.SnapshotStatePending})}},
+	}
+	if len(pendingSnapshots) > 0 {
+		snapshotFilters = append(snapshotFilters, []*ec2.Filter{
+			{Name: aws.String("snapshot-id"), Values: aws.StringSlice(pendingSnapshots)},
+		})
+	}
+
+	for _, filters := range snapshotFilters {
+		snapshots, err := o.ec2Client.ListSnapshotDetails(ctx, o.server.org, filters...)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, s := range snapshots {
+			out[aws.StringValue(s.SnapshotId)] = &watchedResource{
+				kind:  watchedSnapshot,
+				state: aws.StringValue(s.State),
+			}
+		}
+	}
+
+	imageFilters := [][]*ec2.Filter{
+		{{Name: aws.String("state"), Values: aws.StringSlice([]string{ec2.ImageStatePending})}},
+	}
+	if len(pendingImages) > 0 {
+		imageFilters = append(imageFilters, []*ec2.Filter{
+			{Name: aws.String("image-id"), Values: aws.StringSlice(pendingImages)},
+		})
+	}
+
+	for _, filters := range imageFilters {
+		images, err := o.ec2Client.ListImageDetails(ctx, o.server.org, filters...)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, i := range images {
+			out[aws.StringValue(i.ImageId)] = &watchedResource{
+				kind:  watchedImage,
+				state: aws.StringValue(i.State),
+			}
+		}
+	}
+
+	return out, nil
+}
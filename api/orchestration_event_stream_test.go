@@ -0,0 +1,222 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/YaleSpinup/ec2-api/common"
+)
+
+func Test_newEventBroker(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       *common.EventStream
+		wantInterval time.Duration
+		wantErr      bool
+	}{
+		{name: "no config", wantInterval: defaultEventPollInterval},
+		{name: "default interval", config: &common.EventStream{}, wantInterval: defaultEventPollInterval},
+		{name: "interval", config: &common.EventStream{PollInterval: "1m"}, wantInterval: time.Minute},
+		{name: "invalid interval", config: &common.EventStream{PollInterval: "often"}, wantErr: true},
+		{name: "interval too short", config: &common.EventStream{PollInterval: "100ms"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newEventBroker(context.TODO(), tt.config, nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("newEventBroker() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && got.interval != tt.wantInterval {
+				t.Errorf("newEventBroker() interval = %s, want %s", got.interval, tt.wantInterval)
+			}
+		})
+	}
+}
+
+func Test_diffResources(t *testing.T) {
+	now := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	prev := map[string]*watchedResource{
+		"i-1":    {kind: watchedInstance, state: "pending"},
+		"i-2":    {kind: watchedInstance, state: "running"},
+		"vol-1":  {kind: watchedVolume, state: "in-use", attachment: "i-1"},
+		"vol-2":  {kind: watchedVolume, state: "available"},
+		"snap-1": {kind: watchedSnapshot, state: "pending"},
+		"snap-2": {kind: watchedSnapshot, state: "pending"},
+		"snap-3": {kind: watchedSnapshot, state: "pending"},
+		"ami-1":  {kind: watchedImage, state: "pending"},
+		"ami-2":  {kind: watchedImage, state: "pending"},
+	}
+	cur := map[string]*watchedResource{
+		"i-1":    {kind: watchedInstance, state: "running"},
+		"i-2":    {kind: watchedInstance, state: "running"},
+		"i-3":    {kind: watchedInstance, state: "pending"},
+		"vol-1":  {kind: watchedVolume, state: "available"},
+		"vol-2":  {kind: watchedVolume, state: "in-use", attachment: "i-2"},
+		"vol-3":  {kind: watchedVolume, state: "available"},
+		"snap-1": {kind: watchedSnapshot, state: "completed"},
+		"snap-2": {kind: watchedSnapshot, state: "error"},
+		"snap-3": {kind: watchedSnapshot, state: "pending"},
+		"snap-4": {kind: watchedSnapshot, state: "pending"},
+		"ami-1":  {kind: watchedImage, state: "available"},
+		"ami-2":  {kind: watchedImage, state: "failed"},
+	}
+
+	ts := "2023/01/02 03:04:05"
+	want := []*Ec2ResourceEvent{
+		{Type: eventImageAvailable, Account: "012345678901", ResourceId: "ami-1", Previous: "pending", Current: "available", Source: eventSourcePoller, Time: ts},
+		{Type: eventImageFailed, Account: "012345678901", ResourceId: "ami-2", Previous: "pending", Current: "failed", Source: eventSourcePoller, Time: ts},
+		{Type: eventInstanceState, Account: "012345678901", ResourceId: "i-1", Previous: "pending", Current: "running", Source: eventSourcePoller, Time: ts},
+		{Type: eventInstanceState, Account: "012345678901", ResourceId: "i-3", Current: "pending", Source: eventSourcePoller, Time: ts},
+		{Type: eventSnapshotCompleted, Account: "012345678901", ResourceId: "snap-1", Previous: "pending", Current: "completed", Source: eventSourcePoller, Time: ts},
+		{Type: eventSnapshotError, Account: "012345678901", ResourceId: "snap-2", Previous: "pending", Current: "error", Source: eventSourcePoller, Time: ts},
+		{Type: eventVolumeAttachment, Account: "012345678901", ResourceId: "vol-1", Previous: "i-1", Source: eventSourcePoller, Time: ts},
+		{Type: eventVolumeAttachment, Account: "012345678901", ResourceId: "vol-2", Current: "i-2", Source: eventSourcePoller, Time: ts},
+	}
+
+	got := diffResources("012345678901", prev, cur, now)
+	if !reflect.DeepEqual(got, want) {
+		for _, e := range got {
+			t.Logf("got %+v", e)
+		}
+		t.Errorf("diffResources() returned %d events, want %d", len(got), len(want))
+	}
+}
+
+func Test_pendingResources(t *testing.T) {
+	resources := map[string]*watchedResource{
+		"i-1":    {kind: watchedInstance, state: "pending"},
+		"snap-2": {kind: watchedSnapshot, state: "pending"},
+		"snap-1": {kind: watchedSnapshot, state: "pending"},
+		"snap-3": {kind: watchedSnapshot, state: "completed"},
+		"ami-1":  {kind: watchedImage, state: "pending"},
+	}
+
+	want := []string{"ami-1", "snap-1", "snap-2"}
+	if got := pendingResources(resources); !reflect.DeepEqual(got, want) {
+		t.Errorf("pendingResources() = %v, want %v", got, want)
+	}
+
+	if got := pendingResources(nil); len(got) != 0 {
+		t.Errorf("pendingResources(nil) = %v, want none", got)
+	}
+}
+
+// fakeResourcePoller returns the next set of resources on each poll
+type fakeResourcePoller struct {
+	mu      sync.Mutex
+	polls   []map[string]*watchedResource
+	pending [][]string
+}
+
+func (f *fakeResourcePoller) poll(ctx context.Context, account string, pending []string) (map[string]*watchedResource, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.pending = append(f.pending, pending)
+
+	if len(f.polls) == 0 {
+		return nil, context.Canceled
+	}
+
+	out := f.polls[0]
+	f.polls = f.polls[1:]
+	return out, nil
+}
+
+func Test_eventBroker(t *testing.T) {
+	poller := &fakeResourcePoller{
+		polls: []map[string]*watchedResource{
+			{
+				"i-1":    {kind: watchedInstance, state: "pending"},
+				"snap-1": {kind: watchedSnapshot, state: "pending"},
+			},
+			{
+				"i-1":    {kind: watchedInstance, state: "running"},
+				"snap-1": {kind: watchedSnapshot, state: "pending"},
+			},
+		},
+	}
+
+	b := &eventBroker{
+		ctx:      context.TODO(),
+		interval: time.Hour,
+		poll:     poller.poll,
+		accounts: map[string]*accountEvents{},
+	}
+
+	// subscribe directly to the account without starting the poller
+	ae := &accountEvents{
+		cancel:      func() {},
+		subscribers: map[chan *Ec2ResourceEvent]struct{}{},
+	}
+	b.accounts["012345678901"] = ae
+	events, unsubscribe := b.subscribe("012345678901")
+
+	// events from the feed are ignored until the resources are known
+	b.publish(&Ec2ResourceEvent{Type: eventInstanceState, Account: "012345678901", ResourceId: "i-1", Current: "running"})
+
+	b.pollAccount(context.TODO(), "012345678901", ae)
+	b.pollAccount(context.TODO(), "012345678901", ae)
+
+	e := <-events
+	if e.Id != 1 || e.ResourceId != "i-1" || e.Previous != "pending" || e.Current != "running" {
+		t.Errorf("expected instance i-1 state change from pending to running, got %+v", e)
+	}
+
+	if want := [][]string{{}, {"snap-1"}}; !reflect.DeepEqual(poller.pending, want) {
+		t.Errorf("expected pending resources %v, got %v", want, poller.pending)
+	}
+
+	// events from the feed are sent for known resources when they change the state
+	b.publish(&Ec2ResourceEvent{Type: eventSnapshotCompleted, Account: "012345678901", ResourceId: "snap-1", Current: "completed"})
+	b.publish(&Ec2ResourceEvent{Type: eventSnapshotCompleted, Account: "012345678901", ResourceId: "snap-1", Current: "completed"})
+	b.publish(&Ec2ResourceEvent{Type: eventSnapshotCompleted, Account: "012345678901", ResourceId: "snap-9", Current: "completed"})
+	b.publish(&Ec2ResourceEvent{Type: eventSnapshotCompleted, Account: "999999999999", ResourceId: "snap-1", Current: "completed"})
+
+	e = <-events
+	if e.Id != 2 || e.ResourceId != "snap-1" || e.Previous != "pending" || e.Current != "completed" {
+		t.Errorf("expected snapshot snap-1 completed, got %+v", e)
+	}
+
+	select {
+	case e := <-events:
+		t.Errorf("expected no more events, got %+v", e)
+	default:
+	}
+
+	unsubscribe()
+	unsubscribe()
+
+	if _, ok := b.accounts["012345678901"]; ok {
+		t.Error("expected account to be removed after the last subscriber unsubscribed")
+	}
+}
+
+func Test_writeServerSentEvent(t *testing.T) {
+	buf := &bytes.Buffer{}
+	err := writeServerSentEvent(buf, &Ec2ResourceEvent{
+		Id:         7,
+		Type:       eventInstanceState,
+		Account:    "012345678901",
+		ResourceId: "i-1",
+		Previous:   "pending",
+		Current:    "running",
+		Source:     eventSourcePoller,
+		Time:       "2023/01/02 03:04:05",
+	})
+	if err != nil {
+		t.Fatalf("writeServerSentEvent() error = %s", err)
+	}
+
+	want := "id: 7\nevent: instance.state\ndata: " +
+		`{"id":7,"type":"instance.state","account":"012345678901","resource_id":"i-1","previous":"pending","current":"running","source":"poller","time":"2023/01/02 03:04:05"}` +
+		"\n\n"
+	if got := buf.String(); got != want {
+		t.Errorf("writeServerSentEvent() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,493 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/ec2-api/cloudwatch"
+	"github.com/YaleSpinup/ec2-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	awscloudwatch "github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// tags used by the idle detector
+const (
+	// idleSinceTag records when an instance was first found idle, it's removed when the instance is active again
+	idleSinceTag = "spinup:idle-since"
+	// idleOptOutTag exempts an instance from being stopped when it's idle, it's still reported
+	idleOptOutTag = "spinup:idle-exempt"
+)
+
+// defaults of the idle detector
+const (
+	defaultIdleWindow           = 24 * time.Hour
+	defaultIdleCpuThreshold     = 5.0
+	defaultIdleNetworkThreshold = 5120.0
+	defaultIdleGracePeriod      = 24 * time.Hour
+	defaultIdleInterval         = time.Hour
+)
+
+// bounds of the idle window, cloudwatch keeps 5 minute datapoints for 63 days
+const (
+	minIdleWindow = time.Hour
+	maxIdleWindow = 14 * 24 * time.Hour
+)
+
+// idleCoverage is the share of the expected datapoints needed to decide if an instance is idle, instances with
+// less data aren't reported
+const idleCoverage = 0.5
+
+// idleInstancesPerQuery is the number of instances whose metrics are read in one call, each uses 3 queries
+const idleInstancesPerQuery = cloudwatch.MaxMetricDataQueries / 3
+
+// idleSettings are the thresholds an instance is idle under
+type idleSettings struct {
+	window           time.Duration
+	cpuThreshold     float64
+	networkThreshold float64
+}
+
+// idleDetector reports idle instances and, when auto stop is enabled, stops the ones idle for the grace period
+type idleDetector struct {
+	settings    idleSettings
+	autoStop    bool
+	gracePeriod time.Duration
+	interval    time.Duration
+	accounts    []string
+	run         func(ctx context.Context, account string, now time.Time) error
+}
+
+// idleEvaluation is the average use of a running instance over the idle window
+type idleEvaluation struct {
+	instance *ec2.Instance
+	cpu      float64
+	network  float64
+	idle     bool
+}
+
+// newIdleDetector validates the idle configuration.  A detector with the default settings is returned when idle
+// detection isn't configured, it serves the report but isn't started.
+func newIdleDetector(config *common.IdleInstances, accountsMap map[string]string, run func(ctx context.Context, account string, now time.Time) error) (*idleDetector, error) {
+	d := &idleDetector{
+		settings: idleSettings{
+			window:           defaultIdleWindow,
+			cpuThreshold:     defaultIdleCpuThreshold,
+			networkThreshold: defaultIdleNetworkThreshold,
+		},
+		gracePeriod: defaultIdleGracePeriod,
+		interval:    defaultIdleInterval,
+		run:         run,
+	}
+
+	if config == nil {
+		return d, nil
+	}
+
+	for _, c := range []struct {
+		name  string
+		value string
+		d     *time.Duration
+	}{
+		{"window", config.Window, &d.settings.window},
+		{"grace period", config.GracePeriod, &d.gracePeriod},
+		{"interval", config.Interval, &d.interval},
+	} {
+		if c.value == "" {
+			continue
+		}
+
+		v, err := time.ParseDuration(c.value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid idle instances %s", c.name)
+		}
+		*c.d = v
+	}
+
+	if config.CpuThreshold != 0 {
+		d.settings.cpuThreshold = config.CpuThreshold
+	}
+
+	if config.NetworkThreshold != 0 {
+		d.settings.networkThreshold = config.NetworkThreshold
+	}
+
+	if err := validateIdleSettings(d.settings); err != nil {
+		return nil, err
+	}
+
+	if d.gracePeriod < 0 {
+		return nil, errors.New("idle instances grace period can't be negative")
+	}
+
+	if d.interval < time.Minute {
+		return nil, errors.New("idle instances interval must be at least 1m")
+	}
+
+	d.autoStop = config.AutoStop
+	d.accounts = configuredAccounts(config.Accounts, accountsMap)
+	if len(d.accounts) == 0 {
+		return nil, errors.New("idle instances require at least one account")
+	}
+
+	return d, nil
+}
+
+// validateIdleSettings checks the window and the thresholds of idle settings
+func validateIdleSettings(s idleSettings) error {
+	if s.window < minIdleWindow || s.window > maxIdleWindow {
+		return apierror.New(apierror.ErrBadRequest, fmt.Sprintf("idle window must be between %s and %s", minIdleWindow, maxIdleWindow), nil)
+	}
+
+	if s.cpuThreshold <= 0 || s.cpuThreshold > 100 {
+		return apierror.New(apierror.ErrBadRequest, "idle cpu threshold must be a percentage above 0", nil)
+	}
+
+	if s.networkThreshold <= 0 {
+		return apierror.New(apierror.ErrBadRequest, "idle network threshold must be positive", nil)
+	}
+
+	return nil
+}
+
+// parseIdleSettings overrides the default idle settings with the window, cpu and network query parameters
+func parseIdleSettings(defaults idleSettings, window, cpu, network string) (idleSettings, error) {
+	s := defaults
+
+	if window != "" {
+		w, err := time.ParseDuration(window)
+		if err != nil {
+			return s, apierror.New(apierror.ErrBadRequest, "window must be a duration like 24h", err)
+		}
+		s.window = w
+	}
+
+	for _, p := range []struct {
+		name  string
+		value string
+		v     *float64
+	}{
+		{"cpu", cpu, &s.cpuThreshold},
+		{"network", network, &s.networkThreshold},
+	} {
+		if p.value == "" {
+			continue
+		}
+
+		v, err := strconv.ParseFloat(p.value, 64)
+		if err != nil {
+			return s, apierror.New(apierror.ErrBadRequest, p.name+" must be a number", err)
+		}
+		*p.v = v
+	}
+
+	if err := validateIdleSettings(s); err != nil {
+		return s, err
+	}
+
+	return s, nil
+}
+
+// idleMetricPeriod returns the period of the datapoints read for a window, hourly datapoints are enough for
+// longer windows and keep the number of datapoints down
+func idleMetricPeriod(window time.Duration) int64 {
+	if window >= 6*time.Hour {
+		return 3600
+	}
+	return 300
+}
+
+// evaluateIdleInstances reads the average cpu and network use over the window of the running instances in the org
+// that have been running for the whole window
+func (o *metricsOrchestrator) evaluateIdleInstances(ctx context.Context, settings idleSettings, now time.Time) ([]*idleEvaluation, error) {
+	instances, err := o.ec2Client.ListInstanceDetails(ctx, o.server.org, &ec2.Filter{
+		Name:   aws.String("instance-state-name"),
+		Values: aws.StringSlice([]string{ec2.InstanceStateNameRunning}),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	start := now.Add(-settings.window)
+	period := idleMetricPeriod(settings.window)
+
+	eligible := []*ec2.Instance{}
+	for _, i := range instances {
+		if i.LaunchTime != nil && !i.LaunchTime.After(start) {
+			eligible = append(eligible, i)
+		}
+	}
+
+	out := []*idleEvaluation{}
+	for n := 0; n < len(eligible); n += idleInstancesPerQuery {
+		batch := eligible[n:int(math.Min(float64(n+idleInstancesPerQuery), float64(len(eligible))))]
+
+		queries := make([]*awscloudwatch.MetricDataQuery, 0, 3*len(batch))
+		for j, i := range batch {
+			dims := map[string]string{"InstanceId": aws.StringValue(i.InstanceId)}
+			queries = append(queries,
+				cloudwatch.MetricQuery(fmt.Sprintf("cpu_%d", j), "AWS/EC2", "CPUUtilization", "Average", period, dims),
+				cloudwatch.MetricQuery(fmt.Sprintf("in_%d", j), "AWS/EC2", "NetworkIn", "Sum", period, dims),
+				cloudwatch.MetricQuery(fmt.Sprintf("out_%d", j), "AWS/EC2", "NetworkOut", "Sum", period, dims),
+			)
+		}
+
+		results, err := o.cloudwatchClient.GetMetricData(ctx, start, now, queries...)
+		if err != nil {
+			return nil, err
+		}
+
+		byId := map[string]*awscloudwatch.MetricDataResult{}
+		for _, r := range results {
+			byId[aws.StringValue(r.Id)] = r
+		}
+
+		for j, i := range batch {
+			e, ok := evaluateIdle(settings, period, byId[fmt.Sprintf("cpu_%d", j)], byId[fmt.Sprintf("in_%d", j)], byId[fmt.Sprintf("out_%d", j)])
+			if !ok {
+				log.Debugf("not enough metrics to decide if instance %s is idle", aws.StringValue(i.InstanceId))
+				continue
+			}
+
+			e.instance = i
+			out = append(out, e)
+		}
+	}
+
+	return out, nil
+}
+
+// evaluateIdle averages the cpu and network datapoints of an instance and decides if it's idle, it returns false
+// when there are too few datapoints to decide
+func evaluateIdle(settings idleSettings, period int64, cpu, in, out *awscloudwatch.MetricDataResult) (*idleEvaluation, bool) {
+	expected := float64(settings.window/time.Second) / float64(period)
+	enough := func(r *awscloudwatch.MetricDataResult) bool {
+		return r != nil && float64(len(r.Values)) >= expected*idleCoverage
+	}
+
+	if !enough(cpu) || !enough(in) || !enough(out) {
+		return nil, false
+	}
+
+	var cpuTotal float64
+	for _, v := range cpu.Values {
+		cpuTotal += aws.Float64Value(v)
+	}
+
+	var bytes float64
+	for _, r := range []*awscloudwatch.MetricDataResult{in, out} {
+		for _, v := range r.Values {
+			bytes += aws.Float64Value(v)
+		}
+	}
+
+	// the traffic is averaged over the periods with datapoints, in and out can have different gaps
+	periods := math.Max(float64(len(in.Values)), float64(len(out.Values)))
+
+	e := &idleEvaluation{
+		cpu:     cpuTotal / float64(len(cpu.Values)),
+		network: bytes / (periods * float64(period)),
+	}
+	e.idle = e.cpu < settings.cpuThreshold && e.network < settings.networkThreshold
+
+	return e, true
+}
+
+// idleInstanceReport reports the idle instances in the org, along with when they're stopped when auto stop is enabled
+func (o *metricsOrchestrator) idleInstanceReport(ctx context.Context, d *idleDetector, settings idleSettings) (*Ec2IdleInstanceReport, error) {
+	now := time.Now()
+
+	evaluations, err := o.evaluateIdleInstances(ctx, settings, now)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &Ec2IdleInstanceReport{
+		Window:           settings.window.String(),
+		CpuThreshold:     settings.cpuThreshold,
+		NetworkThreshold: settings.networkThreshold,
+		AutoStop:         d.autoStop,
+		Instances:        []*Ec2IdleInstance{},
+	}
+
+	if d.autoStop {
+		out.GracePeriod = d.gracePeriod.String()
+	}
+
+	for _, e := range evaluations {
+		if !e.idle {
+			continue
+		}
+
+		out.Instances = append(out.Instances, toEc2IdleInstance(e, d, now))
+	}
+
+	sort.Slice(out.Instances, func(i, j int) bool {
+		return out.Instances[i].InstanceId < out.Instances[j].InstanceId
+	})
+
+	return out, nil
+}
+
+// runIdleDetection records when instances in the org became idle, clears it for instances active again and, when
+// auto stop is enabled, stops the instances idle for longer than the grace period that haven't opted out
+func (o *metricsOrchestrator) runIdleDetection(ctx context.Context, d *idleDetector, now time.Time) error {
+	evaluations, err := o.evaluateIdleInstances(ctx, d.settings, now)
+	if err != nil {
+		return err
+	}
+
+	stop := []string{}
+	for _, e := range evaluations {
+		id := aws.StringValue(e.instance.InstanceId)
+		idleSince, tagged := instanceIdleSince(e.instance)
+
+		switch {
+		case !e.idle && tagged:
+			log.Infof("instance %s isn't idle anymore", id)
+			if err := o.ec2Client.DeleteTags(ctx, []string{idleSinceTag}, id); err != nil {
+				log.Errorf("failed to clear idle tag of instance %s: %s", id, err)
+			}
+		case e.idle && !tagged:
+			log.Infof("instance %s is idle (cpu: %.2f%%, network: %.0f B/s)", id, e.cpu, e.network)
+			if err := o.ec2Client.UpdateRawTags(ctx, map[string]string{idleSinceTag: now.UTC().Format(time.RFC3339)}, id); err != nil {
+				log.Errorf("failed to tag idle instance %s: %s", id, err)
+			}
+		case e.idle && d.autoStop && !instanceIdleOptedOut(e.instance) && !idleSince.Add(d.gracePeriod).After(now):
+			stop = append(stop, id)
+		}
+	}
+
+	if len(stop) == 0 {
+		return nil
+	}
+
+	log.Infof("stopping idle instances %v in account %s", stop, o.account)
+
+	ec2Orch := &ec2Orchestrator{ec2Client: o.ec2Client, account: o.account, server: o.server}
+	if err := ec2Orch.instancesState(ctx, scheduleActionStop, stop...); err != nil {
+		return err
+	}
+
+	// the idle time starts over when the instances are started again
+	return o.ec2Client.DeleteTags(ctx, []string{idleSinceTag}, stop...)
+}
+
+// instanceIdleSince returns when an instance was first found idle since it was launched
+func instanceIdleSince(instance *ec2.Instance) (time.Time, bool) {
+	for _, t := range instance.Tags {
+		if aws.StringValue(t.Key) != idleSinceTag {
+			continue
+		}
+
+		since, err := time.Parse(time.RFC3339, aws.StringValue(t.Value))
+		if err != nil {
+			return time.Time{}, false
+		}
+
+		// a tag left from before the instance was last started doesn't count
+		if instance.LaunchTime != nil && since.Before(aws.TimeValue(instance.LaunchTime)) {
+			return time.Time{}, false
+		}
+
+		return since, true
+	}
+
+	return time.Time{}, false
+}
+
+// instanceIdleOptedOut returns true if an instance has the idle opt out tag
+func instanceIdleOptedOut(instance *ec2.Instance) bool {
+	for _, t := range instance.Tags {
+		if aws.StringValue(t.Key) == idleOptOutTag {
+			return true
+		}
+	}
+	return false
+}
+
+// start checks the instances of the configured accounts every interval until the context is canceled
+func (d *idleDetector) start(ctx context.Context) {
+	log.Infof("starting idle instance detection for accounts %v every %s (auto stop: %t)", d.accounts, d.interval, d.autoStop)
+
+	go func() {
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				log.Info("stopped idle instance detection")
+				return
+			case now := <-ticker.C:
+				for _, account := range d.accounts {
+					if err := d.run(ctx, account, now); err != nil {
+						log.Errorf("failed to detect idle instances in account %s: %s", account, err)
+					}
+				}
+			}
+		}
+	}()
+}
+
+// runAccountIdleDetection detects and stops the idle instances of the org in an account
+func (s *server) runAccountIdleDetection(ctx context.Context, account string, now time.Time) error {
+	policy, err := generatePolicy([]string{"ec2:StopInstances", "ec2:CreateTags", "ec2:DeleteTags"})
+	if err != nil {
+		return err
+	}
+
+	orch, err := s.newMetricsOrchestrator(ctx, &sessionParams{
+		role:         fmt.Sprintf("arn:aws:iam::%s:role/%s", account, s.session.RoleName),
+		inlinePolicy: policy,
+		policyArns: []string{
+			"arn:aws:iam::aws:policy/AmazonEC2ReadOnlyAccess",
+			"arn:aws:iam::aws:policy/CloudWatchReadOnlyAccess",
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return orch.runIdleDetection(ctx, s.idleDetector, now)
+}
+
+// toEc2IdleInstance converts an idle instance into a response
+func toEc2IdleInstance(e *idleEvaluation, d *idleDetector, now time.Time) *Ec2IdleInstance {
+	i := e.instance
+	out := &Ec2IdleInstance{
+		InstanceId:            aws.StringValue(i.InstanceId),
+		InstanceType:          aws.StringValue(i.InstanceType),
+		LaunchTime:            timeFormat(i.LaunchTime),
+		CpuAverage:            math.Round(e.cpu*100) / 100,
+		NetworkBytesPerSecond: math.Round(e.network*100) / 100,
+		OptedOut:              instanceIdleOptedOut(i),
+	}
+
+	for _, t := range i.Tags {
+		if aws.StringValue(t.Key) == "Name" {
+			out.Name = aws.StringValue(t.Value)
+		}
+	}
+
+	if since, ok := instanceIdleSince(i); ok {
+		out.IdleSince = timeFormat(&since)
+
+		if d.autoStop && !out.OptedOut {
+			stopAfter := since.Add(d.gracePeriod)
+			if stopAfter.Before(now) {
+				stopAfter = now
+			}
+			out.StopAfter = timeFormat(&stopAfter)
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,289 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/YaleSpinup/ec2-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func Test_newIdleDetector(t *testing.T) {
+	accountsMap := map[string]string{"spinup": "012345678901", "spinupsec": "109876543210"}
+	defaults := idleSettings{window: defaultIdleWindow, cpuThreshold: defaultIdleCpuThreshold, networkThreshold: defaultIdleNetworkThreshold}
+
+	tests := []struct {
+		name         string
+		config       *common.IdleInstances
+		accountsMap  map[string]string
+		wantSettings idleSettings
+		wantGrace    time.Duration
+		wantAccounts []string
+		wantErr      bool
+	}{
+		{name: "not configured", wantSettings: defaults, wantGrace: defaultIdleGracePeriod},
+		{
+			name:         "defaults",
+			config:       &common.IdleInstances{},
+			accountsMap:  accountsMap,
+			wantSettings: defaults,
+			wantGrace:    defaultIdleGracePeriod,
+			wantAccounts: []string{"012345678901", "109876543210"},
+		},
+		{
+			name:         "configured",
+			config:       &common.IdleInstances{Window: "72h", CpuThreshold: 2, NetworkThreshold: 1024, GracePeriod: "12h", AutoStop: true, Accounts: []string{"spinup"}},
+			accountsMap:  accountsMap,
+			wantSettings: idleSettings{window: 72 * time.Hour, cpuThreshold: 2, networkThreshold: 1024},
+			wantGrace:    12 * time.Hour,
+			wantAccounts: []string{"012345678901"},
+		},
+		{name: "invalid window", config: &common.IdleInstances{Window: "a while"}, accountsMap: accountsMap, wantErr: true},
+		{name: "short window", config: &common.IdleInstances{Window: "10m"}, accountsMap: accountsMap, wantErr: true},
+		{name: "long window", config: &common.IdleInstances{Window: "720h"}, accountsMap: accountsMap, wantErr: true},
+		{name: "cpu over 100", config: &common.IdleInstances{CpuThreshold: 101}, accountsMap: accountsMap, wantErr: true},
+		{name: "negative network", config: &common.IdleInstances{NetworkThreshold: -1}, accountsMap: accountsMap, wantErr: true},
+		{name: "negative grace period", config: &common.IdleInstances{GracePeriod: "-1h"}, accountsMap: accountsMap, wantErr: true},
+		{name: "short interval", config: &common.IdleInstances{Interval: "10s"}, accountsMap: accountsMap, wantErr: true},
+		{name: "no accounts", config: &common.IdleInstances{}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newIdleDetector(tt.config, tt.accountsMap, nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("newIdleDetector() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got.settings != tt.wantSettings || got.gracePeriod != tt.wantGrace {
+				t.Errorf("newIdleDetector() = %+v with grace period %s, want %+v with grace period %s", got.settings, got.gracePeriod, tt.wantSettings, tt.wantGrace)
+			}
+
+			if !reflect.DeepEqual(got.accounts, tt.wantAccounts) {
+				t.Errorf("newIdleDetector() accounts = %v, want %v", got.accounts, tt.wantAccounts)
+			}
+		})
+	}
+}
+
+func Test_parseIdleSettings(t *testing.T) {
+	defaults := idleSettings{window: defaultIdleWindow, cpuThreshold: defaultIdleCpuThreshold, networkThreshold: defaultIdleNetworkThreshold}
+
+	tests := []struct {
+		name    string
+		window  string
+		cpu     string
+		network string
+		want    idleSettings
+		wantErr bool
+	}{
+		{name: "defaults", want: defaults},
+		{name: "overrides", window: "48h", cpu: "1.5", network: "100", want: idleSettings{window: 48 * time.Hour, cpuThreshold: 1.5, networkThreshold: 100}},
+		{name: "invalid window", window: "2d", wantErr: true},
+		{name: "invalid cpu", cpu: "low", wantErr: true},
+		{name: "zero cpu", cpu: "0", wantErr: true},
+		{name: "invalid network", network: "1kb", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseIdleSettings(defaults, tt.window, tt.cpu, tt.network)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseIdleSettings() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseIdleSettings() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_evaluateIdle(t *testing.T) {
+	settings := idleSettings{window: 6 * time.Hour, cpuThreshold: 5, networkThreshold: 1000}
+
+	result := func(values ...float64) *cloudwatch.MetricDataResult {
+		return &cloudwatch.MetricDataResult{Values: aws.Float64Slice(values)}
+	}
+
+	tests := []struct {
+		name        string
+		cpu         *cloudwatch.MetricDataResult
+		in          *cloudwatch.MetricDataResult
+		out         *cloudwatch.MetricDataResult
+		wantCpu     float64
+		wantNetwork float64
+		wantIdle    bool
+		wantOk      bool
+	}{
+		{
+			name:        "idle",
+			cpu:         result(1, 2, 3, 1, 2, 3),
+			in:          result(360000, 360000, 360000, 360000, 360000, 360000),
+			out:         result(0, 0, 0, 0, 0, 0),
+			wantCpu:     2,
+			wantNetwork: 100,
+			wantIdle:    true,
+			wantOk:      true,
+		},
+		{
+			name:        "busy cpu",
+			cpu:         result(50, 60, 70),
+			in:          result(0, 0, 0),
+			out:         result(0, 0, 0),
+			wantCpu:     60,
+			wantNetwork: 0,
+			wantOk:      true,
+		},
+		{
+			name:        "busy network",
+			cpu:         result(1, 1, 1),
+			in:          result(3600000, 3600000, 3600000),
+			out:         result(3600000, 3600000, 3600000),
+			wantCpu:     1,
+			wantNetwork: 2000,
+			wantOk:      true,
+		},
+		{name: "not enough datapoints", cpu: result(1, 1), in: result(0, 0), out: result(0, 0)},
+		{name: "missing metric", cpu: result(1, 1, 1), in: result(0, 0, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := evaluateIdle(settings, 3600, tt.cpu, tt.in, tt.out)
+			if ok != tt.wantOk {
+				t.Fatalf("evaluateIdle() ok = %v, want %v", ok, tt.wantOk)
+			}
+
+			if !ok {
+				return
+			}
+
+			if got.cpu != tt.wantCpu || got.network != tt.wantNetwork || got.idle != tt.wantIdle {
+				t.Errorf("evaluateIdle() = cpu %v, network %v, idle %v, want cpu %v, network %v, idle %v", got.cpu, got.network, got.idle, tt.wantCpu, tt.wantNetwork, tt.wantIdle)
+			}
+		})
+	}
+}
+
+func Test_instanceIdleSince(t *testing.T) {
+	launched := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		tags   []*ec2.Tag
+		want   time.Time
+		wantOk bool
+	}{
+		{name: "not idle"},
+		{
+			name:   "idle",
+			tags:   []*ec2.Tag{{Key: aws.String(idleSinceTag), Value: aws.String("2023-01-03T10:00:00Z")}},
+			want:   time.Date(2023, 1, 3, 10, 0, 0, 0, time.UTC),
+			wantOk: true,
+		},
+		{name: "before launch", tags: []*ec2.Tag{{Key: aws.String(idleSinceTag), Value: aws.String("2023-01-01T10:00:00Z")}}},
+		{name: "invalid", tags: []*ec2.Tag{{Key: aws.String(idleSinceTag), Value: aws.String("yesterday")}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := instanceIdleSince(&ec2.Instance{LaunchTime: aws.Time(launched), Tags: tt.tags})
+			if ok != tt.wantOk || !got.Equal(tt.want) {
+				t.Errorf("instanceIdleSince() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func Test_toEc2IdleInstance(t *testing.T) {
+	now := time.Date(2023, 1, 4, 0, 0, 0, 0, time.UTC)
+	instance := func(tags ...*ec2.Tag) *ec2.Instance {
+		return &ec2.Instance{
+			InstanceId:   aws.String("i-0123456789abcdef0"),
+			InstanceType: aws.String("t3.large"),
+			LaunchTime:   aws.Time(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)),
+			Tags:         append([]*ec2.Tag{{Key: aws.String("Name"), Value: aws.String("forgotten")}}, tags...),
+		}
+	}
+	idleSince := &ec2.Tag{Key: aws.String(idleSinceTag), Value: aws.String("2023-01-03T12:00:00Z")}
+
+	tests := []struct {
+		name     string
+		instance *ec2.Instance
+		autoStop bool
+		want     *Ec2IdleInstance
+	}{
+		{
+			name:     "newly idle",
+			instance: instance(),
+			autoStop: true,
+			want: &Ec2IdleInstance{
+				InstanceId:            "i-0123456789abcdef0",
+				Name:                  "forgotten",
+				InstanceType:          "t3.large",
+				LaunchTime:            "2023/01/01 00:00:00",
+				CpuAverage:            1.23,
+				NetworkBytesPerSecond: 42.5,
+			},
+		},
+		{
+			name:     "stopped after grace period",
+			instance: instance(idleSince),
+			autoStop: true,
+			want: &Ec2IdleInstance{
+				InstanceId:            "i-0123456789abcdef0",
+				Name:                  "forgotten",
+				InstanceType:          "t3.large",
+				LaunchTime:            "2023/01/01 00:00:00",
+				CpuAverage:            1.23,
+				NetworkBytesPerSecond: 42.5,
+				IdleSince:             "2023/01/03 12:00:00",
+				StopAfter:             "2023/01/04 12:00:00",
+			},
+		},
+		{
+			name:     "opted out",
+			instance: instance(idleSince, &ec2.Tag{Key: aws.String(idleOptOutTag), Value: aws.String("true")}),
+			autoStop: true,
+			want: &Ec2IdleInstance{
+				InstanceId:            "i-0123456789abcdef0",
+				Name:                  "forgotten",
+				InstanceType:          "t3.large",
+				LaunchTime:            "2023/01/01 00:00:00",
+				CpuAverage:            1.23,
+				NetworkBytesPerSecond: 42.5,
+				IdleSince:             "2023/01/03 12:00:00",
+				OptedOut:              true,
+			},
+		},
+		{
+			name:     "auto stop disabled",
+			instance: instance(idleSince),
+			want: &Ec2IdleInstance{
+				InstanceId:            "i-0123456789abcdef0",
+				Name:                  "forgotten",
+				InstanceType:          "t3.large",
+				LaunchTime:            "2023/01/01 00:00:00",
+				CpuAverage:            1.23,
+				NetworkBytesPerSecond: 42.5,
+				IdleSince:             "2023/01/03 12:00:00",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &idleDetector{autoStop: tt.autoStop, gracePeriod: 24 * time.Hour}
+			e := &idleEvaluation{instance: tt.instance, cpu: 1.2345, network: 42.5, idle: true}
+
+			if got := toEc2IdleInstance(e, d, now); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("toEc2IdleInstance() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -41,6 +41,8 @@ func (o *ec2Orchestrator) createImage(ctx context.Context, req *Ec2ImageCreateRe
 		return "", err
 	}
 
+	o.notify(webhookImageCreated, imageId)
+
 	return imageId, nil
 }
 
@@ -55,6 +57,8 @@ func (o *ec2Orchestrator) deleteImage(ctx context.Context, id string) error {
 		return err
 	}
 
+	o.notify(webhookImageDeleted, id)
+
 	snapshotinput := &ec2.DescribeSnapshotsInput{
 		Filters: []*ec2.Filter{{Name: aws.String("description"), Values: aws.StringSlice([]string{fmt.Sprintf("*for %s from vol*", id)})}},
 	}
@@ -69,7 +73,10 @@ func (o *ec2Orchestrator) deleteImage(ctx context.Context, id string) error {
 		}
 		if err := o.ec2Client.DeleteSnapshot(ctx, input); err != nil {
 			log.Warnf("failed to delete snapshot %s: %v", aws.StringValue(s.SnapshotId), err)
+			continue
 		}
+
+		o.notify(webhookSnapshotDeleted, aws.StringValue(s.SnapshotId))
 	}
 	return nil
 }
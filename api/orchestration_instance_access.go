@@ -0,0 +1,279 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+)
+
+// instanceConnectKeyTTL is how long a public key pushed with ec2 instance connect is valid
+const instanceConnectKeyTTL = 60 * time.Second
+
+// osUserRegex matches valid linux user names
+var osUserRegex = regexp.MustCompile(`^[a-z_][a-z0-9_-]{0,31}$`)
+
+// instanceConnectKeyTypes are the ssh public key types supported by ec2 instance connect
+var instanceConnectKeyTypes = map[string]bool{
+	ssh.KeyAlgoRSA:      true,
+	ssh.KeyAlgoED25519:  true,
+	ssh.KeyAlgoECDSA256: true,
+	ssh.KeyAlgoECDSA384: true,
+	ssh.KeyAlgoECDSA521: true,
+}
+
+// session manager documents sessions can be started with
+const (
+	sessionDocumentShell          = "SSM-SessionManagerRunShell"
+	sessionDocumentPortForwarding = "AWS-StartPortForwardingSession"
+)
+
+// sessionDocuments are the session manager documents sessions can be started with, and the validation of the parameters
+// they take.  Documents that run commands or forward ports to other hosts aren't allowed.
+var sessionDocuments = map[string]map[string]func(string) bool{
+	sessionDocumentShell: {},
+	sessionDocumentPortForwarding: {
+		"portNumber":      validSessionPort,
+		"localPortNumber": validSessionPort,
+	},
+}
+
+// sendSSHPublicKey pushes a short-lived ssh public key to a running linux instance for the os user and returns
+// the host to connect to.  The request is logged with the identity of the requester.
+func (o *instanceAccessOrchestrator) sendSSHPublicKey(ctx context.Context, id string, req *Ec2InstanceConnectRequest) (*Ec2InstanceConnectResponse, error) {
+	if id == "" || req == nil {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	if err := validateInstanceConnectRequest(req); err != nil {
+		return nil, err
+	}
+
+	instance, err := o.ec2Client.GetInstance(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := instanceConnectReady(instance); err != nil {
+		return nil, err
+	}
+
+	log.Infof("access: %s requested ssh access to instance %s as %s", req.RequestedBy, id, req.OsUser)
+
+	requestId, err := o.connectClient.SendSSHPublicKey(ctx, id, req.OsUser, req.PublicKey)
+	if err != nil {
+		log.Warnf("access: failed to send ssh public key for %s to instance %s as %s: %s", req.RequestedBy, id, req.OsUser, err)
+		return nil, err
+	}
+
+	log.Infof("access: sent ssh public key for %s to instance %s as %s (request id: %s)", req.RequestedBy, id, req.OsUser, requestId)
+
+	host := aws.StringValue(instance.PublicIpAddress)
+	if host == "" {
+		host = aws.StringValue(instance.PrivateIpAddress)
+	}
+
+	return &Ec2InstanceConnectResponse{
+		InstanceId: id,
+		OsUser:     req.OsUser,
+		Host:       host,
+		RequestId:  requestId,
+		ExpiresAt:  time.Now().UTC().Add(instanceConnectKeyTTL).Format(time.RFC3339),
+	}, nil
+}
+
+// startSession starts an ssm session manager session on a running instance that's online in ssm.  The request
+// is logged with the identity of the requester.
+func (o *instanceAccessOrchestrator) startSession(ctx context.Context, id string, req *Ec2InstanceSessionRequest) (*Ec2InstanceSessionResponse, error) {
+	if id == "" || req == nil {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	if err := validateInstanceSessionRequest(req); err != nil {
+		return nil, err
+	}
+
+	instance, err := o.ec2Client.GetInstance(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if state := instanceStateName(instance); state != ec2.InstanceStateNameRunning {
+		msg := fmt.Sprintf("instance is %s, it must be running to start a session", state)
+		return nil, apierror.New(apierror.ErrConflict, msg, nil)
+	}
+
+	ready, err := checkInstanceSSMStatus(ctx, o.ssmClient, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ready {
+		return nil, apierror.New(apierror.ErrConflict, "instance isn't managed by ssm or isn't online", nil)
+	}
+
+	input := &ssm.StartSessionInput{
+		Target: aws.String(id),
+	}
+
+	if req.DocumentName != "" {
+		input.DocumentName = aws.String(req.DocumentName)
+	}
+
+	if len(req.Parameters) > 0 {
+		input.Parameters = make(map[string][]*string, len(req.Parameters))
+		for k, v := range req.Parameters {
+			input.Parameters[k] = aws.StringSlice(v)
+		}
+	}
+
+	reason := req.Reason
+	if reason == "" {
+		reason = "requested by " + req.RequestedBy
+	}
+	input.Reason = aws.String(reason)
+
+	log.Infof("access: %s requested a session on instance %s (document: '%s', reason: '%s')", req.RequestedBy, id, req.DocumentName, reason)
+
+	out, err := o.ssmClient.StartSession(ctx, input)
+	if err != nil {
+		log.Warnf("access: failed to start session for %s on instance %s: %s", req.RequestedBy, id, err)
+		return nil, err
+	}
+
+	log.Infof("access: started session %s for %s on instance %s", aws.StringValue(out.SessionId), req.RequestedBy, id)
+
+	return &Ec2InstanceSessionResponse{
+		InstanceId: id,
+		SessionId:  aws.StringValue(out.SessionId),
+		StreamUrl:  aws.StringValue(out.StreamUrl),
+		TokenValue: aws.StringValue(out.TokenValue),
+	}, nil
+}
+
+// terminateSession terminates an ssm session manager session on an instance.  The request is logged with the
+// unverified identity given by the requester and the address the request came from.
+func (o *instanceAccessOrchestrator) terminateSession(ctx context.Context, id, sessionId, requestedBy, source string) error {
+	if id == "" || sessionId == "" {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("access: %s (from %s) requested termination of session %s on instance %s", requestedBy, source, sessionId, id)
+
+	session, err := o.ssmClient.GetSession(ctx, sessionId)
+	if err != nil {
+		return err
+	}
+
+	if aws.StringValue(session.Target) != id {
+		log.Warnf("access: session %s isn't on instance %s, not terminating it for %s (from %s)", sessionId, id, requestedBy, source)
+		return apierror.New(apierror.ErrNotFound, "session not found", nil)
+	}
+
+	return o.ssmClient.TerminateSession(ctx, sessionId)
+}
+
+// validateInstanceConnectRequest validates the os user, the ssh public key and the requester of an instance connect request
+func validateInstanceConnectRequest(req *Ec2InstanceConnectRequest) error {
+	if req.RequestedBy == "" {
+		return apierror.New(apierror.ErrBadRequest, "requested_by is required", nil)
+	}
+
+	if !osUserRegex.MatchString(req.OsUser) {
+		return apierror.New(apierror.ErrBadRequest, "os_user must be a valid user name", nil)
+	}
+
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(req.PublicKey))
+	if err != nil {
+		return apierror.New(apierror.ErrBadRequest, "public_key must be an ssh public key in authorized_keys format", err)
+	}
+
+	if !instanceConnectKeyTypes[key.Type()] {
+		msg := fmt.Sprintf("public_key type %s isn't supported, use rsa, ed25519 or ecdsa", key.Type())
+		return apierror.New(apierror.ErrBadRequest, msg, nil)
+	}
+
+	return nil
+}
+
+// validateInstanceSessionRequest validates a start session request, only the allowed session documents can be used with
+// their parameters
+func validateInstanceSessionRequest(req *Ec2InstanceSessionRequest) error {
+	if req.RequestedBy == "" {
+		return apierror.New(apierror.ErrBadRequest, "requested_by is required", nil)
+	}
+
+	document := req.DocumentName
+	if document == "" {
+		document = sessionDocumentShell
+	}
+
+	params, ok := sessionDocuments[document]
+	if !ok {
+		names := make([]string, 0, len(sessionDocuments))
+		for n := range sessionDocuments {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+
+		msg := fmt.Sprintf("document_name %s isn't allowed, it must be one of %s", document, strings.Join(names, ", "))
+		return apierror.New(apierror.ErrBadRequest, msg, nil)
+	}
+
+	for k, v := range req.Parameters {
+		valid, ok := params[k]
+		if !ok {
+			msg := fmt.Sprintf("parameter %s isn't allowed for document %s", k, document)
+			return apierror.New(apierror.ErrBadRequest, msg, nil)
+		}
+
+		if len(v) != 1 || !valid(v[0]) {
+			msg := fmt.Sprintf("invalid value for parameter %s of document %s", k, document)
+			return apierror.New(apierror.ErrBadRequest, msg, nil)
+		}
+	}
+
+	if document == sessionDocumentPortForwarding && len(req.Parameters["portNumber"]) == 0 {
+		return apierror.New(apierror.ErrBadRequest, "parameter portNumber is required for port forwarding", nil)
+	}
+
+	return nil
+}
+
+// validSessionPort returns true for a valid port number
+func validSessionPort(v string) bool {
+	p, err := strconv.Atoi(v)
+	return err == nil && p > 0 && p <= 65535
+}
+
+// instanceConnectReady checks if an ssh public key can be pushed to an instance with ec2 instance connect
+func instanceConnectReady(instance *ec2.Instance) error {
+	if state := instanceStateName(instance); state != ec2.InstanceStateNameRunning {
+		msg := fmt.Sprintf("instance is %s, it must be running to push an ssh public key", state)
+		return apierror.New(apierror.ErrConflict, msg, nil)
+	}
+
+	if strings.EqualFold(aws.StringValue(instance.Platform), ec2.PlatformValuesWindows) {
+		return apierror.New(apierror.ErrBadRequest, "ec2 instance connect isn't supported on windows instances", nil)
+	}
+
+	return nil
+}
+
+// instanceStateName returns the name of the state of an instance
+func instanceStateName(instance *ec2.Instance) string {
+	if instance.State == nil {
+		return ""
+	}
+	return aws.StringValue(instance.State.Name)
+}
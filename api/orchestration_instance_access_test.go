@@ -0,0 +1,199 @@
+package api
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"golang.org/x/crypto/ssh"
+)
+
+func Test_validateInstanceConnectRequest(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey := string(ssh.MarshalAuthorizedKey(sshPub))
+
+	tests := []struct {
+		name    string
+		req     *Ec2InstanceConnectRequest
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			req:  &Ec2InstanceConnectRequest{OsUser: "ec2-user", PublicKey: publicKey, RequestedBy: "ba321"},
+		},
+		{
+			name:    "missing requested by",
+			req:     &Ec2InstanceConnectRequest{OsUser: "ec2-user", PublicKey: publicKey},
+			wantErr: true,
+		},
+		{
+			name:    "missing os user",
+			req:     &Ec2InstanceConnectRequest{PublicKey: publicKey, RequestedBy: "ba321"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid os user",
+			req:     &Ec2InstanceConnectRequest{OsUser: "root; rm -rf /", PublicKey: publicKey, RequestedBy: "ba321"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid public key",
+			req:     &Ec2InstanceConnectRequest{OsUser: "ec2-user", PublicKey: "ssh-ed25519 notakey", RequestedBy: "ba321"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateInstanceConnectRequest(tt.req); (err != nil) != tt.wantErr {
+				t.Errorf("validateInstanceConnectRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_validateInstanceSessionRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     *Ec2InstanceSessionRequest
+		wantErr bool
+	}{
+		{
+			name: "default shell",
+			req:  &Ec2InstanceSessionRequest{RequestedBy: "ba321"},
+		},
+		{
+			name: "shell",
+			req:  &Ec2InstanceSessionRequest{DocumentName: "SSM-SessionManagerRunShell", RequestedBy: "ba321"},
+		},
+		{
+			name: "port forwarding",
+			req: &Ec2InstanceSessionRequest{
+				DocumentName: "AWS-StartPortForwardingSession",
+				Parameters:   map[string][]string{"portNumber": {"80"}, "localPortNumber": {"8080"}},
+				RequestedBy:  "ba321",
+			},
+		},
+		{
+			name:    "missing requested by",
+			req:     &Ec2InstanceSessionRequest{},
+			wantErr: true,
+		},
+		{
+			name: "port forwarding to a remote host",
+			req: &Ec2InstanceSessionRequest{
+				DocumentName: "AWS-StartPortForwardingSessionToRemoteHost",
+				Parameters:   map[string][]string{"host": {"10.0.0.1"}, "portNumber": {"22"}},
+				RequestedBy:  "ba321",
+			},
+			wantErr: true,
+		},
+		{
+			name: "interactive command",
+			req: &Ec2InstanceSessionRequest{
+				DocumentName: "AWS-StartInteractiveCommand",
+				Parameters:   map[string][]string{"command": {"cat /etc/shadow"}},
+				RequestedBy:  "ba321",
+			},
+			wantErr: true,
+		},
+		{
+			name: "shell with parameters",
+			req: &Ec2InstanceSessionRequest{
+				Parameters:  map[string][]string{"portNumber": {"22"}},
+				RequestedBy: "ba321",
+			},
+			wantErr: true,
+		},
+		{
+			name: "port forwarding with an unknown parameter",
+			req: &Ec2InstanceSessionRequest{
+				DocumentName: "AWS-StartPortForwardingSession",
+				Parameters:   map[string][]string{"portNumber": {"80"}, "host": {"10.0.0.1"}},
+				RequestedBy:  "ba321",
+			},
+			wantErr: true,
+		},
+		{
+			name: "port forwarding with an invalid port",
+			req: &Ec2InstanceSessionRequest{
+				DocumentName: "AWS-StartPortForwardingSession",
+				Parameters:   map[string][]string{"portNumber": {"70000"}},
+				RequestedBy:  "ba321",
+			},
+			wantErr: true,
+		},
+		{
+			name: "port forwarding without a port",
+			req: &Ec2InstanceSessionRequest{
+				DocumentName: "AWS-StartPortForwardingSession",
+				Parameters:   map[string][]string{"localPortNumber": {"8080"}},
+				RequestedBy:  "ba321",
+			},
+			wantErr: true,
+		},
+		{
+			name: "port forwarding with multiple ports",
+			req: &Ec2InstanceSessionRequest{
+				DocumentName: "AWS-StartPortForwardingSession",
+				Parameters:   map[string][]string{"portNumber": {"80", "443"}},
+				RequestedBy:  "ba321",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateInstanceSessionRequest(tt.req); (err != nil) != tt.wantErr {
+				t.Errorf("validateInstanceSessionRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_instanceConnectReady(t *testing.T) {
+	tests := []struct {
+		name     string
+		instance *ec2.Instance
+		wantErr  bool
+	}{
+		{
+			name:     "running linux",
+			instance: &ec2.Instance{State: &ec2.InstanceState{Name: aws.String("running")}},
+		},
+		{
+			name:     "stopped",
+			instance: &ec2.Instance{State: &ec2.InstanceState{Name: aws.String("stopped")}},
+			wantErr:  true,
+		},
+		{
+			name: "windows",
+			instance: &ec2.Instance{
+				State:    &ec2.InstanceState{Name: aws.String("running")},
+				Platform: aws.String("windows"),
+			},
+			wantErr: true,
+		},
+		{
+			name:     "missing state",
+			instance: &ec2.Instance{},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := instanceConnectReady(tt.instance); (err != nil) != tt.wantErr {
+				t.Errorf("instanceConnectReady() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// instanceConsoleOutput gets the decoded system console output of an instance, either the latest output or the
+// buffered output from the last boot
+func (o *ec2Orchestrator) instanceConsoleOutput(ctx context.Context, id string, latest bool) (*Ec2InstanceConsoleResponse, error) {
+	if id == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	out, err := o.ec2Client.GetConsoleOutput(ctx, id, latest)
+	if err != nil {
+		return nil, err
+	}
+
+	return toEc2InstanceConsoleResponse(out, latest)
+}
+
+// instanceConsoleScreenshot gets a jpeg screenshot of the console of an instance
+func (o *ec2Orchestrator) instanceConsoleScreenshot(ctx context.Context, id string, wakeUp bool) ([]byte, error) {
+	if id == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	return o.ec2Client.GetConsoleScreenshot(ctx, id, wakeUp)
+}
+
+// toEc2InstanceConsoleResponse decodes the console output of an instance, the output is empty if the instance
+// hasn't written any yet
+func toEc2InstanceConsoleResponse(out *ec2.GetConsoleOutputOutput, latest bool) (*Ec2InstanceConsoleResponse, error) {
+	output, err := base64.StdEncoding.DecodeString(aws.StringValue(out.Output))
+	if err != nil {
+		return nil, apierror.New(apierror.ErrInternalError, "failed to decode console output", err)
+	}
+
+	return &Ec2InstanceConsoleResponse{
+		InstanceId: aws.StringValue(out.InstanceId),
+		Latest:     latest,
+		Timestamp:  timeFormat(out.Timestamp),
+		Output:     string(output),
+	}, nil
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func Test_toEc2InstanceConsoleResponse(t *testing.T) {
+	ts := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		out     *ec2.GetConsoleOutputOutput
+		latest  bool
+		want    *Ec2InstanceConsoleResponse
+		wantErr bool
+	}{
+		{
+			name: "output",
+			out: &ec2.GetConsoleOutputOutput{
+				InstanceId: aws.String("i-123"),
+				Output:     aws.String("WyAgICAwLjAwMDAwMF0gTGludXggdmVyc2lvbgo="),
+				Timestamp:  &ts,
+			},
+			latest: true,
+			want: &Ec2InstanceConsoleResponse{
+				InstanceId: "i-123",
+				Latest:     true,
+				Timestamp:  timeFormat(&ts),
+				Output:     "[    0.000000] Linux version\n",
+			},
+		},
+		{
+			name: "no output yet",
+			out:  &ec2.GetConsoleOutputOutput{InstanceId: aws.String("i-123")},
+			want: &Ec2InstanceConsoleResponse{InstanceId: "i-123"},
+		},
+		{
+			name: "invalid output",
+			out: &ec2.GetConsoleOutputOutput{
+				InstanceId: aws.String("i-123"),
+				Output:     aws.String("not base64!"),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := toEc2InstanceConsoleResponse(tt.out, tt.latest)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("toEc2InstanceConsoleResponse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("toEc2InstanceConsoleResponse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
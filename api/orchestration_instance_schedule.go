@@ -0,0 +1,555 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	// embed the time zone database, so schedules don't depend on the zoneinfo of the host
+	_ "time/tzdata"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/ec2-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// tags storing the power schedule of an instance
+const (
+	scheduleTag          = "spinup:schedule"
+	scheduleTimezoneTag  = "spinup:schedule-timezone"
+	scheduleSkipUntilTag = "spinup:schedule-skip-until"
+)
+
+// scheduleTags are all the tags of a power schedule
+var scheduleTags = []string{scheduleTag, scheduleTimezoneTag, scheduleSkipUntilTag}
+
+// actions of the rules of a power schedule
+const (
+	scheduleActionStart = "start"
+	scheduleActionStop  = "stop"
+)
+
+// scheduleRuleSeparator separates the rules of a schedule in the schedule tag
+const scheduleRuleSeparator = ";"
+
+// maxScheduleTagLength is the longest value of an ec2 tag
+const maxScheduleTagLength = 256
+
+// defaultScheduleInterval is how often schedules are checked when no interval is configured
+const defaultScheduleInterval = time.Minute
+
+// scheduleDeferTimeout is how long a scheduled stop of a pending instance is retried
+const scheduleDeferTimeout = time.Hour
+
+// instanceSchedule is the parsed power schedule of an instance
+type instanceSchedule struct {
+	location  *time.Location
+	rules     []*instanceScheduleRule
+	skipUntil time.Time
+}
+
+// instanceScheduleRule is a parsed rule of a power schedule
+type instanceScheduleRule struct {
+	action string
+	expr   string
+	cron   *cronSchedule
+}
+
+// scheduledAction is an occurrence of a rule of a power schedule
+type scheduledAction struct {
+	action string
+	time   time.Time
+}
+
+// newInstanceSchedule validates the time zone and the rules of a power schedule
+func newInstanceSchedule(timezone string, rules []*Ec2InstanceScheduleRule) (*instanceSchedule, error) {
+	if timezone == "" {
+		timezone = "UTC"
+	}
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		msg := fmt.Sprintf("invalid time zone '%s'", timezone)
+		return nil, apierror.New(apierror.ErrBadRequest, msg, err)
+	}
+
+	schedule := &instanceSchedule{location: loc}
+	for _, r := range rules {
+		if r == nil {
+			return nil, apierror.New(apierror.ErrBadRequest, "schedule rules can't be empty", nil)
+		}
+
+		action := strings.ToLower(r.Action)
+		if action != scheduleActionStart && action != scheduleActionStop {
+			msg := fmt.Sprintf("invalid schedule action '%s', it must be start or stop", r.Action)
+			return nil, apierror.New(apierror.ErrBadRequest, msg, nil)
+		}
+
+		expr := strings.Join(strings.Fields(r.Cron), " ")
+		if strings.Contains(expr, scheduleRuleSeparator) {
+			return nil, apierror.New(apierror.ErrBadRequest, "cron expressions can't contain "+scheduleRuleSeparator, nil)
+		}
+
+		cron, err := parseCron(expr)
+		if err != nil {
+			return nil, apierror.New(apierror.ErrBadRequest, err.Error(), nil)
+		}
+
+		if _, ok := cron.next(time.Now().In(loc)); !ok {
+			msg := fmt.Sprintf("cron expression '%s' never matches", expr)
+			return nil, apierror.New(apierror.ErrBadRequest, msg, nil)
+		}
+
+		schedule.rules = append(schedule.rules, &instanceScheduleRule{action: action, expr: expr, cron: cron})
+	}
+
+	if v := schedule.tags()[scheduleTag]; len(v) > maxScheduleTagLength {
+		msg := fmt.Sprintf("schedule rules are too long, they must fit in %d characters", maxScheduleTagLength)
+		return nil, apierror.New(apierror.ErrBadRequest, msg, nil)
+	}
+
+	return schedule, nil
+}
+
+// parseInstanceSchedule parses the power schedule stored in the tags of an instance, it returns nil when the
+// instance has no schedule
+func parseInstanceSchedule(tags []*ec2.Tag) (*instanceSchedule, error) {
+	values := map[string]string{}
+	for _, t := range tags {
+		values[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+	}
+
+	value, ok := values[scheduleTag]
+	if !ok || strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+
+	rules := []*Ec2InstanceScheduleRule{}
+	for _, r := range strings.Split(value, scheduleRuleSeparator) {
+		if r = strings.TrimSpace(r); r == "" {
+			continue
+		}
+
+		parts := strings.SplitN(r, " ", 2)
+		if len(parts) != 2 {
+			return nil, errors.Errorf("invalid schedule rule '%s'", r)
+		}
+
+		rules = append(rules, &Ec2InstanceScheduleRule{Action: parts[0], Cron: parts[1]})
+	}
+
+	schedule, err := newInstanceSchedule(values[scheduleTimezoneTag], rules)
+	if err != nil {
+		return nil, err
+	}
+
+	if v := values[scheduleSkipUntilTag]; v != "" {
+		skipUntil, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid schedule skip until '%s'", v)
+		}
+		schedule.skipUntil = skipUntil
+	}
+
+	return schedule, nil
+}
+
+// tags returns the tags storing a power schedule
+func (s *instanceSchedule) tags() map[string]string {
+	rules := make([]string, 0, len(s.rules))
+	for _, r := range s.rules {
+		rules = append(rules, r.action+" "+r.expr)
+	}
+
+	tags := map[string]string{
+		scheduleTag:         strings.Join(rules, scheduleRuleSeparator),
+		scheduleTimezoneTag: s.location.String(),
+	}
+
+	if !s.skipUntil.IsZero() {
+		tags[scheduleSkipUntilTag] = s.skipUntil.UTC().Format(time.RFC3339)
+	}
+
+	return tags
+}
+
+// next returns the first action of the schedule after t, or nil if the rules never match
+func (s *instanceSchedule) next(t time.Time) *scheduledAction {
+	var next *scheduledAction
+	for _, r := range s.rules {
+		n, ok := r.cron.next(t.In(s.location))
+		if !ok {
+			continue
+		}
+
+		if next == nil || n.Before(next.time) {
+			next = &scheduledAction{action: r.action, time: n}
+		}
+	}
+
+	return next
+}
+
+// due returns the last action of the schedule after from and until to, or nil if there's none.  When several
+// actions are due, only the last one matters since it decides the state the instance should be in.
+func (s *instanceSchedule) due(from, to time.Time) *scheduledAction {
+	var due *scheduledAction
+	for a := s.next(from); a != nil && !a.time.After(to); a = s.next(a.time) {
+		due = a
+	}
+
+	return due
+}
+
+// skipped returns true if an action is skipped by the override of the schedule
+func (s *instanceSchedule) skipped(a *scheduledAction) bool {
+	return a.time.Before(s.skipUntil)
+}
+
+// getInstanceSchedule gets the power schedule of an instance, instances without a schedule have no rules
+func (o *ec2Orchestrator) getInstanceSchedule(ctx context.Context, id string) (*Ec2InstanceSchedule, error) {
+	if id == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	instance, err := o.ec2Client.GetInstance(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule, err := parseInstanceSchedule(instance.Tags)
+	if err != nil {
+		msg := fmt.Sprintf("invalid schedule tags on instance %s: %s", id, err)
+		return nil, apierror.New(apierror.ErrConflict, msg, err)
+	}
+
+	return toEc2InstanceSchedule(id, schedule, time.Now()), nil
+}
+
+// updateInstanceSchedule replaces the power schedule of an instance and returns it.  An empty list of rules removes
+// the schedule, when the rules are omitted only the skip of the existing schedule is changed.
+func (o *ec2Orchestrator) updateInstanceSchedule(ctx context.Context, id string, req *Ec2InstanceScheduleRequest) (*Ec2InstanceSchedule, error) {
+	if id == "" || req == nil {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	if req.SkipNext && req.SkipUntil != nil {
+		return nil, apierror.New(apierror.ErrBadRequest, "skip_next and skip_until can't be combined", nil)
+	}
+
+	instance, err := o.ec2Client.GetInstance(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// an invalid existing schedule is replaced by the rules in the request
+	current, err := parseInstanceSchedule(instance.Tags)
+	if err != nil && req.Rules == nil {
+		msg := fmt.Sprintf("invalid schedule tags on instance %s: %s", id, err)
+		return nil, apierror.New(apierror.ErrConflict, msg, err)
+	}
+
+	if req.Rules != nil && len(req.Rules) == 0 {
+		if req.SkipNext || req.SkipUntil != nil {
+			return nil, apierror.New(apierror.ErrBadRequest, "a schedule without rules can't be skipped", nil)
+		}
+
+		log.Infof("removing schedule of instance %s", id)
+
+		if err := o.ec2Client.DeleteTags(ctx, scheduleTags, id); err != nil {
+			return nil, err
+		}
+
+		return toEc2InstanceSchedule(id, nil, time.Now()), nil
+	}
+
+	schedule := current
+	if req.Rules != nil {
+		if schedule, err = newInstanceSchedule(req.Timezone, req.Rules); err != nil {
+			return nil, err
+		}
+
+		if current != nil {
+			schedule.skipUntil = current.skipUntil
+		}
+	} else if schedule == nil {
+		return nil, apierror.New(apierror.ErrBadRequest, "instance has no schedule, rules are required", nil)
+	} else if req.Timezone != "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "timezone can only be changed along with the rules", nil)
+	}
+
+	now := time.Now()
+	switch {
+	case req.SkipNext:
+		next := schedule.next(now)
+		if next == nil {
+			return nil, apierror.New(apierror.ErrBadRequest, "schedule has no next action to skip", nil)
+		}
+		schedule.skipUntil = next.time.Add(time.Minute)
+	case req.SkipUntil != nil && *req.SkipUntil == "":
+		schedule.skipUntil = time.Time{}
+	case req.SkipUntil != nil:
+		skipUntil, err := time.Parse(time.RFC3339, *req.SkipUntil)
+		if err != nil {
+			return nil, apierror.New(apierror.ErrBadRequest, "skip_until must be an RFC3339 time", err)
+		}
+		schedule.skipUntil = skipUntil
+	case req.Rules == nil:
+		return nil, apierror.New(apierror.ErrBadRequest, "rules, skip_until or skip_next are required", nil)
+	}
+
+	log.Infof("updating schedule of instance %s: %v", id, schedule.tags())
+
+	if err := o.ec2Client.UpdateRawTags(ctx, schedule.tags(), id); err != nil {
+		return nil, err
+	}
+
+	// the skip tag isn't written when it's cleared, so it's removed separately
+	if schedule.skipUntil.IsZero() && current != nil && !current.skipUntil.IsZero() {
+		if err := o.ec2Client.DeleteTags(ctx, []string{scheduleSkipUntilTag}, id); err != nil {
+			return nil, err
+		}
+	}
+
+	return toEc2InstanceSchedule(id, schedule, now), nil
+}
+
+// runInstanceSchedules runs the actions of the power schedules of the instances in the org that are due after
+// from and until to.  Actions are only run when they change the state of the instance, and failures are logged
+// so one instance doesn't block the others.  Stops of pending instances are deferred to the following runs, until
+// the instance is running.
+func (o *ec2Orchestrator) runInstanceSchedules(ctx context.Context, from, to time.Time) error {
+	instances, err := o.ec2Client.ListInstanceDetails(ctx, o.server.org, &ec2.Filter{
+		Name:   aws.String("tag-key"),
+		Values: aws.StringSlice([]string{scheduleTag}),
+	})
+	if err != nil {
+		return err
+	}
+
+	ids := map[string][]string{}
+	for _, i := range instances {
+		id := aws.StringValue(i.InstanceId)
+
+		schedule, err := parseInstanceSchedule(i.Tags)
+		if err != nil {
+			log.Warnf("ignoring invalid schedule of instance %s: %s", id, err)
+			continue
+		}
+
+		if schedule == nil {
+			continue
+		}
+
+		deferKey := "schedule/" + o.account + "/" + id
+		due := schedule.due(from, to)
+		if due == nil {
+			item, found := o.server.jobs.Get(deferKey)
+			if !found {
+				continue
+			}
+			due = item.(*scheduledAction)
+		}
+		o.server.jobs.Delete(deferKey)
+
+		if schedule.skipped(due) {
+			log.Infof("skipping scheduled %s of instance %s at %s", due.action, id, due.time.Format(time.RFC3339))
+			continue
+		}
+
+		if due.action == scheduleActionStop && instanceStateName(i) == ec2.InstanceStateNamePending {
+			log.Infof("deferring scheduled stop of pending instance %s to the next run", id)
+			o.server.jobs.Set(deferKey, due, scheduleDeferTimeout)
+			continue
+		}
+
+		if !scheduleActionNeeded(due.action, instanceStateName(i)) {
+			log.Debugf("scheduled %s of instance %s isn't needed, instance is %s", due.action, id, instanceStateName(i))
+			continue
+		}
+
+		ids[due.action] = append(ids[due.action], id)
+	}
+
+	actions := make([]string, 0, len(ids))
+	for a := range ids {
+		actions = append(actions, a)
+	}
+	sort.Strings(actions)
+
+	for _, a := range actions {
+		log.Infof("running scheduled %s of instances %v in account %s", a, ids[a], o.account)
+
+		if err := o.instancesState(ctx, a, ids[a]...); err != nil {
+			log.Errorf("failed to run scheduled %s of instances %v in account %s: %s", a, ids[a], o.account, err)
+		}
+	}
+
+	return nil
+}
+
+// scheduleActionNeeded returns true if a scheduled action changes the state of an instance
+func scheduleActionNeeded(action, state string) bool {
+	switch action {
+	case scheduleActionStart:
+		return state == ec2.InstanceStateNameStopped
+	case scheduleActionStop:
+		return state == ec2.InstanceStateNameRunning
+	}
+	return false
+}
+
+// instanceScheduler periodically runs the power schedules of the instances in the configured accounts
+type instanceScheduler struct {
+	interval time.Duration
+	accounts []string
+	run      func(ctx context.Context, account string, from, to time.Time) error
+}
+
+// newInstanceScheduler validates the schedule configuration, it returns nil when schedules aren't configured
+func newInstanceScheduler(config *common.InstanceSchedules, accountsMap map[string]string, run func(ctx context.Context, account string, from, to time.Time) error) (*instanceScheduler, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	interval := defaultScheduleInterval
+	if config.Interval != "" {
+		i, err := time.ParseDuration(config.Interval)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid instance schedule interval")
+		}
+
+		if i < time.Minute {
+			return nil, errors.New("instance schedule interval must be at least 1m")
+		}
+
+		interval = i
+	}
+
+	accounts := configuredAccounts(config.Accounts, accountsMap)
+	if len(accounts) == 0 {
+		return nil, errors.New("instance schedules require at least one account")
+	}
+
+	return &instanceScheduler{
+		interval: interval,
+		accounts: accounts,
+		run:      run,
+	}, nil
+}
+
+// configuredAccounts returns the sorted and unique account numbers of the account names or numbers of a background
+// job, or of all the accounts in the accounts map when none are given
+func configuredAccounts(names []string, accountsMap map[string]string) []string {
+	if len(names) == 0 {
+		for name := range accountsMap {
+			names = append(names, name)
+		}
+	}
+
+	seen := map[string]bool{}
+	accounts := []string{}
+	for _, name := range names {
+		account := name
+		if a, ok := accountsMap[name]; ok {
+			account = a
+		}
+
+		if !seen[account] {
+			seen[account] = true
+			accounts = append(accounts, account)
+		}
+	}
+	sort.Strings(accounts)
+
+	return accounts
+}
+
+// start runs the schedules every interval until the context is canceled.  Each run covers the time since the
+// previous run, so actions due while the api was down aren't run late.
+func (s *instanceScheduler) start(ctx context.Context) {
+	log.Infof("starting instance scheduler for accounts %v every %s", s.accounts, s.interval)
+
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		last := time.Now()
+		for {
+			select {
+			case <-ctx.Done():
+				log.Info("stopped instance scheduler")
+				return
+			case now := <-ticker.C:
+				s.runAll(ctx, last, now)
+				last = now
+			}
+		}
+	}()
+}
+
+// runAll runs the schedules of all accounts for the actions due after from and until to
+func (s *instanceScheduler) runAll(ctx context.Context, from, to time.Time) {
+	for _, account := range s.accounts {
+		if err := s.run(ctx, account, from, to); err != nil {
+			log.Errorf("failed to run instance schedules in account %s: %s", account, err)
+		}
+	}
+}
+
+// runAccountInstanceSchedules runs the power schedules of the instances of the org in an account
+func (s *server) runAccountInstanceSchedules(ctx context.Context, account string, from, to time.Time) error {
+	policy, err := changeInstanceStatePolicy()
+	if err != nil {
+		return err
+	}
+
+	orch, err := s.newEc2Orchestrator(ctx, &sessionParams{
+		role:         fmt.Sprintf("arn:aws:iam::%s:role/%s", account, s.session.RoleName),
+		inlinePolicy: policy,
+		policyArns: []string{
+			"arn:aws:iam::aws:policy/AmazonEC2ReadOnlyAccess",
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return orch.runInstanceSchedules(ctx, from, to)
+}
+
+// toEc2InstanceSchedule converts a power schedule into a response, with the next action after now
+func toEc2InstanceSchedule(id string, schedule *instanceSchedule, now time.Time) *Ec2InstanceSchedule {
+	out := &Ec2InstanceSchedule{
+		InstanceId: id,
+		Rules:      []*Ec2InstanceScheduleRule{},
+	}
+
+	if schedule == nil {
+		return out
+	}
+
+	out.Timezone = schedule.location.String()
+	for _, r := range schedule.rules {
+		out.Rules = append(out.Rules, &Ec2InstanceScheduleRule{Action: r.action, Cron: r.expr})
+	}
+
+	if !schedule.skipUntil.IsZero() && schedule.skipUntil.After(now) {
+		out.SkipUntil = schedule.skipUntil.In(schedule.location).Format(time.RFC3339)
+	}
+
+	if next := schedule.next(now); next != nil {
+		out.NextAction = &Ec2InstanceScheduledAction{
+			Action:  next.action,
+			Time:    next.time.Format(time.RFC3339),
+			Skipped: schedule.skipped(next),
+		}
+	}
+
+	return out
+}
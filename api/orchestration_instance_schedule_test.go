@@ -0,0 +1,358 @@
+package api
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/YaleSpinup/ec2-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func Test_newInstanceSchedule(t *testing.T) {
+	tests := []struct {
+		name     string
+		timezone string
+		rules    []*Ec2InstanceScheduleRule
+		wantTags map[string]string
+		wantErr  bool
+	}{
+		{
+			name:  "default time zone",
+			rules: []*Ec2InstanceScheduleRule{{Action: "stop", Cron: "0 19 * * 1-5"}},
+			wantTags: map[string]string{
+				scheduleTag:         "stop 0 19 * * 1-5",
+				scheduleTimezoneTag: "UTC",
+			},
+		},
+		{
+			name:     "rules are normalized",
+			timezone: "America/New_York",
+			rules: []*Ec2InstanceScheduleRule{
+				{Action: "STOP", Cron: " 0  19 * * 1-5"},
+				{Action: "start", Cron: "0 7 * * 1-5"},
+			},
+			wantTags: map[string]string{
+				scheduleTag:         "stop 0 19 * * 1-5;start 0 7 * * 1-5",
+				scheduleTimezoneTag: "America/New_York",
+			},
+		},
+		{name: "invalid time zone", timezone: "Mars/Olympus", rules: []*Ec2InstanceScheduleRule{{Action: "stop", Cron: "@daily"}}, wantErr: true},
+		{name: "invalid action", rules: []*Ec2InstanceScheduleRule{{Action: "reboot", Cron: "@daily"}}, wantErr: true},
+		{name: "invalid cron", rules: []*Ec2InstanceScheduleRule{{Action: "stop", Cron: "0 25 * * *"}}, wantErr: true},
+		{name: "never matches", rules: []*Ec2InstanceScheduleRule{{Action: "stop", Cron: "0 0 30 2 *"}}, wantErr: true},
+		{name: "separator in cron", rules: []*Ec2InstanceScheduleRule{{Action: "stop", Cron: "0 0 * * *;start"}}, wantErr: true},
+		{name: "nil rule", rules: []*Ec2InstanceScheduleRule{nil}, wantErr: true},
+		{
+			name: "too long",
+			rules: []*Ec2InstanceScheduleRule{
+				{Action: "stop", Cron: "0,1,2,3,4,5,6,7,8,9,10,11,12,13,14,15,16,17,18,19,20,21,22,23,24,25,26,27,28,29 * * * *"},
+				{Action: "start", Cron: "30,31,32,33,34,35,36,37,38,39,40,41,42,43,44,45,46,47,48,49,50,51,52,53,54,55,56,57,58,59 * * * *"},
+				{Action: "stop", Cron: "0,1,2,3,4,5,6,7,8,9,10,11,12,13,14,15,16,17,18,19,20,21,22,23,24,25,26,27,28,29 * * * *"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newInstanceSchedule(tt.timezone, tt.rules)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("newInstanceSchedule() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if tags := got.tags(); !reflect.DeepEqual(tags, tt.wantTags) {
+				t.Errorf("newInstanceSchedule() tags = %v, want %v", tags, tt.wantTags)
+			}
+		})
+	}
+}
+
+func Test_parseInstanceSchedule(t *testing.T) {
+	tags := func(kv ...string) []*ec2.Tag {
+		out := []*ec2.Tag{}
+		for i := 0; i < len(kv); i += 2 {
+			out = append(out, &ec2.Tag{Key: aws.String(kv[i]), Value: aws.String(kv[i+1])})
+		}
+		return out
+	}
+
+	tests := []struct {
+		name     string
+		tags     []*ec2.Tag
+		wantNil  bool
+		wantTags map[string]string
+		wantErr  bool
+	}{
+		{name: "no schedule", tags: tags("Name", "foo"), wantNil: true},
+		{name: "empty schedule", tags: tags(scheduleTag, " "), wantNil: true},
+		{
+			name: "schedule",
+			tags: tags(scheduleTag, "stop 0 19 * * 1-5;start 0 7 * * 1-5", scheduleTimezoneTag, "America/New_York", scheduleSkipUntilTag, "2023-01-03T12:00:00Z"),
+			wantTags: map[string]string{
+				scheduleTag:          "stop 0 19 * * 1-5;start 0 7 * * 1-5",
+				scheduleTimezoneTag:  "America/New_York",
+				scheduleSkipUntilTag: "2023-01-03T12:00:00Z",
+			},
+		},
+		{
+			name: "trailing separator",
+			tags: tags(scheduleTag, "stop @daily;"),
+			wantTags: map[string]string{
+				scheduleTag:         "stop @daily",
+				scheduleTimezoneTag: "UTC",
+			},
+		},
+		{name: "rule without cron", tags: tags(scheduleTag, "stop"), wantErr: true},
+		{name: "invalid rule", tags: tags(scheduleTag, "stop 0 19 * *"), wantErr: true},
+		{name: "invalid skip until", tags: tags(scheduleTag, "stop @daily", scheduleSkipUntilTag, "tomorrow"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseInstanceSchedule(tt.tags)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseInstanceSchedule() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if (got == nil) != tt.wantNil {
+				t.Fatalf("parseInstanceSchedule() = %v, wantNil %v", got, tt.wantNil)
+			}
+
+			if got != nil && !reflect.DeepEqual(got.tags(), tt.wantTags) {
+				t.Errorf("parseInstanceSchedule() tags = %v, want %v", got.tags(), tt.wantTags)
+			}
+		})
+	}
+}
+
+func Test_instanceSchedule_due(t *testing.T) {
+	schedule, err := newInstanceSchedule("America/New_York", []*Ec2InstanceScheduleRule{
+		{Action: "stop", Cron: "0 19 * * mon-fri"},
+		{Action: "start", Cron: "0 7 * * mon-fri"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ny := schedule.location
+	tests := []struct {
+		name string
+		from time.Time
+		to   time.Time
+		want *scheduledAction
+	}{
+		{
+			name: "nothing due",
+			from: time.Date(2023, 1, 2, 10, 0, 0, 0, ny),
+			to:   time.Date(2023, 1, 2, 10, 1, 0, 0, ny),
+		},
+		{
+			name: "stop due",
+			from: time.Date(2023, 1, 2, 18, 59, 0, 0, ny),
+			to:   time.Date(2023, 1, 2, 19, 0, 0, 0, ny),
+			want: &scheduledAction{action: "stop", time: time.Date(2023, 1, 2, 19, 0, 0, 0, ny)},
+		},
+		{
+			name: "from is excluded",
+			from: time.Date(2023, 1, 2, 19, 0, 0, 0, ny),
+			to:   time.Date(2023, 1, 2, 19, 1, 0, 0, ny),
+		},
+		{
+			name: "last of several",
+			from: time.Date(2023, 1, 2, 18, 0, 0, 0, ny),
+			to:   time.Date(2023, 1, 3, 8, 0, 0, 0, ny),
+			want: &scheduledAction{action: "start", time: time.Date(2023, 1, 3, 7, 0, 0, 0, ny)},
+		},
+		{
+			name: "weekend",
+			from: time.Date(2023, 1, 7, 0, 0, 0, 0, ny),
+			to:   time.Date(2023, 1, 8, 23, 59, 0, 0, ny),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := schedule.due(tt.from, tt.to)
+			if (got == nil) != (tt.want == nil) {
+				t.Fatalf("instanceSchedule.due() = %+v, want %+v", got, tt.want)
+			}
+
+			if got != nil && (got.action != tt.want.action || !got.time.Equal(tt.want.time)) {
+				t.Errorf("instanceSchedule.due() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_instanceSchedule_skipped(t *testing.T) {
+	schedule := &instanceSchedule{skipUntil: time.Date(2023, 1, 2, 19, 1, 0, 0, time.UTC)}
+
+	if !schedule.skipped(&scheduledAction{time: time.Date(2023, 1, 2, 19, 0, 0, 0, time.UTC)}) {
+		t.Error("instanceSchedule.skipped() = false for an action before skip until")
+	}
+
+	if schedule.skipped(&scheduledAction{time: time.Date(2023, 1, 3, 19, 0, 0, 0, time.UTC)}) {
+		t.Error("instanceSchedule.skipped() = true for an action after skip until")
+	}
+
+	if (&instanceSchedule{}).skipped(&scheduledAction{time: time.Date(2023, 1, 2, 19, 0, 0, 0, time.UTC)}) {
+		t.Error("instanceSchedule.skipped() = true without skip until")
+	}
+}
+
+func Test_scheduleActionNeeded(t *testing.T) {
+	tests := []struct {
+		action string
+		state  string
+		want   bool
+	}{
+		{action: "start", state: "stopped", want: true},
+		{action: "start", state: "running", want: false},
+		{action: "start", state: "stopping", want: false},
+		{action: "stop", state: "running", want: true},
+		{action: "stop", state: "pending", want: false},
+		{action: "stop", state: "stopped", want: false},
+		{action: "reboot", state: "running", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.action+" "+tt.state, func(t *testing.T) {
+			if got := scheduleActionNeeded(tt.action, tt.state); got != tt.want {
+				t.Errorf("scheduleActionNeeded() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_toEc2InstanceSchedule(t *testing.T) {
+	schedule, err := newInstanceSchedule("America/New_York", []*Ec2InstanceScheduleRule{
+		{Action: "stop", Cron: "0 19 * * *"},
+		{Action: "start", Cron: "0 7 * * *"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	schedule.skipUntil = time.Date(2023, 1, 3, 0, 1, 0, 0, time.UTC)
+
+	now := time.Date(2023, 1, 2, 12, 0, 0, 0, time.UTC)
+	want := &Ec2InstanceSchedule{
+		InstanceId: "i-1",
+		Timezone:   "America/New_York",
+		Rules: []*Ec2InstanceScheduleRule{
+			{Action: "stop", Cron: "0 19 * * *"},
+			{Action: "start", Cron: "0 7 * * *"},
+		},
+		SkipUntil: "2023-01-02T19:01:00-05:00",
+		NextAction: &Ec2InstanceScheduledAction{
+			Action:  "stop",
+			Time:    "2023-01-02T19:00:00-05:00",
+			Skipped: true,
+		},
+	}
+
+	if got := toEc2InstanceSchedule("i-1", schedule, now); !reflect.DeepEqual(got, want) {
+		t.Errorf("toEc2InstanceSchedule() = %+v, want %+v", got, want)
+	}
+
+	// an expired skip isn't returned
+	if got := toEc2InstanceSchedule("i-1", schedule, time.Date(2023, 1, 4, 0, 0, 0, 0, time.UTC)); got.SkipUntil != "" || got.NextAction.Skipped {
+		t.Errorf("toEc2InstanceSchedule() = %+v, want no skip", got)
+	}
+
+	want = &Ec2InstanceSchedule{InstanceId: "i-1", Rules: []*Ec2InstanceScheduleRule{}}
+	if got := toEc2InstanceSchedule("i-1", nil, now); !reflect.DeepEqual(got, want) {
+		t.Errorf("toEc2InstanceSchedule() = %+v, want %+v", got, want)
+	}
+}
+
+func Test_newInstanceScheduler(t *testing.T) {
+	accountsMap := map[string]string{"spinup": "012345678901", "spinupsec": "109876543210", "alias": "012345678901"}
+
+	tests := []struct {
+		name         string
+		config       *common.InstanceSchedules
+		accountsMap  map[string]string
+		wantNil      bool
+		wantAccounts []string
+		wantInterval time.Duration
+		wantErr      bool
+	}{
+		{name: "not configured", accountsMap: accountsMap, wantNil: true},
+		{
+			name:         "all accounts",
+			config:       &common.InstanceSchedules{},
+			accountsMap:  accountsMap,
+			wantAccounts: []string{"012345678901", "109876543210"},
+			wantInterval: time.Minute,
+		},
+		{
+			name:         "some accounts",
+			config:       &common.InstanceSchedules{Interval: "5m", Accounts: []string{"spinupsec", "222222222222"}},
+			accountsMap:  accountsMap,
+			wantAccounts: []string{"109876543210", "222222222222"},
+			wantInterval: 5 * time.Minute,
+		},
+		{name: "no accounts", config: &common.InstanceSchedules{}, wantErr: true},
+		{name: "invalid interval", config: &common.InstanceSchedules{Interval: "often"}, accountsMap: accountsMap, wantErr: true},
+		{name: "short interval", config: &common.InstanceSchedules{Interval: "10s"}, accountsMap: accountsMap, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newInstanceScheduler(tt.config, tt.accountsMap, nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("newInstanceScheduler() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if (got == nil) != tt.wantNil {
+				t.Fatalf("newInstanceScheduler() = %v, wantNil %v", got, tt.wantNil)
+			}
+
+			if got == nil {
+				return
+			}
+
+			if !reflect.DeepEqual(got.accounts, tt.wantAccounts) || got.interval != tt.wantInterval {
+				t.Errorf("newInstanceScheduler() = %v every %s, want %v every %s", got.accounts, got.interval, tt.wantAccounts, tt.wantInterval)
+			}
+		})
+	}
+}
+
+func Test_instanceScheduler_runAll(t *testing.T) {
+	from := time.Date(2023, 1, 2, 18, 59, 0, 0, time.UTC)
+	to := from.Add(time.Minute)
+
+	got := []string{}
+	s := &instanceScheduler{
+		accounts: []string{"012345678901", "109876543210"},
+		run: func(ctx context.Context, account string, f, t time.Time) error {
+			if f.Equal(from) && t.Equal(to) {
+				got = append(got, account)
+			}
+			return context.Canceled
+		},
+	}
+
+	// a failing account doesn't stop the others
+	s.runAll(context.Background(), from, to)
+
+	sort.Strings(got)
+	if want := []string{"012345678901", "109876543210"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("instanceScheduler.runAll() ran %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,256 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	log "github.com/sirupsen/logrus"
+)
+
+// scheduledEventCodes are the codes of the scheduled maintenance events of instances
+var scheduledEventCodes = []string{
+	ec2.EventCodeInstanceReboot,
+	ec2.EventCodeSystemReboot,
+	ec2.EventCodeSystemMaintenance,
+	ec2.EventCodeInstanceRetirement,
+	ec2.EventCodeInstanceStop,
+}
+
+// instanceStatus gets the status checks, the status of the attached volumes and the scheduled events of an instance
+func (o *ec2Orchestrator) instanceStatus(ctx context.Context, id string) (*Ec2InstanceStatusResponse, error) {
+	if id == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	status, err := o.ec2Client.GetInstanceStatus(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	volumes, err := o.ec2Client.ListInstanceVolumes(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	volumeStatuses := []*ec2.VolumeStatusItem{}
+	if len(volumes) > 0 {
+		if volumeStatuses, err = o.ec2Client.ListVolumeStatuses(ctx, volumes...); err != nil {
+			return nil, err
+		}
+	}
+
+	return toEc2InstanceStatusResponse(status, volumeStatuses), nil
+}
+
+// listInstanceEvents lists the pending scheduled events of the instances in the org, ordered by start time
+func (o *ec2Orchestrator) listInstanceEvents(ctx context.Context) ([]*Ec2InstanceEvent, error) {
+	instances, err := o.ec2Client.ListInstanceDetails(ctx, o.server.org)
+	if err != nil {
+		return nil, err
+	}
+
+	out := []*Ec2InstanceEvent{}
+	if len(instances) == 0 {
+		return out, nil
+	}
+
+	// instance statuses can't be filtered by tag, so the statuses with events are limited to the org afterwards
+	inOrg := make(map[string]bool, len(instances))
+	for _, i := range instances {
+		inOrg[aws.StringValue(i.InstanceId)] = true
+	}
+
+	statuses, err := o.ec2Client.ListInstanceStatuses(ctx, &ec2.Filter{
+		Name:   aws.String("event.code"),
+		Values: aws.StringSlice(scheduledEventCodes),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range statuses {
+		id := aws.StringValue(s.InstanceId)
+		if !inOrg[id] {
+			continue
+		}
+
+		for _, e := range s.Events {
+			if instanceEventDone(e) {
+				continue
+			}
+			out = append(out, toEc2InstanceEvent(id, e))
+		}
+	}
+
+	sortInstanceEvents(out)
+
+	return out, nil
+}
+
+// rescheduleInstanceEvent reschedules a scheduled event of an instance.  Only events with a deadline can be
+// rescheduled, and the new start time must be in the future and before the deadline.
+func (o *ec2Orchestrator) rescheduleInstanceEvent(ctx context.Context, id, eventId string, req *Ec2InstanceEventRescheduleRequest) (*Ec2InstanceEvent, error) {
+	if id == "" || eventId == "" || req == nil {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	notBefore, err := time.Parse(time.RFC3339, req.NotBefore)
+	if err != nil {
+		return nil, apierror.New(apierror.ErrBadRequest, "not_before must be an RFC3339 time", err)
+	}
+
+	status, err := o.ec2Client.GetInstanceStatus(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var event *ec2.InstanceStatusEvent
+	for _, e := range status.Events {
+		if aws.StringValue(e.InstanceEventId) == eventId {
+			event = e
+			break
+		}
+	}
+
+	if event == nil {
+		return nil, apierror.New(apierror.ErrNotFound, "instance event not found", nil)
+	}
+
+	if err := validateInstanceEventReschedule(event, notBefore, time.Now()); err != nil {
+		return nil, err
+	}
+
+	log.Infof("rescheduling %s event %s of instance %s from %s to %s", aws.StringValue(event.Code), eventId, id, timeFormat(event.NotBefore), timeFormat(&notBefore))
+
+	out, err := o.ec2Client.ModifyInstanceEventStartTime(ctx, id, eventId, notBefore)
+	if err != nil {
+		return nil, err
+	}
+
+	return toEc2InstanceEvent(id, out), nil
+}
+
+// validateInstanceEventReschedule checks if an instance event can be rescheduled to start after the given time
+func validateInstanceEventReschedule(event *ec2.InstanceStatusEvent, notBefore, now time.Time) error {
+	if !instanceEventReschedulable(event) {
+		msg := fmt.Sprintf("%s event %s can't be rescheduled", aws.StringValue(event.Code), aws.StringValue(event.InstanceEventId))
+		return apierror.New(apierror.ErrBadRequest, msg, nil)
+	}
+
+	if !notBefore.After(now) {
+		return apierror.New(apierror.ErrBadRequest, "not_before must be in the future", nil)
+	}
+
+	if notBefore.After(aws.TimeValue(event.NotBeforeDeadline)) {
+		msg := fmt.Sprintf("not_before must be before the deadline of the event (%s)", aws.TimeValue(event.NotBeforeDeadline).UTC().Format(time.RFC3339))
+		return apierror.New(apierror.ErrBadRequest, msg, nil)
+	}
+
+	return nil
+}
+
+// instanceEventDone returns true if a scheduled event has completed or was canceled, these events are kept for
+// some time with the description prefixed by [Completed] or [Canceled]
+func instanceEventDone(e *ec2.InstanceStatusEvent) bool {
+	d := aws.StringValue(e.Description)
+	return strings.HasPrefix(d, "[Completed]") || strings.HasPrefix(d, "[Canceled]")
+}
+
+// instanceEventReschedulable returns true if a scheduled event can be rescheduled, only pending events with a
+// deadline can be
+func instanceEventReschedulable(e *ec2.InstanceStatusEvent) bool {
+	return e.NotBeforeDeadline != nil && !instanceEventDone(e)
+}
+
+// sortInstanceEvents sorts instance events by start time, then instance id
+func sortInstanceEvents(events []*Ec2InstanceEvent) {
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].NotBefore != events[j].NotBefore {
+			return events[i].NotBefore < events[j].NotBefore
+		}
+		return events[i].InstanceId < events[j].InstanceId
+	})
+}
+
+// toEc2InstanceStatusResponse converts the status of an instance and its volumes to a response
+func toEc2InstanceStatusResponse(status *ec2.InstanceStatus, volumeStatuses []*ec2.VolumeStatusItem) *Ec2InstanceStatusResponse {
+	id := aws.StringValue(status.InstanceId)
+
+	out := &Ec2InstanceStatusResponse{
+		InstanceId:       id,
+		AvailabilityZone: aws.StringValue(status.AvailabilityZone),
+		InstanceStatus:   toEc2StatusCheck(status.InstanceStatus),
+		SystemStatus:     toEc2StatusCheck(status.SystemStatus),
+		EbsStatus:        make([]*Ec2VolumeStatus, 0, len(volumeStatuses)),
+		Events:           make([]*Ec2InstanceEvent, 0, len(status.Events)),
+	}
+
+	if status.InstanceState != nil {
+		out.State = aws.StringValue(status.InstanceState.Name)
+	}
+
+	for _, v := range volumeStatuses {
+		check := &Ec2StatusCheck{}
+		if v.VolumeStatus != nil {
+			check.Status = aws.StringValue(v.VolumeStatus.Status)
+			for _, d := range v.VolumeStatus.Details {
+				check.Details = append(check.Details, &Ec2StatusCheckDetail{
+					Name:   aws.StringValue(d.Name),
+					Status: aws.StringValue(d.Status),
+				})
+			}
+		}
+
+		out.EbsStatus = append(out.EbsStatus, &Ec2VolumeStatus{
+			VolumeId: aws.StringValue(v.VolumeId),
+			Status:   check,
+		})
+	}
+
+	for _, e := range status.Events {
+		out.Events = append(out.Events, toEc2InstanceEvent(id, e))
+	}
+
+	return out
+}
+
+// toEc2StatusCheck converts an instance or system status check
+func toEc2StatusCheck(summary *ec2.InstanceStatusSummary) *Ec2StatusCheck {
+	if summary == nil {
+		return nil
+	}
+
+	check := &Ec2StatusCheck{
+		Status: aws.StringValue(summary.Status),
+	}
+
+	for _, d := range summary.Details {
+		check.Details = append(check.Details, &Ec2StatusCheckDetail{
+			Name:          aws.StringValue(d.Name),
+			Status:        aws.StringValue(d.Status),
+			ImpairedSince: timeFormat(d.ImpairedSince),
+		})
+	}
+
+	return check
+}
+
+// toEc2InstanceEvent converts a scheduled event of an instance
+func toEc2InstanceEvent(instanceId string, e *ec2.InstanceStatusEvent) *Ec2InstanceEvent {
+	return &Ec2InstanceEvent{
+		Id:                aws.StringValue(e.InstanceEventId),
+		InstanceId:        instanceId,
+		Code:              aws.StringValue(e.Code),
+		Description:       aws.StringValue(e.Description),
+		NotBefore:         timeFormat(e.NotBefore),
+		NotAfter:          timeFormat(e.NotAfter),
+		NotBeforeDeadline: timeFormat(e.NotBeforeDeadline),
+		Reschedulable:     instanceEventReschedulable(e),
+	}
+}
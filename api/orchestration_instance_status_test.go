@@ -0,0 +1,222 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func Test_validateInstanceEventReschedule(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	notBefore := now.Add(24 * time.Hour)
+	deadline := now.Add(7 * 24 * time.Hour)
+
+	tests := []struct {
+		name      string
+		event     *ec2.InstanceStatusEvent
+		notBefore time.Time
+		wantErr   bool
+	}{
+		{
+			name: "reschedulable event",
+			event: &ec2.InstanceStatusEvent{
+				InstanceEventId:   aws.String("instance-event-1"),
+				Code:              aws.String("system-reboot"),
+				NotBefore:         &notBefore,
+				NotBeforeDeadline: &deadline,
+			},
+			notBefore: now.Add(72 * time.Hour),
+		},
+		{
+			name: "at the deadline",
+			event: &ec2.InstanceStatusEvent{
+				InstanceEventId:   aws.String("instance-event-1"),
+				Code:              aws.String("system-reboot"),
+				NotBefore:         &notBefore,
+				NotBeforeDeadline: &deadline,
+			},
+			notBefore: deadline,
+		},
+		{
+			name: "after the deadline",
+			event: &ec2.InstanceStatusEvent{
+				InstanceEventId:   aws.String("instance-event-1"),
+				Code:              aws.String("system-reboot"),
+				NotBefore:         &notBefore,
+				NotBeforeDeadline: &deadline,
+			},
+			notBefore: deadline.Add(time.Minute),
+			wantErr:   true,
+		},
+		{
+			name: "in the past",
+			event: &ec2.InstanceStatusEvent{
+				InstanceEventId:   aws.String("instance-event-1"),
+				Code:              aws.String("system-reboot"),
+				NotBefore:         &notBefore,
+				NotBeforeDeadline: &deadline,
+			},
+			notBefore: now.Add(-time.Hour),
+			wantErr:   true,
+		},
+		{
+			name: "no deadline",
+			event: &ec2.InstanceStatusEvent{
+				InstanceEventId: aws.String("instance-event-1"),
+				Code:            aws.String("instance-retirement"),
+				NotBefore:       &notBefore,
+			},
+			notBefore: now.Add(72 * time.Hour),
+			wantErr:   true,
+		},
+		{
+			name: "completed event",
+			event: &ec2.InstanceStatusEvent{
+				InstanceEventId:   aws.String("instance-event-1"),
+				Code:              aws.String("system-reboot"),
+				Description:       aws.String("[Completed] scheduled reboot"),
+				NotBefore:         &notBefore,
+				NotBeforeDeadline: &deadline,
+			},
+			notBefore: now.Add(72 * time.Hour),
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateInstanceEventReschedule(tt.event, tt.notBefore, now); (err != nil) != tt.wantErr {
+				t.Errorf("validateInstanceEventReschedule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_instanceEventDone(t *testing.T) {
+	tests := []struct {
+		description string
+		want        bool
+	}{
+		{description: "The instance is running on degraded hardware", want: false},
+		{description: "[Completed] The instance is running on degraded hardware", want: true},
+		{description: "[Canceled] scheduled reboot", want: true},
+		{description: "", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			if got := instanceEventDone(&ec2.InstanceStatusEvent{Description: aws.String(tt.description)}); got != tt.want {
+				t.Errorf("instanceEventDone() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_sortInstanceEvents(t *testing.T) {
+	events := []*Ec2InstanceEvent{
+		{Id: "e-3", InstanceId: "i-2", NotBefore: "2023/02/01 00:00:00"},
+		{Id: "e-2", InstanceId: "i-2", NotBefore: "2023/01/15 00:00:00"},
+		{Id: "e-1", InstanceId: "i-1", NotBefore: "2023/01/15 00:00:00"},
+	}
+
+	sortInstanceEvents(events)
+
+	got := []string{}
+	for _, e := range events {
+		got = append(got, e.Id)
+	}
+
+	if want := []string{"e-1", "e-2", "e-3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sortInstanceEvents() = %v, want %v", got, want)
+	}
+}
+
+func Test_toEc2InstanceStatusResponse(t *testing.T) {
+	impaired := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	notBefore := time.Date(2023, 1, 15, 6, 0, 0, 0, time.UTC)
+	deadline := time.Date(2023, 1, 22, 6, 0, 0, 0, time.UTC)
+
+	status := &ec2.InstanceStatus{
+		InstanceId:       aws.String("i-123"),
+		AvailabilityZone: aws.String("us-east-1a"),
+		InstanceState:    &ec2.InstanceState{Name: aws.String("running")},
+		InstanceStatus: &ec2.InstanceStatusSummary{
+			Status: aws.String("impaired"),
+			Details: []*ec2.InstanceStatusDetails{
+				{Name: aws.String("reachability"), Status: aws.String("failed"), ImpairedSince: &impaired},
+			},
+		},
+		SystemStatus: &ec2.InstanceStatusSummary{
+			Status: aws.String("ok"),
+			Details: []*ec2.InstanceStatusDetails{
+				{Name: aws.String("reachability"), Status: aws.String("passed")},
+			},
+		},
+		Events: []*ec2.InstanceStatusEvent{
+			{
+				InstanceEventId:   aws.String("instance-event-1"),
+				Code:              aws.String("system-reboot"),
+				Description:       aws.String("scheduled reboot"),
+				NotBefore:         &notBefore,
+				NotBeforeDeadline: &deadline,
+			},
+		},
+	}
+
+	volumeStatuses := []*ec2.VolumeStatusItem{
+		{
+			VolumeId: aws.String("vol-123"),
+			VolumeStatus: &ec2.VolumeStatusInfo{
+				Status: aws.String("ok"),
+				Details: []*ec2.VolumeStatusDetails{
+					{Name: aws.String("io-enabled"), Status: aws.String("passed")},
+				},
+			},
+		},
+	}
+
+	want := &Ec2InstanceStatusResponse{
+		InstanceId:       "i-123",
+		AvailabilityZone: "us-east-1a",
+		State:            "running",
+		InstanceStatus: &Ec2StatusCheck{
+			Status: "impaired",
+			Details: []*Ec2StatusCheckDetail{
+				{Name: "reachability", Status: "failed", ImpairedSince: "2023/01/01 12:00:00"},
+			},
+		},
+		SystemStatus: &Ec2StatusCheck{
+			Status: "ok",
+			Details: []*Ec2StatusCheckDetail{
+				{Name: "reachability", Status: "passed"},
+			},
+		},
+		EbsStatus: []*Ec2VolumeStatus{
+			{
+				VolumeId: "vol-123",
+				Status: &Ec2StatusCheck{
+					Status: "ok",
+					Details: []*Ec2StatusCheckDetail{
+						{Name: "io-enabled", Status: "passed"},
+					},
+				},
+			},
+		},
+		Events: []*Ec2InstanceEvent{
+			{
+				Id:                "instance-event-1",
+				InstanceId:        "i-123",
+				Code:              "system-reboot",
+				Description:       "scheduled reboot",
+				NotBefore:         "2023/01/15 06:00:00",
+				NotBeforeDeadline: "2023/01/22 06:00:00",
+				Reschedulable:     true,
+			},
+		},
+	}
+
+	if got := toEc2InstanceStatusResponse(status, volumeStatuses); !reflect.DeepEqual(got, want) {
+		t.Errorf("toEc2InstanceStatusResponse() = %+v, want %+v", got, want)
+	}
+}
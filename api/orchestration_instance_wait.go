@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+const (
+	// defaultInstanceWaitTimeout is how long to wait for an instance state when no timeout is given
+	defaultInstanceWaitTimeout = 60 * time.Second
+
+	// maxInstanceWaitTimeout keeps waiting for an instance below the write timeout of the server
+	maxInstanceWaitTimeout = 80 * time.Second
+
+	// instanceWaitMargin is the time left after waiting for an instance to get it and write the response
+	instanceWaitMargin = 5 * time.Second
+)
+
+// waitForInstanceState waits until an instance is running, stopped or terminated and returns the instance in its final
+// state.  The wait ends with a timeout error after the timeout, or when the request is canceled.
+func (o *ec2Orchestrator) waitForInstanceState(ctx context.Context, id, state string, timeout time.Duration) (*Ec2InstanceResponse, error) {
+	if id == "" || state == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if err := o.ec2Client.WaitUntilInstanceState(waitCtx, id, state); err != nil {
+		return nil, err
+	}
+
+	// terminated instances aren't found by GetInstance
+	get := o.ec2Client.GetInstance
+	if state == ec2.InstanceStateNameTerminated {
+		get = o.ec2Client.GetInstanceAnyState
+	}
+
+	instance, err := get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return toEc2InstanceResponse(instance), nil
+}
+
+// remainingInstanceWaitTimeout caps the timeout of a wait to the time left before the write timeout of the server for
+// a request started at start, so the time spent assuming a role and changing the instance counts against it
+func remainingInstanceWaitTimeout(start time.Time, timeout time.Duration) time.Duration {
+	if left := serverWriteTimeout - instanceWaitMargin - time.Since(start); left < timeout {
+		return left
+	}
+	return timeout
+}
+
+// parseInstanceWait parses the wait and timeout query parameters of a request
+func parseInstanceWait(q url.Values) (bool, time.Duration, error) {
+	wait := false
+	if w := q.Get("wait"); w != "" {
+		b, err := strconv.ParseBool(w)
+		if err != nil {
+			return false, 0, apierror.New(apierror.ErrBadRequest, "invalid value for wait", err)
+		}
+		wait = b
+	}
+
+	timeout, err := parseInstanceWaitTimeout(q.Get("timeout"))
+	if err != nil {
+		return false, 0, err
+	}
+
+	return wait, timeout, nil
+}
+
+// parseInstanceWaitTimeout parses a wait timeout given in seconds or as a duration (ie. 90s, 2m), it defaults
+// to defaultInstanceWaitTimeout and can't be more than maxInstanceWaitTimeout
+func parseInstanceWaitTimeout(t string) (time.Duration, error) {
+	if t == "" {
+		return defaultInstanceWaitTimeout, nil
+	}
+
+	timeout, err := time.ParseDuration(t)
+	if err != nil {
+		seconds, serr := strconv.Atoi(t)
+		if serr != nil {
+			return 0, apierror.New(apierror.ErrBadRequest, "invalid value for timeout", err)
+		}
+		timeout = time.Duration(seconds) * time.Second
+	}
+
+	if timeout <= 0 || timeout > maxInstanceWaitTimeout {
+		msg := fmt.Sprintf("timeout must be between 1s and %s", maxInstanceWaitTimeout)
+		return 0, apierror.New(apierror.ErrBadRequest, msg, nil)
+	}
+
+	return timeout, nil
+}
+
+// powerStateTarget returns the instance state reached by a power state change.  A rebooted instance stays running, so
+// there's no state to wait for.
+func powerStateTarget(state string) (string, error) {
+	switch strings.ToLower(state) {
+	case "start":
+		return ec2.InstanceStateNameRunning, nil
+	case "reboot":
+		return "", apierror.New(apierror.ErrBadRequest, "can't wait for a reboot, the instance stays running", nil)
+	case "stop", "poweroff":
+		return ec2.InstanceStateNameStopped, nil
+	default:
+		msg := fmt.Sprintf("unknown power state %q", state)
+		return "", apierror.New(apierror.ErrBadRequest, msg, nil)
+	}
+}
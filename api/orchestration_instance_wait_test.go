@@ -0,0 +1,147 @@
+package api
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	pEc2 "github.com/YaleSpinup/ec2-api/ec2"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+)
+
+// mockWaitEC2Client describes a terminated instance, which is filtered out when the instance state is filtered
+type mockWaitEC2Client struct {
+	ec2iface.EC2API
+}
+
+func (m *mockWaitEC2Client) WaitUntilInstanceTerminatedWithContext(ctx context.Context, input *ec2.DescribeInstancesInput, opts ...request.WaiterOption) error {
+	return nil
+}
+
+func (m *mockWaitEC2Client) DescribeInstancesWithContext(ctx context.Context, input *ec2.DescribeInstancesInput, opts ...request.Option) (*ec2.DescribeInstancesOutput, error) {
+	if len(input.Filters) != 0 {
+		return &ec2.DescribeInstancesOutput{}, nil
+	}
+
+	return &ec2.DescribeInstancesOutput{
+		Reservations: []*ec2.Reservation{
+			{
+				Instances: []*ec2.Instance{
+					{
+						InstanceId: input.InstanceIds[0],
+						State:      &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameTerminated)},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+func Test_waitForInstanceStateTerminated(t *testing.T) {
+	o := &ec2Orchestrator{
+		ec2Client: &pEc2.Ec2{Service: &mockWaitEC2Client{}},
+		server:    &server{org: "foo"},
+	}
+
+	got, err := o.waitForInstanceState(context.TODO(), "i-0123456789abcdef0", ec2.InstanceStateNameTerminated, time.Second)
+	if err != nil {
+		t.Fatalf("waitForInstanceState() error = %v", err)
+	}
+
+	if got.ID != "i-0123456789abcdef0" || got.State != ec2.InstanceStateNameTerminated {
+		t.Errorf("waitForInstanceState() = %+v, want terminated instance i-0123456789abcdef0", got)
+	}
+}
+
+func Test_parseInstanceWait(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       string
+		wantWait    bool
+		wantTimeout time.Duration
+		wantErr     bool
+	}{
+		{name: "no parameters", query: "", wantTimeout: defaultInstanceWaitTimeout},
+		{name: "wait", query: "wait=true", wantWait: true, wantTimeout: defaultInstanceWaitTimeout},
+		{name: "don't wait", query: "wait=false&timeout=30", wantTimeout: 30 * time.Second},
+		{name: "timeout in seconds", query: "wait=true&timeout=45", wantWait: true, wantTimeout: 45 * time.Second},
+		{name: "timeout as duration", query: "wait=true&timeout=1m10s", wantWait: true, wantTimeout: 70 * time.Second},
+		{name: "max timeout", query: "wait=true&timeout=80s", wantWait: true, wantTimeout: maxInstanceWaitTimeout},
+		{name: "timeout too long", query: "wait=true&timeout=10m", wantErr: true},
+		{name: "zero timeout", query: "wait=true&timeout=0", wantErr: true},
+		{name: "negative timeout", query: "wait=true&timeout=-5s", wantErr: true},
+		{name: "invalid timeout", query: "wait=true&timeout=soon", wantErr: true},
+		{name: "invalid wait", query: "wait=maybe", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("failed to parse query: %s", err)
+			}
+
+			wait, timeout, err := parseInstanceWait(q)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseInstanceWait() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if wait != tt.wantWait {
+				t.Errorf("parseInstanceWait() wait = %v, want %v", wait, tt.wantWait)
+			}
+			if timeout != tt.wantTimeout {
+				t.Errorf("parseInstanceWait() timeout = %s, want %s", timeout, tt.wantTimeout)
+			}
+		})
+	}
+}
+
+func Test_powerStateTarget(t *testing.T) {
+	tests := []struct {
+		state   string
+		want    string
+		wantErr bool
+	}{
+		{state: "start", want: "running"},
+		{state: "reboot", wantErr: true},
+		{state: "Stop", want: "stopped"},
+		{state: "poweroff", want: "stopped"},
+		{state: "hibernate", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.state, func(t *testing.T) {
+			got, err := powerStateTarget(tt.state)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("powerStateTarget() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("powerStateTarget() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_remainingInstanceWaitTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		timeout time.Duration
+		want    time.Duration
+	}{
+		{name: "timeout fits", elapsed: 0, timeout: 60 * time.Second, want: 60 * time.Second},
+		{name: "capped by the write timeout", elapsed: 30 * time.Second, timeout: maxInstanceWaitTimeout, want: serverWriteTimeout - instanceWaitMargin - 30*time.Second},
+		{name: "no time left", elapsed: serverWriteTimeout, timeout: 60 * time.Second, want: -instanceWaitMargin},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := remainingInstanceWaitTimeout(time.Now().Add(-tt.elapsed), tt.timeout)
+			if diff := tt.want - got; diff < 0 || diff > time.Second {
+				t.Errorf("remainingInstanceWaitTimeout() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
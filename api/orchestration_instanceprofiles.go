@@ -104,6 +104,12 @@ func (o *iamOrchestrator) createRole(ctx context.Context, roleName, instanceID s
 	if instanceProfileOutput, err = o.iamClient.Service.CreateInstanceProfileWithContext(ctx, &iam.CreateInstanceProfileInput{
 		InstanceProfileName: aws.String(roleName),
 		Path:                aws.String("/"),
+		Tags: []*iam.Tag{
+			{
+				Key:   aws.String(spinupOrgTag),
+				Value: aws.String(o.server.org),
+			},
+		},
 	}); err != nil {
 		return rollBackTasks, common.ErrCode("failed to create instance profile "+roleName, err)
 	}
@@ -64,6 +64,8 @@ func (o *ec2Orchestrator) createInstance(ctx context.Context, req *Ec2InstanceCr
 		return "", err
 	}
 
+	o.notify(webhookInstanceCreated, aws.StringValue(out.InstanceId))
+
 	return aws.StringValue(out.InstanceId), nil
 }
 
@@ -78,6 +80,8 @@ func (o *ec2Orchestrator) deleteInstance(ctx context.Context, id string) error {
 		return err
 	}
 
+	o.notify(webhookInstanceTerminated, id)
+
 	return nil
 }
 
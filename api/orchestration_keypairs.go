@@ -0,0 +1,200 @@
+package api
+
+import (
+	"context"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	log "github.com/sirupsen/logrus"
+)
+
+// listKeyPairs lists the key pairs in the org
+func (o *ec2Orchestrator) listKeyPairs(ctx context.Context) ([]*Ec2KeyPairResponse, error) {
+	keyPairs, err := o.ec2Client.ListKeyPairs(ctx, o.server.org)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*Ec2KeyPairResponse, 0, len(keyPairs))
+	for _, kp := range keyPairs {
+		out = append(out, toEc2KeyPairResponse(kp))
+	}
+
+	return out, nil
+}
+
+// getKeyPair gets a key pair in the org by its name, key pairs in other orgs aren't found
+func (o *ec2Orchestrator) getKeyPair(ctx context.Context, name string) (*ec2.KeyPairInfo, error) {
+	if name == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	kp, err := o.ec2Client.GetKeyPair(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if !hasSpinupOrgTag(kp.Tags, o.server.org) {
+		return nil, apierror.New(apierror.ErrNotFound, "key pair not found", nil)
+	}
+
+	return kp, nil
+}
+
+// createKeyPair creates a key pair tagged with the org and returns its private key.  The private key can't be
+// retrieved again, when an ssm orchestrator is given it's also stored in the requested ssm parameter as a
+// SecureString.  If storing the private key fails, the key pair is deleted.
+func (o *ec2Orchestrator) createKeyPair(ctx context.Context, req *Ec2KeyPairCreateRequest, ssmOrch *ssmOrchestrator) (*Ec2KeyPairResponse, error) {
+	if req == nil {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	if req.Name == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "name is required", nil)
+	}
+
+	if req.SsmParameter != "" && ssmOrch == nil {
+		return nil, apierror.New(apierror.ErrInternalError, "missing ssm client to store the private key", nil)
+	}
+
+	log.Debugf("got request to create key pair %s (type: '%s', ssm parameter: '%s')", req.Name, req.KeyType, req.SsmParameter)
+
+	var err error
+	var rollBackTasks []rollbackFunc
+	defer func() {
+		if err != nil {
+			log.Errorf("recovering from error: %s, executing %d rollback tasks", err, len(rollBackTasks))
+			rollBack(&rollBackTasks)
+		}
+	}()
+
+	input := &ec2.CreateKeyPairInput{
+		KeyName: aws.String(req.Name),
+		TagSpecifications: []*ec2.TagSpecification{
+			{
+				ResourceType: aws.String("key-pair"),
+				Tags:         spinupOrgTags(req.Tags, o.server.org),
+			},
+		},
+	}
+
+	if req.KeyType != "" {
+		input.KeyType = aws.String(req.KeyType)
+	}
+
+	if req.KeyFormat != "" {
+		input.KeyFormat = aws.String(req.KeyFormat)
+	}
+
+	out, err := o.ec2Client.CreateKeyPair(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	rollBackTasks = append(rollBackTasks, func(ctx context.Context) error {
+		log.Errorf("rollback: deleting key pair: %s", aws.StringValue(out.KeyPairId))
+		return o.ec2Client.DeleteKeyPair(ctx, aws.StringValue(out.KeyPairId))
+	})
+
+	resp := toEc2KeyPairResponse(&ec2.KeyPairInfo{
+		KeyPairId:      out.KeyPairId,
+		KeyName:        out.KeyName,
+		KeyType:        input.KeyType,
+		KeyFingerprint: out.KeyFingerprint,
+		Tags:           input.TagSpecifications[0].Tags,
+	})
+	resp.PrivateKey = aws.StringValue(out.KeyMaterial)
+
+	if req.SsmParameter != "" {
+		paramReq := &SSMParameterCreateRequest{
+			Name:        aws.String(req.SsmParameter),
+			Type:        aws.String("SecureString"),
+			Value:       out.KeyMaterial,
+			Description: aws.String("private key of key pair " + req.Name),
+			Tags: map[string]string{
+				spinupOrgTag: o.server.org,
+			},
+		}
+
+		if req.SsmKmsKeyId != "" {
+			paramReq.KeyId = aws.String(req.SsmKmsKeyId)
+		}
+
+		// err is used to trigger rollback, don't shadow it here
+		var param *SSMParameterResponse
+		if param, err = ssmOrch.createParameter(ctx, paramReq); err != nil {
+			return nil, err
+		}
+
+		resp.SsmParameter = param.Name
+	}
+
+	return resp, nil
+}
+
+// importKeyPair imports the public key of a key pair tagged with the org
+func (o *ec2Orchestrator) importKeyPair(ctx context.Context, req *Ec2KeyPairImportRequest) (*Ec2KeyPairResponse, error) {
+	if req == nil {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	if req.Name == "" || req.PublicKey == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "name and public_key are required", nil)
+	}
+
+	log.Debugf("got request to import key pair %s", req.Name)
+
+	input := &ec2.ImportKeyPairInput{
+		KeyName:           aws.String(req.Name),
+		PublicKeyMaterial: []byte(req.PublicKey),
+		TagSpecifications: []*ec2.TagSpecification{
+			{
+				ResourceType: aws.String("key-pair"),
+				Tags:         spinupOrgTags(req.Tags, o.server.org),
+			},
+		},
+	}
+
+	out, err := o.ec2Client.ImportKeyPair(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return toEc2KeyPairResponse(&ec2.KeyPairInfo{
+		KeyPairId:      out.KeyPairId,
+		KeyName:        out.KeyName,
+		KeyFingerprint: out.KeyFingerprint,
+		PublicKey:      aws.String(req.PublicKey),
+		Tags:           input.TagSpecifications[0].Tags,
+	}), nil
+}
+
+// updateKeyPairTags updates the tags of a key pair in the org, the org tag can't be changed
+func (o *ec2Orchestrator) updateKeyPairTags(ctx context.Context, name string, tags map[string]string) error {
+	if len(tags) == 0 {
+		return apierror.New(apierror.ErrBadRequest, "tags are required", nil)
+	}
+
+	if _, ok := tags[spinupOrgTag]; ok {
+		return apierror.New(apierror.ErrBadRequest, spinupOrgTag+" tag can't be changed", nil)
+	}
+
+	kp, err := o.getKeyPair(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	return o.ec2Client.UpdateRawTags(ctx, tags, aws.StringValue(kp.KeyPairId))
+}
+
+// deleteKeyPair deletes a key pair in the org.  Instances launched with the key pair keep the public key, and a
+// private key stored in ssm isn't deleted.
+func (o *ec2Orchestrator) deleteKeyPair(ctx context.Context, name string) error {
+	kp, err := o.getKeyPair(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	return o.ec2Client.DeleteKeyPair(ctx, aws.StringValue(kp.KeyPairId))
+}
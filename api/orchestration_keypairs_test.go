@@ -0,0 +1,102 @@
+package api
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func Test_toEc2KeyPairResponse(t *testing.T) {
+	created := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	kp := &ec2.KeyPairInfo{
+		KeyPairId:      aws.String("key-123"),
+		KeyName:        aws.String("deploy"),
+		KeyType:        aws.String("ed25519"),
+		KeyFingerprint: aws.String("fp"),
+		CreateTime:     &created,
+		PublicKey:      aws.String("ssh-ed25519 AAAA"),
+		Tags: []*ec2.Tag{
+			{Key: aws.String("spinup:org"), Value: aws.String("foo")},
+		},
+	}
+
+	want := &Ec2KeyPairResponse{
+		Id:          "key-123",
+		Name:        "deploy",
+		Type:        "ed25519",
+		Fingerprint: "fp",
+		CreatedAt:   timeFormat(&created),
+		PublicKey:   "ssh-ed25519 AAAA",
+		Tags:        []map[string]string{{"spinup:org": "foo"}},
+	}
+
+	if got := toEc2KeyPairResponse(kp); !reflect.DeepEqual(got, want) {
+		t.Errorf("toEc2KeyPairResponse() = %s, want %s", awsutil.Prettify(got), awsutil.Prettify(want))
+	}
+
+	if got := toEc2KeyPairResponse(&ec2.KeyPairInfo{}); got.Tags == nil {
+		t.Error("expected empty tags list, got nil")
+	}
+}
+
+func Test_keyPairRequestValidation(t *testing.T) {
+	o := &ec2Orchestrator{server: &server{org: "foo"}}
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{
+			name: "create nil request",
+			call: func() error {
+				_, err := o.createKeyPair(context.TODO(), nil, nil)
+				return err
+			},
+		},
+		{
+			name: "create missing name",
+			call: func() error {
+				_, err := o.createKeyPair(context.TODO(), &Ec2KeyPairCreateRequest{}, nil)
+				return err
+			},
+		},
+		{
+			name: "create ssm parameter without ssm client",
+			call: func() error {
+				_, err := o.createKeyPair(context.TODO(), &Ec2KeyPairCreateRequest{Name: "deploy", SsmParameter: "/keys/deploy"}, nil)
+				return err
+			},
+		},
+		{
+			name: "import missing public key",
+			call: func() error {
+				_, err := o.importKeyPair(context.TODO(), &Ec2KeyPairImportRequest{Name: "deploy"})
+				return err
+			},
+		},
+		{
+			name: "update missing tags",
+			call: func() error {
+				return o.updateKeyPairTags(context.TODO(), "deploy", nil)
+			},
+		},
+		{
+			name: "update org tag",
+			call: func() error {
+				return o.updateKeyPairTags(context.TODO(), "deploy", map[string]string{"spinup:org": "bar"})
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
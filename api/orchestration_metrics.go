@@ -0,0 +1,342 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/ec2-api/cloudwatch"
+	"github.com/aws/aws-sdk-go/aws"
+	awscloudwatch "github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+// limits of metrics requests
+const (
+	// maxMetricDatapoints is the most datapoints returned per metric
+	maxMetricDatapoints = 1440
+	// maxMetricsPerRequest is the most metrics in a request
+	maxMetricsPerRequest = 10
+	// maxMetricRetention is how far back cloudwatch keeps metrics
+	maxMetricRetention = 455 * 24 * time.Hour
+	// defaultMetricRange is the range of metrics returned when no start is given
+	defaultMetricRange = 3 * time.Hour
+)
+
+// metricPeriods are the periods picked when none is given, the shortest one within the datapoints limit is used
+var metricPeriods = []int64{60, 300, 900, 3600, 21600, 86400}
+
+// metricResolutions are the shortest periods cloudwatch keeps datapoints for, by the age of the datapoints
+var metricResolutions = []struct {
+	age    time.Duration
+	period int64
+}{
+	{age: 15 * 24 * time.Hour, period: 60},
+	{age: 63 * 24 * time.Hour, period: 300},
+	{age: maxMetricRetention, period: 3600},
+}
+
+var (
+	metricNameRe       = regexp.MustCompile(`^[A-Za-z0-9_.\-]{1,255}$`)
+	metricPercentileRe = regexp.MustCompile(`^p(100|[0-9]{1,2})(\.[0-9]{1,2})?$`)
+	metricStatistics   = []string{"Average", "Sum", "Minimum", "Maximum", "SampleCount"}
+)
+
+// metricPreset is a named cloudwatch metric and the statistic it's read with
+type metricPreset struct {
+	name      string
+	metric    string
+	statistic string
+	unit      string
+}
+
+// metricResource is the cloudwatch namespace and dimension of a type of resource, along with its metric presets
+type metricResource struct {
+	namespace string
+	dimension string
+	presets   []metricPreset
+}
+
+var instanceMetricResource = metricResource{
+	namespace: "AWS/EC2",
+	dimension: "InstanceId",
+	presets: []metricPreset{
+		{name: "cpu", metric: "CPUUtilization", statistic: "Average", unit: "Percent"},
+		{name: "network_in", metric: "NetworkIn", statistic: "Sum", unit: "Bytes"},
+		{name: "network_out", metric: "NetworkOut", statistic: "Sum", unit: "Bytes"},
+		{name: "disk_read", metric: "EBSReadBytes", statistic: "Sum", unit: "Bytes"},
+		{name: "disk_write", metric: "EBSWriteBytes", statistic: "Sum", unit: "Bytes"},
+		{name: "status_check_failed", metric: "StatusCheckFailed", statistic: "Maximum", unit: "Count"},
+		{name: "cpu_credit_balance", metric: "CPUCreditBalance", statistic: "Average", unit: "Count"},
+	},
+}
+
+var volumeMetricResource = metricResource{
+	namespace: "AWS/EBS",
+	dimension: "VolumeId",
+	presets: []metricPreset{
+		{name: "read_ops", metric: "VolumeReadOps", statistic: "Sum", unit: "Count"},
+		{name: "write_ops", metric: "VolumeWriteOps", statistic: "Sum", unit: "Count"},
+		{name: "read_bytes", metric: "VolumeReadBytes", statistic: "Sum", unit: "Bytes"},
+		{name: "write_bytes", metric: "VolumeWriteBytes", statistic: "Sum", unit: "Bytes"},
+		{name: "queue_length", metric: "VolumeQueueLength", statistic: "Average", unit: "Count"},
+		{name: "idle_time", metric: "VolumeIdleTime", statistic: "Sum", unit: "Seconds"},
+	},
+}
+
+// metricsRequest is a validated request for the metrics of a resource
+type metricsRequest struct {
+	metrics []metricPreset
+	start   time.Time
+	end     time.Time
+	period  int64
+}
+
+// parseMetricsRequest validates the query parameters of a metrics request.  Metrics are preset names or cloudwatch
+// metric names of the resource namespace, all the presets are returned when none are given.  The statistic overrides
+// the one of the presets, the period defaults to the shortest one within the datapoints limit and the range defaults
+// to the last 3 hours.
+func parseMetricsRequest(resource metricResource, metrics []string, statistic, period, start, end string, now time.Time) (*metricsRequest, error) {
+	req := &metricsRequest{end: now}
+
+	names := []string{}
+	for _, m := range metrics {
+		for _, n := range strings.Split(m, ",") {
+			if n = strings.TrimSpace(n); n != "" {
+				names = append(names, n)
+			}
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, n := range names {
+		p, err := resource.preset(n)
+		if err != nil {
+			return nil, err
+		}
+
+		if seen[p.name] {
+			continue
+		}
+		seen[p.name] = true
+		req.metrics = append(req.metrics, p)
+	}
+
+	if len(req.metrics) == 0 {
+		req.metrics = append(req.metrics, resource.presets...)
+	}
+
+	if len(req.metrics) > maxMetricsPerRequest {
+		return nil, apierror.New(apierror.ErrBadRequest, fmt.Sprintf("at most %d metrics can be requested", maxMetricsPerRequest), nil)
+	}
+
+	if statistic != "" {
+		if !validMetricStatistic(statistic) {
+			msg := fmt.Sprintf("invalid statistic '%s', it must be one of %s or a percentile like p99", statistic, strings.Join(metricStatistics, ", "))
+			return nil, apierror.New(apierror.ErrBadRequest, msg, nil)
+		}
+
+		for i := range req.metrics {
+			req.metrics[i].statistic = statistic
+		}
+	}
+
+	if end != "" {
+		t, err := time.Parse(time.RFC3339, end)
+		if err != nil {
+			return nil, apierror.New(apierror.ErrBadRequest, "end must be an RFC3339 time", err)
+		}
+		req.end = t
+	}
+
+	req.start = req.end.Add(-defaultMetricRange)
+	if start != "" {
+		t, err := time.Parse(time.RFC3339, start)
+		if err != nil {
+			return nil, apierror.New(apierror.ErrBadRequest, "start must be an RFC3339 time", err)
+		}
+		req.start = t
+	}
+
+	if !req.start.Before(req.end) {
+		return nil, apierror.New(apierror.ErrBadRequest, "start must be before end", nil)
+	}
+
+	if req.start.Before(now.Add(-maxMetricRetention)) {
+		return nil, apierror.New(apierror.ErrBadRequest, "start can't be more than 455 days ago", nil)
+	}
+
+	// older datapoints are only kept at a lower resolution, periods shorter than that return no datapoints
+	minPeriod := minMetricPeriod(req.start, now)
+
+	seconds := int64(req.end.Sub(req.start) / time.Second)
+	if period == "" {
+		req.period = metricPeriods[len(metricPeriods)-1]
+		for _, p := range metricPeriods {
+			if p >= minPeriod && seconds/p <= maxMetricDatapoints {
+				req.period = p
+				break
+			}
+		}
+	} else {
+		p, err := strconv.ParseInt(period, 10, 64)
+		if err != nil || p < 60 || p%60 != 0 {
+			return nil, apierror.New(apierror.ErrBadRequest, "period must be a number of seconds, a multiple of 60", err)
+		}
+
+		if p%minPeriod != 0 {
+			msg := fmt.Sprintf("period must be a multiple of %d for a start more than %d days ago", minPeriod, minMetricPeriodAge(minPeriod))
+			return nil, apierror.New(apierror.ErrBadRequest, msg, nil)
+		}
+		req.period = p
+	}
+
+	if seconds/req.period > maxMetricDatapoints {
+		msg := fmt.Sprintf("too many datapoints, at most %d are returned per metric, use a longer period or a shorter range", maxMetricDatapoints)
+		return nil, apierror.New(apierror.ErrBadRequest, msg, nil)
+	}
+
+	return req, nil
+}
+
+// minMetricPeriod returns the shortest period cloudwatch keeps datapoints for at the start of a range
+func minMetricPeriod(start, now time.Time) int64 {
+	age := now.Sub(start)
+	for _, r := range metricResolutions {
+		if age <= r.age {
+			return r.period
+		}
+	}
+	return metricResolutions[len(metricResolutions)-1].period
+}
+
+// minMetricPeriodAge returns the age in days after which datapoints are only kept for the period
+func minMetricPeriodAge(period int64) int {
+	age := 0
+	for _, r := range metricResolutions {
+		if r.period == period {
+			break
+		}
+		age = int(r.age / (24 * time.Hour))
+	}
+	return age
+}
+
+// preset returns the preset with the name or the metric name given, other valid metric names are read as averages
+func (r metricResource) preset(name string) (metricPreset, error) {
+	for _, p := range r.presets {
+		if p.name == name || p.metric == name {
+			return p, nil
+		}
+	}
+
+	if !metricNameRe.MatchString(name) {
+		return metricPreset{}, apierror.New(apierror.ErrBadRequest, fmt.Sprintf("invalid metric '%s'", name), nil)
+	}
+
+	return metricPreset{name: name, metric: name, statistic: "Average"}, nil
+}
+
+// validMetricStatistic returns true for the cloudwatch statistics and percentiles
+func validMetricStatistic(s string) bool {
+	for _, v := range metricStatistics {
+		if s == v {
+			return true
+		}
+	}
+	return metricPercentileRe.MatchString(s)
+}
+
+// instanceMetrics gets the cloudwatch metrics of an instance
+func (o *metricsOrchestrator) instanceMetrics(ctx context.Context, id string, req *metricsRequest) (*Ec2MetricsResponse, error) {
+	if _, err := o.ec2Client.GetInstance(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return o.resourceMetrics(ctx, instanceMetricResource, id, req)
+}
+
+// volumeMetrics gets the cloudwatch metrics of a volume
+func (o *metricsOrchestrator) volumeMetrics(ctx context.Context, id string, req *metricsRequest) (*Ec2MetricsResponse, error) {
+	volumes, err := o.ec2Client.GetVolume(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(volumes) == 0 {
+		return nil, apierror.New(apierror.ErrNotFound, "Resource not found", nil)
+	}
+
+	return o.resourceMetrics(ctx, volumeMetricResource, id, req)
+}
+
+// resourceMetrics gets the requested metrics of a resource, metrics without datapoints have an empty series
+func (o *metricsOrchestrator) resourceMetrics(ctx context.Context, resource metricResource, id string, req *metricsRequest) (*Ec2MetricsResponse, error) {
+	dims := map[string]string{resource.dimension: id}
+
+	queries := make([]*awscloudwatch.MetricDataQuery, 0, len(req.metrics))
+	for i, m := range req.metrics {
+		queries = append(queries, cloudwatch.MetricQuery(fmt.Sprintf("m%d", i), resource.namespace, m.metric, m.statistic, req.period, dims))
+	}
+
+	results, err := o.cloudwatchClient.GetMetricData(ctx, req.start, req.end, queries...)
+	if err != nil {
+		return nil, err
+	}
+
+	byId := make(map[string]*awscloudwatch.MetricDataResult, len(results))
+	for _, r := range results {
+		byId[aws.StringValue(r.Id)] = r
+	}
+
+	out := &Ec2MetricsResponse{
+		Id:      id,
+		Start:   req.start.UTC().Format(time.RFC3339),
+		End:     req.end.UTC().Format(time.RFC3339),
+		Period:  req.period,
+		Metrics: make([]*Ec2MetricSeries, 0, len(req.metrics)),
+	}
+
+	for i, m := range req.metrics {
+		out.Metrics = append(out.Metrics, toEc2MetricSeries(resource, m, byId[fmt.Sprintf("m%d", i)]))
+	}
+
+	return out, nil
+}
+
+// toEc2MetricSeries converts the result of a metric query into a response
+func toEc2MetricSeries(resource metricResource, m metricPreset, result *awscloudwatch.MetricDataResult) *Ec2MetricSeries {
+	out := &Ec2MetricSeries{
+		Name:       m.name,
+		Namespace:  resource.namespace,
+		Metric:     m.metric,
+		Statistic:  m.statistic,
+		Unit:       m.unit,
+		Datapoints: []*Ec2MetricDatapoint{},
+	}
+
+	// the count of a sample isn't in the unit of the metric
+	if m.statistic == "SampleCount" {
+		out.Unit = "Count"
+	}
+
+	if result == nil {
+		return out
+	}
+
+	for i, ts := range result.Timestamps {
+		if i >= len(result.Values) {
+			break
+		}
+
+		out.Datapoints = append(out.Datapoints, &Ec2MetricDatapoint{
+			Timestamp: aws.TimeValue(ts).UTC().Format(time.RFC3339),
+			Value:     aws.Float64Value(result.Values[i]),
+		})
+	}
+
+	return out
+}
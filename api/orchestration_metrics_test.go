@@ -0,0 +1,227 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+func Test_parseMetricsRequest(t *testing.T) {
+	now := time.Date(2023, 1, 2, 12, 0, 0, 0, time.UTC)
+
+	type args struct {
+		metrics   []string
+		statistic string
+		period    string
+		start     string
+		end       string
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantMetrics []string
+		wantStats   []string
+		wantStart   time.Time
+		wantEnd     time.Time
+		wantPeriod  int64
+		wantErr     bool
+	}{
+		{
+			name:        "defaults",
+			wantMetrics: []string{"cpu", "network_in", "network_out", "disk_read", "disk_write", "status_check_failed", "cpu_credit_balance"},
+			wantStats:   []string{"Average", "Sum", "Sum", "Sum", "Sum", "Maximum", "Average"},
+			wantStart:   now.Add(-3 * time.Hour),
+			wantEnd:     now,
+			wantPeriod:  60,
+		},
+		{
+			name:        "presets and metric names",
+			args:        args{metrics: []string{"cpu,network_in", "CPUUtilization", "MetadataNoToken"}},
+			wantMetrics: []string{"cpu", "network_in", "MetadataNoToken"},
+			wantStats:   []string{"Average", "Sum", "Average"},
+			wantStart:   now.Add(-3 * time.Hour),
+			wantEnd:     now,
+			wantPeriod:  60,
+		},
+		{
+			name:        "statistic and period",
+			args:        args{metrics: []string{"cpu"}, statistic: "p99", period: "300", start: "2023-01-01T12:00:00Z"},
+			wantMetrics: []string{"cpu"},
+			wantStats:   []string{"p99"},
+			wantStart:   now.Add(-24 * time.Hour),
+			wantEnd:     now,
+			wantPeriod:  300,
+		},
+		{
+			name:        "period picked from range",
+			args:        args{metrics: []string{"cpu"}, start: "2022-12-01T00:00:00Z", end: "2023-01-01T00:00:00Z"},
+			wantMetrics: []string{"cpu"},
+			wantStats:   []string{"Average"},
+			wantStart:   time.Date(2022, 12, 1, 0, 0, 0, 0, time.UTC),
+			wantEnd:     time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+			wantPeriod:  3600,
+		},
+		{
+			name:        "period of a range older than 15 days",
+			args:        args{metrics: []string{"cpu"}, start: "2022-12-01T00:00:00Z", end: "2022-12-01T12:00:00Z"},
+			wantMetrics: []string{"cpu"},
+			wantStats:   []string{"Average"},
+			wantStart:   time.Date(2022, 12, 1, 0, 0, 0, 0, time.UTC),
+			wantEnd:     time.Date(2022, 12, 1, 12, 0, 0, 0, time.UTC),
+			wantPeriod:  300,
+		},
+		{
+			name:        "period of a range older than 63 days",
+			args:        args{metrics: []string{"cpu"}, start: "2022-10-01T00:00:00Z", end: "2022-10-01T12:00:00Z"},
+			wantMetrics: []string{"cpu"},
+			wantStats:   []string{"Average"},
+			wantStart:   time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC),
+			wantEnd:     time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC),
+			wantPeriod:  3600,
+		},
+		{name: "period too short for a start older than 15 days", args: args{period: "60", start: "2022-12-01T00:00:00Z", end: "2022-12-01T12:00:00Z"}, wantErr: true},
+		{name: "period too short for a start older than 63 days", args: args{period: "900", start: "2022-10-01T00:00:00Z", end: "2022-10-01T12:00:00Z"}, wantErr: true},
+		{name: "invalid metric", args: args{metrics: []string{"cpu;drop"}}, wantErr: true},
+		{name: "invalid statistic", args: args{statistic: "Median"}, wantErr: true},
+		{name: "invalid period", args: args{period: "90"}, wantErr: true},
+		{name: "too many datapoints", args: args{period: "60", start: "2022-12-01T00:00:00Z"}, wantErr: true},
+		{name: "invalid start", args: args{start: "yesterday"}, wantErr: true},
+		{name: "start after end", args: args{start: "2023-01-02T12:00:00Z", end: "2023-01-02T11:00:00Z"}, wantErr: true},
+		{name: "past retention", args: args{start: "2021-01-01T00:00:00Z", period: "86400"}, wantErr: true},
+		{
+			name:    "too many metrics",
+			args:    args{metrics: []string{"a,b,c,d,e,f,g,h,i,j,k"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseMetricsRequest(instanceMetricResource, tt.args.metrics, tt.args.statistic, tt.args.period, tt.args.start, tt.args.end, now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseMetricsRequest() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			metrics, stats := []string{}, []string{}
+			for _, m := range got.metrics {
+				metrics = append(metrics, m.name)
+				stats = append(stats, m.statistic)
+			}
+
+			if !reflect.DeepEqual(metrics, tt.wantMetrics) || !reflect.DeepEqual(stats, tt.wantStats) {
+				t.Errorf("parseMetricsRequest() metrics = %v %v, want %v %v", metrics, stats, tt.wantMetrics, tt.wantStats)
+			}
+
+			if !got.start.Equal(tt.wantStart) || !got.end.Equal(tt.wantEnd) || got.period != tt.wantPeriod {
+				t.Errorf("parseMetricsRequest() = %s - %s every %d, want %s - %s every %d", got.start, got.end, got.period, tt.wantStart, tt.wantEnd, tt.wantPeriod)
+			}
+		})
+	}
+}
+
+func Test_parseMetricsRequest_presetsUnchanged(t *testing.T) {
+	if _, err := parseMetricsRequest(volumeMetricResource, nil, "Maximum", "", "", "", time.Now()); err != nil {
+		t.Fatalf("parseMetricsRequest() error = %v", err)
+	}
+
+	if s := volumeMetricResource.presets[0].statistic; s != "Sum" {
+		t.Errorf("parseMetricsRequest() changed the statistic of the presets to %s", s)
+	}
+}
+
+func Test_minMetricPeriod(t *testing.T) {
+	now := time.Date(2023, 1, 2, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		age     time.Duration
+		want    int64
+		wantAge int
+	}{
+		{name: "recent", age: time.Hour, want: 60, wantAge: 0},
+		{name: "15 days", age: 15 * 24 * time.Hour, want: 60, wantAge: 0},
+		{name: "older than 15 days", age: 16 * 24 * time.Hour, want: 300, wantAge: 15},
+		{name: "older than 63 days", age: 64 * 24 * time.Hour, want: 3600, wantAge: 63},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := minMetricPeriod(now.Add(-tt.age), now)
+			if got != tt.want {
+				t.Errorf("minMetricPeriod() = %v, want %v", got, tt.want)
+			}
+			if age := minMetricPeriodAge(got); age != tt.wantAge {
+				t.Errorf("minMetricPeriodAge() = %v, want %v", age, tt.wantAge)
+			}
+		})
+	}
+}
+
+func Test_toEc2MetricSeries(t *testing.T) {
+	ts := time.Date(2023, 1, 2, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		preset metricPreset
+		result *cloudwatch.MetricDataResult
+		want   *Ec2MetricSeries
+	}{
+		{
+			name:   "datapoints",
+			preset: instanceMetricResource.presets[0],
+			result: &cloudwatch.MetricDataResult{
+				Id:         aws.String("m0"),
+				Timestamps: aws.TimeSlice([]time.Time{ts, ts.Add(5 * time.Minute)}),
+				Values:     aws.Float64Slice([]float64{1.5, 2.5}),
+			},
+			want: &Ec2MetricSeries{
+				Name:      "cpu",
+				Namespace: "AWS/EC2",
+				Metric:    "CPUUtilization",
+				Statistic: "Average",
+				Unit:      "Percent",
+				Datapoints: []*Ec2MetricDatapoint{
+					{Timestamp: "2023-01-02T12:00:00Z", Value: 1.5},
+					{Timestamp: "2023-01-02T12:05:00Z", Value: 2.5},
+				},
+			},
+		},
+		{
+			name:   "no result",
+			preset: metricPreset{name: "MetadataNoToken", metric: "MetadataNoToken", statistic: "Average"},
+			want: &Ec2MetricSeries{
+				Name:       "MetadataNoToken",
+				Namespace:  "AWS/EC2",
+				Metric:     "MetadataNoToken",
+				Statistic:  "Average",
+				Datapoints: []*Ec2MetricDatapoint{},
+			},
+		},
+		{
+			name:   "sample count",
+			preset: metricPreset{name: "network_in", metric: "NetworkIn", statistic: "SampleCount", unit: "Bytes"},
+			result: &cloudwatch.MetricDataResult{},
+			want: &Ec2MetricSeries{
+				Name:       "network_in",
+				Namespace:  "AWS/EC2",
+				Metric:     "NetworkIn",
+				Statistic:  "SampleCount",
+				Unit:       "Count",
+				Datapoints: []*Ec2MetricDatapoint{},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := toEc2MetricSeries(instanceMetricResource, tt.preset, tt.result); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("toEc2MetricSeries() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,663 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/ec2-api/common"
+	"github.com/YaleSpinup/ec2-api/pricing"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/iam"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	orphanTypeVolume          = "volume"
+	orphanTypeSnapshot        = "snapshot"
+	orphanTypeSecurityGroup   = "security_group"
+	orphanTypeInstanceProfile = "instance_profile"
+	orphanTypeImage           = "image"
+)
+
+// orphanTypes are the resource types that are scanned for orphans, in the order they are reported
+var orphanTypes = []string{
+	orphanTypeVolume,
+	orphanTypeSnapshot,
+	orphanTypeImage,
+	orphanTypeSecurityGroup,
+	orphanTypeInstanceProfile,
+}
+
+// orphanProtectionTags are the tag keys that protect a resource from orphan cleanup, unless the value is "false"
+var orphanProtectionTags = []string{"spinup:protected", "DoNotDelete"}
+
+// snapshotMonthlyCostPerGB is the us-east-1 standard tier snapshot storage price.  Since snapshots are incremental,
+// estimates based on the source volume size are an upper bound.
+const snapshotMonthlyCostPerGB = 0.05
+
+// orphanScan determines which resources are scanned for orphans
+type orphanScan struct {
+	types      map[string]bool
+	org        string
+	minAgeDays int
+	now        time.Time
+}
+
+// newOrphanScan validates the given resource types and returns a new orphan scan, all types are scanned if none are given
+func newOrphanScan(types []string, org string, minAgeDays int) (*orphanScan, error) {
+	if minAgeDays < 0 {
+		return nil, apierror.New(apierror.ErrBadRequest, "min_age must be a positive number of days", nil)
+	}
+
+	scan := &orphanScan{
+		types:      map[string]bool{},
+		org:        org,
+		minAgeDays: minAgeDays,
+		now:        time.Now(),
+	}
+
+	for _, t := range types {
+		if !isOrphanType(t) {
+			msg := fmt.Sprintf("invalid orphan type %s, must be one of %s", t, strings.Join(orphanTypes, ", "))
+			return nil, apierror.New(apierror.ErrBadRequest, msg, nil)
+		}
+		scan.types[t] = true
+	}
+
+	if len(scan.types) == 0 {
+		for _, t := range orphanTypes {
+			scan.types[t] = true
+		}
+	}
+
+	return scan, nil
+}
+
+// orphanScanOrg returns the org to scan for orphans, the given org or else the org of the api.  The whole account is
+// only scanned when all orgs are explicitly requested.
+func orphanScanOrg(defaultOrg, org string, allOrgs bool) (string, error) {
+	if allOrgs {
+		if org != "" {
+			return "", apierror.New(apierror.ErrBadRequest, "org can't be given when scanning all orgs", nil)
+		}
+		return "", nil
+	}
+
+	if org == "" {
+		return defaultOrg, nil
+	}
+
+	return org, nil
+}
+
+// findOrphans scans the account for each of the requested orphaned resource types
+func findOrphans(ctx context.Context, ec2Orch *ec2Orchestrator, iamOrch *iamOrchestrator, scan *orphanScan) ([]*OrphanResource, error) {
+	log.Infof("scanning for orphaned resources (org: '%s', types: %+v)", scan.org, scan.types)
+
+	// instances are needed to determine if images and instance profiles are in use
+	var instances []*ec2.Instance
+	if scan.types[orphanTypeImage] || scan.types[orphanTypeInstanceProfile] {
+		var err error
+		if instances, err = ec2Orch.ec2Client.ListInstanceDetails(ctx, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	orphans := []*OrphanResource{}
+
+	if scan.types[orphanTypeVolume] {
+		out, err := ec2Orch.orphanedVolumes(ctx, scan)
+		if err != nil {
+			return nil, err
+		}
+		orphans = append(orphans, out...)
+	}
+
+	if scan.types[orphanTypeSnapshot] {
+		out, err := ec2Orch.orphanedSnapshots(ctx, scan)
+		if err != nil {
+			return nil, err
+		}
+		orphans = append(orphans, out...)
+	}
+
+	if scan.types[orphanTypeImage] {
+		out, err := ec2Orch.orphanedImages(ctx, scan, instances)
+		if err != nil {
+			return nil, err
+		}
+		orphans = append(orphans, out...)
+	}
+
+	if scan.types[orphanTypeSecurityGroup] {
+		out, err := ec2Orch.orphanedSecurityGroups(ctx, scan)
+		if err != nil {
+			return nil, err
+		}
+		orphans = append(orphans, out...)
+	}
+
+	if scan.types[orphanTypeInstanceProfile] {
+		out, err := iamOrch.orphanedInstanceProfiles(ctx, scan, instances)
+		if err != nil {
+			return nil, err
+		}
+		orphans = append(orphans, out...)
+	}
+
+	return scan.filter(orphans), nil
+}
+
+// orphanedVolumes returns the volumes that aren't attached to any instance
+func (o *ec2Orchestrator) orphanedVolumes(ctx context.Context, scan *orphanScan) ([]*OrphanResource, error) {
+	volumes, err := o.ec2Client.ListVolumeDetails(ctx, scan.org, &ec2.Filter{
+		Name:   aws.String("status"),
+		Values: aws.StringSlice([]string{ec2.VolumeStateAvailable}),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return classifyOrphanedVolumes(o.server.prices, o.server.pricesRegion, volumes, scan.now), nil
+}
+
+// orphanedSnapshots returns the snapshots whose source volume no longer exists and that don't back an image
+func (o *ec2Orchestrator) orphanedSnapshots(ctx context.Context, scan *orphanScan) ([]*OrphanResource, error) {
+	snapshots, err := o.ec2Client.ListSnapshotDetails(ctx, scan.org)
+	if err != nil {
+		return nil, err
+	}
+
+	volumes, err := o.ec2Client.ListVolumeDetails(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	images, err := o.ec2Client.ListImageDetails(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return classifyOrphanedSnapshots(snapshots, volumes, images, scan.now), nil
+}
+
+// orphanedImages returns the images that aren't used by any instance
+func (o *ec2Orchestrator) orphanedImages(ctx context.Context, scan *orphanScan, instances []*ec2.Instance) ([]*OrphanResource, error) {
+	images, err := o.ec2Client.ListImageDetails(ctx, scan.org, &ec2.Filter{
+		Name:   aws.String("state"),
+		Values: aws.StringSlice([]string{ec2.ImageStateAvailable}),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return classifyOrphanedImages(images, instances, scan.now), nil
+}
+
+// orphanedSecurityGroups returns the security groups that aren't used by any network interface or referenced by another security group
+func (o *ec2Orchestrator) orphanedSecurityGroups(ctx context.Context, scan *orphanScan) ([]*OrphanResource, error) {
+	sgs, err := o.ec2Client.ListSecurityGroupDetails(ctx, scan.org)
+	if err != nil {
+		return nil, err
+	}
+
+	allSgs := sgs
+	if scan.org != "" {
+		if allSgs, err = o.ec2Client.ListSecurityGroupDetails(ctx, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	enis, err := o.ec2Client.ListNetworkInterfaces(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return classifyOrphanedSecurityGroups(sgs, allSgs, enis), nil
+}
+
+// orphanedInstanceProfiles returns the instance profiles created by the api that aren't associated with any instance
+func (o *iamOrchestrator) orphanedInstanceProfiles(ctx context.Context, scan *orphanScan, instances []*ec2.Instance) ([]*OrphanResource, error) {
+	profiles, err := o.iamClient.ListInstanceProfiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	orphans := []*OrphanResource{}
+	for _, orphan := range classifyOrphanedInstanceProfiles(profiles, instances, scan.now) {
+		tags, err := o.iamClient.ListInstanceProfileTags(ctx, orphan.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		tagsMap := make(map[string]string, len(tags))
+		for _, t := range tags {
+			tagsMap[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+		}
+
+		// only instance profiles created by the api are tagged with an org, any others are never orphans
+		org, ok := tagsMap[spinupOrgTag]
+		if !ok || (scan.org != "" && org != scan.org) {
+			continue
+		}
+
+		orphan.Tags = tagsMap
+		orphan.Protected = isOrphanProtected(tagsMap)
+		orphans = append(orphans, orphan)
+	}
+
+	return orphans, nil
+}
+
+// deleteOrphan deletes a single orphaned resource
+func deleteOrphan(ctx context.Context, ec2Orch *ec2Orchestrator, iamOrch *iamOrchestrator, orphan *OrphanResource) error {
+	log.Infof("deleting orphaned %s %s", orphan.Type, orphan.ID)
+
+	var err error
+	var event string
+	switch orphan.Type {
+	case orphanTypeVolume:
+		err = ec2Orch.ec2Client.DeleteVolume(ctx, orphan.ID)
+		event = webhookVolumeDeleted
+	case orphanTypeSnapshot:
+		err = ec2Orch.ec2Client.DeleteSnapshot(ctx, &ec2.DeleteSnapshotInput{SnapshotId: aws.String(orphan.ID)})
+		event = webhookSnapshotDeleted
+	case orphanTypeImage:
+		err = ec2Orch.ec2Client.DeregisterImage(ctx, &ec2.DeregisterImageInput{ImageId: aws.String(orphan.ID)})
+		event = webhookImageDeleted
+	case orphanTypeSecurityGroup:
+		err = ec2Orch.ec2Client.DeleteSecurityGroup(ctx, orphan.ID)
+		event = webhookSgDeleted
+	case orphanTypeInstanceProfile:
+		return iamOrch.deleteOrphanedInstanceProfile(ctx, orphan.ID)
+	default:
+		return apierror.New(apierror.ErrBadRequest, "invalid orphan type "+orphan.Type, nil)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	ec2Orch.notify(event, orphan.ID)
+
+	return nil
+}
+
+// deleteOrphanedInstanceProfile removes the roles from an instance profile and deletes it, the roles and their policies are
+// left intact since they may be used by other instance profiles
+func (o *iamOrchestrator) deleteOrphanedInstanceProfile(ctx context.Context, name string) error {
+	ip, err := o.iamClient.GetInstanceProfile(ctx, &iam.GetInstanceProfileInput{InstanceProfileName: aws.String(name)})
+	if err != nil {
+		return err
+	}
+
+	for _, r := range ip.Roles {
+		input := &iam.RemoveRoleFromInstanceProfileInput{
+			RoleName:            r.RoleName,
+			InstanceProfileName: ip.InstanceProfileName,
+		}
+		if err := o.iamClient.RemoveRoleFromInstanceProfile(ctx, input); err != nil {
+			return common.ErrCode("failed to remove role from instance profile", err)
+		}
+	}
+
+	if err := o.iamClient.DeleteInstanceProfile(ctx, &iam.DeleteInstanceProfileInput{InstanceProfileName: ip.InstanceProfileName}); err != nil {
+		return common.ErrCode("failed to delete instance profile", err)
+	}
+
+	return nil
+}
+
+// deleteOrphans deletes the selected resources that are still orphaned and not protected
+func deleteOrphans(ctx context.Context, ec2Orch *ec2Orchestrator, iamOrch *iamOrchestrator, req *OrphanDeleteRequest) (*OrphanDeleteResponse, error) {
+	if req == nil || len(req.Resources) == 0 {
+		return nil, apierror.New(apierror.ErrBadRequest, "at least one resource is required", nil)
+	}
+
+	dryRun := true
+	if req.DryRun != nil {
+		dryRun = aws.BoolValue(req.DryRun)
+	}
+
+	types := []string{}
+	for _, r := range req.Resources {
+		if r.ID == "" {
+			return nil, apierror.New(apierror.ErrBadRequest, "resource id is required", nil)
+		}
+		types = append(types, r.Type)
+	}
+
+	org, err := orphanScanOrg(ec2Orch.server.org, aws.StringValue(req.Org), aws.BoolValue(req.AllOrgs))
+	if err != nil {
+		return nil, err
+	}
+
+	scan, err := newOrphanScan(types, org, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// re-scan so only resources that are still orphaned are deleted
+	orphans, err := findOrphans(ctx, ec2Orch, iamOrch, scan)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]*OrphanResource, len(orphans))
+	for _, o := range orphans {
+		found[o.Type+"/"+o.ID] = o
+	}
+
+	out := &OrphanDeleteResponse{
+		DryRun:  dryRun,
+		Results: make([]*OrphanDeleteResult, 0, len(req.Resources)),
+	}
+
+	for _, r := range req.Resources {
+		result := &OrphanDeleteResult{Type: r.Type, ID: r.ID}
+		out.Results = append(out.Results, result)
+
+		orphan, ok := found[r.Type+"/"+r.ID]
+		switch {
+		case !ok:
+			result.Status = "skipped"
+			result.Reason = "not an orphaned " + r.Type
+		case orphan.Protected:
+			result.Status = "skipped"
+			result.Reason = "protected by tag"
+		case dryRun:
+			result.Status = "would_delete"
+			result.EstimatedMonthlyCost = orphan.EstimatedMonthlyCost
+		default:
+			if err := deleteOrphan(ctx, ec2Orch, iamOrch, orphan); err != nil {
+				log.Errorf("failed to delete orphaned %s %s: %s", orphan.Type, orphan.ID, err)
+				result.Status = "failed"
+				result.Error = err.Error()
+				continue
+			}
+
+			result.Status = "deleted"
+			result.EstimatedMonthlyCost = orphan.EstimatedMonthlyCost
+		}
+	}
+
+	return out, nil
+}
+
+// filter removes orphans newer than the minimum age and sorts the result.  Resources without a known
+// creation time (security groups) are never filtered by age.
+func (scan *orphanScan) filter(orphans []*OrphanResource) []*OrphanResource {
+	out := []*OrphanResource{}
+	for _, o := range orphans {
+		if o.CreatedAt != "" && o.AgeDays < scan.minAgeDays {
+			continue
+		}
+		out = append(out, o)
+	}
+
+	order := map[string]int{}
+	for i, t := range orphanTypes {
+		order[t] = i
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Type != out[j].Type {
+			return order[out[i].Type] < order[out[j].Type]
+		}
+		return out[i].ID < out[j].ID
+	})
+
+	return out
+}
+
+func classifyOrphanedVolumes(catalog *pricing.Catalog, region string, volumes []*ec2.Volume, now time.Time) []*OrphanResource {
+	orphans := []*OrphanResource{}
+	for _, v := range volumes {
+		if aws.StringValue(v.State) != ec2.VolumeStateAvailable {
+			continue
+		}
+
+		tags := ec2TagsMap(v.Tags)
+		orphans = append(orphans, &OrphanResource{
+			Type:                 orphanTypeVolume,
+			ID:                   aws.StringValue(v.VolumeId),
+			Name:                 tags["Name"],
+			Reason:               "volume is not attached to an instance",
+			CreatedAt:            timeFormat(v.CreateTime),
+			AgeDays:              orphanAgeDays(v.CreateTime, now),
+			Size:                 aws.Int64Value(v.Size),
+			EstimatedMonthlyCost: roundCost(volumeMonthlyCost(catalog, region, aws.StringValue(v.VolumeType), aws.Int64Value(v.Size), aws.Int64Value(v.Iops), aws.Int64Value(v.Throughput))),
+			Protected:            isOrphanProtected(tags),
+			Tags:                 tags,
+		})
+	}
+
+	return orphans
+}
+
+func classifyOrphanedSnapshots(snapshots []*ec2.Snapshot, volumes []*ec2.Volume, images []*ec2.Image, now time.Time) []*OrphanResource {
+	volumeIds := make(map[string]bool, len(volumes))
+	for _, v := range volumes {
+		volumeIds[aws.StringValue(v.VolumeId)] = true
+	}
+
+	imageSnapshots := map[string]bool{}
+	for _, i := range images {
+		for _, bdm := range i.BlockDeviceMappings {
+			if bdm.Ebs != nil && bdm.Ebs.SnapshotId != nil {
+				imageSnapshots[aws.StringValue(bdm.Ebs.SnapshotId)] = true
+			}
+		}
+	}
+
+	orphans := []*OrphanResource{}
+	for _, s := range snapshots {
+		id := aws.StringValue(s.SnapshotId)
+		if volumeIds[aws.StringValue(s.VolumeId)] || imageSnapshots[id] {
+			continue
+		}
+
+		tags := ec2TagsMap(s.Tags)
+		orphans = append(orphans, &OrphanResource{
+			Type:                 orphanTypeSnapshot,
+			ID:                   id,
+			Name:                 tags["Name"],
+			Reason:               fmt.Sprintf("source volume %s no longer exists and snapshot is not used by an image", aws.StringValue(s.VolumeId)),
+			CreatedAt:            timeFormat(s.StartTime),
+			AgeDays:              orphanAgeDays(s.StartTime, now),
+			Size:                 aws.Int64Value(s.VolumeSize),
+			EstimatedMonthlyCost: roundCost(float64(aws.Int64Value(s.VolumeSize)) * snapshotMonthlyCostPerGB),
+			Protected:            isOrphanProtected(tags),
+			Tags:                 tags,
+		})
+	}
+
+	return orphans
+}
+
+func classifyOrphanedImages(images []*ec2.Image, instances []*ec2.Instance, now time.Time) []*OrphanResource {
+	inUse := map[string]bool{}
+	for _, i := range instances {
+		inUse[aws.StringValue(i.ImageId)] = true
+	}
+
+	orphans := []*OrphanResource{}
+	for _, i := range images {
+		id := aws.StringValue(i.ImageId)
+		if inUse[id] {
+			continue
+		}
+
+		var size int64
+		for _, bdm := range i.BlockDeviceMappings {
+			if bdm.Ebs != nil {
+				size += aws.Int64Value(bdm.Ebs.VolumeSize)
+			}
+		}
+
+		var created *time.Time
+		if t, err := time.Parse(time.RFC3339, aws.StringValue(i.CreationDate)); err == nil {
+			created = &t
+		}
+
+		tags := ec2TagsMap(i.Tags)
+		orphans = append(orphans, &OrphanResource{
+			Type:                 orphanTypeImage,
+			ID:                   id,
+			Name:                 aws.StringValue(i.Name),
+			Reason:               "image is not used by any instance",
+			CreatedAt:            timeFormat(created),
+			AgeDays:              orphanAgeDays(created, now),
+			Size:                 size,
+			EstimatedMonthlyCost: roundCost(float64(size) * snapshotMonthlyCostPerGB),
+			Protected:            isOrphanProtected(tags),
+			Tags:                 tags,
+		})
+	}
+
+	return orphans
+}
+
+func classifyOrphanedSecurityGroups(sgs, allSgs []*ec2.SecurityGroup, enis []*ec2.NetworkInterface) []*OrphanResource {
+	inUse := map[string]bool{}
+	for _, eni := range enis {
+		for _, g := range eni.Groups {
+			inUse[aws.StringValue(g.GroupId)] = true
+		}
+	}
+
+	// security groups referenced by rules in other security groups can't be deleted
+	referenced := map[string]bool{}
+	for _, sg := range allSgs {
+		for _, permissions := range [][]*ec2.IpPermission{sg.IpPermissions, sg.IpPermissionsEgress} {
+			for _, p := range permissions {
+				for _, pair := range p.UserIdGroupPairs {
+					if ref := aws.StringValue(pair.GroupId); ref != aws.StringValue(sg.GroupId) {
+						referenced[ref] = true
+					}
+				}
+			}
+		}
+	}
+
+	orphans := []*OrphanResource{}
+	for _, sg := range sgs {
+		id := aws.StringValue(sg.GroupId)
+		if aws.StringValue(sg.GroupName) == "default" || inUse[id] || referenced[id] {
+			continue
+		}
+
+		tags := ec2TagsMap(sg.Tags)
+		name := tags["Name"]
+		if name == "" {
+			name = aws.StringValue(sg.GroupName)
+		}
+
+		orphans = append(orphans, &OrphanResource{
+			Type:      orphanTypeSecurityGroup,
+			ID:        id,
+			Name:      name,
+			Reason:    "security group is not used by any network interface or referenced by another security group",
+			Protected: isOrphanProtected(tags),
+			Tags:      tags,
+		})
+	}
+
+	return orphans
+}
+
+func classifyOrphanedInstanceProfiles(profiles []*iam.InstanceProfile, instances []*ec2.Instance, now time.Time) []*OrphanResource {
+	inUse := map[string]bool{}
+	for _, i := range instances {
+		if i.IamInstanceProfile != nil {
+			inUse[aws.StringValue(i.IamInstanceProfile.Arn)] = true
+		}
+	}
+
+	orphans := []*OrphanResource{}
+	for _, p := range profiles {
+		// skip instance profiles managed by aws services
+		if strings.HasPrefix(aws.StringValue(p.Path), "/aws-") || inUse[aws.StringValue(p.Arn)] {
+			continue
+		}
+
+		name := aws.StringValue(p.InstanceProfileName)
+		orphans = append(orphans, &OrphanResource{
+			Type:      orphanTypeInstanceProfile,
+			ID:        name,
+			Name:      name,
+			Reason:    "instance profile is not associated with any instance",
+			CreatedAt: timeFormat(p.CreateDate),
+			AgeDays:   orphanAgeDays(p.CreateDate, now),
+		})
+	}
+
+	return orphans
+}
+
+// summarizeOrphans returns the totals for a list of orphaned resources
+func summarizeOrphans(orphans []*OrphanResource) OrphanSummary {
+	summary := OrphanSummary{
+		ByType: map[string]int{},
+	}
+
+	for _, o := range orphans {
+		summary.Total++
+		summary.ByType[o.Type]++
+
+		if o.Protected {
+			summary.Protected++
+			continue
+		}
+
+		summary.EstimatedMonthlyCost += o.EstimatedMonthlyCost
+	}
+
+	summary.EstimatedMonthlyCost = roundCost(summary.EstimatedMonthlyCost)
+
+	return summary
+}
+
+// isOrphanProtected returns true if any of the protection tags are set to a value other than "false"
+func isOrphanProtected(tags map[string]string) bool {
+	for _, k := range orphanProtectionTags {
+		if v, ok := tags[k]; ok && !strings.EqualFold(v, "false") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isOrphanType(t string) bool {
+	for _, o := range orphanTypes {
+		if t == o {
+			return true
+		}
+	}
+
+	return false
+}
+
+// orphanAgeDays returns the number of whole days since the given time, or 0 if it's unknown
+func orphanAgeDays(t *time.Time, now time.Time) int {
+	if t == nil {
+		return 0
+	}
+
+	return int(now.Sub(*t).Hours() / 24)
+}
+
+func ec2TagsMap(tags []*ec2.Tag) map[string]string {
+	out := make(map[string]string, len(tags))
+	for _, t := range tags {
+		out[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+	}
+
+	return out
+}
@@ -0,0 +1,263 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/YaleSpinup/ec2-api/pricing"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/iam"
+)
+
+var orphanTestNow = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+func Test_newOrphanScan(t *testing.T) {
+	tests := []struct {
+		name      string
+		types     []string
+		minAge    int
+		wantTypes int
+		wantErr   bool
+	}{
+		{name: "all types", wantTypes: len(orphanTypes)},
+		{name: "selected types", types: []string{"volume", "snapshot"}, wantTypes: 2},
+		{name: "invalid type", types: []string{"volume", "bucket"}, wantErr: true},
+		{name: "negative age", minAge: -1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newOrphanScan(tt.types, "", tt.minAge)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("newOrphanScan() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && len(got.types) != tt.wantTypes {
+				t.Errorf("newOrphanScan() types = %v, want %d types", got.types, tt.wantTypes)
+			}
+		})
+	}
+}
+
+func Test_orphanScanOrg(t *testing.T) {
+	tests := []struct {
+		name    string
+		org     string
+		allOrgs bool
+		want    string
+		wantErr bool
+	}{
+		{name: "default org", want: "spinup"},
+		{name: "given org", org: "other", want: "other"},
+		{name: "all orgs", allOrgs: true, want: ""},
+		{name: "all orgs with org", org: "other", allOrgs: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := orphanScanOrg("spinup", tt.org, tt.allOrgs)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("orphanScanOrg() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("orphanScanOrg() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_classifyOrphanedVolumes(t *testing.T) {
+	catalog, err := pricing.Parse(testPrices)
+	if err != nil {
+		t.Fatalf("failed to parse test prices: %s", err)
+	}
+
+	created := orphanTestNow.Add(-10 * 24 * time.Hour)
+	volumes := []*ec2.Volume{
+		{
+			VolumeId:   aws.String("vol-1"),
+			State:      aws.String("available"),
+			Size:       aws.Int64(100),
+			VolumeType: aws.String("gp2"),
+			CreateTime: &created,
+			Tags:       []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String("data")}},
+		},
+		{
+			VolumeId: aws.String("vol-2"),
+			State:    aws.String("in-use"),
+		},
+	}
+
+	want := []*OrphanResource{
+		{
+			Type:                 "volume",
+			ID:                   "vol-1",
+			Name:                 "data",
+			Reason:               "volume is not attached to an instance",
+			CreatedAt:            "2024/02/20 00:00:00",
+			AgeDays:              10,
+			Size:                 100,
+			EstimatedMonthlyCost: 10,
+			Tags:                 map[string]string{"Name": "data"},
+		},
+	}
+
+	if got := classifyOrphanedVolumes(catalog, "us-east-1", volumes, orphanTestNow); !reflect.DeepEqual(got, want) {
+		t.Errorf("classifyOrphanedVolumes() = %+v, want %+v", got, want)
+	}
+}
+
+func Test_classifyOrphanedSnapshots(t *testing.T) {
+	snapshots := []*ec2.Snapshot{
+		{SnapshotId: aws.String("snap-1"), VolumeId: aws.String("vol-1"), VolumeSize: aws.Int64(10)},
+		{SnapshotId: aws.String("snap-2"), VolumeId: aws.String("vol-gone"), VolumeSize: aws.Int64(20)},
+		{SnapshotId: aws.String("snap-3"), VolumeId: aws.String("vol-gone"), VolumeSize: aws.Int64(30)},
+	}
+	volumes := []*ec2.Volume{{VolumeId: aws.String("vol-1")}}
+	images := []*ec2.Image{
+		{
+			ImageId: aws.String("ami-1"),
+			BlockDeviceMappings: []*ec2.BlockDeviceMapping{
+				{Ebs: &ec2.EbsBlockDevice{SnapshotId: aws.String("snap-3")}},
+			},
+		},
+	}
+
+	got := classifyOrphanedSnapshots(snapshots, volumes, images, orphanTestNow)
+	if len(got) != 1 || got[0].ID != "snap-2" || got[0].EstimatedMonthlyCost != 1 {
+		t.Errorf("classifyOrphanedSnapshots() = %+v, want only snap-2 costing 1", got)
+	}
+}
+
+func Test_classifyOrphanedImages(t *testing.T) {
+	images := []*ec2.Image{
+		{ImageId: aws.String("ami-1"), CreationDate: aws.String("2024-01-01T00:00:00.000Z")},
+		{
+			ImageId:      aws.String("ami-2"),
+			Name:         aws.String("old-image"),
+			CreationDate: aws.String("2024-01-01T00:00:00.000Z"),
+			BlockDeviceMappings: []*ec2.BlockDeviceMapping{
+				{Ebs: &ec2.EbsBlockDevice{VolumeSize: aws.Int64(8)}},
+				{Ebs: &ec2.EbsBlockDevice{VolumeSize: aws.Int64(12)}},
+			},
+		},
+	}
+	instances := []*ec2.Instance{{InstanceId: aws.String("i-1"), ImageId: aws.String("ami-1")}}
+
+	got := classifyOrphanedImages(images, instances, orphanTestNow)
+	if len(got) != 1 {
+		t.Fatalf("classifyOrphanedImages() = %+v, want 1 orphan", got)
+	}
+
+	if got[0].ID != "ami-2" || got[0].Name != "old-image" || got[0].Size != 20 || got[0].AgeDays != 60 || got[0].EstimatedMonthlyCost != 1 {
+		t.Errorf("classifyOrphanedImages() = %+v, unexpected orphan", got[0])
+	}
+}
+
+func Test_classifyOrphanedSecurityGroups(t *testing.T) {
+	sgs := []*ec2.SecurityGroup{
+		{GroupId: aws.String("sg-default"), GroupName: aws.String("default")},
+		{GroupId: aws.String("sg-used"), GroupName: aws.String("used")},
+		{GroupId: aws.String("sg-referenced"), GroupName: aws.String("referenced")},
+		{
+			GroupId:   aws.String("sg-self"),
+			GroupName: aws.String("self"),
+			IpPermissions: []*ec2.IpPermission{
+				{UserIdGroupPairs: []*ec2.UserIdGroupPair{{GroupId: aws.String("sg-self")}}},
+			},
+			Tags: []*ec2.Tag{{Key: aws.String("spinup:protected"), Value: aws.String("true")}},
+		},
+	}
+	allSgs := append(sgs, &ec2.SecurityGroup{
+		GroupId: aws.String("sg-other"),
+		IpPermissionsEgress: []*ec2.IpPermission{
+			{UserIdGroupPairs: []*ec2.UserIdGroupPair{{GroupId: aws.String("sg-referenced")}}},
+		},
+	})
+	enis := []*ec2.NetworkInterface{
+		{Groups: []*ec2.GroupIdentifier{{GroupId: aws.String("sg-used")}, {GroupId: aws.String("sg-other")}}},
+	}
+
+	got := classifyOrphanedSecurityGroups(sgs, allSgs, enis)
+	if len(got) != 1 || got[0].ID != "sg-self" || got[0].Name != "self" || !got[0].Protected {
+		t.Errorf("classifyOrphanedSecurityGroups() = %+v, want only protected sg-self", got)
+	}
+}
+
+func Test_classifyOrphanedInstanceProfiles(t *testing.T) {
+	profiles := []*iam.InstanceProfile{
+		{InstanceProfileName: aws.String("used"), Arn: aws.String("arn:aws:iam::1:instance-profile/used"), Path: aws.String("/")},
+		{InstanceProfileName: aws.String("unused"), Arn: aws.String("arn:aws:iam::1:instance-profile/unused"), Path: aws.String("/")},
+		{InstanceProfileName: aws.String("service"), Arn: aws.String("arn:aws:iam::1:instance-profile/aws-service-role/service"), Path: aws.String("/aws-service-role/")},
+	}
+	instances := []*ec2.Instance{
+		{IamInstanceProfile: &ec2.IamInstanceProfile{Arn: aws.String("arn:aws:iam::1:instance-profile/used")}},
+		{},
+	}
+
+	got := classifyOrphanedInstanceProfiles(profiles, instances, orphanTestNow)
+	if len(got) != 1 || got[0].ID != "unused" {
+		t.Errorf("classifyOrphanedInstanceProfiles() = %+v, want only unused", got)
+	}
+}
+
+func Test_orphanScan_filter(t *testing.T) {
+	scan := &orphanScan{minAgeDays: 7}
+	orphans := []*OrphanResource{
+		{Type: "snapshot", ID: "snap-2", CreatedAt: "x", AgeDays: 30},
+		{Type: "volume", ID: "vol-2", CreatedAt: "x", AgeDays: 3},
+		{Type: "security_group", ID: "sg-1"},
+		{Type: "volume", ID: "vol-1", CreatedAt: "x", AgeDays: 7},
+	}
+
+	var got []string
+	for _, o := range scan.filter(orphans) {
+		got = append(got, o.ID)
+	}
+
+	want := []string{"vol-1", "snap-2", "sg-1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("orphanScan.filter() = %v, want %v", got, want)
+	}
+}
+
+func Test_summarizeOrphans(t *testing.T) {
+	orphans := []*OrphanResource{
+		{Type: "volume", EstimatedMonthlyCost: 10},
+		{Type: "volume", EstimatedMonthlyCost: 5, Protected: true},
+		{Type: "snapshot", EstimatedMonthlyCost: 1.25},
+	}
+
+	want := OrphanSummary{
+		Total:                3,
+		Protected:            1,
+		EstimatedMonthlyCost: 11.25,
+		ByType:               map[string]int{"volume": 2, "snapshot": 1},
+	}
+
+	if got := summarizeOrphans(orphans); !reflect.DeepEqual(got, want) {
+		t.Errorf("summarizeOrphans() = %+v, want %+v", got, want)
+	}
+}
+
+func Test_isOrphanProtected(t *testing.T) {
+	tests := []struct {
+		name string
+		tags map[string]string
+		want bool
+	}{
+		{name: "no tags", want: false},
+		{name: "protected", tags: map[string]string{"spinup:protected": "true"}, want: true},
+		{name: "do not delete", tags: map[string]string{"DoNotDelete": "yes"}, want: true},
+		{name: "explicitly unprotected", tags: map[string]string{"spinup:protected": "False"}, want: false},
+		{name: "other tags", tags: map[string]string{"Name": "foo"}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isOrphanProtected(tt.tags); got != tt.want {
+				t.Errorf("isOrphanProtected() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,487 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	log "github.com/sirupsen/logrus"
+)
+
+// listPrefixLists lists the customer-managed prefix lists owned by the account
+func (o *ec2Orchestrator) listPrefixLists(ctx context.Context, account string) ([]*Ec2PrefixListResponse, error) {
+	if account == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	lists, err := o.ec2Client.ListManagedPrefixLists(ctx, &ec2.Filter{
+		Name:   aws.String("owner-id"),
+		Values: aws.StringSlice([]string{account}),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*Ec2PrefixListResponse, 0, len(lists))
+	for _, pl := range lists {
+		out = append(out, toEc2PrefixListResponse(pl, nil))
+	}
+
+	return out, nil
+}
+
+// getPrefixList returns a prefix list with its entries, or the entries of the given previous version
+func (o *ec2Orchestrator) getPrefixList(ctx context.Context, id string, version *int64) (*Ec2PrefixListResponse, error) {
+	if id == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	pl, err := o.ec2Client.GetManagedPrefixList(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if version != nil && (aws.Int64Value(version) < 1 || aws.Int64Value(version) > aws.Int64Value(pl.Version)) {
+		msg := fmt.Sprintf("version should be between 1 and %d", aws.Int64Value(pl.Version))
+		return nil, apierror.New(apierror.ErrBadRequest, msg, nil)
+	}
+
+	entries, err := o.ec2Client.GetManagedPrefixListEntries(ctx, id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	out := toEc2PrefixListResponse(pl, entries)
+	if version != nil {
+		out.EntriesVersion = aws.Int64Value(version)
+	}
+
+	return out, nil
+}
+
+// createPrefixList creates a customer-managed prefix list.  The prefix list is created asynchronously, its state is
+// create-in-progress until the entries are applied.
+func (o *ec2Orchestrator) createPrefixList(ctx context.Context, req *Ec2PrefixListCreateRequest) (*Ec2PrefixListResponse, error) {
+	if req == nil {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Debugf("got request to create prefix list: %s", awsutil.Prettify(req))
+
+	if req.Name == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "name is required", nil)
+	}
+
+	family, err := prefixListAddressFamily(req.AddressFamily)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validatePrefixListEntries(family, req.Entries); err != nil {
+		return nil, err
+	}
+
+	maxEntries := req.MaxEntries
+	if maxEntries == 0 {
+		maxEntries = int64(len(req.Entries))
+	}
+
+	if maxEntries < 1 || maxEntries < int64(len(req.Entries)) {
+		return nil, apierror.New(apierror.ErrBadRequest, "max_entries should be at least 1 and at least the number of entries", nil)
+	}
+
+	input := &ec2.CreateManagedPrefixListInput{
+		AddressFamily:  aws.String(family),
+		Entries:        addPrefixListEntries(req.Entries),
+		MaxEntries:     aws.Int64(maxEntries),
+		PrefixListName: aws.String(req.Name),
+	}
+
+	if len(req.Tags) > 0 {
+		input.SetTagSpecifications([]*ec2.TagSpecification{
+			{
+				ResourceType: aws.String("prefix-list"),
+				Tags:         normalizeTags(req.Tags),
+			},
+		})
+	}
+
+	pl, err := o.ec2Client.CreateManagedPrefixList(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	out := toEc2PrefixListResponse(pl, nil)
+	out.Entries = normalizePrefixListEntries(req.Entries)
+
+	return out, nil
+}
+
+// updatePrefixList changes the name, max entries or entries of a prefix list and updates its tags.  Changing the entries
+// creates a new version of the prefix list.
+func (o *ec2Orchestrator) updatePrefixList(ctx context.Context, id string, req *Ec2PrefixListUpdateRequest) (*Ec2PrefixListResponse, error) {
+	if id == "" || req == nil {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Debugf("got request to update prefix list %s: %s", id, awsutil.Prettify(req))
+
+	changesEntries := req.Entries != nil || len(req.AddEntries) > 0 || len(req.RemoveEntries) > 0
+	if req.Entries != nil && (len(req.AddEntries) > 0 || len(req.RemoveEntries) > 0) {
+		return nil, apierror.New(apierror.ErrBadRequest, "entries can't be combined with add_entries or remove_entries", nil)
+	}
+
+	if changesEntries && req.MaxEntries != nil {
+		return nil, apierror.New(apierror.ErrBadRequest, "max_entries can't be changed together with the entries", nil)
+	}
+
+	if !changesEntries && req.Name == nil && req.MaxEntries == nil && len(req.Tags) == 0 {
+		return nil, apierror.New(apierror.ErrBadRequest, "nothing to update", nil)
+	}
+
+	pl, err := o.ec2Client.GetManagedPrefixList(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.CurrentVersion != nil && aws.Int64Value(req.CurrentVersion) != aws.Int64Value(pl.Version) {
+		msg := fmt.Sprintf("prefix list has changed, current version is %d", aws.Int64Value(pl.Version))
+		return nil, apierror.New(apierror.ErrConflict, msg, nil)
+	}
+
+	if strings.HasSuffix(aws.StringValue(pl.State), "-in-progress") {
+		msg := fmt.Sprintf("prefix list is being changed (%s)", aws.StringValue(pl.State))
+		return nil, apierror.New(apierror.ErrConflict, msg, nil)
+	}
+
+	current, err := o.ec2Client.GetManagedPrefixListEntries(ctx, id, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.MaxEntries != nil && aws.Int64Value(req.MaxEntries) < int64(len(current)) {
+		msg := fmt.Sprintf("max_entries should be at least the number of entries (%d)", len(current))
+		return nil, apierror.New(apierror.ErrBadRequest, msg, nil)
+	}
+
+	input := &ec2.ModifyManagedPrefixListInput{
+		PrefixListId:   aws.String(id),
+		PrefixListName: req.Name,
+		MaxEntries:     req.MaxEntries,
+	}
+
+	desired := normalizePrefixListEntries(toEc2PrefixListResponse(pl, current).Entries)
+	if changesEntries {
+		family := aws.StringValue(pl.AddressFamily)
+
+		var add, remove []*Ec2PrefixListEntry
+		if req.Entries != nil {
+			if err := validatePrefixListEntries(family, req.Entries); err != nil {
+				return nil, err
+			}
+			add, remove = diffPrefixListEntries(current, req.Entries)
+		} else {
+			for _, entries := range [][]*Ec2PrefixListEntry{req.AddEntries, req.RemoveEntries} {
+				if err := validatePrefixListEntries(family, entries); err != nil {
+					return nil, err
+				}
+			}
+			add, remove = req.AddEntries, req.RemoveEntries
+		}
+
+		desired = applyPrefixListEntries(desired, add, remove)
+		if int64(len(desired)) > aws.Int64Value(pl.MaxEntries) {
+			msg := fmt.Sprintf("prefix list would have %d entries, max_entries is %d", len(desired), aws.Int64Value(pl.MaxEntries))
+			return nil, apierror.New(apierror.ErrBadRequest, msg, nil)
+		}
+
+		input.AddEntries = addPrefixListEntries(add)
+		input.RemoveEntries = removePrefixListEntries(remove)
+		input.CurrentVersion = pl.Version
+	}
+
+	out := toEc2PrefixListResponse(pl, nil)
+
+	modify := len(input.AddEntries) > 0 || len(input.RemoveEntries) > 0 || req.Name != nil || req.MaxEntries != nil
+	if modify {
+		modified, err := o.ec2Client.ModifyManagedPrefixList(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		out = toEc2PrefixListResponse(modified, nil)
+	}
+
+	if len(req.Tags) > 0 {
+		if err := o.ec2Client.UpdateRawTags(ctx, req.Tags, id); err != nil {
+			return nil, err
+		}
+	}
+
+	out.Entries = desired
+
+	return out, nil
+}
+
+// restorePrefixList restores the entries of a prefix list to a previous version, which creates a new version
+func (o *ec2Orchestrator) restorePrefixList(ctx context.Context, id string, version int64) (*Ec2PrefixListResponse, error) {
+	if id == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Debugf("got request to restore prefix list %s to version %d", id, version)
+
+	pl, err := o.ec2Client.GetManagedPrefixList(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if version < 1 || version >= aws.Int64Value(pl.Version) {
+		msg := fmt.Sprintf("version should be a previous version between 1 and %d", aws.Int64Value(pl.Version)-1)
+		return nil, apierror.New(apierror.ErrBadRequest, msg, nil)
+	}
+
+	restored, err := o.ec2Client.RestoreManagedPrefixListVersion(ctx, id, aws.Int64Value(pl.Version), version)
+	if err != nil {
+		return nil, err
+	}
+
+	return toEc2PrefixListResponse(restored, nil), nil
+}
+
+// deletePrefixList deletes a prefix list if no security groups reference it
+func (o *ec2Orchestrator) deletePrefixList(ctx context.Context, id string) error {
+	refs, err := o.prefixListReferences(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if len(refs.SecurityGroups) > 0 {
+		ids := make([]string, 0, len(refs.SecurityGroups))
+		for _, sg := range refs.SecurityGroups {
+			ids = append(ids, sg.GroupId)
+		}
+
+		msg := fmt.Sprintf("prefix list is referenced by security groups %s", strings.Join(ids, ", "))
+		return apierror.New(apierror.ErrConflict, msg, nil)
+	}
+
+	return o.ec2Client.DeleteManagedPrefixList(ctx, id)
+}
+
+// prefixListReferences returns the security groups with rules referencing a prefix list
+func (o *ec2Orchestrator) prefixListReferences(ctx context.Context, id string) (*Ec2PrefixListReferencesResponse, error) {
+	if id == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	pl, err := o.ec2Client.GetManagedPrefixList(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var referencing []*ec2.SecurityGroup
+	for _, name := range []string{"ip-permission.prefix-list-id", "egress.ip-permission.prefix-list-id"} {
+		sgs, err := o.ec2Client.ListSecurityGroupDetails(ctx, "", &ec2.Filter{
+			Name:   aws.String(name),
+			Values: aws.StringSlice([]string{id}),
+		})
+		if err != nil {
+			return nil, err
+		}
+		referencing = append(referencing, sgs...)
+	}
+
+	return newPrefixListReferences(pl, referencing), nil
+}
+
+// newPrefixListReferences builds the references to a prefix list from the security groups with rules referencing it
+func newPrefixListReferences(pl *ec2.ManagedPrefixList, referencing []*ec2.SecurityGroup) *Ec2PrefixListReferencesResponse {
+	id := aws.StringValue(pl.PrefixListId)
+
+	out := &Ec2PrefixListReferencesResponse{
+		PrefixListId:   id,
+		Name:           aws.StringValue(pl.PrefixListName),
+		SecurityGroups: []*Ec2SecurityGroupReference{},
+	}
+
+	seen := map[string]bool{}
+	for _, sg := range referencing {
+		sgId := aws.StringValue(sg.GroupId)
+		if seen[sgId] {
+			continue
+		}
+		seen[sgId] = true
+
+		reference := &Ec2SecurityGroupReference{
+			GroupId:   sgId,
+			GroupName: aws.StringValue(sg.GroupName),
+			Ingress:   rulesReferencingPrefixList(sg.IpPermissions, id),
+			Egress:    rulesReferencingPrefixList(sg.IpPermissionsEgress, id),
+		}
+
+		if len(reference.Ingress) > 0 || len(reference.Egress) > 0 {
+			out.SecurityGroups = append(out.SecurityGroups, reference)
+		}
+	}
+
+	sort.Slice(out.SecurityGroups, func(i, j int) bool {
+		return out.SecurityGroups[i].GroupId < out.SecurityGroups[j].GroupId
+	})
+
+	return out
+}
+
+// rulesReferencingPrefixList returns the rules in the permissions that reference the given prefix list
+func rulesReferencingPrefixList(permissions []*ec2.IpPermission, id string) []*Ec2SecurityGroupRule {
+	rules := []*Ec2SecurityGroupRule{}
+	for _, r := range flattenIpPermissions(permissions) {
+		if r.PrefixListId == id {
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
+// prefixListAddressFamily validates the address family of a prefix list, defaulting to IPv4
+func prefixListAddressFamily(family string) (string, error) {
+	switch strings.ToLower(family) {
+	case "", "ipv4":
+		return "IPv4", nil
+	case "ipv6":
+		return "IPv6", nil
+	default:
+		return "", apierror.New(apierror.ErrBadRequest, "address_family should be [IPv4|IPv6]", nil)
+	}
+}
+
+// validatePrefixListEntries checks that the entries are CIDR blocks of the address family without duplicates
+func validatePrefixListEntries(family string, entries []*Ec2PrefixListEntry) error {
+	seen := map[string]bool{}
+	for _, e := range entries {
+		if e == nil || e.Cidr == "" {
+			return apierror.New(apierror.ErrBadRequest, "cidr is required for each entry", nil)
+		}
+
+		ip, network, err := net.ParseCIDR(e.Cidr)
+		if err != nil {
+			return apierror.New(apierror.ErrBadRequest, "invalid cidr "+e.Cidr, err)
+		}
+
+		if (ip.To4() != nil) != (family == "IPv4") {
+			msg := fmt.Sprintf("cidr %s is not an %s cidr", e.Cidr, family)
+			return apierror.New(apierror.ErrBadRequest, msg, nil)
+		}
+
+		if network.String() != e.Cidr {
+			msg := fmt.Sprintf("cidr %s should be %s", e.Cidr, network.String())
+			return apierror.New(apierror.ErrBadRequest, msg, nil)
+		}
+
+		if seen[e.Cidr] {
+			return apierror.New(apierror.ErrBadRequest, "duplicate cidr "+e.Cidr, nil)
+		}
+		seen[e.Cidr] = true
+	}
+
+	return nil
+}
+
+// diffPrefixListEntries returns the entries to add and remove to change the current entries to the desired ones.
+// Entries with a changed description are added again, which updates the description.
+func diffPrefixListEntries(current []*ec2.PrefixListEntry, desired []*Ec2PrefixListEntry) (add, remove []*Ec2PrefixListEntry) {
+	existing := make(map[string]string, len(current))
+	for _, e := range current {
+		existing[aws.StringValue(e.Cidr)] = aws.StringValue(e.Description)
+	}
+
+	wanted := make(map[string]bool, len(desired))
+	for _, e := range desired {
+		if e == nil {
+			continue
+		}
+		wanted[e.Cidr] = true
+
+		if description, ok := existing[e.Cidr]; !ok || description != e.Description {
+			add = append(add, e)
+		}
+	}
+
+	for _, e := range current {
+		if !wanted[aws.StringValue(e.Cidr)] {
+			remove = append(remove, &Ec2PrefixListEntry{Cidr: aws.StringValue(e.Cidr)})
+		}
+	}
+
+	return add, remove
+}
+
+// applyPrefixListEntries returns the entries after adding and removing the given entries, sorted by cidr
+func applyPrefixListEntries(entries, add, remove []*Ec2PrefixListEntry) []*Ec2PrefixListEntry {
+	byCidr := map[string]*Ec2PrefixListEntry{}
+	for _, e := range entries {
+		byCidr[e.Cidr] = e
+	}
+
+	for _, e := range remove {
+		delete(byCidr, e.Cidr)
+	}
+
+	for _, e := range add {
+		byCidr[e.Cidr] = &Ec2PrefixListEntry{Cidr: e.Cidr, Description: e.Description}
+	}
+
+	out := make([]*Ec2PrefixListEntry, 0, len(byCidr))
+	for _, e := range byCidr {
+		out = append(out, e)
+	}
+
+	return normalizePrefixListEntries(out)
+}
+
+// normalizePrefixListEntries returns a copy of the entries sorted by cidr
+func normalizePrefixListEntries(entries []*Ec2PrefixListEntry) []*Ec2PrefixListEntry {
+	out := make([]*Ec2PrefixListEntry, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, &Ec2PrefixListEntry{Cidr: e.Cidr, Description: e.Description})
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Cidr < out[j].Cidr })
+
+	return out
+}
+
+func addPrefixListEntries(entries []*Ec2PrefixListEntry) []*ec2.AddPrefixListEntry {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	out := make([]*ec2.AddPrefixListEntry, 0, len(entries))
+	for _, e := range entries {
+		entry := &ec2.AddPrefixListEntry{Cidr: aws.String(e.Cidr)}
+		if e.Description != "" {
+			entry.Description = aws.String(e.Description)
+		}
+		out = append(out, entry)
+	}
+
+	return out
+}
+
+func removePrefixListEntries(entries []*Ec2PrefixListEntry) []*ec2.RemovePrefixListEntry {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	out := make([]*ec2.RemovePrefixListEntry, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, &ec2.RemovePrefixListEntry{Cidr: aws.String(e.Cidr)})
+	}
+
+	return out
+}
@@ -0,0 +1,282 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func Test_prefixListAddressFamily(t *testing.T) {
+	tests := []struct {
+		family  string
+		want    string
+		wantErr bool
+	}{
+		{family: "", want: "IPv4"},
+		{family: "ipv4", want: "IPv4"},
+		{family: "IPv6", want: "IPv6"},
+		{family: "ipx", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.family, func(t *testing.T) {
+			got, err := prefixListAddressFamily(tt.family)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("prefixListAddressFamily() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("prefixListAddressFamily() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_validatePrefixListEntries(t *testing.T) {
+	tests := []struct {
+		name    string
+		family  string
+		entries []*Ec2PrefixListEntry
+		wantErr bool
+	}{
+		{
+			name:   "valid",
+			family: "IPv4",
+			entries: []*Ec2PrefixListEntry{
+				{Cidr: "10.0.0.0/8", Description: "campus"},
+				{Cidr: "192.168.1.10/32"},
+			},
+		},
+		{
+			name:    "valid ipv6",
+			family:  "IPv6",
+			entries: []*Ec2PrefixListEntry{{Cidr: "2001:db8::/32"}},
+		},
+		{
+			name:   "empty",
+			family: "IPv4",
+		},
+		{
+			name:    "missing cidr",
+			family:  "IPv4",
+			entries: []*Ec2PrefixListEntry{{Description: "campus"}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid cidr",
+			family:  "IPv4",
+			entries: []*Ec2PrefixListEntry{{Cidr: "10.0.0.0/33"}},
+			wantErr: true,
+		},
+		{
+			name:    "host bits set",
+			family:  "IPv4",
+			entries: []*Ec2PrefixListEntry{{Cidr: "10.1.2.3/8"}},
+			wantErr: true,
+		},
+		{
+			name:    "wrong family",
+			family:  "IPv4",
+			entries: []*Ec2PrefixListEntry{{Cidr: "2001:db8::/32"}},
+			wantErr: true,
+		},
+		{
+			name:    "duplicate",
+			family:  "IPv4",
+			entries: []*Ec2PrefixListEntry{{Cidr: "10.0.0.0/8"}, {Cidr: "10.0.0.0/8", Description: "again"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validatePrefixListEntries(tt.family, tt.entries); (err != nil) != tt.wantErr {
+				t.Errorf("validatePrefixListEntries() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_diffPrefixListEntries(t *testing.T) {
+	current := []*ec2.PrefixListEntry{
+		{Cidr: aws.String("10.0.0.0/8"), Description: aws.String("campus")},
+		{Cidr: aws.String("172.16.0.0/12"), Description: aws.String("vpn")},
+		{Cidr: aws.String("192.168.0.0/16")},
+	}
+
+	tests := []struct {
+		name       string
+		desired    []*Ec2PrefixListEntry
+		wantAdd    []*Ec2PrefixListEntry
+		wantRemove []*Ec2PrefixListEntry
+	}{
+		{
+			name: "unchanged",
+			desired: []*Ec2PrefixListEntry{
+				{Cidr: "192.168.0.0/16"},
+				{Cidr: "10.0.0.0/8", Description: "campus"},
+				{Cidr: "172.16.0.0/12", Description: "vpn"},
+			},
+		},
+		{
+			name: "changed",
+			desired: []*Ec2PrefixListEntry{
+				{Cidr: "10.0.0.0/8", Description: "campus network"},
+				{Cidr: "172.16.0.0/12", Description: "vpn"},
+				{Cidr: "100.64.0.0/10", Description: "lab"},
+			},
+			wantAdd: []*Ec2PrefixListEntry{
+				{Cidr: "10.0.0.0/8", Description: "campus network"},
+				{Cidr: "100.64.0.0/10", Description: "lab"},
+			},
+			wantRemove: []*Ec2PrefixListEntry{
+				{Cidr: "192.168.0.0/16"},
+			},
+		},
+		{
+			name:    "empty",
+			desired: []*Ec2PrefixListEntry{},
+			wantRemove: []*Ec2PrefixListEntry{
+				{Cidr: "10.0.0.0/8"},
+				{Cidr: "172.16.0.0/12"},
+				{Cidr: "192.168.0.0/16"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			add, remove := diffPrefixListEntries(current, tt.desired)
+			if !reflect.DeepEqual(add, tt.wantAdd) {
+				t.Errorf("diffPrefixListEntries() add = %s, want %s", awsutil.Prettify(add), awsutil.Prettify(tt.wantAdd))
+			}
+			if !reflect.DeepEqual(remove, tt.wantRemove) {
+				t.Errorf("diffPrefixListEntries() remove = %s, want %s", awsutil.Prettify(remove), awsutil.Prettify(tt.wantRemove))
+			}
+		})
+	}
+}
+
+func Test_applyPrefixListEntries(t *testing.T) {
+	entries := []*Ec2PrefixListEntry{
+		{Cidr: "172.16.0.0/12", Description: "vpn"},
+		{Cidr: "10.0.0.0/8", Description: "campus"},
+	}
+
+	add := []*Ec2PrefixListEntry{
+		{Cidr: "10.0.0.0/8", Description: "campus network"},
+		{Cidr: "100.64.0.0/10", Description: "lab"},
+	}
+
+	remove := []*Ec2PrefixListEntry{
+		{Cidr: "172.16.0.0/12"},
+	}
+
+	want := []*Ec2PrefixListEntry{
+		{Cidr: "10.0.0.0/8", Description: "campus network"},
+		{Cidr: "100.64.0.0/10", Description: "lab"},
+	}
+
+	if got := applyPrefixListEntries(entries, add, remove); !reflect.DeepEqual(got, want) {
+		t.Errorf("applyPrefixListEntries() = %s, want %s", awsutil.Prettify(got), awsutil.Prettify(want))
+	}
+
+	if got := applyPrefixListEntries(nil, nil, nil); !reflect.DeepEqual(got, []*Ec2PrefixListEntry{}) {
+		t.Errorf("applyPrefixListEntries() = %s, want []", awsutil.Prettify(got))
+	}
+}
+
+func Test_newPrefixListReferences(t *testing.T) {
+	pl := &ec2.ManagedPrefixList{PrefixListId: aws.String("pl-123"), PrefixListName: aws.String("campus")}
+
+	permissions := []*ec2.IpPermission{
+		{
+			IpProtocol: aws.String("tcp"),
+			FromPort:   aws.Int64(22),
+			ToPort:     aws.Int64(22),
+			PrefixListIds: []*ec2.PrefixListId{
+				{PrefixListId: aws.String("pl-123"), Description: aws.String("ssh from campus")},
+				{PrefixListId: aws.String("pl-456")},
+			},
+			IpRanges: []*ec2.IpRange{
+				{CidrIp: aws.String("10.0.0.0/8")},
+			},
+		},
+	}
+
+	referencing := []*ec2.SecurityGroup{
+		{GroupId: aws.String("sg-789"), GroupName: aws.String("db"), IpPermissionsEgress: permissions},
+		{GroupId: aws.String("sg-123"), GroupName: aws.String("web"), IpPermissions: permissions},
+		{GroupId: aws.String("sg-123"), GroupName: aws.String("web"), IpPermissions: permissions},
+		{GroupId: aws.String("sg-abc"), GroupName: aws.String("other")},
+	}
+
+	rule := &Ec2SecurityGroupRule{
+		IpProtocol:   "tcp",
+		FromPort:     aws.Int64(22),
+		ToPort:       aws.Int64(22),
+		PrefixListId: "pl-123",
+		Description:  "ssh from campus",
+	}
+
+	want := &Ec2PrefixListReferencesResponse{
+		PrefixListId: "pl-123",
+		Name:         "campus",
+		SecurityGroups: []*Ec2SecurityGroupReference{
+			{
+				GroupId:   "sg-123",
+				GroupName: "web",
+				Ingress:   []*Ec2SecurityGroupRule{rule},
+				Egress:    []*Ec2SecurityGroupRule{},
+			},
+			{
+				GroupId:   "sg-789",
+				GroupName: "db",
+				Ingress:   []*Ec2SecurityGroupRule{},
+				Egress:    []*Ec2SecurityGroupRule{rule},
+			},
+		},
+	}
+
+	if got := newPrefixListReferences(pl, referencing); !reflect.DeepEqual(got, want) {
+		t.Errorf("newPrefixListReferences() = %s, want %s", awsutil.Prettify(got), awsutil.Prettify(want))
+	}
+}
+
+func Test_toEc2PrefixListResponse(t *testing.T) {
+	pl := &ec2.ManagedPrefixList{
+		PrefixListId:   aws.String("pl-123"),
+		PrefixListName: aws.String("campus"),
+		PrefixListArn:  aws.String("arn:aws:ec2:us-east-1:012345678901:prefix-list/pl-123"),
+		AddressFamily:  aws.String("IPv4"),
+		State:          aws.String("modify-complete"),
+		Version:        aws.Int64(3),
+		MaxEntries:     aws.Int64(10),
+		OwnerId:        aws.String("012345678901"),
+		Tags: []*ec2.Tag{
+			{Key: aws.String("spinup:org"), Value: aws.String("spinup")},
+		},
+	}
+
+	want := &Ec2PrefixListResponse{
+		PrefixListId:  "pl-123",
+		Name:          "campus",
+		Arn:           "arn:aws:ec2:us-east-1:012345678901:prefix-list/pl-123",
+		AddressFamily: "IPv4",
+		State:         "modify-complete",
+		Version:       3,
+		MaxEntries:    10,
+		OwnerId:       "012345678901",
+		Tags:          []map[string]string{{"spinup:org": "spinup"}},
+	}
+
+	if got := toEc2PrefixListResponse(pl, nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("toEc2PrefixListResponse() = %s, want %s", awsutil.Prettify(got), awsutil.Prettify(want))
+	}
+
+	want.Entries = []*Ec2PrefixListEntry{{Cidr: "10.0.0.0/8", Description: "campus"}}
+	entries := []*ec2.PrefixListEntry{{Cidr: aws.String("10.0.0.0/8"), Description: aws.String("campus")}}
+	if got := toEc2PrefixListResponse(pl, entries); !reflect.DeepEqual(got, want) {
+		t.Errorf("toEc2PrefixListResponse() = %s, want %s", awsutil.Prettify(got), awsutil.Prettify(want))
+	}
+}
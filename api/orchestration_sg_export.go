@@ -0,0 +1,344 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	log "github.com/sirupsen/logrus"
+)
+
+// sgExportVersion is the version of the security group export format
+const sgExportVersion = 1
+
+// exportSecurityGroups exports the given security groups and their rules
+func (o *ec2Orchestrator) exportSecurityGroups(ctx context.Context, ids ...string) (*Ec2SecurityGroupExport, error) {
+	if len(ids) == 0 {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	sgs, err := o.ec2Client.GetSecurityGroup(ctx, ids...)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sgs) != len(ids) {
+		return nil, apierror.New(apierror.ErrNotFound, "security group not found", nil)
+	}
+
+	return newSecurityGroupExport(sgs), nil
+}
+
+// importSecurityGroups creates a set of security groups in a VPC.  Each group is created with its rules that don't
+// reference the set, then references to groups in the set are translated to the new group ids and the complete rule
+// sets are applied.  If anything fails, the groups already created are removed.
+func (o *ec2Orchestrator) importSecurityGroups(ctx context.Context, req *Ec2SecurityGroupImportRequest) (*Ec2SecurityGroupImportResponse, error) {
+	if err := validateSecurityGroupImport(req); err != nil {
+		return nil, err
+	}
+
+	log.Debugf("got request to import security groups: %s", awsutil.Prettify(req))
+
+	keys := make(map[string]string, len(req.Groups))
+	for _, g := range req.Groups {
+		keys[g.Key] = ""
+	}
+
+	var err error
+	var rollBackTasks []rollbackFunc
+	defer func() {
+		if err != nil {
+			log.Errorf("recovering from error: %s, executing %d rollback tasks", err, len(rollBackTasks))
+			rollBack(&rollBackTasks)
+		}
+	}()
+
+	out := &Ec2SecurityGroupImportResponse{
+		VpcId:  req.VpcId,
+		Groups: []*Ec2SecurityGroupImported{},
+	}
+
+	created := []string{}
+	for _, g := range req.Groups {
+		var id string
+
+		// err is used to trigger rollback, don't shadow it here
+		id, err = o.createSecurityGroup(ctx, &Ec2SecurityGroupRequest{
+			Description: g.Description,
+			GroupName:   g.GroupName,
+			InitRules:   initRulesFromDefinition(g, keys),
+			Tags:        g.Tags,
+			VpcId:       req.VpcId,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		rollBackTasks = append(rollBackTasks, func(ctx context.Context) error {
+			log.Errorf("rollback: deleting imported security group: %s", id)
+			if err := o.ec2Client.DeleteSecurityGroup(ctx, id); err != nil {
+				return err
+			}
+
+			// the group was announced when it was created
+			o.notify(webhookSgDeleted, id)
+			return nil
+		})
+
+		keys[g.Key] = id
+		created = append(created, id)
+		out.Groups = append(out.Groups, &Ec2SecurityGroupImported{
+			Key:       g.Key,
+			GroupId:   id,
+			GroupName: g.GroupName,
+		})
+	}
+
+	// references between the new groups block deleting them, so they are revoked first
+	rollBackTasks = append(rollBackTasks, func(ctx context.Context) error {
+		log.Errorf("rollback: revoking references between imported security groups %v", created)
+		return o.revokeSecurityGroupReferences(ctx, created...)
+	})
+
+	for _, g := range req.Groups {
+		if g.Ingress == nil && g.Egress == nil {
+			continue
+		}
+
+		// err is used to trigger rollback, don't shadow it here
+		_, err = o.syncSecurityGroupRules(ctx, keys[g.Key], &Ec2SecurityGroupRulesSyncRequest{
+			Ingress: translateSecurityGroupRules(g.Ingress, keys),
+			Egress:  translateSecurityGroupRules(g.Egress, keys),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
+
+// revokeSecurityGroupReferences revokes the rules in the given security groups that reference each other
+func (o *ec2Orchestrator) revokeSecurityGroupReferences(ctx context.Context, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+
+	sgs, err := o.ec2Client.GetSecurityGroup(ctx, ids...)
+	if err != nil {
+		return err
+	}
+
+	for _, sg := range sgs {
+		for _, d := range []struct {
+			direction   string
+			permissions []*ec2.IpPermission
+		}{
+			{"inbound", sg.IpPermissions},
+			{"outbound", sg.IpPermissionsEgress},
+		} {
+			rules := []*Ec2SecurityGroupRule{}
+			for _, r := range flattenIpPermissions(d.permissions) {
+				if set[r.SgId] {
+					rules = append(rules, r)
+				}
+			}
+
+			if len(rules) == 0 {
+				continue
+			}
+
+			if err := o.ec2Client.RevokeSecurityGroup(ctx, d.direction, aws.StringValue(sg.GroupId), ipPermissionsFromRules(rules)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// newSecurityGroupExport builds the export of a set of security groups, the key of each group is its id
+func newSecurityGroupExport(sgs []*ec2.SecurityGroup) *Ec2SecurityGroupExport {
+	export := &Ec2SecurityGroupExport{
+		Version: sgExportVersion,
+		Groups:  []*Ec2SecurityGroupDefinition{},
+	}
+
+	accounts, vpcs := map[string]bool{}, map[string]bool{}
+	for _, sg := range sgs {
+		accounts[aws.StringValue(sg.OwnerId)] = true
+		vpcs[aws.StringValue(sg.VpcId)] = true
+
+		var tags []map[string]string
+		for _, t := range sg.Tags {
+			// tags with the aws: prefix are reserved and can't be created
+			if strings.HasPrefix(aws.StringValue(t.Key), "aws:") {
+				continue
+			}
+			tags = append(tags, map[string]string{aws.StringValue(t.Key): aws.StringValue(t.Value)})
+		}
+
+		export.Groups = append(export.Groups, &Ec2SecurityGroupDefinition{
+			Key:         aws.StringValue(sg.GroupId),
+			GroupName:   aws.StringValue(sg.GroupName),
+			Description: aws.StringValue(sg.Description),
+			Tags:        tags,
+			Ingress:     flattenIpPermissions(sg.IpPermissions),
+			Egress:      flattenIpPermissions(sg.IpPermissionsEgress),
+		})
+	}
+
+	if len(accounts) == 1 {
+		export.SourceAccount = aws.StringValue(sgs[0].OwnerId)
+	}
+
+	if len(vpcs) == 1 {
+		export.SourceVpcId = aws.StringValue(sgs[0].VpcId)
+	}
+
+	return export
+}
+
+// validateSecurityGroupImport validates the groups and rules in an import
+func validateSecurityGroupImport(req *Ec2SecurityGroupImportRequest) error {
+	if req == nil {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	if req.VpcId == "" {
+		return apierror.New(apierror.ErrBadRequest, "vpc_id is required", nil)
+	}
+
+	if len(req.Groups) == 0 {
+		return apierror.New(apierror.ErrBadRequest, "at least one group is required", nil)
+	}
+
+	keys, names := map[string]bool{}, map[string]bool{}
+	for _, g := range req.Groups {
+		if g == nil || g.Key == "" || g.GroupName == "" {
+			return apierror.New(apierror.ErrBadRequest, "key and group_name are required for each group", nil)
+		}
+
+		if keys[g.Key] {
+			return apierror.New(apierror.ErrBadRequest, "duplicate group key "+g.Key, nil)
+		}
+		keys[g.Key] = true
+
+		if names[g.GroupName] {
+			return apierror.New(apierror.ErrBadRequest, "duplicate group name "+g.GroupName, nil)
+		}
+		names[g.GroupName] = true
+
+		for _, rules := range [][]*Ec2SecurityGroupRule{g.Ingress, g.Egress} {
+			for _, r := range rules {
+				if _, err := normalizeSecurityGroupRule(r); err != nil {
+					msg := fmt.Sprintf("invalid rule in group %s", g.Key)
+					if aerr, ok := err.(apierror.Error); ok {
+						msg = fmt.Sprintf("%s: %s", msg, aerr.Message)
+					}
+					return apierror.New(apierror.ErrBadRequest, msg, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// initRulesFromDefinition returns the inbound rules of a group definition that can be created with the group,
+// the rules referencing groups in the set are applied once all of the groups exist
+func initRulesFromDefinition(g *Ec2SecurityGroupDefinition, keys map[string]string) []*Ec2SecurityGroupRuleRequest {
+	optional := func(s string) *string {
+		if s == "" {
+			return nil
+		}
+		return aws.String(s)
+	}
+
+	rules := []*Ec2SecurityGroupRuleRequest{}
+	for _, rule := range g.Ingress {
+		if _, ok := keys[rule.SgId]; ok && rule.SgId != "" {
+			continue
+		}
+
+		r, err := normalizeSecurityGroupRule(rule)
+		if err != nil {
+			continue
+		}
+
+		rules = append(rules, &Ec2SecurityGroupRuleRequest{
+			RuleType:     aws.String("inbound"),
+			Action:       aws.String("add"),
+			CidrIp:       optional(r.CidrIp),
+			CidrIpv6:     optional(r.CidrIpv6),
+			SgId:         optional(r.SgId),
+			UserId:       optional(r.UserId),
+			PrefixListId: optional(r.PrefixListId),
+			IpProtocol:   aws.String(r.IpProtocol),
+			FromPort:     r.FromPort,
+			ToPort:       r.ToPort,
+			Description:  optional(r.Description),
+		})
+	}
+
+	return rules
+}
+
+// translateSecurityGroupRules returns a copy of the rules with references to groups in the set replaced by the
+// new group ids.  A nil list stays nil so unmanaged rules are left alone.
+func translateSecurityGroupRules(rules []*Ec2SecurityGroupRule, keys map[string]string) []*Ec2SecurityGroupRule {
+	if rules == nil {
+		return nil
+	}
+
+	translated := make([]*Ec2SecurityGroupRule, 0, len(rules))
+	for _, rule := range rules {
+		r := *rule
+		if id, ok := keys[r.SgId]; ok && r.SgId != "" {
+			// the new group is in the target account
+			r.SgId = id
+			r.UserId = ""
+		}
+		translated = append(translated, &r)
+	}
+
+	return translated
+}
+
+// enforceImport checks the rules of the groups in an import in strict mode, references to groups in the set are
+// treated as references in the target account
+func (l *sgLinter) enforceImport(account string, req *Ec2SecurityGroupImportRequest) error {
+	if req == nil {
+		return nil
+	}
+
+	keys := make(map[string]string, len(req.Groups))
+	for _, g := range req.Groups {
+		if g != nil {
+			keys[g.Key] = g.Key
+		}
+	}
+
+	for _, g := range req.Groups {
+		if g == nil {
+			continue
+		}
+
+		incoming := toEc2SecurityGroupIpPermissions(ipPermissionsFromRules(translateSecurityGroupRules(g.Ingress, keys)))
+		outgoing := toEc2SecurityGroupIpPermissions(ipPermissionsFromRules(translateSecurityGroupRules(g.Egress, keys)))
+		if err := l.enforce(account, g.Key, incoming, outgoing); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
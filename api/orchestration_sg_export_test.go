@@ -0,0 +1,296 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/YaleSpinup/ec2-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+var testSecurityGroupExport = &Ec2SecurityGroupExport{
+	Version:       sgExportVersion,
+	SourceAccount: "012345678901",
+	SourceVpcId:   "vpc-123",
+	Groups: []*Ec2SecurityGroupDefinition{
+		{
+			Key:         "sg-web",
+			GroupName:   "web",
+			Description: "web servers",
+			Tags:        []map[string]string{{"Name": "web"}},
+			Ingress: []*Ec2SecurityGroupRule{
+				{IpProtocol: "tcp", FromPort: aws.Int64(443), ToPort: aws.Int64(443), CidrIp: "10.0.0.0/8"},
+				{IpProtocol: "-1", SgId: "sg-web", UserId: "012345678901"},
+			},
+			Egress: []*Ec2SecurityGroupRule{
+				{IpProtocol: "tcp", FromPort: aws.Int64(5432), ToPort: aws.Int64(5432), SgId: "sg-db", UserId: "012345678901", Description: "database"},
+			},
+		},
+		{
+			Key:         "sg-db",
+			GroupName:   "db",
+			Description: "database servers",
+			Ingress: []*Ec2SecurityGroupRule{
+				{IpProtocol: "tcp", FromPort: aws.Int64(5432), ToPort: aws.Int64(5432), SgId: "sg-web", UserId: "012345678901"},
+				{IpProtocol: "tcp", FromPort: aws.Int64(5432), ToPort: aws.Int64(5432), SgId: "sg-backup", UserId: "210987654321"},
+			},
+			Egress: []*Ec2SecurityGroupRule{},
+		},
+	},
+}
+
+func Test_newSecurityGroupExport(t *testing.T) {
+	sgs := []*ec2.SecurityGroup{
+		{
+			GroupId:     aws.String("sg-web"),
+			GroupName:   aws.String("web"),
+			Description: aws.String("web servers"),
+			OwnerId:     aws.String("012345678901"),
+			VpcId:       aws.String("vpc-123"),
+			Tags: []*ec2.Tag{
+				{Key: aws.String("Name"), Value: aws.String("web")},
+				{Key: aws.String("aws:cloudformation:stack-name"), Value: aws.String("stack")},
+			},
+			IpPermissions: []*ec2.IpPermission{
+				{
+					IpProtocol: aws.String("tcp"),
+					FromPort:   aws.Int64(443),
+					ToPort:     aws.Int64(443),
+					IpRanges:   []*ec2.IpRange{{CidrIp: aws.String("10.0.0.0/8")}},
+				},
+				{
+					IpProtocol:       aws.String("-1"),
+					UserIdGroupPairs: []*ec2.UserIdGroupPair{{GroupId: aws.String("sg-web"), UserId: aws.String("012345678901")}},
+				},
+			},
+			IpPermissionsEgress: []*ec2.IpPermission{
+				{
+					IpProtocol: aws.String("tcp"),
+					FromPort:   aws.Int64(5432),
+					ToPort:     aws.Int64(5432),
+					UserIdGroupPairs: []*ec2.UserIdGroupPair{
+						{GroupId: aws.String("sg-db"), UserId: aws.String("012345678901"), Description: aws.String("database")},
+					},
+				},
+			},
+		},
+		{
+			GroupId:     aws.String("sg-db"),
+			GroupName:   aws.String("db"),
+			Description: aws.String("database servers"),
+			OwnerId:     aws.String("012345678901"),
+			VpcId:       aws.String("vpc-123"),
+			IpPermissions: []*ec2.IpPermission{
+				{
+					IpProtocol: aws.String("tcp"),
+					FromPort:   aws.Int64(5432),
+					ToPort:     aws.Int64(5432),
+					UserIdGroupPairs: []*ec2.UserIdGroupPair{
+						{GroupId: aws.String("sg-web"), UserId: aws.String("012345678901")},
+						{GroupId: aws.String("sg-backup"), UserId: aws.String("210987654321")},
+					},
+				},
+			},
+		},
+	}
+
+	if got := newSecurityGroupExport(sgs); !reflect.DeepEqual(got, testSecurityGroupExport) {
+		t.Errorf("newSecurityGroupExport() = %s, want %s", awsutil.Prettify(got), awsutil.Prettify(testSecurityGroupExport))
+	}
+
+	sgs[1].VpcId = aws.String("vpc-456")
+	if got := newSecurityGroupExport(sgs); got.SourceVpcId != "" {
+		t.Errorf("expected empty source vpc for groups in multiple vpcs, got %s", got.SourceVpcId)
+	}
+}
+
+func Test_validateSecurityGroupImport(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     *Ec2SecurityGroupImportRequest
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			req:  &Ec2SecurityGroupImportRequest{VpcId: "vpc-456", Groups: testSecurityGroupExport.Groups},
+		},
+		{
+			name:    "nil",
+			wantErr: true,
+		},
+		{
+			name:    "missing vpc",
+			req:     &Ec2SecurityGroupImportRequest{Groups: testSecurityGroupExport.Groups},
+			wantErr: true,
+		},
+		{
+			name:    "no groups",
+			req:     &Ec2SecurityGroupImportRequest{VpcId: "vpc-456"},
+			wantErr: true,
+		},
+		{
+			name: "missing key",
+			req: &Ec2SecurityGroupImportRequest{VpcId: "vpc-456", Groups: []*Ec2SecurityGroupDefinition{
+				{GroupName: "web"},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "duplicate key",
+			req: &Ec2SecurityGroupImportRequest{VpcId: "vpc-456", Groups: []*Ec2SecurityGroupDefinition{
+				{Key: "web", GroupName: "web"},
+				{Key: "web", GroupName: "web2"},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "duplicate name",
+			req: &Ec2SecurityGroupImportRequest{VpcId: "vpc-456", Groups: []*Ec2SecurityGroupDefinition{
+				{Key: "web", GroupName: "web"},
+				{Key: "web2", GroupName: "web"},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "invalid rule",
+			req: &Ec2SecurityGroupImportRequest{VpcId: "vpc-456", Groups: []*Ec2SecurityGroupDefinition{
+				{Key: "web", GroupName: "web", Ingress: []*Ec2SecurityGroupRule{{IpProtocol: "tcp", CidrIp: "10.0.0.0/8"}}},
+			}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateSecurityGroupImport(tt.req); (err != nil) != tt.wantErr {
+				t.Errorf("validateSecurityGroupImport() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_initRulesFromDefinition(t *testing.T) {
+	keys := map[string]string{"sg-web": "", "sg-db": ""}
+
+	want := []*Ec2SecurityGroupRuleRequest{
+		{
+			RuleType:   aws.String("inbound"),
+			Action:     aws.String("add"),
+			SgId:       aws.String("sg-backup"),
+			UserId:     aws.String("210987654321"),
+			IpProtocol: aws.String("tcp"),
+			FromPort:   aws.Int64(5432),
+			ToPort:     aws.Int64(5432),
+		},
+	}
+
+	if got := initRulesFromDefinition(testSecurityGroupExport.Groups[1], keys); !reflect.DeepEqual(got, want) {
+		t.Errorf("initRulesFromDefinition() = %s, want %s", awsutil.Prettify(got), awsutil.Prettify(want))
+	}
+
+	want = []*Ec2SecurityGroupRuleRequest{
+		{
+			RuleType:   aws.String("inbound"),
+			Action:     aws.String("add"),
+			CidrIp:     aws.String("10.0.0.0/8"),
+			IpProtocol: aws.String("tcp"),
+			FromPort:   aws.Int64(443),
+			ToPort:     aws.Int64(443),
+		},
+	}
+
+	if got := initRulesFromDefinition(testSecurityGroupExport.Groups[0], keys); !reflect.DeepEqual(got, want) {
+		t.Errorf("initRulesFromDefinition() = %s, want %s", awsutil.Prettify(got), awsutil.Prettify(want))
+	}
+}
+
+func Test_translateSecurityGroupRules(t *testing.T) {
+	keys := map[string]string{"sg-web": "sg-new-web", "sg-db": "sg-new-db"}
+
+	if got := translateSecurityGroupRules(nil, keys); got != nil {
+		t.Errorf("expected nil rules to stay nil, got %v", got)
+	}
+
+	want := []*Ec2SecurityGroupRule{
+		{IpProtocol: "tcp", FromPort: aws.Int64(5432), ToPort: aws.Int64(5432), SgId: "sg-new-web"},
+		{IpProtocol: "tcp", FromPort: aws.Int64(5432), ToPort: aws.Int64(5432), SgId: "sg-backup", UserId: "210987654321"},
+	}
+
+	if got := translateSecurityGroupRules(testSecurityGroupExport.Groups[1].Ingress, keys); !reflect.DeepEqual(got, want) {
+		t.Errorf("translateSecurityGroupRules() = %s, want %s", awsutil.Prettify(got), awsutil.Prettify(want))
+	}
+
+	// the original rules aren't modified
+	if testSecurityGroupExport.Groups[1].Ingress[0].SgId != "sg-web" {
+		t.Error("expected original rules to be unchanged")
+	}
+}
+
+func Test_sgLinter_enforceImport(t *testing.T) {
+	strict, _ := newSgLinter(&common.SecurityGroupLint{Strict: true, StrictSeverity: "medium"})
+
+	// the reference to sg-backup is in another account
+	req := &Ec2SecurityGroupImportRequest{VpcId: "vpc-456", Groups: testSecurityGroupExport.Groups}
+	if err := strict.enforceImport("012345678901", req); err == nil {
+		t.Error("expected error for cross account reference, got nil")
+	}
+
+	// references within the set are translated to the target account
+	allowed, _ := newSgLinter(&common.SecurityGroupLint{Strict: true, StrictSeverity: "medium", AllowedAccounts: []string{"210987654321"}})
+	if err := allowed.enforceImport("333333333333", req); err != nil {
+		t.Errorf("expected no error for references within the set, got %s", err)
+	}
+
+	// references outside of the set keep their account
+	req = &Ec2SecurityGroupImportRequest{VpcId: "vpc-456", Groups: testSecurityGroupExport.Groups[:1]}
+	if err := allowed.enforceImport("333333333333", req); err == nil {
+		t.Error("expected error for reference outside of the set, got nil")
+	}
+}
+
+func Test_securityGroupExportYAML(t *testing.T) {
+	y, err := marshalYAML(testSecurityGroupExport)
+	if err != nil {
+		t.Fatalf("unexpected error marshalling yaml: %s", err)
+	}
+
+	got := &Ec2SecurityGroupExport{}
+	if err := unmarshalYAML(y, got); err != nil {
+		t.Fatalf("unexpected error unmarshalling yaml: %s", err)
+	}
+
+	if !reflect.DeepEqual(got, testSecurityGroupExport) {
+		t.Errorf("yaml round trip = %s, want %s", awsutil.Prettify(got), awsutil.Prettify(testSecurityGroupExport))
+	}
+}
+
+func Test_ipPermissionsFromRequest(t *testing.T) {
+	got := ipPermissionsFromRequest(&Ec2SecurityGroupRuleRequest{
+		IpProtocol: aws.String("tcp"),
+		FromPort:   aws.Int64(22),
+		ToPort:     aws.Int64(22),
+		CidrIpv6:   aws.String("2001:db8::/32"),
+		SgId:       aws.String("sg-123"),
+		UserId:     aws.String("012345678901"),
+	})
+
+	want := []*ec2.IpPermission{
+		{
+			IpProtocol: aws.String("tcp"),
+			FromPort:   aws.Int64(22),
+			ToPort:     aws.Int64(22),
+			Ipv6Ranges: []*ec2.Ipv6Range{{CidrIpv6: aws.String("2001:db8::/32")}},
+		},
+		{
+			IpProtocol:       aws.String("tcp"),
+			FromPort:         aws.Int64(22),
+			ToPort:           aws.Int64(22),
+			UserIdGroupPairs: []*ec2.UserIdGroupPair{{GroupId: aws.String("sg-123"), UserId: aws.String("012345678901")}},
+		},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ipPermissionsFromRequest() = %s, want %s", awsutil.Prettify(got), awsutil.Prettify(want))
+	}
+}
@@ -0,0 +1,429 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/ec2-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	sgFindingSeverityLow      = "low"
+	sgFindingSeverityMedium   = "medium"
+	sgFindingSeverityHigh     = "high"
+	sgFindingSeverityCritical = "critical"
+
+	sgCheckPublicAdminPort       = "public_admin_port"
+	sgCheckPublicDatabasePort    = "public_database_port"
+	sgCheckPublicAllTraffic      = "public_all_traffic"
+	sgCheckAllProtocols          = "all_protocols"
+	sgCheckWidePortRange         = "wide_port_range"
+	sgCheckCrossAccountReference = "cross_account_reference"
+
+	defaultSgMaxPortRange = 1000
+)
+
+// sgFindingSeverities orders the finding severities from least to most severe
+var sgFindingSeverities = map[string]int{
+	sgFindingSeverityLow:      1,
+	sgFindingSeverityMedium:   2,
+	sgFindingSeverityHigh:     3,
+	sgFindingSeverityCritical: 4,
+}
+
+var sgChecks = []string{
+	sgCheckPublicAdminPort,
+	sgCheckPublicDatabasePort,
+	sgCheckPublicAllTraffic,
+	sgCheckAllProtocols,
+	sgCheckWidePortRange,
+	sgCheckCrossAccountReference,
+}
+
+// sgAdminPorts are remote administration ports that should never be open to the internet
+var sgAdminPorts = map[int64]string{
+	22:   "SSH",
+	3389: "RDP",
+}
+
+// sgDatabasePorts are database and cache ports that should never be open to the internet
+var sgDatabasePorts = map[int64]string{
+	1433:  "SQL Server",
+	1521:  "Oracle",
+	3306:  "MySQL",
+	5432:  "PostgreSQL",
+	5439:  "Redshift",
+	6379:  "Redis",
+	9200:  "Elasticsearch",
+	11211: "Memcached",
+	27017: "MongoDB",
+}
+
+// sgLinter reports risky patterns in security group rules
+type sgLinter struct {
+	strict             bool
+	strictSeverity     string
+	maxPortRange       int64
+	allowedPublicPorts map[int64]bool
+	allowedAccounts    map[string]bool
+	allowedGroups      map[string]bool
+	disabledChecks     map[string]bool
+}
+
+// newSgLinter creates a security group linter from the configuration, a nil configuration uses the defaults
+func newSgLinter(config *common.SecurityGroupLint) (*sgLinter, error) {
+	l := &sgLinter{
+		strictSeverity:     sgFindingSeverityHigh,
+		maxPortRange:       defaultSgMaxPortRange,
+		allowedPublicPorts: map[int64]bool{},
+		allowedAccounts:    map[string]bool{},
+		allowedGroups:      map[string]bool{},
+		disabledChecks:     map[string]bool{},
+	}
+
+	if config == nil {
+		return l, nil
+	}
+
+	l.strict = config.Strict
+
+	if config.StrictSeverity != "" {
+		severity := strings.ToLower(config.StrictSeverity)
+		if _, ok := sgFindingSeverities[severity]; !ok {
+			return nil, fmt.Errorf("invalid security group lint strict severity '%s'", config.StrictSeverity)
+		}
+		l.strictSeverity = severity
+	}
+
+	if config.MaxPortRange < 0 {
+		return nil, fmt.Errorf("invalid security group lint max port range %d", config.MaxPortRange)
+	} else if config.MaxPortRange > 0 {
+		l.maxPortRange = config.MaxPortRange
+	}
+
+	for _, p := range config.AllowedPublicPorts {
+		l.allowedPublicPorts[p] = true
+	}
+
+	for _, a := range config.AllowedAccounts {
+		l.allowedAccounts[a] = true
+	}
+
+	for _, g := range config.AllowedGroups {
+		l.allowedGroups[g] = true
+	}
+
+	for _, c := range config.DisabledChecks {
+		if !isSgCheck(c) {
+			return nil, fmt.Errorf("invalid security group lint check '%s'", c)
+		}
+		l.disabledChecks[c] = true
+	}
+
+	return l, nil
+}
+
+// securityGroupFindings lints the rules of a single security group
+func (o *ec2Orchestrator) securityGroupFindings(ctx context.Context, id, minSeverity string) (*Ec2SecurityGroupFindingsResponse, error) {
+	if id == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	sgs, err := o.ec2Client.GetSecurityGroup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sgs) != 1 {
+		return nil, apierror.New(apierror.ErrNotFound, "security group not found", nil)
+	}
+
+	return o.lintSecurityGroups(sgs, minSeverity)
+}
+
+// listSecurityGroupFindings lints the rules of all of the security groups in the account, optionally limited to an org
+func (o *ec2Orchestrator) listSecurityGroupFindings(ctx context.Context, org, minSeverity string) (*Ec2SecurityGroupFindingsResponse, error) {
+	sgs, err := o.ec2Client.ListSecurityGroupDetails(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.lintSecurityGroups(sgs, minSeverity)
+}
+
+func (o *ec2Orchestrator) lintSecurityGroups(sgs []*ec2.SecurityGroup, minSeverity string) (*Ec2SecurityGroupFindingsResponse, error) {
+	if minSeverity != "" {
+		if _, ok := sgFindingSeverities[minSeverity]; !ok {
+			msg := fmt.Sprintf("invalid severity '%s', should be one of [low|medium|high|critical]", minSeverity)
+			return nil, apierror.New(apierror.ErrBadRequest, msg, nil)
+		}
+	}
+
+	findings := []*Ec2SecurityGroupFinding{}
+	for _, sg := range sgs {
+		findings = append(findings, o.server.sgLinter.lint(
+			aws.StringValue(sg.OwnerId),
+			aws.StringValue(sg.GroupId),
+			aws.StringValue(sg.GroupName),
+			toEc2SecurityGroupIpPermissions(sg.IpPermissions),
+			toEc2SecurityGroupIpPermissions(sg.IpPermissionsEgress),
+		)...)
+	}
+
+	findings = filterSgFindings(findings, minSeverity)
+
+	log.Debugf("found %d findings in %d security groups", len(findings), len(sgs))
+
+	return &Ec2SecurityGroupFindingsResponse{
+		Groups:   len(sgs),
+		Summary:  summarizeSgFindings(findings),
+		Findings: findings,
+	}, nil
+}
+
+// lint returns the findings for the incoming and outgoing rules of a security group owned by the given account
+func (l *sgLinter) lint(account, groupId, groupName string, incoming, outgoing []*Ec2SecurityGroupIpPermission) []*Ec2SecurityGroupFinding {
+	findings := []*Ec2SecurityGroupFinding{}
+	if l == nil || l.allowedGroups[groupId] {
+		return findings
+	}
+
+	for _, p := range incoming {
+		findings = append(findings, l.lintPermission(account, groupId, "inbound", p)...)
+	}
+
+	for _, p := range outgoing {
+		findings = append(findings, l.lintPermission(account, groupId, "outbound", p)...)
+	}
+
+	for _, f := range findings {
+		f.GroupId = groupId
+		f.GroupName = groupName
+	}
+
+	return findings
+}
+
+// enforce returns a bad request error if strict mode is enabled and any of the rules have findings
+// at or above the strict severity
+func (l *sgLinter) enforce(account, groupId string, incoming, outgoing []*Ec2SecurityGroupIpPermission) error {
+	if l == nil || !l.strict {
+		return nil
+	}
+
+	findings := filterSgFindings(l.lint(account, groupId, "", incoming, outgoing), l.strictSeverity)
+	if len(findings) == 0 {
+		return nil
+	}
+
+	messages := make([]string, 0, len(findings))
+	for _, f := range findings {
+		messages = append(messages, fmt.Sprintf("%s (%s)", f.Message, f.Severity))
+	}
+
+	msg := fmt.Sprintf("security group rules rejected: %s", strings.Join(messages, "; "))
+	return apierror.New(apierror.ErrBadRequest, msg, nil)
+}
+
+func (l *sgLinter) lintPermission(account, groupId, direction string, p *Ec2SecurityGroupIpPermission) []*Ec2SecurityGroupFinding {
+	if p == nil {
+		return nil
+	}
+
+	protocol := strings.ToLower(p.IpProtocol)
+	if name, ok := ipProtocolNames[protocol]; ok {
+		protocol = name
+	}
+
+	hasPorts := protocol == "tcp" || protocol == "udp"
+
+	newFinding := func(check, severity, source, msg string) *Ec2SecurityGroupFinding {
+		f := &Ec2SecurityGroupFinding{
+			Check:      check,
+			Severity:   severity,
+			Direction:  direction,
+			IpProtocol: protocol,
+			Source:     source,
+			Message:    msg,
+		}
+
+		if hasPorts {
+			f.FromPort = aws.Int64(p.FromPort)
+			f.ToPort = aws.Int64(p.ToPort)
+		}
+
+		return f
+	}
+
+	var public, private []string
+	for _, r := range p.IpRanges {
+		if r.CidrIp == "0.0.0.0/0" {
+			public = append(public, r.CidrIp)
+		} else {
+			private = append(private, r.CidrIp)
+		}
+	}
+
+	for _, r := range p.Ipv6Ranges {
+		if r.CidrIpv6 == "::/0" {
+			public = append(public, r.CidrIpv6)
+		} else {
+			private = append(private, r.CidrIpv6)
+		}
+	}
+
+	for _, r := range p.PrefixListIds {
+		private = append(private, r.PrefixListId)
+	}
+
+	findings := []*Ec2SecurityGroupFinding{}
+	add := func(f *Ec2SecurityGroupFinding) {
+		if !l.disabledChecks[f.Check] {
+			findings = append(findings, f)
+		}
+	}
+
+	for _, pair := range p.UserIdGroupPairs {
+		source := pair.GroupId
+		if pair.UserId != "" && pair.UserId != account {
+			source = pair.UserId + "/" + pair.GroupId
+
+			if !l.allowedAccounts[pair.UserId] {
+				msg := fmt.Sprintf("%s rule references security group %s in account %s", direction, pair.GroupId, pair.UserId)
+				add(newFinding(sgCheckCrossAccountReference, sgFindingSeverityMedium, source, msg))
+			}
+		}
+
+		// referencing the group itself is the common way to allow traffic between its members
+		if pair.GroupId != groupId {
+			private = append(private, source)
+		}
+	}
+
+	// traffic to the internet is expected, only inbound rules are checked for exposure
+	if direction != "inbound" {
+		return findings
+	}
+
+	wide := hasPorts && p.ToPort-p.FromPort+1 > l.maxPortRange
+	portRange := fmt.Sprintf("%s ports %d-%d", protocol, p.FromPort, p.ToPort)
+
+	for _, source := range public {
+		if protocol == "-1" {
+			add(newFinding(sgCheckPublicAllTraffic, sgFindingSeverityCritical, source, fmt.Sprintf("all traffic is open to %s", source)))
+			continue
+		}
+
+		if !hasPorts {
+			continue
+		}
+
+		if exposed := l.exposedPorts(sgAdminPorts, p.FromPort, p.ToPort); exposed != "" {
+			add(newFinding(sgCheckPublicAdminPort, sgFindingSeverityCritical, source, fmt.Sprintf("%s open to %s", exposed, source)))
+		}
+
+		if exposed := l.exposedPorts(sgDatabasePorts, p.FromPort, p.ToPort); exposed != "" {
+			add(newFinding(sgCheckPublicDatabasePort, sgFindingSeverityHigh, source, fmt.Sprintf("%s open to %s", exposed, source)))
+		}
+
+		if wide {
+			add(newFinding(sgCheckWidePortRange, sgFindingSeverityHigh, source, fmt.Sprintf("%s open to %s", portRange, source)))
+		}
+	}
+
+	for _, source := range private {
+		if protocol == "-1" {
+			add(newFinding(sgCheckAllProtocols, sgFindingSeverityLow, source, fmt.Sprintf("all traffic is allowed from %s", source)))
+			continue
+		}
+
+		if wide {
+			add(newFinding(sgCheckWidePortRange, sgFindingSeverityMedium, source, fmt.Sprintf("%s allowed from %s", portRange, source)))
+		}
+	}
+
+	return findings
+}
+
+// exposedPorts returns a description of the given ports in the range which aren't allowed to be public
+func (l *sgLinter) exposedPorts(ports map[int64]string, from, to int64) string {
+	var matched []int64
+	for port := range ports {
+		if port >= from && port <= to && !l.allowedPublicPorts[port] {
+			matched = append(matched, port)
+		}
+	}
+
+	if len(matched) == 0 {
+		return ""
+	}
+
+	sort.Slice(matched, func(i, j int) bool { return matched[i] < matched[j] })
+
+	names := make([]string, 0, len(matched))
+	for _, port := range matched {
+		names = append(names, fmt.Sprintf("%s (%d)", ports[port], port))
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// sgRequestPermissions converts security group rule requests to incoming and outgoing permissions for linting
+func sgRequestPermissions(rules ...*Ec2SecurityGroupRuleRequest) ([]*Ec2SecurityGroupIpPermission, []*Ec2SecurityGroupIpPermission) {
+	var incoming, outgoing []*ec2.IpPermission
+	for _, r := range rules {
+		switch aws.StringValue(r.RuleType) {
+		case "inbound":
+			incoming = append(incoming, ipPermissionsFromRequest(r)...)
+		case "outbound":
+			outgoing = append(outgoing, ipPermissionsFromRequest(r)...)
+		}
+	}
+
+	return toEc2SecurityGroupIpPermissions(incoming), toEc2SecurityGroupIpPermissions(outgoing)
+}
+
+// filterSgFindings returns the findings at or above the given severity
+func filterSgFindings(findings []*Ec2SecurityGroupFinding, minSeverity string) []*Ec2SecurityGroupFinding {
+	if minSeverity == "" {
+		return findings
+	}
+
+	filtered := []*Ec2SecurityGroupFinding{}
+	for _, f := range findings {
+		if sgFindingSeverities[f.Severity] >= sgFindingSeverities[minSeverity] {
+			filtered = append(filtered, f)
+		}
+	}
+
+	return filtered
+}
+
+// summarizeSgFindings counts the findings by severity
+func summarizeSgFindings(findings []*Ec2SecurityGroupFinding) map[string]int {
+	summary := make(map[string]int, len(sgFindingSeverities))
+	for s := range sgFindingSeverities {
+		summary[s] = 0
+	}
+
+	for _, f := range findings {
+		summary[f.Severity]++
+	}
+
+	return summary
+}
+
+func isSgCheck(check string) bool {
+	for _, c := range sgChecks {
+		if c == check {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,415 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/YaleSpinup/ec2-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+)
+
+func Test_newSgLinter(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *common.SecurityGroupLint
+		want    *sgLinter
+		wantErr bool
+	}{
+		{
+			name:   "nil config",
+			config: nil,
+			want: &sgLinter{
+				strictSeverity:     sgFindingSeverityHigh,
+				maxPortRange:       defaultSgMaxPortRange,
+				allowedPublicPorts: map[int64]bool{},
+				allowedAccounts:    map[string]bool{},
+				allowedGroups:      map[string]bool{},
+				disabledChecks:     map[string]bool{},
+			},
+		},
+		{
+			name: "full config",
+			config: &common.SecurityGroupLint{
+				Strict:             true,
+				StrictSeverity:     "Critical",
+				MaxPortRange:       100,
+				AllowedPublicPorts: []int64{22},
+				AllowedAccounts:    []string{"012345678901"},
+				AllowedGroups:      []string{"sg-123"},
+				DisabledChecks:     []string{sgCheckAllProtocols},
+			},
+			want: &sgLinter{
+				strict:             true,
+				strictSeverity:     sgFindingSeverityCritical,
+				maxPortRange:       100,
+				allowedPublicPorts: map[int64]bool{22: true},
+				allowedAccounts:    map[string]bool{"012345678901": true},
+				allowedGroups:      map[string]bool{"sg-123": true},
+				disabledChecks:     map[string]bool{sgCheckAllProtocols: true},
+			},
+		},
+		{
+			name:    "invalid severity",
+			config:  &common.SecurityGroupLint{StrictSeverity: "scary"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid max port range",
+			config:  &common.SecurityGroupLint{MaxPortRange: -1},
+			wantErr: true,
+		},
+		{
+			name:    "invalid check",
+			config:  &common.SecurityGroupLint{DisabledChecks: []string{"open_everything"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newSgLinter(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("newSgLinter() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("newSgLinter() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_sgLinter_lint(t *testing.T) {
+	defaultLinter, _ := newSgLinter(nil)
+	allowLinter, _ := newSgLinter(&common.SecurityGroupLint{
+		AllowedPublicPorts: []int64{22},
+		AllowedAccounts:    []string{"210987654321"},
+		DisabledChecks:     []string{sgCheckWidePortRange},
+	})
+	exemptLinter, _ := newSgLinter(&common.SecurityGroupLint{AllowedGroups: []string{"sg-123"}})
+
+	tcp := func(from, to int64, cidrs ...string) *Ec2SecurityGroupIpPermission {
+		p := &Ec2SecurityGroupIpPermission{IpProtocol: "tcp", FromPort: from, ToPort: to}
+		for _, c := range cidrs {
+			p.IpRanges = append(p.IpRanges, &Ec2SecurityGroupIpRange{CidrIp: c})
+		}
+		return p
+	}
+
+	tests := []struct {
+		name     string
+		linter   *sgLinter
+		incoming []*Ec2SecurityGroupIpPermission
+		outgoing []*Ec2SecurityGroupIpPermission
+		want     []*Ec2SecurityGroupFinding
+	}{
+		{
+			name:     "no rules",
+			linter:   defaultLinter,
+			incoming: []*Ec2SecurityGroupIpPermission{},
+			want:     []*Ec2SecurityGroupFinding{},
+		},
+		{
+			name:     "private rules",
+			linter:   defaultLinter,
+			incoming: []*Ec2SecurityGroupIpPermission{tcp(22, 22, "10.0.0.0/8"), tcp(443, 443, "0.0.0.0/0")},
+			want:     []*Ec2SecurityGroupFinding{},
+		},
+		{
+			name:     "public ssh",
+			linter:   defaultLinter,
+			incoming: []*Ec2SecurityGroupIpPermission{tcp(22, 22, "0.0.0.0/0", "10.0.0.0/8")},
+			want: []*Ec2SecurityGroupFinding{
+				{
+					GroupId:    "sg-123",
+					GroupName:  "test",
+					Check:      sgCheckPublicAdminPort,
+					Severity:   sgFindingSeverityCritical,
+					Direction:  "inbound",
+					IpProtocol: "tcp",
+					FromPort:   aws.Int64(22),
+					ToPort:     aws.Int64(22),
+					Source:     "0.0.0.0/0",
+					Message:    "SSH (22) open to 0.0.0.0/0",
+				},
+			},
+		},
+		{
+			name:   "public ipv6 database",
+			linter: defaultLinter,
+			incoming: []*Ec2SecurityGroupIpPermission{
+				{
+					IpProtocol: "6",
+					FromPort:   5432,
+					ToPort:     5432,
+					Ipv6Ranges: []*Ec2SecurityGroupIpv6Range{{CidrIpv6: "::/0"}},
+				},
+			},
+			want: []*Ec2SecurityGroupFinding{
+				{
+					GroupId:    "sg-123",
+					GroupName:  "test",
+					Check:      sgCheckPublicDatabasePort,
+					Severity:   sgFindingSeverityHigh,
+					Direction:  "inbound",
+					IpProtocol: "tcp",
+					FromPort:   aws.Int64(5432),
+					ToPort:     aws.Int64(5432),
+					Source:     "::/0",
+					Message:    "PostgreSQL (5432) open to ::/0",
+				},
+			},
+		},
+		{
+			name:     "public wide range",
+			linter:   defaultLinter,
+			incoming: []*Ec2SecurityGroupIpPermission{tcp(1, 3400, "0.0.0.0/0")},
+			want: []*Ec2SecurityGroupFinding{
+				{
+					GroupId:    "sg-123",
+					GroupName:  "test",
+					Check:      sgCheckPublicAdminPort,
+					Severity:   sgFindingSeverityCritical,
+					Direction:  "inbound",
+					IpProtocol: "tcp",
+					FromPort:   aws.Int64(1),
+					ToPort:     aws.Int64(3400),
+					Source:     "0.0.0.0/0",
+					Message:    "SSH (22), RDP (3389) open to 0.0.0.0/0",
+				},
+				{
+					GroupId:    "sg-123",
+					GroupName:  "test",
+					Check:      sgCheckPublicDatabasePort,
+					Severity:   sgFindingSeverityHigh,
+					Direction:  "inbound",
+					IpProtocol: "tcp",
+					FromPort:   aws.Int64(1),
+					ToPort:     aws.Int64(3400),
+					Source:     "0.0.0.0/0",
+					Message:    "SQL Server (1433), Oracle (1521), MySQL (3306) open to 0.0.0.0/0",
+				},
+				{
+					GroupId:    "sg-123",
+					GroupName:  "test",
+					Check:      sgCheckWidePortRange,
+					Severity:   sgFindingSeverityHigh,
+					Direction:  "inbound",
+					IpProtocol: "tcp",
+					FromPort:   aws.Int64(1),
+					ToPort:     aws.Int64(3400),
+					Source:     "0.0.0.0/0",
+					Message:    "tcp ports 1-3400 open to 0.0.0.0/0",
+				},
+			},
+		},
+		{
+			name:     "private wide range",
+			linter:   defaultLinter,
+			incoming: []*Ec2SecurityGroupIpPermission{tcp(1024, 65535, "10.0.0.0/8")},
+			want: []*Ec2SecurityGroupFinding{
+				{
+					GroupId:    "sg-123",
+					GroupName:  "test",
+					Check:      sgCheckWidePortRange,
+					Severity:   sgFindingSeverityMedium,
+					Direction:  "inbound",
+					IpProtocol: "tcp",
+					FromPort:   aws.Int64(1024),
+					ToPort:     aws.Int64(65535),
+					Source:     "10.0.0.0/8",
+					Message:    "tcp ports 1024-65535 allowed from 10.0.0.0/8",
+				},
+			},
+		},
+		{
+			name:   "all traffic",
+			linter: defaultLinter,
+			incoming: []*Ec2SecurityGroupIpPermission{
+				{
+					IpProtocol: "-1",
+					IpRanges:   []*Ec2SecurityGroupIpRange{{CidrIp: "0.0.0.0/0"}},
+					PrefixListIds: []*Ec2SecurityGroupPrefixListId{
+						{PrefixListId: "pl-123"},
+					},
+					UserIdGroupPairs: []*Ec2SecurityGroupUserIdGroupPair{
+						{GroupId: "sg-123", UserId: "012345678901"},
+					},
+				},
+			},
+			want: []*Ec2SecurityGroupFinding{
+				{
+					GroupId:    "sg-123",
+					GroupName:  "test",
+					Check:      sgCheckPublicAllTraffic,
+					Severity:   sgFindingSeverityCritical,
+					Direction:  "inbound",
+					IpProtocol: "-1",
+					Source:     "0.0.0.0/0",
+					Message:    "all traffic is open to 0.0.0.0/0",
+				},
+				{
+					GroupId:    "sg-123",
+					GroupName:  "test",
+					Check:      sgCheckAllProtocols,
+					Severity:   sgFindingSeverityLow,
+					Direction:  "inbound",
+					IpProtocol: "-1",
+					Source:     "pl-123",
+					Message:    "all traffic is allowed from pl-123",
+				},
+			},
+		},
+		{
+			name:     "public egress",
+			linter:   defaultLinter,
+			outgoing: []*Ec2SecurityGroupIpPermission{{IpProtocol: "-1", IpRanges: []*Ec2SecurityGroupIpRange{{CidrIp: "0.0.0.0/0"}}}},
+			want:     []*Ec2SecurityGroupFinding{},
+		},
+		{
+			name:   "cross account reference",
+			linter: defaultLinter,
+			outgoing: []*Ec2SecurityGroupIpPermission{
+				{
+					IpProtocol: "tcp",
+					FromPort:   443,
+					ToPort:     443,
+					UserIdGroupPairs: []*Ec2SecurityGroupUserIdGroupPair{
+						{GroupId: "sg-456", UserId: "012345678901"},
+						{GroupId: "sg-789", UserId: "210987654321"},
+					},
+				},
+			},
+			want: []*Ec2SecurityGroupFinding{
+				{
+					GroupId:    "sg-123",
+					GroupName:  "test",
+					Check:      sgCheckCrossAccountReference,
+					Severity:   sgFindingSeverityMedium,
+					Direction:  "outbound",
+					IpProtocol: "tcp",
+					FromPort:   aws.Int64(443),
+					ToPort:     aws.Int64(443),
+					Source:     "210987654321/sg-789",
+					Message:    "outbound rule references security group sg-789 in account 210987654321",
+				},
+			},
+		},
+		{
+			name:   "allow lists",
+			linter: allowLinter,
+			incoming: []*Ec2SecurityGroupIpPermission{
+				tcp(0, 65535, "10.0.0.0/8"),
+				tcp(22, 22, "0.0.0.0/0"),
+				{
+					IpProtocol: "tcp",
+					FromPort:   80,
+					ToPort:     80,
+					UserIdGroupPairs: []*Ec2SecurityGroupUserIdGroupPair{
+						{GroupId: "sg-789", UserId: "210987654321"},
+					},
+				},
+			},
+			want: []*Ec2SecurityGroupFinding{},
+		},
+		{
+			name:     "exempt group",
+			linter:   exemptLinter,
+			incoming: []*Ec2SecurityGroupIpPermission{tcp(22, 22, "0.0.0.0/0")},
+			want:     []*Ec2SecurityGroupFinding{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.linter.lint("012345678901", "sg-123", "test", tt.incoming, tt.outgoing)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lint() = %s, want %s", awsutil.Prettify(got), awsutil.Prettify(tt.want))
+			}
+		})
+	}
+}
+
+func Test_sgLinter_enforce(t *testing.T) {
+	strict, _ := newSgLinter(&common.SecurityGroupLint{Strict: true})
+	strictCritical, _ := newSgLinter(&common.SecurityGroupLint{Strict: true, StrictSeverity: "critical"})
+	lenient, _ := newSgLinter(nil)
+
+	publicDatabase, _ := sgRequestPermissions(&Ec2SecurityGroupRuleRequest{
+		RuleType:   aws.String("inbound"),
+		IpProtocol: aws.String("tcp"),
+		FromPort:   aws.Int64(3306),
+		ToPort:     aws.Int64(3306),
+		CidrIp:     aws.String("0.0.0.0/0"),
+	})
+
+	tests := []struct {
+		name    string
+		linter  *sgLinter
+		wantErr bool
+	}{
+		{"nil linter", nil, false},
+		{"not strict", lenient, false},
+		{"strict", strict, true},
+		{"strict below severity", strictCritical, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.linter.enforce("012345678901", "", publicDatabase, nil); (err != nil) != tt.wantErr {
+				t.Errorf("enforce() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_sgRequestPermissions(t *testing.T) {
+	incoming, outgoing := sgRequestPermissions(
+		&Ec2SecurityGroupRuleRequest{
+			RuleType:   aws.String("inbound"),
+			IpProtocol: aws.String("tcp"),
+			FromPort:   aws.Int64(22),
+			ToPort:     aws.Int64(22),
+			CidrIp:     aws.String("10.0.0.0/8"),
+			SgId:       aws.String("sg-123"),
+		},
+		&Ec2SecurityGroupRuleRequest{
+			RuleType:   aws.String("outbound"),
+			IpProtocol: aws.String("-1"),
+			CidrIp:     aws.String("0.0.0.0/0"),
+		},
+	)
+
+	if len(incoming) != 2 {
+		t.Errorf("expected 2 incoming permissions, got %d", len(incoming))
+	}
+
+	if len(outgoing) != 1 {
+		t.Errorf("expected 1 outgoing permission, got %d", len(outgoing))
+	}
+}
+
+func Test_filterSgFindings(t *testing.T) {
+	findings := []*Ec2SecurityGroupFinding{
+		{Check: sgCheckAllProtocols, Severity: sgFindingSeverityLow},
+		{Check: sgCheckCrossAccountReference, Severity: sgFindingSeverityMedium},
+		{Check: sgCheckPublicDatabasePort, Severity: sgFindingSeverityHigh},
+		{Check: sgCheckPublicAdminPort, Severity: sgFindingSeverityCritical},
+	}
+
+	if got := filterSgFindings(findings, ""); len(got) != 4 {
+		t.Errorf("expected 4 unfiltered findings, got %d", len(got))
+	}
+
+	if got := filterSgFindings(findings, sgFindingSeverityHigh); len(got) != 2 {
+		t.Errorf("expected 2 high findings, got %d", len(got))
+	}
+
+	want := map[string]int{"low": 1, "medium": 1, "high": 1, "critical": 1}
+	if got := summarizeSgFindings(findings); !reflect.DeepEqual(got, want) {
+		t.Errorf("summarizeSgFindings() = %v, want %v", got, want)
+	}
+
+	want = map[string]int{"low": 0, "medium": 0, "high": 0, "critical": 0}
+	if got := summarizeSgFindings(nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("summarizeSgFindings() = %v, want %v", got, want)
+	}
+}
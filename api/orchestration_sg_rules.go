@@ -0,0 +1,405 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	log "github.com/sirupsen/logrus"
+)
+
+// ipProtocolNames maps protocol numbers and aliases to the names returned by the EC2 API
+var ipProtocolNames = map[string]string{
+	"all": "-1",
+	"1":   "icmp",
+	"6":   "tcp",
+	"17":  "udp",
+	"58":  "icmpv6",
+}
+
+// syncSecurityGroupRules makes the rules of a security group match the desired rules, applying the minimal set
+// of authorize, revoke and description updates.  Rules are authorized before revoking so traffic allowed by both the
+// current and desired rules isn't interrupted.  If any operation fails, the operations already applied are rolled back.
+func (o *ec2Orchestrator) syncSecurityGroupRules(ctx context.Context, id string, req *Ec2SecurityGroupRulesSyncRequest) (*Ec2SecurityGroupRulesSyncResponse, error) {
+	if id == "" || req == nil {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	if req.Ingress == nil && req.Egress == nil {
+		return nil, apierror.New(apierror.ErrBadRequest, "ingress or egress rules are required", nil)
+	}
+
+	log.Debugf("got request to sync security group %s rules: %s", id, awsutil.Prettify(req))
+
+	sgs, err := o.ec2Client.GetSecurityGroup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sgs) != 1 {
+		return nil, apierror.New(apierror.ErrNotFound, "security group not found", nil)
+	}
+	sg := sgs[0]
+
+	out := &Ec2SecurityGroupRulesSyncResponse{
+		GroupId: id,
+		DryRun:  aws.BoolValue(req.DryRun),
+	}
+
+	if req.Ingress != nil {
+		if out.Ingress, err = diffSecurityGroupRules(flattenIpPermissions(sg.IpPermissions), req.Ingress); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Egress != nil {
+		if out.Egress, err = diffSecurityGroupRules(flattenIpPermissions(sg.IpPermissionsEgress), req.Egress); err != nil {
+			return nil, err
+		}
+	}
+
+	out.Changed = out.Ingress.changed() || out.Egress.changed()
+
+	// only the added rules are checked in strict mode, existing rules can still be kept or removed
+	if o.server != nil {
+		var incoming, outgoing []*ec2.IpPermission
+		if out.Ingress != nil {
+			incoming = ipPermissionsFromRules(out.Ingress.Add)
+		}
+		if out.Egress != nil {
+			outgoing = ipPermissionsFromRules(out.Egress.Add)
+		}
+
+		err = o.server.sgLinter.enforce(aws.StringValue(sg.OwnerId), id, toEc2SecurityGroupIpPermissions(incoming), toEc2SecurityGroupIpPermissions(outgoing))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if out.DryRun || !out.Changed {
+		return out, nil
+	}
+
+	var rollBackTasks []rollbackFunc
+	defer func() {
+		if err != nil {
+			log.Errorf("recovering from error: %s, executing %d rollback tasks", err, len(rollBackTasks))
+			rollBack(&rollBackTasks)
+		}
+	}()
+
+	directions := []struct {
+		direction string
+		diff      *Ec2SecurityGroupRuleDiff
+	}{
+		{"inbound", out.Ingress},
+		{"outbound", out.Egress},
+	}
+
+	for _, d := range directions {
+		if d.diff == nil || len(d.diff.Add) == 0 {
+			continue
+		}
+
+		direction, permissions := d.direction, ipPermissionsFromRules(d.diff.Add)
+
+		// err is used to trigger rollback, don't shadow it here
+		if err = o.ec2Client.AuthorizeSecurityGroup(ctx, direction, id, permissions); err != nil {
+			return nil, err
+		}
+
+		rollBackTasks = append(rollBackTasks, func(ctx context.Context) error {
+			log.Errorf("rollback: revoking %s rules authorized on security group %s", direction, id)
+			return o.ec2Client.RevokeSecurityGroup(ctx, direction, id, permissions)
+		})
+	}
+
+	for _, d := range directions {
+		if d.diff == nil || len(d.diff.Remove) == 0 {
+			continue
+		}
+
+		direction, permissions := d.direction, ipPermissionsFromRules(d.diff.Remove)
+
+		if err = o.ec2Client.RevokeSecurityGroup(ctx, direction, id, permissions); err != nil {
+			return nil, err
+		}
+
+		rollBackTasks = append(rollBackTasks, func(ctx context.Context) error {
+			log.Errorf("rollback: re-authorizing %s rules revoked from security group %s", direction, id)
+			return o.ec2Client.AuthorizeSecurityGroup(ctx, direction, id, permissions)
+		})
+	}
+
+	for _, d := range directions {
+		if d.diff == nil || len(d.diff.UpdateDescription) == 0 {
+			continue
+		}
+
+		direction := d.direction
+		previous := make([]*Ec2SecurityGroupRule, len(d.diff.UpdateDescription))
+		for i, r := range d.diff.UpdateDescription {
+			p := *r
+			p.Description = r.PreviousDescription
+			previous[i] = &p
+		}
+
+		if err = o.ec2Client.UpdateSecurityGroupRuleDescriptions(ctx, direction, id, ipPermissionsFromRules(d.diff.UpdateDescription)); err != nil {
+			return nil, err
+		}
+
+		rollBackTasks = append(rollBackTasks, func(ctx context.Context) error {
+			log.Errorf("rollback: restoring %s rule descriptions on security group %s", direction, id)
+			return o.ec2Client.UpdateSecurityGroupRuleDescriptions(ctx, direction, id, ipPermissionsFromRules(previous))
+		})
+	}
+
+	return out, nil
+}
+
+// diffSecurityGroupRules compares the current and desired rules for one direction of a security group.  Rules are identified
+// by protocol, ports and source/destination, a rule that only differs in description is updated in place.
+func diffSecurityGroupRules(current, desired []*Ec2SecurityGroupRule) (*Ec2SecurityGroupRuleDiff, error) {
+	diff := &Ec2SecurityGroupRuleDiff{
+		Add:               []*Ec2SecurityGroupRule{},
+		Remove:            []*Ec2SecurityGroupRule{},
+		UpdateDescription: []*Ec2SecurityGroupRule{},
+	}
+
+	currentRules := make(map[string]*Ec2SecurityGroupRule, len(current))
+	for _, r := range current {
+		currentRules[securityGroupRuleKey(r)] = r
+	}
+
+	desiredRules := make(map[string]*Ec2SecurityGroupRule, len(desired))
+	for _, d := range desired {
+		r, err := normalizeSecurityGroupRule(d)
+		if err != nil {
+			return nil, err
+		}
+
+		key := securityGroupRuleKey(r)
+		if _, ok := desiredRules[key]; ok {
+			return nil, apierror.New(apierror.ErrBadRequest, "duplicate rule "+key, nil)
+		}
+		desiredRules[key] = r
+
+		c, ok := currentRules[key]
+		switch {
+		case !ok:
+			diff.Add = append(diff.Add, r)
+		case c.Description != r.Description:
+			// keep the current owner of a referenced group so the update matches the existing rule
+			r.UserId = c.UserId
+			r.PreviousDescription = c.Description
+			diff.UpdateDescription = append(diff.UpdateDescription, r)
+		default:
+			diff.Unchanged++
+		}
+	}
+
+	for key, c := range currentRules {
+		if _, ok := desiredRules[key]; !ok {
+			diff.Remove = append(diff.Remove, c)
+		}
+	}
+
+	for _, rules := range [][]*Ec2SecurityGroupRule{diff.Add, diff.Remove, diff.UpdateDescription} {
+		sort.Slice(rules, func(i, j int) bool {
+			return securityGroupRuleKey(rules[i]) < securityGroupRuleKey(rules[j])
+		})
+	}
+
+	return diff, nil
+}
+
+func (d *Ec2SecurityGroupRuleDiff) changed() bool {
+	if d == nil {
+		return false
+	}
+
+	return len(d.Add) > 0 || len(d.Remove) > 0 || len(d.UpdateDescription) > 0
+}
+
+// normalizeSecurityGroupRule validates a rule and returns a copy with the protocol and ports in the form returned by the EC2 API
+func normalizeSecurityGroupRule(rule *Ec2SecurityGroupRule) (*Ec2SecurityGroupRule, error) {
+	if rule == nil {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid rule", nil)
+	}
+
+	r := *rule
+	r.PreviousDescription = ""
+
+	r.IpProtocol = strings.ToLower(strings.TrimSpace(r.IpProtocol))
+	if p, ok := ipProtocolNames[r.IpProtocol]; ok {
+		r.IpProtocol = p
+	}
+
+	if r.IpProtocol == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "ip_protocol is required", nil)
+	}
+
+	targets := 0
+	for _, t := range []string{r.CidrIp, r.CidrIpv6, r.SgId, r.PrefixListId} {
+		if t != "" {
+			targets++
+		}
+	}
+
+	if targets != 1 {
+		return nil, apierror.New(apierror.ErrBadRequest, "exactly one of cidr_ip, cidr_ipv6, sg_id or prefix_list_id is required", nil)
+	}
+
+	if r.CidrIp != "" {
+		if _, n, err := net.ParseCIDR(r.CidrIp); err != nil || n.IP.To4() == nil {
+			return nil, apierror.New(apierror.ErrBadRequest, "invalid cidr_ip "+r.CidrIp, nil)
+		}
+	}
+
+	if r.CidrIpv6 != "" {
+		if _, n, err := net.ParseCIDR(r.CidrIpv6); err != nil || n.IP.To4() != nil {
+			return nil, apierror.New(apierror.ErrBadRequest, "invalid cidr_ipv6 "+r.CidrIpv6, nil)
+		}
+	}
+
+	switch r.IpProtocol {
+	case "tcp", "udp":
+		if r.FromPort == nil || r.ToPort == nil {
+			return nil, apierror.New(apierror.ErrBadRequest, "from_port and to_port are required for "+r.IpProtocol, nil)
+		}
+
+		from, to := aws.Int64Value(r.FromPort), aws.Int64Value(r.ToPort)
+		if from < 0 || to > 65535 || from > to {
+			msg := fmt.Sprintf("invalid port range %d-%d for %s", from, to, r.IpProtocol)
+			return nil, apierror.New(apierror.ErrBadRequest, msg, nil)
+		}
+	case "icmp", "icmpv6":
+		// the from port is the icmp type and the to port is the icmp code, -1 means all
+		if r.FromPort == nil {
+			r.FromPort = aws.Int64(-1)
+		}
+
+		if r.ToPort == nil {
+			r.ToPort = aws.Int64(-1)
+		}
+	default:
+		// ports don't apply to other protocols
+		r.FromPort = nil
+		r.ToPort = nil
+	}
+
+	return &r, nil
+}
+
+// securityGroupRuleKey returns the identity of a rule, the description isn't part of it
+func securityGroupRuleKey(r *Ec2SecurityGroupRule) string {
+	ports := "all"
+	if r.FromPort != nil || r.ToPort != nil {
+		ports = fmt.Sprintf("%d-%d", aws.Int64Value(r.FromPort), aws.Int64Value(r.ToPort))
+	}
+
+	var target string
+	switch {
+	case r.CidrIp != "":
+		target = r.CidrIp
+	case r.CidrIpv6 != "":
+		target = r.CidrIpv6
+	case r.SgId != "":
+		target = r.SgId
+	case r.PrefixListId != "":
+		target = r.PrefixListId
+	}
+
+	return fmt.Sprintf("%s/%s/%s", r.IpProtocol, ports, target)
+}
+
+// flattenIpPermissions converts ip permissions to a list of rules with a single source/destination each
+func flattenIpPermissions(permissions []*ec2.IpPermission) []*Ec2SecurityGroupRule {
+	rules := []*Ec2SecurityGroupRule{}
+	for _, p := range permissions {
+		base := Ec2SecurityGroupRule{
+			IpProtocol: aws.StringValue(p.IpProtocol),
+			FromPort:   p.FromPort,
+			ToPort:     p.ToPort,
+		}
+
+		// ports aren't returned for all traffic, but normalize in case they are
+		if base.IpProtocol == "-1" {
+			base.FromPort = nil
+			base.ToPort = nil
+		}
+
+		for _, r := range p.IpRanges {
+			rule := base
+			rule.CidrIp = aws.StringValue(r.CidrIp)
+			rule.Description = aws.StringValue(r.Description)
+			rules = append(rules, &rule)
+		}
+
+		for _, r := range p.Ipv6Ranges {
+			rule := base
+			rule.CidrIpv6 = aws.StringValue(r.CidrIpv6)
+			rule.Description = aws.StringValue(r.Description)
+			rules = append(rules, &rule)
+		}
+
+		for _, r := range p.UserIdGroupPairs {
+			rule := base
+			rule.SgId = aws.StringValue(r.GroupId)
+			rule.UserId = aws.StringValue(r.UserId)
+			rule.Description = aws.StringValue(r.Description)
+			rules = append(rules, &rule)
+		}
+
+		for _, r := range p.PrefixListIds {
+			rule := base
+			rule.PrefixListId = aws.StringValue(r.PrefixListId)
+			rule.Description = aws.StringValue(r.Description)
+			rules = append(rules, &rule)
+		}
+	}
+
+	return rules
+}
+
+// ipPermissionsFromRules converts a list of rules to ip permissions
+func ipPermissionsFromRules(rules []*Ec2SecurityGroupRule) []*ec2.IpPermission {
+	permissions := make([]*ec2.IpPermission, 0, len(rules))
+	for _, r := range rules {
+		p := &ec2.IpPermission{
+			IpProtocol: aws.String(r.IpProtocol),
+			FromPort:   r.FromPort,
+			ToPort:     r.ToPort,
+		}
+
+		var description *string
+		if r.Description != "" {
+			description = aws.String(r.Description)
+		}
+
+		switch {
+		case r.CidrIp != "":
+			p.IpRanges = []*ec2.IpRange{{CidrIp: aws.String(r.CidrIp), Description: description}}
+		case r.CidrIpv6 != "":
+			p.Ipv6Ranges = []*ec2.Ipv6Range{{CidrIpv6: aws.String(r.CidrIpv6), Description: description}}
+		case r.SgId != "":
+			pair := &ec2.UserIdGroupPair{GroupId: aws.String(r.SgId), Description: description}
+			if r.UserId != "" {
+				pair.UserId = aws.String(r.UserId)
+			}
+			p.UserIdGroupPairs = []*ec2.UserIdGroupPair{pair}
+		case r.PrefixListId != "":
+			p.PrefixListIds = []*ec2.PrefixListId{{PrefixListId: aws.String(r.PrefixListId), Description: description}}
+		}
+
+		permissions = append(permissions, p)
+	}
+
+	return permissions
+}
@@ -0,0 +1,203 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func Test_normalizeSecurityGroupRule(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    *Ec2SecurityGroupRule
+		want    *Ec2SecurityGroupRule
+		wantErr bool
+	}{
+		{
+			name: "tcp rule",
+			rule: &Ec2SecurityGroupRule{IpProtocol: "TCP", FromPort: aws.Int64(22), ToPort: aws.Int64(22), CidrIp: "10.0.0.0/8"},
+			want: &Ec2SecurityGroupRule{IpProtocol: "tcp", FromPort: aws.Int64(22), ToPort: aws.Int64(22), CidrIp: "10.0.0.0/8"},
+		},
+		{
+			name: "protocol number",
+			rule: &Ec2SecurityGroupRule{IpProtocol: "17", FromPort: aws.Int64(53), ToPort: aws.Int64(53), SgId: "sg-123"},
+			want: &Ec2SecurityGroupRule{IpProtocol: "udp", FromPort: aws.Int64(53), ToPort: aws.Int64(53), SgId: "sg-123"},
+		},
+		{
+			name: "all traffic drops ports",
+			rule: &Ec2SecurityGroupRule{IpProtocol: "all", FromPort: aws.Int64(0), ToPort: aws.Int64(0), CidrIpv6: "::/0"},
+			want: &Ec2SecurityGroupRule{IpProtocol: "-1", CidrIpv6: "::/0"},
+		},
+		{
+			name: "icmp defaults to all types",
+			rule: &Ec2SecurityGroupRule{IpProtocol: "icmp", PrefixListId: "pl-123"},
+			want: &Ec2SecurityGroupRule{IpProtocol: "icmp", FromPort: aws.Int64(-1), ToPort: aws.Int64(-1), PrefixListId: "pl-123"},
+		},
+		{
+			name:    "missing protocol",
+			rule:    &Ec2SecurityGroupRule{CidrIp: "10.0.0.0/8"},
+			wantErr: true,
+		},
+		{
+			name:    "missing ports",
+			rule:    &Ec2SecurityGroupRule{IpProtocol: "tcp", CidrIp: "10.0.0.0/8"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid port range",
+			rule:    &Ec2SecurityGroupRule{IpProtocol: "tcp", FromPort: aws.Int64(443), ToPort: aws.Int64(80), CidrIp: "10.0.0.0/8"},
+			wantErr: true,
+		},
+		{
+			name:    "multiple targets",
+			rule:    &Ec2SecurityGroupRule{IpProtocol: "-1", CidrIp: "10.0.0.0/8", SgId: "sg-123"},
+			wantErr: true,
+		},
+		{
+			name:    "no target",
+			rule:    &Ec2SecurityGroupRule{IpProtocol: "-1"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid cidr",
+			rule:    &Ec2SecurityGroupRule{IpProtocol: "-1", CidrIp: "10.0.0.0"},
+			wantErr: true,
+		},
+		{
+			name:    "ipv6 cidr as ipv4",
+			rule:    &Ec2SecurityGroupRule{IpProtocol: "-1", CidrIp: "::/0"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeSecurityGroupRule(tt.rule)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("normalizeSecurityGroupRule() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("normalizeSecurityGroupRule() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_flattenIpPermissions(t *testing.T) {
+	permissions := []*ec2.IpPermission{
+		{
+			IpProtocol: aws.String("tcp"),
+			FromPort:   aws.Int64(443),
+			ToPort:     aws.Int64(443),
+			IpRanges: []*ec2.IpRange{
+				{CidrIp: aws.String("10.0.0.0/8"), Description: aws.String("campus")},
+				{CidrIp: aws.String("172.16.0.0/12")},
+			},
+			UserIdGroupPairs: []*ec2.UserIdGroupPair{
+				{GroupId: aws.String("sg-123"), UserId: aws.String("012345678901")},
+			},
+		},
+		{
+			IpProtocol: aws.String("-1"),
+			Ipv6Ranges: []*ec2.Ipv6Range{{CidrIpv6: aws.String("::/0")}},
+			PrefixListIds: []*ec2.PrefixListId{
+				{PrefixListId: aws.String("pl-123"), Description: aws.String("s3")},
+			},
+		},
+	}
+
+	want := []*Ec2SecurityGroupRule{
+		{IpProtocol: "tcp", FromPort: aws.Int64(443), ToPort: aws.Int64(443), CidrIp: "10.0.0.0/8", Description: "campus"},
+		{IpProtocol: "tcp", FromPort: aws.Int64(443), ToPort: aws.Int64(443), CidrIp: "172.16.0.0/12"},
+		{IpProtocol: "tcp", FromPort: aws.Int64(443), ToPort: aws.Int64(443), SgId: "sg-123", UserId: "012345678901"},
+		{IpProtocol: "-1", CidrIpv6: "::/0"},
+		{IpProtocol: "-1", PrefixListId: "pl-123", Description: "s3"},
+	}
+
+	got := flattenIpPermissions(permissions)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("flattenIpPermissions() = %+v, want %+v", got, want)
+	}
+
+	// converting back and flattening again should be lossless
+	if again := flattenIpPermissions(ipPermissionsFromRules(got)); !reflect.DeepEqual(again, want) {
+		t.Errorf("flattenIpPermissions(ipPermissionsFromRules()) = %+v, want %+v", again, want)
+	}
+}
+
+func Test_diffSecurityGroupRules(t *testing.T) {
+	current := []*Ec2SecurityGroupRule{
+		{IpProtocol: "tcp", FromPort: aws.Int64(22), ToPort: aws.Int64(22), CidrIp: "10.0.0.0/8", Description: "ssh"},
+		{IpProtocol: "tcp", FromPort: aws.Int64(443), ToPort: aws.Int64(443), CidrIp: "0.0.0.0/0", Description: "old"},
+		{IpProtocol: "tcp", FromPort: aws.Int64(3389), ToPort: aws.Int64(3389), SgId: "sg-123", UserId: "012345678901"},
+	}
+
+	t.Run("changes", func(t *testing.T) {
+		desired := []*Ec2SecurityGroupRule{
+			{IpProtocol: "6", FromPort: aws.Int64(22), ToPort: aws.Int64(22), CidrIp: "10.0.0.0/8", Description: "ssh"},
+			{IpProtocol: "tcp", FromPort: aws.Int64(443), ToPort: aws.Int64(443), CidrIp: "0.0.0.0/0", Description: "https"},
+			{IpProtocol: "udp", FromPort: aws.Int64(53), ToPort: aws.Int64(53), CidrIp: "10.0.0.0/8"},
+		}
+
+		want := &Ec2SecurityGroupRuleDiff{
+			Add: []*Ec2SecurityGroupRule{
+				{IpProtocol: "udp", FromPort: aws.Int64(53), ToPort: aws.Int64(53), CidrIp: "10.0.0.0/8"},
+			},
+			Remove: []*Ec2SecurityGroupRule{
+				{IpProtocol: "tcp", FromPort: aws.Int64(3389), ToPort: aws.Int64(3389), SgId: "sg-123", UserId: "012345678901"},
+			},
+			UpdateDescription: []*Ec2SecurityGroupRule{
+				{IpProtocol: "tcp", FromPort: aws.Int64(443), ToPort: aws.Int64(443), CidrIp: "0.0.0.0/0", Description: "https", PreviousDescription: "old"},
+			},
+			Unchanged: 1,
+		}
+
+		got, err := diffSecurityGroupRules(current, desired)
+		if err != nil {
+			t.Fatalf("diffSecurityGroupRules() unexpected error %s", err)
+		}
+
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("diffSecurityGroupRules() = %+v, want %+v", got, want)
+		}
+
+		if !got.changed() {
+			t.Error("diffSecurityGroupRules() expected changes")
+		}
+	})
+
+	t.Run("no changes", func(t *testing.T) {
+		got, err := diffSecurityGroupRules(current, current)
+		if err != nil {
+			t.Fatalf("diffSecurityGroupRules() unexpected error %s", err)
+		}
+
+		if got.changed() || got.Unchanged != len(current) {
+			t.Errorf("diffSecurityGroupRules() = %+v, want no changes", got)
+		}
+	})
+
+	t.Run("remove all", func(t *testing.T) {
+		got, err := diffSecurityGroupRules(current, []*Ec2SecurityGroupRule{})
+		if err != nil {
+			t.Fatalf("diffSecurityGroupRules() unexpected error %s", err)
+		}
+
+		if len(got.Remove) != len(current) || len(got.Add) != 0 {
+			t.Errorf("diffSecurityGroupRules() = %+v, want all rules removed", got)
+		}
+	})
+
+	t.Run("duplicate rules", func(t *testing.T) {
+		desired := []*Ec2SecurityGroupRule{
+			{IpProtocol: "tcp", FromPort: aws.Int64(22), ToPort: aws.Int64(22), CidrIp: "10.0.0.0/8", Description: "one"},
+			{IpProtocol: "6", FromPort: aws.Int64(22), ToPort: aws.Int64(22), CidrIp: "10.0.0.0/8", Description: "two"},
+		}
+
+		if _, err := diffSecurityGroupRules(current, desired); err == nil {
+			t.Error("diffSecurityGroupRules() expected error for duplicate rules")
+		}
+	})
+}
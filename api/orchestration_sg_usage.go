@@ -0,0 +1,275 @@
+package api
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// securityGroupUsage returns the network interfaces and instances a security group is attached to and the
+// other security groups with rules referencing it
+func (o *ec2Orchestrator) securityGroupUsage(ctx context.Context, id string) (*Ec2SecurityGroupUsageResponse, error) {
+	if id == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	sgs, err := o.ec2Client.GetSecurityGroup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sgs) != 1 {
+		return nil, apierror.New(apierror.ErrNotFound, "security group not found", nil)
+	}
+
+	enis, err := o.ec2Client.ListNetworkInterfaces(ctx, "", &ec2.Filter{
+		Name:   aws.String("group-id"),
+		Values: aws.StringSlice([]string{id}),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var referencing []*ec2.SecurityGroup
+	for _, name := range []string{"ip-permission.group-id", "egress.ip-permission.group-id"} {
+		refs, err := o.ec2Client.ListSecurityGroupDetails(ctx, "", &ec2.Filter{
+			Name:   aws.String(name),
+			Values: aws.StringSlice([]string{id}),
+		})
+		if err != nil {
+			return nil, err
+		}
+		referencing = append(referencing, refs...)
+	}
+
+	return newSecurityGroupUsage(sgs[0], enis, referencing), nil
+}
+
+// deleteSecurityGroup deletes a security group if it's not in use.  If it's in use, the usage is returned as blockers
+// and the security group isn't deleted.  In cascade mode, the security group is detached from network interfaces and
+// rules referencing it are revoked before deleting, unless it's attached to a network interface where it can't be
+// detached.  If the cascade or the delete fails, the changes already applied are rolled back.
+func (o *ec2Orchestrator) deleteSecurityGroup(ctx context.Context, id string, cascade bool) (*Ec2SecurityGroupDeleteResponse, error) {
+	usage, err := o.securityGroupUsage(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &Ec2SecurityGroupDeleteResponse{GroupId: id}
+
+	if usage.InUse && !cascade {
+		out.Message = "security group is in use"
+		out.Blockers = usage
+		return out, nil
+	}
+
+	if blockers := cascadeBlockers(usage); blockers.InUse {
+		out.Message = "security group is attached to network interfaces where it can't be detached"
+		out.Blockers = blockers
+		return out, nil
+	}
+
+	var rollBackTasks []rollbackFunc
+	defer func() {
+		if err != nil {
+			log.Errorf("recovering from error: %s, executing %d rollback tasks", err, len(rollBackTasks))
+			rollBack(&rollBackTasks)
+		}
+	}()
+
+	for _, eni := range usage.NetworkInterfaces {
+		eniId, groups := eni.NetworkInterfaceId, eni.Groups
+
+		// err is used to trigger rollback, don't shadow it here
+		if err = o.ec2Client.UpdateNetworkInterfaceGroups(ctx, eniId, removeString(groups, id)); err != nil {
+			return nil, err
+		}
+
+		rollBackTasks = append(rollBackTasks, func(ctx context.Context) error {
+			log.Errorf("rollback: restoring security groups %v for network interface %s", groups, eniId)
+			return o.ec2Client.UpdateNetworkInterfaceGroups(ctx, eniId, groups)
+		})
+
+		out.DetachedNetworkInterfaces = append(out.DetachedNetworkInterfaces, eniId)
+	}
+
+	for _, ref := range usage.ReferencingGroups {
+		for _, r := range []struct {
+			direction string
+			rules     []*Ec2SecurityGroupRule
+		}{
+			{"inbound", ref.Ingress},
+			{"outbound", ref.Egress},
+		} {
+			if len(r.rules) == 0 {
+				continue
+			}
+
+			groupId, direction, permissions := ref.GroupId, r.direction, ipPermissionsFromRules(r.rules)
+
+			// err is used to trigger rollback, don't shadow it here
+			if err = o.ec2Client.RevokeSecurityGroup(ctx, direction, groupId, permissions); err != nil {
+				return nil, err
+			}
+
+			rollBackTasks = append(rollBackTasks, func(ctx context.Context) error {
+				log.Errorf("rollback: authorizing %s rules referencing %s in security group %s", direction, id, groupId)
+				return o.ec2Client.AuthorizeSecurityGroup(ctx, direction, groupId, permissions)
+			})
+		}
+
+		out.RevokedReferences = append(out.RevokedReferences, ref)
+	}
+
+	// detaching from network interfaces is eventually consistent, retry while the dependency is reported
+	attempts := 1
+	if usage.InUse {
+		attempts = 5
+	}
+
+	err = retry(attempts, 2*time.Second, func() error {
+		if err := o.ec2Client.DeleteSecurityGroup(ctx, id); err != nil {
+			if aerr, ok := errors.Cause(err).(apierror.Error); ok && aerr.Code == apierror.ErrConflict {
+				return err
+			}
+			return stop{err}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out.Deleted = true
+
+	o.notify(webhookSgDeleted, id)
+
+	return out, nil
+}
+
+// newSecurityGroupUsage builds the usage of a security group from the network interfaces it's attached to and the
+// security groups with rules referencing it
+func newSecurityGroupUsage(sg *ec2.SecurityGroup, enis []*ec2.NetworkInterface, referencing []*ec2.SecurityGroup) *Ec2SecurityGroupUsageResponse {
+	id := aws.StringValue(sg.GroupId)
+
+	usage := &Ec2SecurityGroupUsageResponse{
+		GroupId:           id,
+		GroupName:         aws.StringValue(sg.GroupName),
+		Instances:         []string{},
+		NetworkInterfaces: []*Ec2SecurityGroupNetworkInterface{},
+		ReferencingGroups: []*Ec2SecurityGroupReference{},
+	}
+
+	instances := map[string]bool{}
+	for _, eni := range enis {
+		groups := make([]string, 0, len(eni.Groups))
+		for _, g := range eni.Groups {
+			groups = append(groups, aws.StringValue(g.GroupId))
+		}
+
+		var instanceId string
+		if eni.Attachment != nil {
+			instanceId = aws.StringValue(eni.Attachment.InstanceId)
+		}
+
+		if instanceId != "" && !instances[instanceId] {
+			instances[instanceId] = true
+			usage.Instances = append(usage.Instances, instanceId)
+		}
+
+		usage.NetworkInterfaces = append(usage.NetworkInterfaces, &Ec2SecurityGroupNetworkInterface{
+			NetworkInterfaceId: aws.StringValue(eni.NetworkInterfaceId),
+			InterfaceType:      aws.StringValue(eni.InterfaceType),
+			Description:        aws.StringValue(eni.Description),
+			Status:             aws.StringValue(eni.Status),
+			InstanceId:         instanceId,
+			RequesterManaged:   aws.BoolValue(eni.RequesterManaged),
+			Groups:             groups,
+		})
+	}
+
+	sort.Strings(usage.Instances)
+
+	seen := map[string]bool{}
+	for _, ref := range referencing {
+		refId := aws.StringValue(ref.GroupId)
+
+		// rules referencing the security group itself don't block deleting it
+		if refId == id || seen[refId] {
+			continue
+		}
+		seen[refId] = true
+
+		reference := &Ec2SecurityGroupReference{
+			GroupId:   refId,
+			GroupName: aws.StringValue(ref.GroupName),
+			Ingress:   rulesReferencingGroup(ref.IpPermissions, id),
+			Egress:    rulesReferencingGroup(ref.IpPermissionsEgress, id),
+		}
+
+		if len(reference.Ingress) > 0 || len(reference.Egress) > 0 {
+			usage.ReferencingGroups = append(usage.ReferencingGroups, reference)
+		}
+	}
+
+	usage.InUse = len(usage.NetworkInterfaces) > 0 || len(usage.ReferencingGroups) > 0
+
+	return usage
+}
+
+// cascadeBlockers returns the usage that can't be removed by a cascading delete, network interfaces managed by
+// AWS services or where the security group is the only one attached
+func cascadeBlockers(usage *Ec2SecurityGroupUsageResponse) *Ec2SecurityGroupUsageResponse {
+	blockers := &Ec2SecurityGroupUsageResponse{
+		GroupId:           usage.GroupId,
+		GroupName:         usage.GroupName,
+		Instances:         []string{},
+		NetworkInterfaces: []*Ec2SecurityGroupNetworkInterface{},
+		ReferencingGroups: []*Ec2SecurityGroupReference{},
+	}
+
+	instances := map[string]bool{}
+	for _, eni := range usage.NetworkInterfaces {
+		if !eni.RequesterManaged && len(removeString(eni.Groups, usage.GroupId)) > 0 {
+			continue
+		}
+
+		blockers.NetworkInterfaces = append(blockers.NetworkInterfaces, eni)
+		if eni.InstanceId != "" && !instances[eni.InstanceId] {
+			instances[eni.InstanceId] = true
+			blockers.Instances = append(blockers.Instances, eni.InstanceId)
+		}
+	}
+
+	blockers.InUse = len(blockers.NetworkInterfaces) > 0
+
+	return blockers
+}
+
+// rulesReferencingGroup returns the rules in the permissions that reference the given security group
+func rulesReferencingGroup(permissions []*ec2.IpPermission, id string) []*Ec2SecurityGroupRule {
+	rules := []*Ec2SecurityGroupRule{}
+	for _, r := range flattenIpPermissions(permissions) {
+		if r.SgId == id {
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
+// removeString returns a copy of the list without the given string
+func removeString(list []string, s string) []string {
+	out := make([]string, 0, len(list))
+	for _, l := range list {
+		if l != s {
+			out = append(out, l)
+		}
+	}
+	return out
+}
@@ -0,0 +1,208 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func Test_newSecurityGroupUsage(t *testing.T) {
+	sg := &ec2.SecurityGroup{GroupId: aws.String("sg-123"), GroupName: aws.String("web")}
+
+	reference := func(id string) []*ec2.IpPermission {
+		return []*ec2.IpPermission{
+			{
+				IpProtocol: aws.String("tcp"),
+				FromPort:   aws.Int64(443),
+				ToPort:     aws.Int64(443),
+				UserIdGroupPairs: []*ec2.UserIdGroupPair{
+					{GroupId: aws.String(id), UserId: aws.String("012345678901")},
+				},
+				IpRanges: []*ec2.IpRange{
+					{CidrIp: aws.String("10.0.0.0/8")},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		enis        []*ec2.NetworkInterface
+		referencing []*ec2.SecurityGroup
+		want        *Ec2SecurityGroupUsageResponse
+	}{
+		{
+			name: "not in use",
+			referencing: []*ec2.SecurityGroup{
+				{GroupId: aws.String("sg-123"), GroupName: aws.String("web"), IpPermissions: reference("sg-123")},
+			},
+			want: &Ec2SecurityGroupUsageResponse{
+				GroupId:           "sg-123",
+				GroupName:         "web",
+				Instances:         []string{},
+				NetworkInterfaces: []*Ec2SecurityGroupNetworkInterface{},
+				ReferencingGroups: []*Ec2SecurityGroupReference{},
+			},
+		},
+		{
+			name: "in use",
+			enis: []*ec2.NetworkInterface{
+				{
+					NetworkInterfaceId: aws.String("eni-2"),
+					InterfaceType:      aws.String("interface"),
+					Status:             aws.String("in-use"),
+					Attachment:         &ec2.NetworkInterfaceAttachment{InstanceId: aws.String("i-2")},
+					Groups: []*ec2.GroupIdentifier{
+						{GroupId: aws.String("sg-123")},
+						{GroupId: aws.String("sg-456")},
+					},
+				},
+				{
+					NetworkInterfaceId: aws.String("eni-1"),
+					InterfaceType:      aws.String("interface"),
+					Status:             aws.String("in-use"),
+					Attachment:         &ec2.NetworkInterfaceAttachment{InstanceId: aws.String("i-1")},
+					Groups: []*ec2.GroupIdentifier{
+						{GroupId: aws.String("sg-123")},
+					},
+				},
+				{
+					NetworkInterfaceId: aws.String("eni-3"),
+					InterfaceType:      aws.String("interface"),
+					Description:        aws.String("ELB app/web"),
+					Status:             aws.String("in-use"),
+					RequesterManaged:   aws.Bool(true),
+					Groups: []*ec2.GroupIdentifier{
+						{GroupId: aws.String("sg-123")},
+					},
+				},
+			},
+			referencing: []*ec2.SecurityGroup{
+				{GroupId: aws.String("sg-789"), GroupName: aws.String("db"), IpPermissions: reference("sg-123")},
+				{GroupId: aws.String("sg-789"), GroupName: aws.String("db"), IpPermissions: reference("sg-123")},
+				{GroupId: aws.String("sg-abc"), GroupName: aws.String("cache"), IpPermissionsEgress: reference("sg-123")},
+			},
+			want: &Ec2SecurityGroupUsageResponse{
+				GroupId:   "sg-123",
+				GroupName: "web",
+				InUse:     true,
+				Instances: []string{"i-1", "i-2"},
+				NetworkInterfaces: []*Ec2SecurityGroupNetworkInterface{
+					{
+						NetworkInterfaceId: "eni-2",
+						InterfaceType:      "interface",
+						Status:             "in-use",
+						InstanceId:         "i-2",
+						Groups:             []string{"sg-123", "sg-456"},
+					},
+					{
+						NetworkInterfaceId: "eni-1",
+						InterfaceType:      "interface",
+						Status:             "in-use",
+						InstanceId:         "i-1",
+						Groups:             []string{"sg-123"},
+					},
+					{
+						NetworkInterfaceId: "eni-3",
+						InterfaceType:      "interface",
+						Description:        "ELB app/web",
+						Status:             "in-use",
+						RequesterManaged:   true,
+						Groups:             []string{"sg-123"},
+					},
+				},
+				ReferencingGroups: []*Ec2SecurityGroupReference{
+					{
+						GroupId:   "sg-789",
+						GroupName: "db",
+						Ingress: []*Ec2SecurityGroupRule{
+							{IpProtocol: "tcp", FromPort: aws.Int64(443), ToPort: aws.Int64(443), SgId: "sg-123", UserId: "012345678901"},
+						},
+						Egress: []*Ec2SecurityGroupRule{},
+					},
+					{
+						GroupId:   "sg-abc",
+						GroupName: "cache",
+						Ingress:   []*Ec2SecurityGroupRule{},
+						Egress: []*Ec2SecurityGroupRule{
+							{IpProtocol: "tcp", FromPort: aws.Int64(443), ToPort: aws.Int64(443), SgId: "sg-123", UserId: "012345678901"},
+						},
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newSecurityGroupUsage(sg, tt.enis, tt.referencing)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("newSecurityGroupUsage() = %s, want %s", awsutil.Prettify(got), awsutil.Prettify(tt.want))
+			}
+		})
+	}
+}
+
+func Test_cascadeBlockers(t *testing.T) {
+	detachable := &Ec2SecurityGroupNetworkInterface{NetworkInterfaceId: "eni-1", InstanceId: "i-1", Groups: []string{"sg-123", "sg-456"}}
+	onlyGroup := &Ec2SecurityGroupNetworkInterface{NetworkInterfaceId: "eni-2", InstanceId: "i-2", Groups: []string{"sg-123"}}
+	managed := &Ec2SecurityGroupNetworkInterface{NetworkInterfaceId: "eni-3", RequesterManaged: true, Groups: []string{"sg-123", "sg-456"}}
+
+	tests := []struct {
+		name  string
+		usage *Ec2SecurityGroupUsageResponse
+		want  *Ec2SecurityGroupUsageResponse
+	}{
+		{
+			name: "detachable",
+			usage: &Ec2SecurityGroupUsageResponse{
+				GroupId:           "sg-123",
+				InUse:             true,
+				Instances:         []string{"i-1"},
+				NetworkInterfaces: []*Ec2SecurityGroupNetworkInterface{detachable},
+				ReferencingGroups: []*Ec2SecurityGroupReference{{GroupId: "sg-789"}},
+			},
+			want: &Ec2SecurityGroupUsageResponse{
+				GroupId:           "sg-123",
+				Instances:         []string{},
+				NetworkInterfaces: []*Ec2SecurityGroupNetworkInterface{},
+				ReferencingGroups: []*Ec2SecurityGroupReference{},
+			},
+		},
+		{
+			name: "blocked",
+			usage: &Ec2SecurityGroupUsageResponse{
+				GroupId:           "sg-123",
+				InUse:             true,
+				Instances:         []string{"i-1", "i-2"},
+				NetworkInterfaces: []*Ec2SecurityGroupNetworkInterface{detachable, onlyGroup, managed},
+			},
+			want: &Ec2SecurityGroupUsageResponse{
+				GroupId:           "sg-123",
+				InUse:             true,
+				Instances:         []string{"i-2"},
+				NetworkInterfaces: []*Ec2SecurityGroupNetworkInterface{onlyGroup, managed},
+				ReferencingGroups: []*Ec2SecurityGroupReference{},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cascadeBlockers(tt.usage); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cascadeBlockers() = %s, want %s", awsutil.Prettify(got), awsutil.Prettify(tt.want))
+			}
+		})
+	}
+}
+
+func Test_removeString(t *testing.T) {
+	if got := removeString([]string{"a", "b", "a", "c"}, "a"); !reflect.DeepEqual(got, []string{"b", "c"}) {
+		t.Errorf("removeString() = %v, want [b c]", got)
+	}
+
+	if got := removeString(nil, "a"); !reflect.DeepEqual(got, []string{}) {
+		t.Errorf("removeString() = %v, want []", got)
+	}
+}
@@ -60,8 +60,8 @@ func (o *ec2Orchestrator) createSecurityGroup(ctx context.Context, req *Ec2Secur
 		for _, r := range req.InitRules {
 			log.Debugf("creating securitygrouprulerequest with %+v", r)
 
-			if r.CidrIp == nil && r.SgId == nil {
-				return "", apierror.New(apierror.ErrBadRequest, "cidr_ip or sg_id is required", nil)
+			if r.CidrIp == nil && r.CidrIpv6 == nil && r.SgId == nil && r.PrefixListId == nil {
+				return "", apierror.New(apierror.ErrBadRequest, "cidr_ip, cidr_ipv6, sg_id or prefix_list_id is required", nil)
 			}
 
 			ipPermissions := ipPermissionsFromRequest(r)
@@ -73,6 +73,8 @@ func (o *ec2Orchestrator) createSecurityGroup(ctx context.Context, req *Ec2Secur
 		}
 	}
 
+	o.notify(webhookSgCreated, aws.StringValue(out.GroupId))
+
 	return aws.StringValue(out.GroupId), nil
 }
 
@@ -116,6 +118,20 @@ func ipPermissionsFromRequest(r *Ec2SecurityGroupRuleRequest) []*ec2.IpPermissio
 		})
 	}
 
+	if r.CidrIpv6 != nil {
+		ipPermissions = append(ipPermissions, &ec2.IpPermission{
+			IpProtocol: r.IpProtocol,
+			FromPort:   r.FromPort,
+			ToPort:     r.ToPort,
+			Ipv6Ranges: []*ec2.Ipv6Range{
+				{
+					CidrIpv6:    r.CidrIpv6,
+					Description: r.Description,
+				},
+			},
+		})
+	}
+
 	if r.SgId != nil {
 		ipPermissions = append(ipPermissions, &ec2.IpPermission{
 			IpProtocol: r.IpProtocol,
@@ -124,6 +140,7 @@ func ipPermissionsFromRequest(r *Ec2SecurityGroupRuleRequest) []*ec2.IpPermissio
 			UserIdGroupPairs: []*ec2.UserIdGroupPair{
 				{
 					GroupId:     r.SgId,
+					UserId:      r.UserId,
 					Description: r.Description,
 				},
 			},
@@ -43,6 +43,8 @@ func (o *ec2Orchestrator) createSnapshot(ctx context.Context, req *Ec2SnapshotCr
 		return "", err
 	}
 
+	o.notify(webhookSnapshotCreated, snapshotId)
+
 	return snapshotId, nil
 }
 
@@ -60,6 +62,8 @@ func (o *ec2Orchestrator) deleteSnapshot(ctx context.Context, id string) error {
 		return err
 	}
 
+	o.notify(webhookSnapshotDeleted, id)
+
 	return nil
 }
 
@@ -0,0 +1,267 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/ec2-api/pricing"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// summaryCSVHeader are the columns of a summary exported as CSV after the group column
+var summaryCSVHeader = []string{"resource", "type", "count", "size_gb", "monthly_cost"}
+
+// accountSummary summarizes the instances, volumes, snapshots and images in the org grouped by the value of a tag
+func (o *ec2Orchestrator) accountSummary(ctx context.Context, groupBy string) (*Ec2SummaryResponse, error) {
+	if len(groupBy) > 128 {
+		return nil, apierror.New(apierror.ErrBadRequest, "groupBy must be a tag key of at most 128 characters", nil)
+	}
+
+	instances, err := o.ec2Client.ListInstanceDetails(ctx, o.server.org)
+	if err != nil {
+		return nil, err
+	}
+
+	volumes, err := o.ec2Client.ListVolumeDetails(ctx, o.server.org)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots, err := o.ec2Client.ListSnapshotDetails(ctx, o.server.org)
+	if err != nil {
+		return nil, err
+	}
+
+	images, err := o.ec2Client.ListImageDetails(ctx, o.server.org)
+	if err != nil {
+		return nil, err
+	}
+
+	return summarizeResources(o.server.prices, o.server.pricesRegion, groupBy, instances, volumes, snapshots, images), nil
+}
+
+// summarizeResources groups resources by the value of a tag and adds up their count, size and estimated monthly cost.
+// Running instances, volumes and snapshots are costed from the price catalog, snapshots at their full size.
+func summarizeResources(catalog *pricing.Catalog, region, groupBy string, instances []*ec2.Instance, volumes []*ec2.Volume, snapshots []*ec2.Snapshot, images []*ec2.Image) *Ec2SummaryResponse {
+	out := &Ec2SummaryResponse{
+		GroupBy: groupBy,
+		Region:  region,
+		Groups:  []*Ec2SummaryGroup{},
+	}
+
+	if catalog != nil {
+		out.Currency = catalog.Currency
+	}
+
+	groups := map[string]*Ec2SummaryGroup{}
+	group := func(tags []*ec2.Tag) *Ec2SummaryGroup {
+		value := ""
+		if groupBy != "" {
+			for _, t := range tags {
+				if aws.StringValue(t.Key) == groupBy {
+					value = aws.StringValue(t.Value)
+					break
+				}
+			}
+		}
+
+		g, ok := groups[value]
+		if !ok {
+			g = &Ec2SummaryGroup{
+				Value: value,
+				Instances: &Ec2InstanceSummary{
+					Ec2ResourceSummary: Ec2ResourceSummary{ByType: map[string]*Ec2SummaryItem{}},
+					ByState:            map[string]int{},
+				},
+				Volumes:   &Ec2ResourceSummary{ByType: map[string]*Ec2SummaryItem{}},
+				Snapshots: &Ec2ResourceSummary{},
+				Images:    &Ec2ResourceSummary{},
+			}
+			groups[value] = g
+		}
+
+		return g
+	}
+
+	unpriced := map[string]bool{}
+	warn := func(msg string) {
+		if !unpriced[msg] {
+			unpriced[msg] = true
+			out.Warnings = append(out.Warnings, msg)
+		}
+	}
+
+	for _, i := range instances {
+		g := group(i.Tags)
+		instanceType := aws.StringValue(i.InstanceType)
+		state := instanceStateName(i)
+
+		var cost float64
+		if state == ec2.InstanceStateNameRunning {
+			if hourly, ok := instanceHourly(catalog, region, instanceType); ok {
+				cost = hourly * pricing.HoursPerMonth
+			} else {
+				warn(fmt.Sprintf("no price for instance type %s", instanceType))
+			}
+		}
+
+		g.Instances.add(instanceType, 0, cost)
+		g.Instances.ByState[state]++
+	}
+
+	for _, v := range volumes {
+		g := group(v.Tags)
+		volumeType := aws.StringValue(v.VolumeType)
+		size := aws.Int64Value(v.Size)
+
+		var cost float64
+		if catalog != nil {
+			c, ok := catalog.VolumeMonthly(region, volumeType, size, aws.Int64Value(v.Iops), aws.Int64Value(v.Throughput))
+			if !ok {
+				warn(fmt.Sprintf("no price for volume type %s", volumeType))
+			}
+			cost = c
+		}
+
+		g.Volumes.add(volumeType, size, cost)
+	}
+
+	for _, s := range snapshots {
+		g := group(s.Tags)
+		size := aws.Int64Value(s.VolumeSize)
+
+		var cost float64
+		if catalog != nil {
+			cost, _ = catalog.SnapshotMonthly(region, size)
+		}
+
+		g.Snapshots.add("", size, cost)
+	}
+
+	for _, i := range images {
+		var size int64
+		for _, bd := range i.BlockDeviceMappings {
+			if bd.Ebs != nil {
+				size += aws.Int64Value(bd.Ebs.VolumeSize)
+			}
+		}
+
+		group(i.Tags).Images.add("", size, 0)
+	}
+
+	for _, g := range groups {
+		for _, r := range []*Ec2ResourceSummary{&g.Instances.Ec2ResourceSummary, g.Volumes, g.Snapshots, g.Images} {
+			r.round()
+			g.MonthlyCost += r.MonthlyCost
+		}
+
+		g.MonthlyCost = roundCost(g.MonthlyCost)
+		out.MonthlyCost += g.MonthlyCost
+		out.Groups = append(out.Groups, g)
+	}
+	out.MonthlyCost = roundCost(out.MonthlyCost)
+
+	// resources without the tag are listed last
+	sort.Slice(out.Groups, func(i, j int) bool {
+		a, b := out.Groups[i].Value, out.Groups[j].Value
+		if a == "" || b == "" {
+			return a != ""
+		}
+		return a < b
+	})
+
+	return out
+}
+
+// instanceHourly returns the hourly price of an instance type, and false if there's no price
+func instanceHourly(catalog *pricing.Catalog, region, instanceType string) (float64, bool) {
+	if catalog == nil {
+		return 0, false
+	}
+	return catalog.InstanceHourly(region, instanceType)
+}
+
+// add counts a resource of a type in the summary, resources without a type aren't counted by type
+func (r *Ec2ResourceSummary) add(resourceType string, size int64, cost float64) {
+	r.Count++
+	r.SizeGB += size
+	r.MonthlyCost += cost
+
+	if r.ByType == nil || resourceType == "" {
+		return
+	}
+
+	item, ok := r.ByType[resourceType]
+	if !ok {
+		item = &Ec2SummaryItem{}
+		r.ByType[resourceType] = item
+	}
+
+	item.Count++
+	item.SizeGB += size
+	item.MonthlyCost += cost
+}
+
+// round rounds the costs of a summary to cents
+func (r *Ec2ResourceSummary) round() {
+	r.MonthlyCost = roundCost(r.MonthlyCost)
+	for _, item := range r.ByType {
+		item.MonthlyCost = roundCost(item.MonthlyCost)
+	}
+}
+
+// summaryCSV flattens a summary into CSV records with a row for each instance and volume type, snapshots and images
+// of each group
+func summaryCSV(s *Ec2SummaryResponse) [][]string {
+	groupColumn := s.GroupBy
+	if groupColumn == "" {
+		groupColumn = "group"
+	}
+
+	records := [][]string{append([]string{groupColumn}, summaryCSVHeader...)}
+	row := func(g *Ec2SummaryGroup, resource, resourceType string, count int, size int64, cost float64) {
+		records = append(records, []string{
+			g.Value,
+			resource,
+			resourceType,
+			strconv.Itoa(count),
+			strconv.FormatInt(size, 10),
+			strconv.FormatFloat(cost, 'f', 2, 64),
+		})
+	}
+
+	for _, g := range s.Groups {
+		for _, r := range []struct {
+			name    string
+			summary *Ec2ResourceSummary
+		}{
+			{"instance", &g.Instances.Ec2ResourceSummary},
+			{"volume", g.Volumes},
+		} {
+			types := make([]string, 0, len(r.summary.ByType))
+			for t := range r.summary.ByType {
+				types = append(types, t)
+			}
+			sort.Strings(types)
+
+			for _, t := range types {
+				item := r.summary.ByType[t]
+				row(g, r.name, t, item.Count, item.SizeGB, item.MonthlyCost)
+			}
+		}
+
+		if g.Snapshots.Count > 0 {
+			row(g, "snapshot", "", g.Snapshots.Count, g.Snapshots.SizeGB, g.Snapshots.MonthlyCost)
+		}
+
+		if g.Images.Count > 0 {
+			row(g, "image", "", g.Images.Count, g.Images.SizeGB, g.Images.MonthlyCost)
+		}
+	}
+
+	return records
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/YaleSpinup/ec2-api/pricing"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func Test_summarizeResources(t *testing.T) {
+	catalog, err := pricing.Parse([]byte(`{
+  "regions": {
+    "us-east-1": {
+      "instances": { "m5.large": 0.096 },
+      "volumes": { "gp2": { "gb_month": 0.10 }, "gp3": { "gb_month": 0.08, "iops_month": 0.005, "included_iops": 3000 } },
+      "snapshot_gb_month": 0.05
+    }
+  }
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tags := func(value string) []*ec2.Tag {
+		if value == "" {
+			return []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String("untagged")}}
+		}
+		return []*ec2.Tag{{Key: aws.String("ChargingAccount"), Value: aws.String(value)}}
+	}
+
+	instances := []*ec2.Instance{
+		{InstanceType: aws.String("m5.large"), State: &ec2.InstanceState{Name: aws.String("running")}, Tags: tags("chem")},
+		{InstanceType: aws.String("m5.large"), State: &ec2.InstanceState{Name: aws.String("stopped")}, Tags: tags("chem")},
+		{InstanceType: aws.String("x9.huge"), State: &ec2.InstanceState{Name: aws.String("running")}, Tags: tags("")},
+	}
+	volumes := []*ec2.Volume{
+		{VolumeType: aws.String("gp3"), Size: aws.Int64(100), Iops: aws.Int64(3000), Tags: tags("chem")},
+		{VolumeType: aws.String("gp2"), Size: aws.Int64(50), Tags: tags("bio")},
+	}
+	snapshots := []*ec2.Snapshot{
+		{VolumeSize: aws.Int64(100), Tags: tags("chem")},
+	}
+	images := []*ec2.Image{
+		{
+			Tags: tags("bio"),
+			BlockDeviceMappings: []*ec2.BlockDeviceMapping{
+				{Ebs: &ec2.EbsBlockDevice{VolumeSize: aws.Int64(8)}},
+				{VirtualName: aws.String("ephemeral0")},
+			},
+		},
+	}
+
+	want := &Ec2SummaryResponse{
+		GroupBy:     "ChargingAccount",
+		Region:      "us-east-1",
+		Currency:    "USD",
+		MonthlyCost: 88.08,
+		Groups: []*Ec2SummaryGroup{
+			{
+				Value:       "bio",
+				MonthlyCost: 5,
+				Instances: &Ec2InstanceSummary{
+					Ec2ResourceSummary: Ec2ResourceSummary{ByType: map[string]*Ec2SummaryItem{}},
+					ByState:            map[string]int{},
+				},
+				Volumes: &Ec2ResourceSummary{
+					Count:       1,
+					SizeGB:      50,
+					MonthlyCost: 5,
+					ByType:      map[string]*Ec2SummaryItem{"gp2": {Count: 1, SizeGB: 50, MonthlyCost: 5}},
+				},
+				Snapshots: &Ec2ResourceSummary{},
+				Images:    &Ec2ResourceSummary{Count: 1, SizeGB: 8},
+			},
+			{
+				Value:       "chem",
+				MonthlyCost: 83.08,
+				Instances: &Ec2InstanceSummary{
+					Ec2ResourceSummary: Ec2ResourceSummary{
+						Count:       2,
+						MonthlyCost: 70.08,
+						ByType:      map[string]*Ec2SummaryItem{"m5.large": {Count: 2, MonthlyCost: 70.08}},
+					},
+					ByState: map[string]int{"running": 1, "stopped": 1},
+				},
+				Volumes: &Ec2ResourceSummary{
+					Count:       1,
+					SizeGB:      100,
+					MonthlyCost: 8,
+					ByType:      map[string]*Ec2SummaryItem{"gp3": {Count: 1, SizeGB: 100, MonthlyCost: 8}},
+				},
+				Snapshots: &Ec2ResourceSummary{Count: 1, SizeGB: 100, MonthlyCost: 5},
+				Images:    &Ec2ResourceSummary{},
+			},
+			{
+				Value: "",
+				Instances: &Ec2InstanceSummary{
+					Ec2ResourceSummary: Ec2ResourceSummary{
+						Count:  1,
+						ByType: map[string]*Ec2SummaryItem{"x9.huge": {Count: 1}},
+					},
+					ByState: map[string]int{"running": 1},
+				},
+				Volumes:   &Ec2ResourceSummary{ByType: map[string]*Ec2SummaryItem{}},
+				Snapshots: &Ec2ResourceSummary{},
+				Images:    &Ec2ResourceSummary{},
+			},
+		},
+		Warnings: []string{"no price for instance type x9.huge"},
+	}
+
+	got := summarizeResources(catalog, "us-east-1", "ChargingAccount", instances, volumes, snapshots, images)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("summarizeResources() = %s, want %s", awsutil.Prettify(got), awsutil.Prettify(want))
+	}
+
+	wantCSV := [][]string{
+		{"ChargingAccount", "resource", "type", "count", "size_gb", "monthly_cost"},
+		{"bio", "volume", "gp2", "1", "50", "5.00"},
+		{"bio", "image", "", "1", "8", "0.00"},
+		{"chem", "instance", "m5.large", "2", "0", "70.08"},
+		{"chem", "volume", "gp3", "1", "100", "8.00"},
+		{"chem", "snapshot", "", "1", "100", "5.00"},
+		{"", "instance", "x9.huge", "1", "0", "0.00"},
+	}
+
+	if records := summaryCSV(got); !reflect.DeepEqual(records, wantCSV) {
+		t.Errorf("summaryCSV() = %v, want %v", records, wantCSV)
+	}
+}
+
+func Test_summarizeResources_ungrouped(t *testing.T) {
+	instances := []*ec2.Instance{
+		{InstanceType: aws.String("m5.large"), State: &ec2.InstanceState{Name: aws.String("running")}, Tags: []*ec2.Tag{{Key: aws.String("ChargingAccount"), Value: aws.String("chem")}}},
+		{InstanceType: aws.String("m5.large"), State: &ec2.InstanceState{Name: aws.String("running")}},
+	}
+
+	got := summarizeResources(nil, "us-east-1", "", instances, nil, nil, nil)
+	if len(got.Groups) != 1 || got.Groups[0].Value != "" || got.Groups[0].Instances.Count != 2 {
+		t.Errorf("summarizeResources() = %s, want a single group of 2 instances", awsutil.Prettify(got))
+	}
+
+	if records := summaryCSV(got); records[0][0] != "group" {
+		t.Errorf("summaryCSV() header = %v, want group as the first column", records[0])
+	}
+}
@@ -0,0 +1,422 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/ec2-api/pricing"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultMigrationConcurrency = 5
+	maxMigrationConcurrency     = 20
+)
+
+// migrationPollInterval is how often in flight volume migrations are refreshed
+var migrationPollInterval = 30 * time.Second
+
+// migrationTimeout is the maximum amount of time a volume migration will be tracked
+var migrationTimeout = 24 * time.Hour
+
+// supportedVolumeMigrations maps the supported source volume types to their target volume types
+var supportedVolumeMigrations = map[string]string{
+	"gp2": "gp3",
+	"io1": "io2",
+}
+
+// volumeMigration holds the state of a volume migration, it is safe for concurrent use
+type volumeMigration struct {
+	mu       sync.RWMutex
+	response *Ec2VolumeMigrationResponse
+}
+
+// snapshot returns a copy of the current state of the migration
+func (m *volumeMigration) snapshot() *Ec2VolumeMigrationResponse {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	out := *m.response
+	out.Volumes = make([]*Ec2VolumeMigrationItem, 0, len(m.response.Volumes))
+	for _, v := range m.response.Volumes {
+		item := *v
+		out.Volumes = append(out.Volumes, &item)
+	}
+
+	return &out
+}
+
+// update runs the given function with the migration locked for writing and recomputes the summary
+func (m *volumeMigration) update(f func(r *Ec2VolumeMigrationResponse)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f(m.response)
+	summarizeVolumeMigration(m.response)
+	m.response.UpdatedAt = timeFormat(aws.Time(time.Now()))
+}
+
+// planVolumeMigration selects the volumes to be migrated and computes the target performance and cost for each
+func (o *ec2Orchestrator) planVolumeMigration(ctx context.Context, req *Ec2VolumeMigrationRequest) (*Ec2VolumeMigrationResponse, error) {
+	if req == nil || req.SourceType == nil || req.TargetType == nil {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Debugf("got request to plan volume migration: %s", awsutil.Prettify(req))
+
+	sourceType, targetType := aws.StringValue(req.SourceType), aws.StringValue(req.TargetType)
+	if t, ok := supportedVolumeMigrations[sourceType]; !ok || t != targetType {
+		msg := fmt.Sprintf("unsupported volume migration from %s to %s, supported migrations are gp2 to gp3 and io1 to io2", sourceType, targetType)
+		return nil, apierror.New(apierror.ErrBadRequest, msg, nil)
+	}
+
+	filters := []*ec2.Filter{
+		{
+			Name:   aws.String("volume-type"),
+			Values: aws.StringSlice([]string{sourceType}),
+		},
+		{
+			Name:   aws.String("status"),
+			Values: aws.StringSlice([]string{"available", "in-use"}),
+		},
+	}
+
+	if len(req.VolumeIds) > 0 {
+		filters = append(filters, &ec2.Filter{
+			Name:   aws.String("volume-id"),
+			Values: aws.StringSlice(req.VolumeIds),
+		})
+	}
+
+	for k, v := range req.Tags {
+		filters = append(filters, &ec2.Filter{
+			Name:   aws.String("tag:" + k),
+			Values: aws.StringSlice([]string{v}),
+		})
+	}
+
+	org := o.server.org
+	if req.Org != nil {
+		org = aws.StringValue(req.Org)
+	}
+
+	volumes, err := o.ec2Client.ListVolumeDetails(ctx, org, filters...)
+	if err != nil {
+		return nil, err
+	}
+
+	now := timeFormat(aws.Time(time.Now()))
+	response := &Ec2VolumeMigrationResponse{
+		ID:         uuid.New().String(),
+		Status:     "planned",
+		DryRun:     aws.BoolValue(req.DryRun),
+		SourceType: sourceType,
+		TargetType: targetType,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		Volumes:    make([]*Ec2VolumeMigrationItem, 0, len(volumes)),
+	}
+
+	for _, v := range volumes {
+		response.Volumes = append(response.Volumes, volumeMigrationItem(o.server.prices, o.server.pricesRegion, v, targetType))
+	}
+
+	sort.Slice(response.Volumes, func(i, j int) bool {
+		return response.Volumes[i].VolumeId < response.Volumes[j].VolumeId
+	})
+
+	summarizeVolumeMigration(response)
+
+	return response, nil
+}
+
+// submitVolumeMigration submits the ModifyVolume calls for a planned migration, limiting the number of concurrent calls
+func (o *ec2Orchestrator) submitVolumeMigration(ctx context.Context, m *volumeMigration, concurrency int) {
+	plan := m.snapshot()
+
+	log.Infof("submitting volume migration %s for %d volumes with concurrency %d", plan.ID, len(plan.Volumes), concurrency)
+
+	m.update(func(r *Ec2VolumeMigrationResponse) {
+		r.Status = "in_progress"
+	})
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, item := range plan.Volumes {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(i int, item *Ec2VolumeMigrationItem) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			input := &ec2.ModifyVolumeInput{
+				VolumeId:   aws.String(item.VolumeId),
+				VolumeType: aws.String(item.TargetType),
+				Iops:       aws.Int64(item.TargetIops),
+			}
+
+			if item.TargetThroughput > 0 {
+				input.Throughput = aws.Int64(item.TargetThroughput)
+			}
+
+			out, err := o.ec2Client.ModifyVolume(ctx, input)
+			m.update(func(r *Ec2VolumeMigrationResponse) {
+				v := r.Volumes[i]
+				if err != nil {
+					log.Errorf("failed to submit migration for volume %s: %s", item.VolumeId, err)
+					v.ModificationState = ec2.VolumeModificationStateFailed
+					v.Error = err.Error()
+					return
+				}
+
+				v.ModificationState = aws.StringValue(out.ModificationState)
+				v.Progress = aws.Int64Value(out.Progress)
+				v.StatusMessage = aws.StringValue(out.StatusMessage)
+			})
+		}(i, item)
+	}
+
+	wg.Wait()
+}
+
+// refreshVolumeMigration updates the state of each in flight volume modification and reports whether the migration is finished
+func (o *ec2Orchestrator) refreshVolumeMigration(ctx context.Context, m *volumeMigration) bool {
+	current := m.snapshot()
+
+	done := true
+	for i, item := range current.Volumes {
+		if volumeModificationFinished(item.ModificationState) {
+			continue
+		}
+
+		modifications, err := o.ec2Client.ListVolumeModifications(ctx, item.VolumeId)
+		if err != nil {
+			log.Warnf("failed to list modifications for volume %s: %s", item.VolumeId, err)
+			done = false
+			continue
+		}
+
+		latest := latestVolumeModification(modifications)
+		if latest == nil {
+			done = false
+			continue
+		}
+
+		m.update(func(r *Ec2VolumeMigrationResponse) {
+			v := r.Volumes[i]
+			v.ModificationState = aws.StringValue(latest.ModificationState)
+			v.Progress = aws.Int64Value(latest.Progress)
+			v.StatusMessage = aws.StringValue(latest.StatusMessage)
+		})
+
+		if !volumeModificationFinished(aws.StringValue(latest.ModificationState)) {
+			done = false
+		}
+	}
+
+	if done {
+		m.update(func(r *Ec2VolumeMigrationResponse) {
+			r.Status = "completed"
+			if r.Summary.Failed > 0 {
+				r.Status = "completed_with_errors"
+			}
+		})
+	}
+
+	return done
+}
+
+// trackVolumeMigration submits the volume modifications of a migration then polls them until they are finished or the
+// migration times out
+func (s *server) trackVolumeMigration(sp *sessionParams, m *volumeMigration, concurrency int) {
+	ctx, cancel := context.WithTimeout(s.context, migrationTimeout)
+	defer cancel()
+
+	id := m.snapshot().ID
+
+	orch, err := s.newEc2Orchestrator(ctx, sp)
+	if err != nil {
+		log.Errorf("failed to initialize orchestrator for volume migration %s: %s", id, err)
+		m.update(func(r *Ec2VolumeMigrationResponse) {
+			r.Status = "failed"
+		})
+		return
+	}
+
+	orch.submitVolumeMigration(ctx, m, concurrency)
+
+	ticker := time.NewTicker(migrationPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Warnf("stopped tracking volume migration %s: %s", id, ctx.Err())
+			m.update(func(r *Ec2VolumeMigrationResponse) {
+				r.Status = "tracking_stopped"
+			})
+			return
+		case <-ticker.C:
+			// the orchestrator is recreated on each poll since the assumed role session expires
+			orch, err := s.newEc2Orchestrator(ctx, sp)
+			if err != nil {
+				log.Warnf("failed to initialize orchestrator for volume migration %s: %s", id, err)
+				continue
+			}
+
+			if orch.refreshVolumeMigration(ctx, m) {
+				log.Infof("volume migration %s finished", id)
+				return
+			}
+		}
+	}
+}
+
+// volumeMigrationItem computes the migration plan for a single volume, with its costs from the price catalog
+func volumeMigrationItem(catalog *pricing.Catalog, region string, v *ec2.Volume, targetType string) *Ec2VolumeMigrationItem {
+	size := aws.Int64Value(v.Size)
+	sourceType := aws.StringValue(v.VolumeType)
+
+	iops, throughput := aws.Int64Value(v.Iops), aws.Int64Value(v.Throughput)
+	if sourceType == "gp2" {
+		iops, throughput = gp2Performance(size)
+	}
+
+	targetIops, targetThroughput := migrationTargetPerformance(targetType, size, iops, throughput)
+
+	return &Ec2VolumeMigrationItem{
+		VolumeId:           aws.StringValue(v.VolumeId),
+		Size:               size,
+		OriginalType:       sourceType,
+		OriginalIops:       iops,
+		OriginalThroughput: throughput,
+		TargetType:         targetType,
+		TargetIops:         targetIops,
+		TargetThroughput:   targetThroughput,
+		CurrentMonthlyCost: roundCost(volumeMonthlyCost(catalog, region, sourceType, size, iops, throughput)),
+		TargetMonthlyCost:  roundCost(volumeMonthlyCost(catalog, region, targetType, size, targetIops, targetThroughput)),
+	}
+}
+
+// gp2Performance returns the baseline iops and maximum throughput (MiB/s) of a gp2 volume of the given size (GiB)
+func gp2Performance(size int64) (int64, int64) {
+	iops := size * 3
+	if iops < 100 {
+		iops = 100
+	}
+
+	if iops > 16000 {
+		iops = 16000
+	}
+
+	throughput := int64(250)
+	if size <= 170 {
+		throughput = 128
+	}
+
+	return iops, throughput
+}
+
+// migrationTargetPerformance returns the iops and throughput to provision on the target volume type so
+// that performance doesn't regress from the source volume
+func migrationTargetPerformance(targetType string, size, iops, throughput int64) (int64, int64) {
+	switch targetType {
+	case "gp3":
+		// gp3 includes 3000 iops and 125 MiB/s at no additional cost
+		targetIops := iops
+		if targetIops < 3000 {
+			targetIops = 3000
+		}
+
+		if targetIops > 16000 {
+			targetIops = 16000
+		}
+
+		// gp3 supports a maximum of 500 iops per GiB above the baseline
+		if targetIops > 3000 && targetIops > size*500 {
+			targetIops = size * 500
+		}
+
+		targetThroughput := throughput
+		if targetThroughput < 125 {
+			targetThroughput = 125
+		}
+
+		// gp3 supports a maximum of 0.25 MiB/s per provisioned iops, up to 1000 MiB/s
+		if targetThroughput > targetIops/4 {
+			targetThroughput = targetIops / 4
+		}
+
+		if targetThroughput > 1000 {
+			targetThroughput = 1000
+		}
+
+		return targetIops, targetThroughput
+	case "io2":
+		return iops, 0
+	default:
+		return iops, throughput
+	}
+}
+
+// summarizeVolumeMigration recomputes the migration summary from the volume items
+func summarizeVolumeMigration(r *Ec2VolumeMigrationResponse) {
+	summary := Ec2VolumeMigrationSummary{
+		Total: len(r.Volumes),
+	}
+
+	for _, v := range r.Volumes {
+		summary.CurrentMonthlyCost += v.CurrentMonthlyCost
+		summary.TargetMonthlyCost += v.TargetMonthlyCost
+
+		switch v.ModificationState {
+		case "":
+		case ec2.VolumeModificationStateFailed:
+			summary.Failed++
+		case ec2.VolumeModificationStateCompleted:
+			summary.Submitted++
+			summary.Completed++
+		default:
+			summary.Submitted++
+		}
+	}
+
+	summary.CurrentMonthlyCost = roundCost(summary.CurrentMonthlyCost)
+	summary.TargetMonthlyCost = roundCost(summary.TargetMonthlyCost)
+	summary.EstimatedMonthlySavings = roundCost(summary.CurrentMonthlyCost - summary.TargetMonthlyCost)
+
+	r.Summary = summary
+}
+
+// volumeModificationFinished returns true if the modification state is terminal
+func volumeModificationFinished(state string) bool {
+	return state == ec2.VolumeModificationStateCompleted || state == ec2.VolumeModificationStateFailed
+}
+
+// latestVolumeModification returns the most recently started volume modification
+func latestVolumeModification(modifications []*ec2.VolumeModification) *ec2.VolumeModification {
+	var latest *ec2.VolumeModification
+	for _, m := range modifications {
+		if latest == nil || aws.TimeValue(m.StartTime).After(aws.TimeValue(latest.StartTime)) {
+			latest = m
+		}
+	}
+	return latest
+}
+
+// roundCost rounds a cost to cents
+func roundCost(c float64) float64 {
+	return math.Round(c*100) / 100
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/YaleSpinup/ec2-api/pricing"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func Test_gp2Performance(t *testing.T) {
+	tests := []struct {
+		name           string
+		size           int64
+		wantIops       int64
+		wantThroughput int64
+	}{
+		{name: "small volume", size: 8, wantIops: 100, wantThroughput: 128},
+		{name: "medium volume", size: 170, wantIops: 510, wantThroughput: 128},
+		{name: "large volume", size: 1000, wantIops: 3000, wantThroughput: 250},
+		{name: "max iops volume", size: 16384, wantIops: 16000, wantThroughput: 250},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			iops, throughput := gp2Performance(tt.size)
+			if iops != tt.wantIops {
+				t.Errorf("gp2Performance() iops = %v, want %v", iops, tt.wantIops)
+			}
+			if throughput != tt.wantThroughput {
+				t.Errorf("gp2Performance() throughput = %v, want %v", throughput, tt.wantThroughput)
+			}
+		})
+	}
+}
+
+func Test_migrationTargetPerformance(t *testing.T) {
+	type args struct {
+		targetType string
+		size       int64
+		iops       int64
+		throughput int64
+	}
+	tests := []struct {
+		name           string
+		args           args
+		wantIops       int64
+		wantThroughput int64
+	}{
+		{
+			name:           "small gp2 to gp3 uses baseline",
+			args:           args{targetType: "gp3", size: 8, iops: 100, throughput: 128},
+			wantIops:       3000,
+			wantThroughput: 128,
+		},
+		{
+			name:           "large gp2 to gp3 keeps iops and throughput",
+			args:           args{targetType: "gp3", size: 2000, iops: 6000, throughput: 250},
+			wantIops:       6000,
+			wantThroughput: 250,
+		},
+		{
+			name:           "io1 to io2 keeps iops",
+			args:           args{targetType: "io2", size: 100, iops: 5000},
+			wantIops:       5000,
+			wantThroughput: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			iops, throughput := migrationTargetPerformance(tt.args.targetType, tt.args.size, tt.args.iops, tt.args.throughput)
+			if iops != tt.wantIops {
+				t.Errorf("migrationTargetPerformance() iops = %v, want %v", iops, tt.wantIops)
+			}
+			if throughput != tt.wantThroughput {
+				t.Errorf("migrationTargetPerformance() throughput = %v, want %v", throughput, tt.wantThroughput)
+			}
+		})
+	}
+}
+
+func Test_volumeMigrationItem(t *testing.T) {
+	catalog, err := pricing.Parse(testPrices)
+	if err != nil {
+		t.Fatalf("failed to parse test prices: %s", err)
+	}
+
+	volume := &ec2.Volume{
+		VolumeId:   aws.String("vol-0123456789abcdef0"),
+		VolumeType: aws.String("gp2"),
+		Size:       aws.Int64(100),
+		Iops:       aws.Int64(300),
+	}
+
+	want := &Ec2VolumeMigrationItem{
+		VolumeId:           "vol-0123456789abcdef0",
+		Size:               100,
+		OriginalType:       "gp2",
+		OriginalIops:       300,
+		OriginalThroughput: 128,
+		TargetType:         "gp3",
+		TargetIops:         3000,
+		TargetThroughput:   128,
+		CurrentMonthlyCost: 10,
+		TargetMonthlyCost:  8.12,
+	}
+
+	if got := volumeMigrationItem(catalog, "us-east-1", volume, "gp3"); !reflect.DeepEqual(got, want) {
+		t.Errorf("volumeMigrationItem() = %+v, want %+v", got, want)
+	}
+}
+
+func Test_summarizeVolumeMigration(t *testing.T) {
+	r := &Ec2VolumeMigrationResponse{
+		Volumes: []*Ec2VolumeMigrationItem{
+			{VolumeId: "vol-1", CurrentMonthlyCost: 10, TargetMonthlyCost: 8, ModificationState: "completed"},
+			{VolumeId: "vol-2", CurrentMonthlyCost: 20, TargetMonthlyCost: 16, ModificationState: "optimizing"},
+			{VolumeId: "vol-3", CurrentMonthlyCost: 5, TargetMonthlyCost: 4, ModificationState: "failed"},
+			{VolumeId: "vol-4", CurrentMonthlyCost: 1, TargetMonthlyCost: 0.8},
+		},
+	}
+
+	summarizeVolumeMigration(r)
+
+	want := Ec2VolumeMigrationSummary{
+		Total:                   4,
+		Submitted:               2,
+		Completed:               1,
+		Failed:                  1,
+		CurrentMonthlyCost:      36,
+		TargetMonthlyCost:       28.8,
+		EstimatedMonthlySavings: 7.2,
+	}
+
+	if !reflect.DeepEqual(r.Summary, want) {
+		t.Errorf("summarizeVolumeMigration() = %+v, want %+v", r.Summary, want)
+	}
+}
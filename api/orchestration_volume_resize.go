@@ -0,0 +1,361 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	log "github.com/sirupsen/logrus"
+)
+
+// resizePollInterval is how often the volume modification and resize command are polled
+var resizePollInterval = 5 * time.Second
+
+// resizeTimeout is the maximum amount of time a volume resize will be tracked
+var resizeTimeout = 30 * time.Minute
+
+var (
+	volumeIdRegexp    = regexp.MustCompile(`^vol-[0-9a-f]+$`)
+	devicePathRegexp  = regexp.MustCompile(`^/dev/[a-z0-9]+$`)
+	mountPointRegexp  = regexp.MustCompile(`^/[A-Za-z0-9._/-]*$`)
+	driveLetterRegexp = regexp.MustCompile(`^[A-Za-z]$`)
+)
+
+// volumeResize holds the state of a volume resize, it is safe for concurrent use
+type volumeResize struct {
+	mu       sync.RWMutex
+	response *Ec2VolumeResizeResponse
+}
+
+// snapshot returns a copy of the current state of the resize
+func (v *volumeResize) snapshot() *Ec2VolumeResizeResponse {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	out := *v.response
+	return &out
+}
+
+// update runs the given function with the resize locked for writing
+func (v *volumeResize) update(f func(r *Ec2VolumeResizeResponse)) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	f(v.response)
+	v.response.UpdatedAt = timeFormat(aws.Time(time.Now()))
+}
+
+// fail marks the resize as failed with the given error
+func (v *volumeResize) fail(err error) {
+	log.Errorf("volume resize %s failed: %s", v.snapshot().ID, err)
+	v.update(func(r *Ec2VolumeResizeResponse) {
+		r.Status = "failed"
+		r.Error = err.Error()
+	})
+}
+
+// prepareVolumeResize validates the resize request against the volume and the instance it's attached to and returns the initial resize state
+func (o *ec2Orchestrator) prepareVolumeResize(ctx context.Context, id string, req *Ec2VolumeResizeRequest) (*Ec2VolumeResizeResponse, error) {
+	if id == "" || req == nil || req.Size == nil {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	if !volumeIdRegexp.MatchString(id) {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid volume id", nil)
+	}
+
+	if req.MountPoint != nil && !mountPointRegexp.MatchString(aws.StringValue(req.MountPoint)) {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid mount_point", nil)
+	}
+
+	if req.DriveLetter != nil && !driveLetterRegexp.MatchString(strings.TrimSuffix(aws.StringValue(req.DriveLetter), ":")) {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid drive_letter", nil)
+	}
+
+	volumes, err := o.ec2Client.GetVolume(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(volumes) != 1 {
+		return nil, apierror.New(apierror.ErrNotFound, "volume not found", nil)
+	}
+	volume := volumes[0]
+
+	if aws.Int64Value(req.Size) <= aws.Int64Value(volume.Size) {
+		msg := fmt.Sprintf("size must be larger than the current volume size (%d)", aws.Int64Value(volume.Size))
+		return nil, apierror.New(apierror.ErrBadRequest, msg, nil)
+	}
+
+	var attachment *ec2.VolumeAttachment
+	for _, a := range volume.Attachments {
+		if aws.StringValue(a.State) == ec2.VolumeAttachmentStateAttached {
+			if attachment != nil {
+				return nil, apierror.New(apierror.ErrBadRequest, "resizing multi-attached volumes is not supported", nil)
+			}
+			attachment = a
+		}
+	}
+
+	if attachment == nil {
+		return nil, apierror.New(apierror.ErrBadRequest, "volume must be attached to an instance to grow the filesystem", nil)
+	}
+
+	instance, err := o.ec2Client.GetInstance(ctx, aws.StringValue(attachment.InstanceId))
+	if err != nil {
+		return nil, err
+	}
+
+	var state string
+	if instance.State != nil {
+		state = aws.StringValue(instance.State.Name)
+	}
+
+	if state != ec2.InstanceStateNameRunning {
+		msg := fmt.Sprintf("instance %s must be running to grow the filesystem (state: %s)", aws.StringValue(instance.InstanceId), state)
+		return nil, apierror.New(apierror.ErrBadRequest, msg, nil)
+	}
+
+	platform := "linux"
+	if strings.EqualFold(aws.StringValue(instance.Platform), "windows") {
+		platform = "windows"
+	}
+
+	now := timeFormat(aws.Time(time.Now()))
+	return &Ec2VolumeResizeResponse{
+		VolumeId:     id,
+		InstanceId:   aws.StringValue(attachment.InstanceId),
+		Device:       aws.StringValue(attachment.Device),
+		Platform:     platform,
+		Status:       "modifying",
+		OriginalSize: aws.Int64Value(volume.Size),
+		TargetSize:   aws.Int64Value(req.Size),
+		MountPoint:   aws.StringValue(req.MountPoint),
+		DriveLetter:  strings.ToUpper(strings.TrimSuffix(aws.StringValue(req.DriveLetter), ":")),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}, nil
+}
+
+// waitForVolumeOptimizing polls the modifications for a volume until the latest one reaches the optimizing or completed state
+func (o *ec2Orchestrator) waitForVolumeOptimizing(ctx context.Context, v *volumeResize) error {
+	id := v.snapshot().VolumeId
+
+	for {
+		modifications, err := o.ec2Client.ListVolumeModifications(ctx, id)
+		if err != nil {
+			log.Warnf("failed to list modifications for volume %s: %s", id, err)
+		}
+
+		if latest := latestVolumeModification(modifications); latest != nil {
+			state := aws.StringValue(latest.ModificationState)
+			v.update(func(r *Ec2VolumeResizeResponse) {
+				r.ModificationState = state
+			})
+
+			switch state {
+			case ec2.VolumeModificationStateOptimizing, ec2.VolumeModificationStateCompleted:
+				return nil
+			case ec2.VolumeModificationStateFailed:
+				return apierror.New(apierror.ErrInternalError, "volume modification failed: "+aws.StringValue(latest.StatusMessage), nil)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(resizePollInterval):
+		}
+	}
+}
+
+// growFilesystem sends the command to grow the partition and filesystem on the instance and waits for it to finish
+func (o *ssmOrchestrator) growFilesystem(ctx context.Context, v *volumeResize) error {
+	resize := v.snapshot()
+
+	req := &SsmCommandRequest{
+		DocumentName:   "AWS-RunShellScript",
+		TimeoutSeconds: aws.Int64(600),
+	}
+
+	var script string
+	if resize.Platform == "windows" {
+		req.DocumentName = "AWS-RunPowerShellScript"
+		script = windowsGrowFilesystemScript(resize.VolumeId, resize.DriveLetter)
+	} else {
+		if resize.Device != "" && !devicePathRegexp.MatchString(resize.Device) {
+			return apierror.New(apierror.ErrBadRequest, "unexpected device name "+resize.Device, nil)
+		}
+		script = linuxGrowFilesystemScript(resize.VolumeId, resize.Device, resize.MountPoint)
+	}
+
+	req.Parameters = map[string][]*string{
+		"commands": {aws.String(script)},
+	}
+
+	commandId, err := o.sendInstancesCommand(ctx, req, resize.InstanceId)
+	if err != nil {
+		return err
+	}
+
+	v.update(func(r *Ec2VolumeResizeResponse) {
+		r.Status = "growing_filesystem"
+		r.CommandId = commandId
+	})
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(resizePollInterval):
+		}
+
+		// the invocation may not exist immediately after the command is sent
+		out, err := o.ssmClient.GetCommandInvocation(ctx, resize.InstanceId, commandId)
+		if err != nil {
+			log.Warnf("failed to get command invocation %s for instance %s: %s", commandId, resize.InstanceId, err)
+			continue
+		}
+
+		status := aws.StringValue(out.Status)
+		v.update(func(r *Ec2VolumeResizeResponse) {
+			r.CommandStatus = status
+			r.Output = strings.TrimSpace(aws.StringValue(out.StandardOutputContent))
+		})
+
+		switch status {
+		case ssm.CommandInvocationStatusSuccess:
+			size, err := parseFilesystemSize(aws.StringValue(out.StandardOutputContent))
+			if err != nil {
+				return err
+			}
+
+			v.update(func(r *Ec2VolumeResizeResponse) {
+				r.FilesystemSize = size
+			})
+
+			return nil
+		case ssm.CommandInvocationStatusCancelled, ssm.CommandInvocationStatusTimedOut, ssm.CommandInvocationStatusFailed:
+			msg := fmt.Sprintf("filesystem resize command %s: %s", strings.ToLower(status), strings.TrimSpace(aws.StringValue(out.StandardErrorContent)))
+			return apierror.New(apierror.ErrInternalError, msg, nil)
+		}
+	}
+}
+
+// trackVolumeResize waits for the volume modification to reach optimizing then grows the filesystem on the attached instance
+func (s *server) trackVolumeResize(ec2Params, ssmParams *sessionParams, v *volumeResize) {
+	ctx, cancel := context.WithTimeout(s.context, resizeTimeout)
+	defer cancel()
+
+	ec2Orch, err := s.newEc2Orchestrator(ctx, ec2Params)
+	if err != nil {
+		v.fail(err)
+		return
+	}
+
+	if err := ec2Orch.waitForVolumeOptimizing(ctx, v); err != nil {
+		v.fail(err)
+		return
+	}
+
+	// a new orchestrator is used since waiting for the modification may outlive the first session
+	ssmOrch, err := s.newSSMOrchestrator(ctx, ssmParams)
+	if err != nil {
+		v.fail(err)
+		return
+	}
+
+	if err := ssmOrch.growFilesystem(ctx, v); err != nil {
+		v.fail(err)
+		return
+	}
+
+	v.update(func(r *Ec2VolumeResizeResponse) {
+		r.Status = "completed"
+	})
+
+	log.Infof("volume resize %s completed", v.snapshot().ID)
+}
+
+// linuxGrowFilesystemScript returns the shell script used to grow the partition and filesystem for the volume.  The disk is
+// found by the NVMe serial number (the volume id without the dash) or by the attachment device name on Xen based instances.
+// The last line of output is the filesystem size in bytes.
+func linuxGrowFilesystemScript(volumeId, device, mountPoint string) string {
+	serial := strings.Replace(volumeId, "-", "", 1)
+
+	return fmt.Sprintf(`set -e
+SERIAL="%s"
+DEVICE="%s"
+MOUNT="%s"
+DISK=$(lsblk -dno NAME,SERIAL | awk -v s="$SERIAL" '$2 == s { print $1 }')
+if [ -z "$DISK" ] && [ -n "$DEVICE" ]; then
+  DISK=$(basename "$(readlink -f "$DEVICE" 2>/dev/null || readlink -f "$(echo "$DEVICE" | sed 's|/dev/sd|/dev/xvd|')")")
+fi
+if [ -z "$DISK" ] || [ ! -b "/dev/$DISK" ]; then
+  echo "unable to find disk for $SERIAL" >&2
+  exit 1
+fi
+if [ -z "$MOUNT" ]; then
+  MOUNT=$(lsblk -lno MOUNTPOINT "/dev/$DISK" | grep -v '^$' | head -n 1)
+fi
+if [ -z "$MOUNT" ]; then
+  echo "no mounted filesystem found on /dev/$DISK" >&2
+  exit 1
+fi
+SOURCE=$(findmnt -no SOURCE --target "$MOUNT")
+FSTYPE=$(findmnt -no FSTYPE --target "$MOUNT")
+PART=$(basename "$SOURCE")
+if [ -f "/sys/class/block/$PART/partition" ]; then
+  growpart "/dev/$DISK" "$(cat /sys/class/block/$PART/partition)" || [ $? -eq 1 ]
+fi
+case "$FSTYPE" in
+  xfs) xfs_growfs -d "$MOUNT" ;;
+  ext2|ext3|ext4) resize2fs "$SOURCE" ;;
+  *) echo "unsupported filesystem type $FSTYPE" >&2; exit 1 ;;
+esac
+df -B1 --output=size "$MOUNT" | tail -n 1`, serial, device, mountPoint)
+}
+
+// windowsGrowFilesystemScript returns the PowerShell script used to grow the partition for the volume.  The disk is found by the
+// NVMe serial number (the volume id without the dash), unless a drive letter is given.  The last line of output is the volume size in bytes.
+func windowsGrowFilesystemScript(volumeId, driveLetter string) string {
+	serial := strings.Replace(volumeId, "-", "", 1)
+
+	return fmt.Sprintf(`$ErrorActionPreference = 'Stop'
+$serial = '%s'
+$letter = '%s'
+Update-HostStorageCache
+if (-not $letter) {
+  $disk = Get-Disk | Where-Object { $_.SerialNumber -and $_.SerialNumber.Trim() -like "$serial*" } | Select-Object -First 1
+  if (-not $disk) { throw "unable to find disk for $serial" }
+  $partition = Get-Partition -DiskNumber $disk.Number | Where-Object { $_.DriveLetter } | Sort-Object -Property Size -Descending | Select-Object -First 1
+  if (-not $partition) { throw "no partition with a drive letter found on disk $($disk.Number)" }
+  $letter = $partition.DriveLetter
+}
+$supported = Get-PartitionSupportedSize -DriveLetter $letter
+if ((Get-Partition -DriveLetter $letter).Size -lt $supported.SizeMax) {
+  Resize-Partition -DriveLetter $letter -Size $supported.SizeMax
+}
+(Get-Volume -DriveLetter $letter).Size`, serial, driveLetter)
+}
+
+// parseFilesystemSize parses the filesystem size in bytes from the last line of the resize command output
+func parseFilesystemSize(output string) (int64, error) {
+	lines := strings.Split(strings.TrimSpace(strings.ReplaceAll(output, "\r\n", "\n")), "\n")
+	last := strings.TrimSpace(lines[len(lines)-1])
+
+	size, err := strconv.ParseInt(last, 10, 64)
+	if err != nil {
+		return 0, apierror.New(apierror.ErrInternalError, "unable to determine filesystem size from command output", err)
+	}
+
+	return size, nil
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_parseFilesystemSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    int64
+		wantErr bool
+	}{
+		{name: "single line", output: "107374182400\n", want: 107374182400},
+		{name: "multi line", output: "CHANGED: partition=1 start=4096\nmeta-data=/dev/nvme1n1p1\n 214748364800 \n", want: 214748364800},
+		{name: "windows output", output: "\r\n214748364800\r\n", want: 214748364800},
+		{name: "empty output", output: "", wantErr: true},
+		{name: "non numeric", output: "resize2fs: Bad magic number", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFilesystemSize(tt.output)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseFilesystemSize() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("parseFilesystemSize() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_linuxGrowFilesystemScript(t *testing.T) {
+	got := linuxGrowFilesystemScript("vol-0123456789abcdef0", "/dev/sdf", "/data")
+
+	for _, want := range []string{`SERIAL="vol0123456789abcdef0"`, `DEVICE="/dev/sdf"`, `MOUNT="/data"`, "growpart", "xfs_growfs", "resize2fs", "df -B1"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("linuxGrowFilesystemScript() missing %q", want)
+		}
+	}
+}
+
+func Test_windowsGrowFilesystemScript(t *testing.T) {
+	got := windowsGrowFilesystemScript("vol-0123456789abcdef0", "D")
+
+	for _, want := range []string{"$serial = 'vol0123456789abcdef0'", "$letter = 'D'", "Get-PartitionSupportedSize", "Resize-Partition"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("windowsGrowFilesystemScript() missing %q", want)
+		}
+	}
+}
@@ -33,6 +33,8 @@ func (o *ec2Orchestrator) createVolume(ctx context.Context, req *Ec2VolumeCreate
 		return "", err
 	}
 
+	o.notify(webhookVolumeCreated, aws.StringValue(out.VolumeId))
+
 	return aws.StringValue(out.VolumeId), nil
 }
 
@@ -47,6 +49,8 @@ func (o *ec2Orchestrator) deleteVolume(ctx context.Context, id string) error {
 		return err
 	}
 
+	o.notify(webhookVolumeDeleted, id)
+
 	return nil
 }
 
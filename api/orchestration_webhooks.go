@@ -0,0 +1,448 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/YaleSpinup/ec2-api/common"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// types of resource lifecycle events sent to webhooks
+const (
+	webhookInstanceCreated    = "instance.created"
+	webhookInstanceTerminated = "instance.terminated"
+	webhookVolumeCreated      = "volume.created"
+	webhookVolumeDeleted      = "volume.deleted"
+	webhookSnapshotCreated    = "snapshot.created"
+	webhookSnapshotDeleted    = "snapshot.deleted"
+	webhookImageCreated       = "image.created"
+	webhookImageDeleted       = "image.deleted"
+	webhookSgCreated          = "sg.created"
+	webhookSgDeleted          = "sg.deleted"
+)
+
+// webhookEvents are the types of events that can be sent to webhooks
+var webhookEvents = []string{
+	webhookInstanceCreated,
+	webhookInstanceTerminated,
+	webhookVolumeCreated,
+	webhookVolumeDeleted,
+	webhookSnapshotCreated,
+	webhookSnapshotDeleted,
+	webhookImageCreated,
+	webhookImageDeleted,
+	webhookSgCreated,
+	webhookSgDeleted,
+}
+
+// headers sent with webhook deliveries
+const (
+	webhookEventHeader     = "X-Spinup-Event"
+	webhookDeliveryHeader  = "X-Spinup-Delivery"
+	webhookTimestampHeader = "X-Spinup-Timestamp"
+	webhookSignatureHeader = "X-Spinup-Signature"
+)
+
+// defaults of the webhook configuration
+const (
+	defaultWebhookMaxRetries = 5
+	defaultWebhookBackoff    = 1 * time.Second
+	defaultWebhookMaxBackoff = 5 * time.Minute
+	defaultWebhookTimeout    = 10 * time.Second
+)
+
+// webhookQueueSize is the number of deliveries waiting for a worker, deliveries are dead lettered when the queue is full
+const webhookQueueSize = 256
+
+// webhookWorkers is the number of deliveries sent at the same time
+const webhookWorkers = 4
+
+// webhookDispatcher sends resource lifecycle events to the configured webhook endpoints
+type webhookDispatcher struct {
+	client         *http.Client
+	endpoints      []*webhookEndpoint
+	maxRetries     int
+	backoff        time.Duration
+	maxBackoff     time.Duration
+	deadLetterFile string
+	queue          chan *webhookDelivery
+
+	// mu serializes writes to the dead letter file
+	mu sync.Mutex
+}
+
+// webhookEndpoint is an endpoint receiving the events matching its filters
+type webhookEndpoint struct {
+	url     string
+	secret  []byte
+	filters []string
+}
+
+// webhookDelivery is an event to be sent to an endpoint
+type webhookDelivery struct {
+	endpoint *webhookEndpoint
+	event    *Ec2LifecycleEvent
+	body     []byte
+}
+
+// webhookDeadLetter is the record of a failed delivery appended to the dead letter file
+type webhookDeadLetter struct {
+	Endpoint string             `json:"endpoint"`
+	Event    *Ec2LifecycleEvent `json:"event"`
+	Attempts int                `json:"attempts"`
+	Error    string             `json:"error"`
+	Time     string             `json:"time"`
+}
+
+// webhookStatusError is returned when an endpoint responds with an error status
+type webhookStatusError struct {
+	status int
+}
+
+func (e *webhookStatusError) Error() string {
+	return fmt.Sprintf("endpoint responded with status %d", e.status)
+}
+
+// newWebhookDispatcher validates the webhook configuration and returns a dispatcher, it returns nil when no
+// endpoints are configured
+func newWebhookDispatcher(config *common.Webhooks) (*webhookDispatcher, error) {
+	if config == nil || len(config.Endpoints) == 0 {
+		return nil, nil
+	}
+
+	d := &webhookDispatcher{
+		maxRetries:     defaultWebhookMaxRetries,
+		backoff:        defaultWebhookBackoff,
+		maxBackoff:     defaultWebhookMaxBackoff,
+		deadLetterFile: config.DeadLetterFile,
+		queue:          make(chan *webhookDelivery, webhookQueueSize),
+	}
+
+	if config.MaxRetries != nil {
+		if *config.MaxRetries < 0 {
+			return nil, errors.New("webhook max retries can't be negative")
+		}
+		d.maxRetries = *config.MaxRetries
+	}
+
+	timeout := defaultWebhookTimeout
+	for _, c := range []struct {
+		name  string
+		value string
+		d     *time.Duration
+	}{
+		{"backoff", config.Backoff, &d.backoff},
+		{"max backoff", config.MaxBackoff, &d.maxBackoff},
+		{"timeout", config.Timeout, &timeout},
+	} {
+		if c.value == "" {
+			continue
+		}
+
+		v, err := time.ParseDuration(c.value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid webhook %s", c.name)
+		}
+
+		if v <= 0 {
+			return nil, errors.Errorf("webhook %s must be positive", c.name)
+		}
+
+		*c.d = v
+	}
+
+	if d.maxBackoff < d.backoff {
+		return nil, errors.New("webhook max backoff can't be shorter than the backoff")
+	}
+
+	d.client = &http.Client{Timeout: timeout}
+
+	for i, e := range config.Endpoints {
+		if e == nil {
+			return nil, errors.Errorf("webhook endpoint %d is empty", i)
+		}
+
+		u, err := url.Parse(e.Url)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, errors.Errorf("webhook endpoint %d url must be an http or https url", i)
+		}
+
+		if e.Secret == "" {
+			return nil, errors.Errorf("webhook endpoint %s requires a secret", u.Host)
+		}
+
+		for _, f := range e.Events {
+			if !validWebhookFilter(f) {
+				return nil, errors.Errorf("invalid event filter '%s' for webhook endpoint %s", f, u.Host)
+			}
+		}
+
+		d.endpoints = append(d.endpoints, &webhookEndpoint{
+			url:     e.Url,
+			secret:  []byte(e.Secret),
+			filters: e.Events,
+		})
+	}
+
+	return d, nil
+}
+
+// start starts the workers sending the deliveries until the context is canceled
+func (d *webhookDispatcher) start(ctx context.Context) {
+	log.Infof("starting webhook dispatcher for %d endpoints", len(d.endpoints))
+
+	for i := 0; i < webhookWorkers; i++ {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case delivery := <-d.queue:
+					d.deliver(ctx, delivery)
+				}
+			}
+		}()
+	}
+}
+
+// dispatch queues an event for the endpoints with matching filters, it's a no-op when webhooks aren't configured.
+// The id and the time of the event are set when they're empty.
+func (d *webhookDispatcher) dispatch(event *Ec2LifecycleEvent) {
+	if d == nil || event == nil {
+		return
+	}
+
+	if event.Id == "" {
+		event.Id = newWebhookDeliveryId()
+	}
+
+	if event.Time == "" {
+		now := time.Now()
+		event.Time = timeFormat(&now)
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Errorf("failed to encode %s webhook event for %s: %s", event.Type, event.ResourceId, err)
+		return
+	}
+
+	for _, e := range d.endpoints {
+		if !webhookFilterMatches(e.filters, event.Type) {
+			continue
+		}
+
+		delivery := &webhookDelivery{endpoint: e, event: event, body: body}
+		select {
+		case d.queue <- delivery:
+		default:
+			d.deadLetter(delivery, 0, errors.New("webhook queue is full"))
+		}
+	}
+}
+
+// deliver sends a delivery to its endpoint, retrying failures with exponential backoff.  Deliveries still failing
+// after the last retry, or when the context is canceled, are dead lettered.
+func (d *webhookDispatcher) deliver(ctx context.Context, delivery *webhookDelivery) {
+	backoff := d.backoff
+
+	var err error
+	attempt := 0
+	for {
+		attempt++
+
+		if err = d.send(ctx, delivery); err == nil {
+			log.Debugf("delivered %s webhook event %s to %s", delivery.event.Type, delivery.event.Id, delivery.endpoint.url)
+			return
+		}
+
+		if attempt > d.maxRetries || !retryableWebhookError(err) {
+			break
+		}
+
+		log.Warnf("failed to deliver %s webhook event %s to %s (attempt %d), retrying in %s: %s",
+			delivery.event.Type, delivery.event.Id, delivery.endpoint.url, attempt, backoff, err)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			d.deadLetter(delivery, attempt, errors.Wrap(err, "webhook dispatcher stopped"))
+			return
+		case <-timer.C:
+		}
+
+		if backoff *= 2; backoff > d.maxBackoff {
+			backoff = d.maxBackoff
+		}
+	}
+
+	d.deadLetter(delivery, attempt, err)
+}
+
+// send posts a signed delivery to its endpoint once
+func (d *webhookDispatcher) send(ctx context.Context, delivery *webhookDelivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.endpoint.url, bytes.NewReader(delivery.body))
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "spinup-ec2-api")
+	req.Header.Set(webhookEventHeader, delivery.event.Type)
+	req.Header.Set(webhookDeliveryHeader, delivery.event.Id)
+	req.Header.Set(webhookTimestampHeader, timestamp)
+	req.Header.Set(webhookSignatureHeader, signWebhook(delivery.endpoint.secret, timestamp, delivery.body))
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	// drain the body so the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64*1024))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return &webhookStatusError{status: res.StatusCode}
+	}
+
+	return nil
+}
+
+// deadLetter records a failed delivery in the dead letter file, or only logs it when there's no dead letter file
+func (d *webhookDispatcher) deadLetter(delivery *webhookDelivery, attempts int, cause error) {
+	log.Errorf("giving up delivering %s webhook event %s for %s to %s after %d attempts: %s",
+		delivery.event.Type, delivery.event.Id, delivery.event.ResourceId, delivery.endpoint.url, attempts, cause)
+
+	if d.deadLetterFile == "" {
+		return
+	}
+
+	now := time.Now()
+	line, err := json.Marshal(&webhookDeadLetter{
+		Endpoint: delivery.endpoint.url,
+		Event:    delivery.event,
+		Attempts: attempts,
+		Error:    cause.Error(),
+		Time:     timeFormat(&now),
+	})
+	if err != nil {
+		log.Errorf("failed to encode dead letter of webhook event %s: %s", delivery.event.Id, err)
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	f, err := os.OpenFile(d.deadLetterFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		log.Errorf("failed to open webhook dead letter file %s: %s", d.deadLetterFile, err)
+		return
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		log.Errorf("failed to write webhook event %s to dead letter file %s: %s", delivery.event.Id, d.deadLetterFile, err)
+	}
+}
+
+// notify sends a lifecycle event for a resource in the account of the orchestrator to the webhooks
+func (o *ec2Orchestrator) notify(eventType, id string) {
+	if o.server == nil {
+		return
+	}
+
+	o.server.webhooks.dispatch(&Ec2LifecycleEvent{
+		Type:       eventType,
+		Account:    o.account,
+		Org:        o.server.org,
+		ResourceId: id,
+	})
+}
+
+// signWebhook returns the signature of a webhook body, the hex encoded HMAC-SHA256 of the timestamp and the body
+// joined by a dot
+func signWebhook(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookFilterMatches returns true if an event type matches the filters of an endpoint.  Filters are exact event
+// types, prefixes like 'instance.*' or '*', endpoints without filters receive all events.
+func webhookFilterMatches(filters []string, eventType string) bool {
+	if len(filters) == 0 {
+		return true
+	}
+
+	for _, f := range filters {
+		if f == "*" || f == eventType {
+			return true
+		}
+
+		if strings.HasSuffix(f, ".*") && strings.HasPrefix(eventType, strings.TrimSuffix(f, "*")) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validWebhookFilter returns true if a filter matches at least one event type
+func validWebhookFilter(filter string) bool {
+	if filter == "*" {
+		return true
+	}
+
+	for _, e := range webhookEvents {
+		if webhookFilterMatches([]string{filter}, e) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// retryableWebhookError returns true if a failed delivery should be retried, client errors other than 408 and 429
+// won't succeed on retry
+func retryableWebhookError(err error) bool {
+	var statusErr *webhookStatusError
+	if errors.As(err, &statusErr) {
+		s := statusErr.status
+		return s >= 500 || s == http.StatusRequestTimeout || s == http.StatusTooManyRequests
+	}
+
+	return true
+}
+
+// newWebhookDeliveryId returns a random id for a webhook event
+func newWebhookDeliveryId() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,389 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/YaleSpinup/ec2-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/pkg/errors"
+)
+
+func Test_signWebhook(t *testing.T) {
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		body      string
+		want      string
+	}{
+		{
+			name:      "signature",
+			secret:    "sekret",
+			timestamp: "1672628645",
+			body:      `{"id":"1"}`,
+			// echo -n '1672628645.{"id":"1"}' | openssl dgst -sha256 -hmac sekret
+			want: "sha256=8ce257a10c78787e31957ca8880ef0bc470fe423801a6f9f9454c7a4ccceefb0",
+		},
+		{
+			name:      "empty body",
+			secret:    "sekret",
+			timestamp: "1672628645",
+			// echo -n '1672628645.' | openssl dgst -sha256 -hmac sekret
+			want: "sha256=b3e42de6d15c0e476a736f674fe5cdb544be3134378e04f6e7c6d384f0e6daa4",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := signWebhook([]byte(tt.secret), tt.timestamp, []byte(tt.body)); got != tt.want {
+				t.Errorf("signWebhook() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if signWebhook([]byte("sekret"), "1672628645", []byte(`{"id":"1"}`)) == signWebhook([]byte("other"), "1672628645", []byte(`{"id":"1"}`)) {
+		t.Error("signWebhook() doesn't depend on the secret")
+	}
+}
+
+func Test_webhookFilterMatches(t *testing.T) {
+	tests := []struct {
+		name      string
+		filters   []string
+		eventType string
+		want      bool
+	}{
+		{name: "no filters", eventType: webhookInstanceCreated, want: true},
+		{name: "wildcard", filters: []string{"*"}, eventType: webhookSgDeleted, want: true},
+		{name: "exact", filters: []string{"instance.created"}, eventType: webhookInstanceCreated, want: true},
+		{name: "exact mismatch", filters: []string{"instance.created"}, eventType: webhookInstanceTerminated, want: false},
+		{name: "prefix", filters: []string{"volume.*"}, eventType: webhookVolumeDeleted, want: true},
+		{name: "prefix mismatch", filters: []string{"volume.*"}, eventType: webhookSnapshotDeleted, want: false},
+		{name: "prefix without dot", filters: []string{"sg*"}, eventType: webhookSgCreated, want: false},
+		{name: "any of several", filters: []string{"image.*", "sg.deleted"}, eventType: webhookSgDeleted, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := webhookFilterMatches(tt.filters, tt.eventType); got != tt.want {
+				t.Errorf("webhookFilterMatches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_validWebhookFilter(t *testing.T) {
+	tests := []struct {
+		filter string
+		want   bool
+	}{
+		{filter: "*", want: true},
+		{filter: "instance.terminated", want: true},
+		{filter: "sg.*", want: true},
+		{filter: "instance.deleted", want: false},
+		{filter: "eip.*", want: false},
+		{filter: "", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			if got := validWebhookFilter(tt.filter); got != tt.want {
+				t.Errorf("validWebhookFilter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_retryableWebhookError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "network error", err: errors.New("connection refused"), want: true},
+		{name: "server error", err: &webhookStatusError{status: http.StatusBadGateway}, want: true},
+		{name: "too many requests", err: &webhookStatusError{status: http.StatusTooManyRequests}, want: true},
+		{name: "request timeout", err: &webhookStatusError{status: http.StatusRequestTimeout}, want: true},
+		{name: "bad request", err: &webhookStatusError{status: http.StatusBadRequest}, want: false},
+		{name: "not found", err: &webhookStatusError{status: http.StatusNotFound}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryableWebhookError(tt.err); got != tt.want {
+				t.Errorf("retryableWebhookError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_newWebhookDispatcher(t *testing.T) {
+	endpoint := &common.WebhookEndpoint{Url: "https://example.com/hook", Secret: "sekret"}
+
+	tests := []struct {
+		name    string
+		config  *common.Webhooks
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "nil config", wantNil: true},
+		{name: "no endpoints", config: &common.Webhooks{}, wantNil: true},
+		{name: "defaults", config: &common.Webhooks{Endpoints: []*common.WebhookEndpoint{endpoint}}},
+		{
+			name: "custom",
+			config: &common.Webhooks{
+				Endpoints:  []*common.WebhookEndpoint{endpoint},
+				MaxRetries: aws.Int(0),
+				Backoff:    "500ms",
+				MaxBackoff: "10s",
+				Timeout:    "5s",
+			},
+		},
+		{name: "negative retries", config: &common.Webhooks{Endpoints: []*common.WebhookEndpoint{endpoint}, MaxRetries: aws.Int(-1)}, wantErr: true},
+		{name: "invalid backoff", config: &common.Webhooks{Endpoints: []*common.WebhookEndpoint{endpoint}, Backoff: "soon"}, wantErr: true},
+		{name: "zero timeout", config: &common.Webhooks{Endpoints: []*common.WebhookEndpoint{endpoint}, Timeout: "0s"}, wantErr: true},
+		{name: "max backoff shorter than backoff", config: &common.Webhooks{Endpoints: []*common.WebhookEndpoint{endpoint}, Backoff: "1m", MaxBackoff: "1s"}, wantErr: true},
+		{name: "nil endpoint", config: &common.Webhooks{Endpoints: []*common.WebhookEndpoint{nil}}, wantErr: true},
+		{name: "invalid url", config: &common.Webhooks{Endpoints: []*common.WebhookEndpoint{{Url: "example.com/hook", Secret: "sekret"}}}, wantErr: true},
+		{name: "missing secret", config: &common.Webhooks{Endpoints: []*common.WebhookEndpoint{{Url: "https://example.com/hook"}}}, wantErr: true},
+		{name: "invalid filter", config: &common.Webhooks{Endpoints: []*common.WebhookEndpoint{{Url: "https://example.com/hook", Secret: "sekret", Events: []string{"eip.created"}}}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newWebhookDispatcher(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("newWebhookDispatcher() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if (got == nil) != tt.wantNil {
+				t.Errorf("newWebhookDispatcher() = %v, wantNil %v", got, tt.wantNil)
+			}
+		})
+	}
+}
+
+// webhookReceiver is a test endpoint responding with a sequence of status codes
+type webhookReceiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	status := http.StatusOK
+	if n := len(r.requests); n < len(r.statuses) {
+		status = r.statuses[n]
+	}
+
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, body)
+
+	w.WriteHeader(status)
+}
+
+func newTestWebhookDispatcher(t *testing.T, url string, maxRetries int) *webhookDispatcher {
+	d, err := newWebhookDispatcher(&common.Webhooks{
+		Endpoints:      []*common.WebhookEndpoint{{Url: url, Secret: "sekret"}},
+		MaxRetries:     aws.Int(maxRetries),
+		Backoff:        "1ms",
+		MaxBackoff:     "2ms",
+		DeadLetterFile: filepath.Join(t.TempDir(), "dead-letter.jsonl"),
+	})
+	if err != nil {
+		t.Fatalf("failed to create webhook dispatcher: %s", err)
+	}
+
+	return d
+}
+
+func readWebhookDeadLetters(t *testing.T, file string) []*webhookDeadLetter {
+	b, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		t.Fatalf("failed to read dead letter file: %s", err)
+	}
+
+	out := []*webhookDeadLetter{}
+	for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+		dl := &webhookDeadLetter{}
+		if err := json.Unmarshal([]byte(line), dl); err != nil {
+			t.Fatalf("failed to decode dead letter %s: %s", line, err)
+		}
+		out = append(out, dl)
+	}
+
+	return out
+}
+
+func Test_webhookDispatcher_deliver(t *testing.T) {
+	tests := []struct {
+		name           string
+		statuses       []int
+		maxRetries     int
+		wantAttempts   int
+		wantDeadLetter bool
+	}{
+		{name: "delivered", wantAttempts: 1, maxRetries: 3},
+		{name: "retried until delivered", statuses: []int{500, 503, 429}, maxRetries: 3, wantAttempts: 4},
+		{name: "retries exhausted", statuses: []int{500, 500, 500}, maxRetries: 2, wantAttempts: 3, wantDeadLetter: true},
+		{name: "client error isn't retried", statuses: []int{400}, maxRetries: 3, wantAttempts: 1, wantDeadLetter: true},
+		{name: "no retries", statuses: []int{502}, maxRetries: 0, wantAttempts: 1, wantDeadLetter: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receiver := &webhookReceiver{statuses: tt.statuses}
+			ts := httptest.NewServer(receiver)
+			defer ts.Close()
+
+			d := newTestWebhookDispatcher(t, ts.URL, tt.maxRetries)
+
+			event := &Ec2LifecycleEvent{Id: "abc", Type: webhookInstanceCreated, Account: "012345678901", Org: "foo", ResourceId: "i-1", Time: "2023/01/02 03:04:05"}
+			body, _ := json.Marshal(event)
+			d.deliver(context.Background(), &webhookDelivery{endpoint: d.endpoints[0], event: event, body: body})
+
+			if len(receiver.requests) != tt.wantAttempts {
+				t.Errorf("deliver() attempts = %d, want %d", len(receiver.requests), tt.wantAttempts)
+			}
+
+			for i, req := range receiver.requests {
+				if req.Header.Get(webhookEventHeader) != webhookInstanceCreated || req.Header.Get(webhookDeliveryHeader) != "abc" {
+					t.Errorf("deliver() attempt %d headers = %v", i, req.Header)
+				}
+
+				want := signWebhook([]byte("sekret"), req.Header.Get(webhookTimestampHeader), receiver.bodies[i])
+				if got := req.Header.Get(webhookSignatureHeader); got != want {
+					t.Errorf("deliver() attempt %d signature = %s, want %s", i, got, want)
+				}
+
+				if string(receiver.bodies[i]) != string(body) {
+					t.Errorf("deliver() attempt %d body = %s, want %s", i, receiver.bodies[i], body)
+				}
+			}
+
+			deadLetters := readWebhookDeadLetters(t, d.deadLetterFile)
+			if tt.wantDeadLetter {
+				if len(deadLetters) != 1 {
+					t.Fatalf("deliver() dead letters = %d, want 1", len(deadLetters))
+				}
+
+				dl := deadLetters[0]
+				if dl.Endpoint != ts.URL || dl.Attempts != tt.wantAttempts || dl.Event.ResourceId != "i-1" || dl.Error == "" {
+					t.Errorf("deliver() dead letter = %+v", dl)
+				}
+			} else if len(deadLetters) != 0 {
+				t.Errorf("deliver() dead letters = %d, want none", len(deadLetters))
+			}
+		})
+	}
+}
+
+func Test_webhookDispatcher_deliverCanceled(t *testing.T) {
+	receiver := &webhookReceiver{statuses: []int{500, 500, 500}}
+	ts := httptest.NewServer(receiver)
+	defer ts.Close()
+
+	d := newTestWebhookDispatcher(t, ts.URL, 5)
+	d.backoff = time.Minute
+	d.maxBackoff = time.Minute
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	event := &Ec2LifecycleEvent{Id: "abc", Type: webhookVolumeDeleted, ResourceId: "vol-1"}
+	d.deliver(ctx, &webhookDelivery{endpoint: d.endpoints[0], event: event, body: []byte(`{}`)})
+
+	deadLetters := readWebhookDeadLetters(t, d.deadLetterFile)
+	if len(deadLetters) != 1 || deadLetters[0].Attempts != 1 {
+		t.Errorf("deliver() dead letters = %+v, want 1 after 1 attempt", deadLetters)
+	}
+}
+
+func Test_webhookDispatcher_dispatch(t *testing.T) {
+	// a nil dispatcher is a no-op
+	var nilDispatcher *webhookDispatcher
+	nilDispatcher.dispatch(&Ec2LifecycleEvent{Type: webhookInstanceCreated})
+
+	d, err := newWebhookDispatcher(&common.Webhooks{
+		Endpoints: []*common.WebhookEndpoint{
+			{Url: "https://billing.example.com", Secret: "sekret", Events: []string{"instance.*"}},
+			{Url: "https://cmdb.example.com", Secret: "sekret"},
+			{Url: "https://sgs.example.com", Secret: "sekret", Events: []string{"sg.created"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create webhook dispatcher: %s", err)
+	}
+
+	d.dispatch(&Ec2LifecycleEvent{Type: webhookInstanceTerminated, ResourceId: "i-1"})
+
+	got := []string{}
+	for len(d.queue) > 0 {
+		delivery := <-d.queue
+		got = append(got, delivery.endpoint.url)
+
+		if delivery.event.Id == "" || delivery.event.Time == "" {
+			t.Errorf("dispatch() event = %+v, want id and time", delivery.event)
+		}
+
+		e := &Ec2LifecycleEvent{}
+		if err := json.Unmarshal(delivery.body, e); err != nil || e.ResourceId != "i-1" {
+			t.Errorf("dispatch() body = %s", delivery.body)
+		}
+	}
+
+	want := []string{"https://billing.example.com", "https://cmdb.example.com"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("dispatch() endpoints = %v, want %v", got, want)
+	}
+}
+
+func Test_webhookDispatcher_dispatchQueueFull(t *testing.T) {
+	d := newTestWebhookDispatcher(t, "https://example.com", 0)
+	d.queue = make(chan *webhookDelivery, 1)
+
+	d.dispatch(&Ec2LifecycleEvent{Type: webhookImageCreated, ResourceId: "ami-1"})
+	d.dispatch(&Ec2LifecycleEvent{Type: webhookImageCreated, ResourceId: "ami-2"})
+
+	deadLetters := readWebhookDeadLetters(t, d.deadLetterFile)
+	if len(deadLetters) != 1 || deadLetters[0].Event.ResourceId != "ami-2" || deadLetters[0].Attempts != 0 {
+		t.Errorf("dispatch() dead letters = %+v, want ami-2 without attempts", deadLetters)
+	}
+}
+
+func Test_ec2Orchestrator_notify(t *testing.T) {
+	d := newTestWebhookDispatcher(t, "https://example.com", 0)
+
+	o := &ec2Orchestrator{account: "012345678901", server: &server{org: "foo", webhooks: d}}
+	o.notify(webhookSgCreated, "sg-1")
+
+	if len(d.queue) != 1 {
+		t.Fatalf("notify() queued %d deliveries, want 1", len(d.queue))
+	}
+
+	got := (<-d.queue).event
+	if got.Type != webhookSgCreated || got.Account != "012345678901" || got.Org != "foo" || got.ResourceId != "sg-1" {
+		t.Errorf("notify() event = %+v", got)
+	}
+
+	// webhooks aren't configured
+	o = &ec2Orchestrator{server: &server{org: "foo"}}
+	o.notify(webhookSgCreated, "sg-1")
+}
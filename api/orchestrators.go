@@ -3,9 +3,12 @@ package api
 import (
 	"context"
 
+	"github.com/YaleSpinup/ec2-api/cloudwatch"
 	"github.com/YaleSpinup/ec2-api/ec2"
+	"github.com/YaleSpinup/ec2-api/ec2instanceconnect"
 	"github.com/YaleSpinup/ec2-api/iam"
 	"github.com/YaleSpinup/ec2-api/ssm"
+	"github.com/aws/aws-sdk-go/aws/arn"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -19,6 +22,7 @@ type sessionParams struct {
 
 type ec2Orchestrator struct {
 	ec2Client *ec2.Ec2
+	account   string
 	server    *server
 }
 
@@ -36,8 +40,15 @@ func (s *server) newEc2Orchestrator(ctx context.Context, sp *sessionParams) (*ec
 		return nil, err
 	}
 
+	// the account is only used to label webhook events, so a role that isn't an arn isn't an error here
+	var account string
+	if a, err := arn.Parse(sp.role); err == nil {
+		account = a.AccountID
+	}
+
 	return &ec2Orchestrator{
 		ec2Client: ec2.New(ec2.WithSession(session.Session)),
+		account:   account,
 		server:    s,
 	}, nil
 }
@@ -67,6 +78,71 @@ func (s *server) newSSMOrchestrator(ctx context.Context, sp *sessionParams) (*ss
 	}, nil
 }
 
+// instanceAccessOrchestrator gives shell access to instances with ec2 instance connect or ssm session manager
+type instanceAccessOrchestrator struct {
+	ec2Client     *ec2.Ec2
+	ssmClient     *ssm.SSM
+	connectClient *ec2instanceconnect.EC2InstanceConnect
+	server        *server
+}
+
+func (s *server) newInstanceAccessOrchestrator(ctx context.Context, sp *sessionParams) (*instanceAccessOrchestrator, error) {
+	log.Debugf("initializing instanceAccessOrchestrator")
+
+	session, err := s.assumeRole(
+		ctx,
+		s.session.ExternalID,
+		sp.role,
+		sp.inlinePolicy,
+		sp.policyArns...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &instanceAccessOrchestrator{
+		ec2Client:     ec2.New(ec2.WithSession(session.Session)),
+		ssmClient:     ssm.New(ssm.WithSession(session.Session)),
+		connectClient: ec2instanceconnect.New(ec2instanceconnect.WithSession(session.Session)),
+		server:        s,
+	}, nil
+}
+
+// metricsOrchestrator reads the cloudwatch metrics of instances and volumes
+type metricsOrchestrator struct {
+	ec2Client        *ec2.Ec2
+	cloudwatchClient *cloudwatch.CloudWatch
+	account          string
+	server           *server
+}
+
+func (s *server) newMetricsOrchestrator(ctx context.Context, sp *sessionParams) (*metricsOrchestrator, error) {
+	log.Debugf("initializing metricsOrchestrator")
+
+	session, err := s.assumeRole(
+		ctx,
+		s.session.ExternalID,
+		sp.role,
+		sp.inlinePolicy,
+		sp.policyArns...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var account string
+	if a, err := arn.Parse(sp.role); err == nil {
+		account = a.AccountID
+	}
+
+	return &metricsOrchestrator{
+		ec2Client:        ec2.New(ec2.WithSession(session.Session)),
+		cloudwatchClient: cloudwatch.New(cloudwatch.WithSession(session.Session)),
+		account:          account,
+		server:           s,
+	}, nil
+}
+
 type iamOrchestrator struct {
 	iamClient *iam.Iam
 	server    *server
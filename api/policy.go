@@ -3,6 +3,8 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/YaleSpinup/aws-go/services/iam"
 
@@ -168,6 +170,45 @@ func sgDeletePolicy(id string) (string, error) {
 	return string(j), nil
 }
 
+// sgCascadeDeletePolicy allows deleting a security group after detaching it from network interfaces and
+// revoking the rules referencing it in other security groups
+func sgCascadeDeletePolicy(id string) (string, error) {
+	log.Debugf("generating sg cascade delete policy document")
+
+	sgResource := fmt.Sprintf("arn:aws:ec2:*:*:security-group/%s", id)
+
+	policy := iam.PolicyDocument{
+		Version: "2012-10-17",
+		Statement: []iam.StatementEntry{
+			{
+				Effect: "Allow",
+				Action: []string{
+					"ec2:DeleteSecurityGroup",
+				},
+				Resource: []string{sgResource},
+			},
+			{
+				Effect: "Allow",
+				Action: []string{
+					"ec2:ModifyNetworkInterfaceAttribute",
+					"ec2:AuthorizeSecurityGroupEgress",
+					"ec2:AuthorizeSecurityGroupIngress",
+					"ec2:RevokeSecurityGroupEgress",
+					"ec2:RevokeSecurityGroupIngress",
+				},
+				Resource: []string{"*"},
+			},
+		},
+	}
+
+	j, err := json.Marshal(policy)
+	if err != nil {
+		return "", err
+	}
+
+	return string(j), nil
+}
+
 func sgCreatePolicy() (string, error) {
 	log.Debugf("generating sg crete policy document")
 
@@ -213,6 +254,8 @@ func sgUpdatePolicy(id string) (string, error) {
 					"ec2:AuthorizeSecurityGroupIngress",
 					"ec2:RevokeSecurityGroupEgress",
 					"ec2:RevokeSecurityGroupIngress",
+					"ec2:UpdateSecurityGroupRuleDescriptionsEgress",
+					"ec2:UpdateSecurityGroupRuleDescriptionsIngress",
 				},
 				Resource: []string{sgResource},
 			},
@@ -272,6 +315,42 @@ func generatePolicy(actions []string) (string, error) {
 	return string(j), nil
 }
 
+// instanceSessionPolicy allows starting a session manager session on an instance with the allowed session documents
+func instanceSessionPolicy(id string) (string, error) {
+	log.Debugf("generating instance session policy document")
+
+	resources := []string{fmt.Sprintf("arn:aws:ec2:*:*:instance/%s", id)}
+	for d := range sessionDocuments {
+		// documents owned by aws have no account in their arn
+		if strings.HasPrefix(d, "AWS-") {
+			resources = append(resources, fmt.Sprintf("arn:aws:ssm:*::document/%s", d))
+		} else {
+			resources = append(resources, fmt.Sprintf("arn:aws:ssm:*:*:document/%s", d))
+		}
+	}
+	sort.Strings(resources)
+
+	policy := iam.PolicyDocument{
+		Version: "2012-10-17",
+		Statement: []iam.StatementEntry{
+			{
+				Effect: "Allow",
+				Action: []string{
+					"ssm:StartSession",
+				},
+				Resource: resources,
+			},
+		},
+	}
+
+	j, err := json.Marshal(policy)
+	if err != nil {
+		return "", err
+	}
+
+	return string(j), nil
+}
+
 func volumeDeletePolicy(id string) (string, error) {
 	log.Debugf("generating volume delete policy document")
 
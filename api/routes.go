@@ -30,14 +30,22 @@ func (s *server) routes() {
 
 	api.HandleFunc("/", s.AccountsHandler).Methods(http.MethodGet)
 	api.HandleFunc("/{account}/select", s.InstanceSelectorHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/summary", s.SummaryHandler).Methods(http.MethodGet)
 
 	// instance endpoints
 	api.HandleFunc("/{account}/instances", s.InstanceListHandler).Methods(http.MethodGet)
 	api.HandleFunc("/{account}/instances/types", s.InstanceListTypeOfferings).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/instances/idle", s.IdleInstancesHandler).Methods(http.MethodGet)
 	api.HandleFunc("/{account}/instances/{id}", s.InstanceGetHandler).Methods(http.MethodGet)
 	api.HandleFunc("/{account}/instances/{id}/volumes", s.InstanceVolumesHandler).Methods(http.MethodGet)
 	api.HandleFunc("/{account}/instances/{id}/volumes/{vid}", s.InstanceVolumesHandler).Methods(http.MethodGet)
 	api.HandleFunc("/{account}/instances/{id}/snapshots", s.InstanceListSnapshotsHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/instances/{id}/console", s.InstanceConsoleHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/instances/{id}/screenshot", s.InstanceScreenshotHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/instances/{id}/status", s.InstanceStatusHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/instances/{id}/wait", s.InstanceWaitHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/instances/{id}/schedule", s.InstanceScheduleHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/instances/{id}/metrics", s.InstanceMetricsHandler).Methods(http.MethodGet)
 
 	api.HandleFunc("/{account}/instances/{id}/ssm/command", s.InstanceGetCommandHandler).Methods(http.MethodGet).Queries("command_id", "{cid}")
 	api.HandleFunc("/{account}/instances/{id}/ssm/association", s.DescribeAssociationHandler).Methods(http.MethodGet).Queries("document", "{doc}")
@@ -48,11 +56,31 @@ func (s *server) routes() {
 	api.HandleFunc("/{account}/ssm/parameters/{name:.*}", s.ParameterGetHandler).Methods(http.MethodGet)
 
 	api.HandleFunc("/{account}/sgs", s.SecurityGroupListHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/sgs/findings", s.SecurityGroupFindingsListHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/sgs/export", s.SecurityGroupExportHandler).Methods(http.MethodGet)
 	api.HandleFunc("/{account}/sgs/{id}", s.SecurityGroupGetHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/sgs/{id}/findings", s.SecurityGroupFindingsHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/sgs/{id}/usage", s.SecurityGroupUsageHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/sgs/{id}/export", s.SecurityGroupExportHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/prefixlists", s.PrefixListListHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/prefixlists/{id}", s.PrefixListGetHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/prefixlists/{id}/sgs", s.PrefixListReferencesHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/eips", s.ElasticIpListHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/eips/{id}", s.ElasticIpGetHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/enis", s.NetworkInterfaceListHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/enis/{id}", s.NetworkInterfaceGetHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/keypairs", s.KeyPairListHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/keypairs/{name}", s.KeyPairGetHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/events", s.InstanceEventListHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/events/stream", s.EventStreamHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/orphans", s.OrphanListHandler).Methods(http.MethodGet)
 	api.HandleFunc("/{account}/volumes", s.VolumeListHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/volumes/migrations/{mid}", s.VolumeMigrationGetHandler).Methods(http.MethodGet)
 	api.HandleFunc("/{account}/volumes/{id}", s.VolumeGetHandler).Methods(http.MethodGet)
 	api.HandleFunc("/{account}/volumes/{id}/modifications", s.VolumeListModificationsHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/volumes/{id}/resize/{rid}", s.VolumeResizeGetHandler).Methods(http.MethodGet)
 	api.HandleFunc("/{account}/volumes/{id}/snapshots", s.VolumeListSnapshotsHandler).Methods(http.MethodGet)
+	api.HandleFunc("/{account}/volumes/{id}/metrics", s.VolumeMetricsHandler).Methods(http.MethodGet)
 	api.HandleFunc("/{account}/snapshots", s.SnapshotListHandler).Methods(http.MethodGet)
 	api.HandleFunc("/{account}/snapshots/synctags", s.SnapshotSyncTagHandler).Methods(http.MethodPut)
 	api.HandleFunc("/{account}/snapshots/{id}", s.SnapshotGetHandler).Methods(http.MethodGet)
@@ -65,11 +93,23 @@ func (s *server) routes() {
 	api.HandleFunc("/{account}/vpcs", s.VpcListHandler).Methods(http.MethodGet)
 	api.HandleFunc("/{account}/vpcs/{id}", s.VpcShowHandler).Methods(http.MethodGet)
 
+	api.HandleFunc("/{account}/estimate", s.EstimateHandler).Methods(http.MethodPost)
 	api.HandleFunc("/{account}/instances", s.InstanceCreateHandler).Methods(http.MethodPost)
 	api.HandleFunc("/{account}/instances/{id}/volumes", s.VolumeAttachHandler).Methods(http.MethodPost)
+	api.HandleFunc("/{account}/instances/{id}/connect", s.InstanceConnectHandler).Methods(http.MethodPost)
+	api.HandleFunc("/{account}/instances/{id}/ssm/session", s.InstanceSessionStartHandler).Methods(http.MethodPost)
 	api.HandleFunc("/{account}/sgs", s.SecurityGroupCreateHandler).Methods(http.MethodPost)
+	api.HandleFunc("/{account}/sgs/import", s.SecurityGroupImportHandler).Methods(http.MethodPost)
+	api.HandleFunc("/{account}/sgs/clone", s.SecurityGroupCloneHandler).Methods(http.MethodPost)
+	api.HandleFunc("/{account}/prefixlists", s.PrefixListCreateHandler).Methods(http.MethodPost)
+	api.HandleFunc("/{account}/eips", s.ElasticIpAllocateHandler).Methods(http.MethodPost)
+	api.HandleFunc("/{account}/enis", s.NetworkInterfaceCreateHandler).Methods(http.MethodPost)
+	api.HandleFunc("/{account}/keypairs", s.KeyPairCreateHandler).Methods(http.MethodPost)
+	api.HandleFunc("/{account}/keypairs/import", s.KeyPairImportHandler).Methods(http.MethodPost)
 	api.HandleFunc("/{account}/ssm/association", s.SSMAssociationByTagHandler).Methods(http.MethodPost)
 	api.HandleFunc("/{account}/volumes", s.VolumeCreateHandler).Methods(http.MethodPost)
+	api.HandleFunc("/{account}/volumes/migrations", s.VolumeMigrationCreateHandler).Methods(http.MethodPost)
+	api.HandleFunc("/{account}/volumes/{id}/resize", s.VolumeResizeHandler).Methods(http.MethodPost)
 	api.HandleFunc("/{account}/snapshots", s.SnapshotCreateHandler).Methods(http.MethodPost)
 	api.HandleFunc("/{account}/images", s.ImageCreateHandler).Methods(http.MethodPost)
 	api.HandleFunc("/{account}/ssm/parameters", s.ParameterCreateHandler).Methods(http.MethodPost)
@@ -81,19 +121,39 @@ func (s *server) routes() {
 	api.HandleFunc("/{account}/instances/{id}/ssm/association", s.InstanceSSMAssociationHandler).Methods(http.MethodPut)
 	api.HandleFunc("/{account}/instances/{id}/tags", s.InstanceUpdateHandler).Methods(http.MethodPut)
 	api.HandleFunc("/{account}/instances/{id}/attribute", s.InstanceUpdateHandler).Methods(http.MethodPut)
+	api.HandleFunc("/{account}/instances/{id}/events/{eid}", s.InstanceEventRescheduleHandler).Methods(http.MethodPut)
+	api.HandleFunc("/{account}/instances/{id}/schedule", s.InstanceScheduleUpdateHandler).Methods(http.MethodPut)
 	api.HandleFunc("/{account}/sgs/{id}", s.SecurityGroupUpdateHandler).Methods(http.MethodPut)
 	api.HandleFunc("/{account}/sgs/{id}/tags", s.SecurityGroupUpdateHandler).Methods(http.MethodPut)
+	api.HandleFunc("/{account}/sgs/{id}/rules", s.SecurityGroupRulesSyncHandler).Methods(http.MethodPut)
+	api.HandleFunc("/{account}/prefixlists/{id}", s.PrefixListUpdateHandler).Methods(http.MethodPut)
+	api.HandleFunc("/{account}/prefixlists/{id}/tags", s.PrefixListUpdateHandler).Methods(http.MethodPut)
+	api.HandleFunc("/{account}/prefixlists/{id}/restore", s.PrefixListRestoreHandler).Methods(http.MethodPut)
+	api.HandleFunc("/{account}/eips/{id}/tags", s.ElasticIpUpdateHandler).Methods(http.MethodPut)
+	api.HandleFunc("/{account}/eips/{id}/association", s.ElasticIpAssociateHandler).Methods(http.MethodPut)
+	api.HandleFunc("/{account}/enis/{id}", s.NetworkInterfaceUpdateHandler).Methods(http.MethodPut)
+	api.HandleFunc("/{account}/enis/{id}/tags", s.NetworkInterfaceUpdateTagsHandler).Methods(http.MethodPut)
+	api.HandleFunc("/{account}/enis/{id}/attachment", s.NetworkInterfaceAttachHandler).Methods(http.MethodPut)
+	api.HandleFunc("/{account}/keypairs/{name}/tags", s.KeyPairUpdateHandler).Methods(http.MethodPut)
 	api.HandleFunc("/{account}/volumes/{id}", s.VolumeUpdateHandler).Methods(http.MethodPut)
 	api.HandleFunc("/{account}/volumes/{id}/tags", s.VolumeUpdateHandler).Methods(http.MethodPut)
 	api.HandleFunc("/{account}/ssm/parameters/{name:.*}", s.ParameterUpdateHandler).Methods(http.MethodPut)
 
 	api.HandleFunc("/{account}/instances/{id}", s.InstanceDeleteHandler).Methods(http.MethodDelete)
 	api.HandleFunc("/{account}/instances/{id}/volumes/{vid}", s.VolumeDetachHandler).Methods(http.MethodDelete)
+	api.HandleFunc("/{account}/instances/{id}/ssm/session/{sid}", s.InstanceSessionTerminateHandler).Methods(http.MethodDelete)
 	api.HandleFunc("/{account}/instanceprofiles/{name}", s.InstanceProfileDeleteHandler).Methods(http.MethodDelete)
 	api.HandleFunc("/{account}/instanceprofiles/{name}", s.InstanceProfileGetHandler).Methods(http.MethodGet)
 	api.HandleFunc("/{account}/instanceprofiles/{name}", s.InstanceProfileCopyHandler).Methods(http.MethodPost)
 	api.HandleFunc("/{account}/sgs/{id}", s.SecurityGroupDeleteHandler).Methods(http.MethodDelete)
+	api.HandleFunc("/{account}/prefixlists/{id}", s.PrefixListDeleteHandler).Methods(http.MethodDelete)
+	api.HandleFunc("/{account}/eips/{id}", s.ElasticIpReleaseHandler).Methods(http.MethodDelete)
+	api.HandleFunc("/{account}/eips/{id}/association", s.ElasticIpDisassociateHandler).Methods(http.MethodDelete)
+	api.HandleFunc("/{account}/enis/{id}", s.NetworkInterfaceDeleteHandler).Methods(http.MethodDelete)
+	api.HandleFunc("/{account}/enis/{id}/attachment", s.NetworkInterfaceDetachHandler).Methods(http.MethodDelete)
+	api.HandleFunc("/{account}/keypairs/{name}", s.KeyPairDeleteHandler).Methods(http.MethodDelete)
 	api.HandleFunc("/{account}/volumes/{id}", s.VolumeDeleteHandler).Methods(http.MethodDelete)
+	api.HandleFunc("/{account}/orphans", s.OrphanDeleteHandler).Methods(http.MethodDelete)
 	api.HandleFunc("/{account}/snapshots/{id}", s.SnapshotDeleteHandler).Methods(http.MethodDelete)
 	api.HandleFunc("/{account}/images/{id}", s.ImageDeleteHandler).Methods(http.MethodDelete)
 	api.HandleFunc("/{account}/ssm/parameters/{name:.*}", s.ParameterDeleteHandler).Methods(http.MethodDelete)
@@ -25,7 +25,9 @@ import (
 	"time"
 
 	"github.com/YaleSpinup/ec2-api/common"
+	"github.com/YaleSpinup/ec2-api/pricing"
 	"github.com/YaleSpinup/ec2-api/session"
+	"github.com/YaleSpinup/ec2-api/sqs"
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 	"github.com/patrickmn/go-cache"
@@ -33,6 +35,9 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+// serverWriteTimeout is the time a request has to write its response
+const serverWriteTimeout = 90 * time.Second
+
 func init() {
 	rand.Seed(time.Now().UnixNano())
 }
@@ -59,10 +64,17 @@ type server struct {
 	context      context.Context
 	session      session.Session
 	sessionCache *cache.Cache
+	jobs         *cache.Cache
 	backend      *proxyBackend
 	accountsMap  map[string]string
 	orgPolicy    string
 	org          string
+	sgLinter     *sgLinter
+	eventBroker  *eventBroker
+	webhooks     *webhookDispatcher
+	idleDetector *idleDetector
+	prices       *pricing.Catalog
+	pricesRegion string
 }
 
 // NewServer creates a new server and starts it
@@ -80,6 +92,7 @@ func NewServer(config common.Config) error {
 		context:      ctx,
 		org:          config.Org,
 		sessionCache: cache.New(600*time.Second, 900*time.Second),
+		jobs:         cache.New(48*time.Hour, 1*time.Hour),
 		accountsMap:  config.AccountsMap,
 	}
 
@@ -95,6 +108,53 @@ func NewServer(config common.Config) error {
 	}
 	s.orgPolicy = orgPolicy
 
+	sgLinter, err := newSgLinter(config.SecurityGroupLint)
+	if err != nil {
+		return err
+	}
+	s.sgLinter = sgLinter
+
+	eventBroker, err := newEventBroker(ctx, config.EventStream, s.pollWatchedResources)
+	if err != nil {
+		return err
+	}
+	s.eventBroker = eventBroker
+
+	webhooks, err := newWebhookDispatcher(config.Webhooks)
+	if err != nil {
+		return err
+	}
+
+	if webhooks != nil {
+		webhooks.start(ctx)
+		s.webhooks = webhooks
+	}
+
+	scheduler, err := newInstanceScheduler(config.InstanceSchedules, config.AccountsMap, s.runAccountInstanceSchedules)
+	if err != nil {
+		return err
+	}
+
+	idleDetector, err := newIdleDetector(config.IdleInstances, config.AccountsMap, s.runAccountIdleDetection)
+	if err != nil {
+		return err
+	}
+	s.idleDetector = idleDetector
+
+	s.pricesRegion = config.Account.Region
+	if p := config.Pricing; p != nil && p.CatalogFile != "" {
+		s.prices, err = pricing.Load(p.CatalogFile)
+	} else {
+		s.prices, err = pricing.Bundled()
+	}
+	if err != nil {
+		return err
+	}
+
+	if p := config.Pricing; p != nil && p.Region != "" {
+		s.pricesRegion = p.Region
+	}
+
 	if b := config.ProxyBackend; b != nil {
 		log.Debugf("configuring proxy backend %s", b.BaseUrl)
 		s.backend = &proxyBackend{
@@ -113,6 +173,25 @@ func NewServer(config common.Config) error {
 		session.WithExternalRoleName(config.Account.Role),
 	)
 
+	if es := config.EventStream; es != nil && es.QueueUrl != "" {
+		log.Debugf("configuring event stream feed from %s", es.QueueUrl)
+		feed := &eventFeed{
+			sqsClient: sqs.New(sqs.WithSession(s.session.Session)),
+			queueUrl:  es.QueueUrl,
+			broker:    s.eventBroker,
+		}
+		go feed.run(ctx)
+	}
+
+	// the scheduler and the idle detector assume roles with the session, so they're started once the session exists
+	if scheduler != nil {
+		scheduler.start(ctx)
+	}
+
+	if config.IdleInstances != nil {
+		idleDetector.start(ctx)
+	}
+
 	publicURLs := map[string]string{
 		"/v2/ec2/ping":    "public",
 		"/v2/ec2/version": "public",
@@ -129,7 +208,7 @@ func NewServer(config common.Config) error {
 	srv := &http.Server{
 		Handler:      handler,
 		Addr:         config.ListenAddress,
-		WriteTimeout: 90 * time.Second,
+		WriteTimeout: serverWriteTimeout,
 		ReadTimeout:  90 * time.Second,
 	}
 
@@ -146,6 +225,11 @@ type LogWriter struct {
 	http.ResponseWriter
 }
 
+// Unwrap returns the wrapped http.ResponseWriter, it's used by http.ResponseController
+func (w LogWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 // Write log message if http response writer returns an error
 func (w LogWriter) Write(p []byte) (n int, err error) {
 	n, err = w.ResponseWriter.Write(p)
@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"sort"
 	"time"
 
 	"github.com/aws/aws-sdk-go/service/iam"
@@ -34,6 +35,12 @@ func tzTimeFormat(t *time.Time) string {
 	return t.UTC().Format("2006-01-02 15:04:05 MST")
 }
 
+// Ec2InstanceCreatePendingResponse is returned when an instance is created but waiting for it to be running fails
+type Ec2InstanceCreatePendingResponse struct {
+	ID      string `json:"id"`
+	Message string `json:"message"`
+}
+
 type Ec2InstanceCreateRequest struct {
 	Type            *string          `json:"type"`
 	Image           *string          `json:"image"`
@@ -88,21 +95,24 @@ type Ec2InstanceProfile struct {
 }
 
 type Ec2InstanceResponse struct {
-	Az        string              `json:"az"`
-	CreatedAt string              `json:"created_at"`
-	CreatedBy string              `json:"created_by"`
-	ID        string              `json:"id"`
-	Image     string              `json:"image"`
-	Ip        string              `json:"ip"`
-	Key       string              `json:"key"`
-	Name      string              `json:"name"`
-	Platform  string              `json:"platform"`
-	Sgs       []map[string]string `json:"sgs"`
-	State     string              `json:"state"`
-	Subnet    string              `json:"subnet"`
-	Tags      []map[string]string `json:"tags"`
-	Type      string              `json:"type"`
-	Volumes   map[string]*Volume  `json:"volumes"`
+	Az        string                 `json:"az"`
+	CreatedAt string                 `json:"created_at"`
+	CreatedBy string                 `json:"created_by"`
+	ID        string                 `json:"id"`
+	Image     string                 `json:"image"`
+	Ip        string                 `json:"ip"`
+	PublicIp  string                 `json:"public_ip,omitempty"`
+	ElasticIp string                 `json:"elastic_ip,omitempty"`
+	Key       string                 `json:"key"`
+	Name      string                 `json:"name"`
+	Platform  string                 `json:"platform"`
+	Sgs       []map[string]string    `json:"sgs"`
+	Enis      []*Ec2NetworkInterface `json:"enis"`
+	State     string                 `json:"state"`
+	Subnet    string                 `json:"subnet"`
+	Tags      []map[string]string    `json:"tags"`
+	Type      string                 `json:"type"`
+	Volumes   map[string]*Volume     `json:"volumes"`
 }
 
 func toEc2InstanceResponse(instance *ec2.Instance) *Ec2InstanceResponse {
@@ -168,6 +178,17 @@ func toEc2InstanceResponse(instance *ec2.Instance) *Ec2InstanceResponse {
 		state = aws.StringValue(instance.State.Name)
 	}
 
+	// public ips owned by the account rather than amazon are elastic ips
+	var elasticIp string
+	for _, eni := range instance.NetworkInterfaces {
+		if eni.Association != nil && aws.StringValue(eni.Association.IpOwnerId) != "amazon" {
+			elasticIp = aws.StringValue(eni.Association.PublicIp)
+			if aws.StringValue(eni.Association.PublicIp) == aws.StringValue(instance.PublicIpAddress) {
+				break
+			}
+		}
+	}
+
 	// TODO pull createdat from tags
 	response := Ec2InstanceResponse{
 		Az:        az,
@@ -176,10 +197,13 @@ func toEc2InstanceResponse(instance *ec2.Instance) *Ec2InstanceResponse {
 		ID:        aws.StringValue(instance.InstanceId),
 		Image:     aws.StringValue(instance.ImageId),
 		Ip:        aws.StringValue(instance.PrivateIpAddress),
+		PublicIp:  aws.StringValue(instance.PublicIpAddress),
+		ElasticIp: elasticIp,
 		Key:       aws.StringValue(instance.KeyName),
 		Name:      name,
 		Platform:  platform,
 		Sgs:       sgs,
+		Enis:      toEc2InstanceNetworkInterfaces(instance.NetworkInterfaces),
 		State:     state,
 		Subnet:    aws.StringValue(instance.SubnetId),
 		Tags:      tagsList,
@@ -472,7 +496,9 @@ type Ec2SecurityGroupRuleRequest struct {
 	RuleType     *string            `json:"rule_type"`      // Direction of traffic: [inbound|outbound]
 	Action       *string            `json:"action"`         // Adding or removing the rule: [add|remove]
 	CidrIp       *string            `json:"cidr_ip"`        // IPv4 CIDR address range to allow traffic to/from
+	CidrIpv6     *string            `json:"cidr_ipv6"`      // IPv6 CIDR address range to allow traffic to/from
 	SgId         *string            `json:"sg_id"`          // Security group to allow traffic to/from
+	UserId       *string            `json:"user_id"`        // Account owning sg_id, when it's in another account
 	IpProtocol   *string            `json:"ip_protocol"`    // IP Protocol name [tcp|udp|icmp|-1]
 	PrefixListId *string            `json:"prefix_list_id"` // The prefix list id to associate a rule with
 	FromPort     *int64             `json:"from_port"`      // The starting port (not required if Protocol -1)
@@ -481,6 +507,144 @@ type Ec2SecurityGroupRuleRequest struct {
 	Tags         *map[string]string `json:"tags,omitempty"`
 }
 
+type Ec2SecurityGroupRule struct {
+	IpProtocol          string `json:"ip_protocol"`                    // IP Protocol name [tcp|udp|icmp|icmpv6|-1] or number
+	FromPort            *int64 `json:"from_port,omitempty"`            // The starting port (icmp type), not used for protocol -1
+	ToPort              *int64 `json:"to_port,omitempty"`              // The ending port (icmp code), not used for protocol -1
+	CidrIp              string `json:"cidr_ip,omitempty"`              // IPv4 CIDR address range to allow traffic to/from
+	CidrIpv6            string `json:"cidr_ipv6,omitempty"`            // IPv6 CIDR address range to allow traffic to/from
+	SgId                string `json:"sg_id,omitempty"`                // Security group to allow traffic to/from
+	UserId              string `json:"user_id,omitempty"`              // Account owning sg_id, when it's in another account
+	PrefixListId        string `json:"prefix_list_id,omitempty"`       // Prefix list to allow traffic to/from
+	Description         string `json:"description,omitempty"`          // Optional description for this rule
+	PreviousDescription string `json:"previous_description,omitempty"` // Current description, when the description is updated
+}
+
+// Ec2SecurityGroupRulesSyncRequest is the complete desired rule set for a security group.  Omitting ingress or egress
+// leaves those rules unchanged, an empty list removes all of them.
+type Ec2SecurityGroupRulesSyncRequest struct {
+	Ingress []*Ec2SecurityGroupRule `json:"ingress"`
+	Egress  []*Ec2SecurityGroupRule `json:"egress"`
+	DryRun  *bool                   `json:"dry_run"`
+}
+
+type Ec2SecurityGroupRuleDiff struct {
+	Add               []*Ec2SecurityGroupRule `json:"add"`
+	Remove            []*Ec2SecurityGroupRule `json:"remove"`
+	UpdateDescription []*Ec2SecurityGroupRule `json:"update_description"`
+	Unchanged         int                     `json:"unchanged"`
+}
+
+type Ec2SecurityGroupRulesSyncResponse struct {
+	GroupId string                    `json:"group_id"`
+	DryRun  bool                      `json:"dry_run"`
+	Changed bool                      `json:"changed"`
+	Ingress *Ec2SecurityGroupRuleDiff `json:"ingress,omitempty"`
+	Egress  *Ec2SecurityGroupRuleDiff `json:"egress,omitempty"`
+}
+
+// Ec2SecurityGroupFinding is a risky pattern found in a security group rule
+type Ec2SecurityGroupFinding struct {
+	GroupId    string `json:"group_id,omitempty"`
+	GroupName  string `json:"group_name,omitempty"`
+	Check      string `json:"check"`               // The check that reported the finding
+	Severity   string `json:"severity"`            // [low|medium|high|critical]
+	Direction  string `json:"direction"`           // Direction of traffic: [inbound|outbound]
+	IpProtocol string `json:"ip_protocol"`         // IP Protocol name [tcp|udp|icmp|icmpv6|-1] or number
+	FromPort   *int64 `json:"from_port,omitempty"` // The starting port, only for tcp and udp
+	ToPort     *int64 `json:"to_port,omitempty"`   // The ending port, only for tcp and udp
+	Source     string `json:"source"`              // The CIDR, security group or prefix list the rule applies to
+	Message    string `json:"message"`
+}
+
+type Ec2SecurityGroupFindingsResponse struct {
+	Groups   int                        `json:"groups"`
+	Summary  map[string]int             `json:"summary"`
+	Findings []*Ec2SecurityGroupFinding `json:"findings"`
+}
+
+// Ec2SecurityGroupUsageResponse lists the resources that use or reference a security group
+type Ec2SecurityGroupUsageResponse struct {
+	GroupId           string                              `json:"group_id"`
+	GroupName         string                              `json:"group_name"`
+	InUse             bool                                `json:"in_use"`
+	Instances         []string                            `json:"instances"`
+	NetworkInterfaces []*Ec2SecurityGroupNetworkInterface `json:"network_interfaces"`
+	ReferencingGroups []*Ec2SecurityGroupReference        `json:"referencing_groups"`
+}
+
+// Ec2SecurityGroupNetworkInterface is a network interface the security group is attached to
+type Ec2SecurityGroupNetworkInterface struct {
+	NetworkInterfaceId string   `json:"network_interface_id"`
+	InterfaceType      string   `json:"interface_type"`
+	Description        string   `json:"description"`
+	Status             string   `json:"status"`
+	InstanceId         string   `json:"instance_id,omitempty"`
+	RequesterManaged   bool     `json:"requester_managed"` // Managed by an AWS service, its security groups can't be changed
+	Groups             []string `json:"groups"`            // All of the security groups attached to the network interface
+}
+
+// Ec2SecurityGroupReference is another security group with rules referencing the security group
+type Ec2SecurityGroupReference struct {
+	GroupId   string                  `json:"group_id"`
+	GroupName string                  `json:"group_name"`
+	Ingress   []*Ec2SecurityGroupRule `json:"ingress"`
+	Egress    []*Ec2SecurityGroupRule `json:"egress"`
+}
+
+// Ec2SecurityGroupDeleteResponse is returned when a security group is deleted or, with a 409, when it's blocked
+// from being deleted
+type Ec2SecurityGroupDeleteResponse struct {
+	GroupId                   string                         `json:"group_id"`
+	Deleted                   bool                           `json:"deleted"`
+	Message                   string                         `json:"message,omitempty"`
+	DetachedNetworkInterfaces []string                       `json:"detached_network_interfaces,omitempty"`
+	RevokedReferences         []*Ec2SecurityGroupReference   `json:"revoked_references,omitempty"`
+	Blockers                  *Ec2SecurityGroupUsageResponse `json:"blockers,omitempty"`
+}
+
+// Ec2SecurityGroupExport is a portable definition of a set of security groups and their rules.  Rules referencing
+// another group in the set use the key of that group, so the references can be translated when the set is imported.
+type Ec2SecurityGroupExport struct {
+	Version       int                           `json:"version"`
+	SourceAccount string                        `json:"source_account,omitempty"`
+	SourceVpcId   string                        `json:"source_vpc_id,omitempty"`
+	Groups        []*Ec2SecurityGroupDefinition `json:"groups"`
+}
+
+type Ec2SecurityGroupDefinition struct {
+	Key         string                  `json:"key"` // Identifies the group in the set, the original group id when exported
+	GroupName   string                  `json:"group_name"`
+	Description string                  `json:"description"`
+	Tags        []map[string]string     `json:"tags,omitempty"`
+	Ingress     []*Ec2SecurityGroupRule `json:"ingress"`
+	Egress      []*Ec2SecurityGroupRule `json:"egress"` // Omit to keep the default egress rule of a new group
+}
+
+// Ec2SecurityGroupImportRequest creates a set of security groups in a VPC, it accepts an export with the target vpc_id
+type Ec2SecurityGroupImportRequest struct {
+	VpcId  string                        `json:"vpc_id"`
+	Groups []*Ec2SecurityGroupDefinition `json:"groups"`
+}
+
+type Ec2SecurityGroupImportResponse struct {
+	VpcId  string                      `json:"vpc_id"`
+	Groups []*Ec2SecurityGroupImported `json:"groups"`
+}
+
+type Ec2SecurityGroupImported struct {
+	Key       string `json:"key"`
+	GroupId   string `json:"group_id"`
+	GroupName string `json:"group_name"`
+}
+
+// Ec2SecurityGroupCloneRequest copies a set of security groups to a VPC, optionally in another account
+type Ec2SecurityGroupCloneRequest struct {
+	GroupIds      []string `json:"group_ids"`
+	VpcId         string   `json:"vpc_id"`
+	TargetAccount string   `json:"target_account"` // Defaults to the account of the source groups
+}
+
 type Ec2SecurityGroupUserIdGroupPair struct {
 	Description          string `json:"description,omitempty"`
 	GroupId              string `json:"group_id,omitempty"`
@@ -601,6 +765,636 @@ func toEc2SecurityGroupResponse(sg *ec2.SecurityGroup) *Ec2SecurityGroupResponse
 	}
 }
 
+// Ec2PrefixListEntry is a CIDR block in a managed prefix list
+type Ec2PrefixListEntry struct {
+	Cidr        string `json:"cidr"`
+	Description string `json:"description,omitempty"`
+}
+
+// Ec2PrefixListCreateRequest creates a customer-managed prefix list
+type Ec2PrefixListCreateRequest struct {
+	Name          string                `json:"name"`
+	AddressFamily string                `json:"address_family"` // IPv4 or IPv6, defaults to IPv4
+	MaxEntries    int64                 `json:"max_entries"`    // Defaults to the number of entries
+	Entries       []*Ec2PrefixListEntry `json:"entries"`
+	Tags          []map[string]string   `json:"tags"`
+}
+
+// Ec2PrefixListUpdateRequest modifies a prefix list.  Entries is the complete desired set of entries, alternatively
+// add_entries and remove_entries change individual entries.  If current_version is given, the update is rejected
+// when the prefix list has changed since that version.
+type Ec2PrefixListUpdateRequest struct {
+	Name           *string               `json:"name"`
+	MaxEntries     *int64                `json:"max_entries"` // Can't be changed together with the entries
+	CurrentVersion *int64                `json:"current_version"`
+	Entries        []*Ec2PrefixListEntry `json:"entries"`
+	AddEntries     []*Ec2PrefixListEntry `json:"add_entries"`
+	RemoveEntries  []*Ec2PrefixListEntry `json:"remove_entries"`
+	Tags           map[string]string     `json:"tags"`
+}
+
+// Ec2PrefixListRestoreRequest restores the entries of a prefix list to a previous version
+type Ec2PrefixListRestoreRequest struct {
+	Version int64 `json:"version"`
+}
+
+type Ec2PrefixListResponse struct {
+	PrefixListId   string                `json:"prefix_list_id"`
+	Name           string                `json:"name"`
+	AddressFamily  string                `json:"address_family"`
+	State          string                `json:"state"`
+	StateMessage   string                `json:"state_message,omitempty"`
+	Version        int64                 `json:"version"`
+	MaxEntries     int64                 `json:"max_entries"`
+	OwnerId        string                `json:"owner_id"`
+	Arn            string                `json:"arn"`
+	EntriesVersion int64                 `json:"entries_version,omitempty"` // Set when the entries of a previous version are returned
+	Entries        []*Ec2PrefixListEntry `json:"entries,omitempty"`
+	Tags           []map[string]string   `json:"tags"`
+}
+
+// Ec2PrefixListReferencesResponse lists the security groups with rules referencing a prefix list
+type Ec2PrefixListReferencesResponse struct {
+	PrefixListId   string                       `json:"prefix_list_id"`
+	Name           string                       `json:"name"`
+	SecurityGroups []*Ec2SecurityGroupReference `json:"security_groups"`
+}
+
+// toEc2PrefixListResponse converts a managed prefix list and, optionally, its entries to the json response format
+func toEc2PrefixListResponse(pl *ec2.ManagedPrefixList, entries []*ec2.PrefixListEntry) *Ec2PrefixListResponse {
+	out := &Ec2PrefixListResponse{
+		PrefixListId:  aws.StringValue(pl.PrefixListId),
+		Name:          aws.StringValue(pl.PrefixListName),
+		AddressFamily: aws.StringValue(pl.AddressFamily),
+		State:         aws.StringValue(pl.State),
+		StateMessage:  aws.StringValue(pl.StateMessage),
+		Version:       aws.Int64Value(pl.Version),
+		MaxEntries:    aws.Int64Value(pl.MaxEntries),
+		OwnerId:       aws.StringValue(pl.OwnerId),
+		Arn:           aws.StringValue(pl.PrefixListArn),
+		Tags:          make([]map[string]string, 0, len(pl.Tags)),
+	}
+
+	for _, t := range pl.Tags {
+		out.Tags = append(out.Tags, map[string]string{
+			aws.StringValue(t.Key): aws.StringValue(t.Value),
+		})
+	}
+
+	if entries != nil {
+		out.Entries = make([]*Ec2PrefixListEntry, 0, len(entries))
+		for _, e := range entries {
+			out.Entries = append(out.Entries, &Ec2PrefixListEntry{
+				Cidr:        aws.StringValue(e.Cidr),
+				Description: aws.StringValue(e.Description),
+			})
+		}
+	}
+
+	return out
+}
+
+// Ec2ElasticIpAllocateRequest allocates an elastic ip, it's always tagged with the spinup:org of the api
+type Ec2ElasticIpAllocateRequest struct {
+	NetworkBorderGroup string              `json:"network_border_group"`
+	PublicIpv4Pool     string              `json:"public_ipv4_pool"`
+	Tags               []map[string]string `json:"tags"`
+}
+
+// Ec2ElasticIpAssociateRequest associates an elastic ip with either an instance or a network interface
+type Ec2ElasticIpAssociateRequest struct {
+	InstanceId         string `json:"instance_id"`
+	NetworkInterfaceId string `json:"network_interface_id"`
+	PrivateIp          string `json:"private_ip"`          // Defaults to the primary private ip
+	AllowReassociation bool   `json:"allow_reassociation"` // Move the elastic ip if it's already associated
+}
+
+type Ec2ElasticIpUpdateRequest struct {
+	Tags map[string]string `json:"tags"`
+}
+
+type Ec2ElasticIpResponse struct {
+	AllocationId       string              `json:"allocation_id"`
+	PublicIp           string              `json:"public_ip"`
+	Domain             string              `json:"domain"`
+	NetworkBorderGroup string              `json:"network_border_group,omitempty"`
+	PublicIpv4Pool     string              `json:"public_ipv4_pool,omitempty"`
+	AssociationId      string              `json:"association_id,omitempty"`
+	InstanceId         string              `json:"instance_id,omitempty"`
+	NetworkInterfaceId string              `json:"network_interface_id,omitempty"`
+	PrivateIp          string              `json:"private_ip,omitempty"`
+	Tags               []map[string]string `json:"tags"`
+}
+
+// toEc2ElasticIpResponse converts an elastic ip to the json response format
+func toEc2ElasticIpResponse(address *ec2.Address) *Ec2ElasticIpResponse {
+	tags := make([]map[string]string, 0, len(address.Tags))
+	for _, t := range address.Tags {
+		tags = append(tags, map[string]string{
+			aws.StringValue(t.Key): aws.StringValue(t.Value),
+		})
+	}
+
+	return &Ec2ElasticIpResponse{
+		AllocationId:       aws.StringValue(address.AllocationId),
+		PublicIp:           aws.StringValue(address.PublicIp),
+		Domain:             aws.StringValue(address.Domain),
+		NetworkBorderGroup: aws.StringValue(address.NetworkBorderGroup),
+		PublicIpv4Pool:     aws.StringValue(address.PublicIpv4Pool),
+		AssociationId:      aws.StringValue(address.AssociationId),
+		InstanceId:         aws.StringValue(address.InstanceId),
+		NetworkInterfaceId: aws.StringValue(address.NetworkInterfaceId),
+		PrivateIp:          aws.StringValue(address.PrivateIpAddress),
+		Tags:               tags,
+	}
+}
+
+// Ec2NetworkInterfaceCreateRequest creates a network interface in a subnet
+type Ec2NetworkInterfaceCreateRequest struct {
+	SubnetId                string              `json:"subnet_id"`
+	Description             string              `json:"description"`
+	Sgs                     []string            `json:"sgs"`                        // Defaults to the default security group of the vpc
+	PrivateIp               string              `json:"private_ip"`                 // Primary private ip, defaults to an ip from the subnet
+	SecondaryPrivateIps     []string            `json:"secondary_private_ips"`      // Specific secondary private ips
+	SecondaryPrivateIpCount int64               `json:"secondary_private_ip_count"` // Number of secondary private ips to assign from the subnet
+	Ipv6AddressCount        int64               `json:"ipv6_address_count"`
+	Tags                    []map[string]string `json:"tags"`
+}
+
+// Ec2NetworkInterfaceUpdateRequest modifies the security groups, source/destination check or description of a network interface
+type Ec2NetworkInterfaceUpdateRequest struct {
+	Sgs             []string          `json:"sgs"`
+	SourceDestCheck *bool             `json:"source_dest_check"`
+	Description     *string           `json:"description"`
+	Tags            map[string]string `json:"tags"`
+}
+
+// Ec2NetworkInterfaceAttachRequest attaches a network interface to an instance
+type Ec2NetworkInterfaceAttachRequest struct {
+	InstanceId  string `json:"instance_id"`
+	DeviceIndex *int64 `json:"device_index"` // Defaults to the next free device index
+}
+
+type Ec2NetworkInterface struct {
+	Id              string                          `json:"id"`
+	Description     string                          `json:"description"`
+	InterfaceType   string                          `json:"interface_type"`
+	Status          string                          `json:"status"`
+	SubnetId        string                          `json:"subnet_id"`
+	VpcId           string                          `json:"vpc_id"`
+	Az              string                          `json:"az,omitempty"`
+	MacAddress      string                          `json:"mac_address"`
+	InstanceId      string                          `json:"instance_id,omitempty"`
+	AttachmentId    string                          `json:"attachment_id,omitempty"`
+	DeviceIndex     *int64                          `json:"device_index,omitempty"`
+	PrivateIp       string                          `json:"private_ip"`
+	PublicIp        string                          `json:"public_ip,omitempty"`
+	PrivateIps      []*Ec2NetworkInterfacePrivateIp `json:"private_ips"`
+	Ipv6Addresses   []string                        `json:"ipv6_addresses"`
+	Sgs             []map[string]string             `json:"sgs"`
+	SourceDestCheck bool                            `json:"source_dest_check"`
+	Tags            []map[string]string             `json:"tags,omitempty"`
+}
+
+type Ec2NetworkInterfacePrivateIp struct {
+	PrivateIp string `json:"private_ip"`
+	Primary   bool   `json:"primary"`
+	PublicIp  string `json:"public_ip,omitempty"`
+}
+
+// toEc2NetworkInterfaceResponse converts a network interface to the json response format
+func toEc2NetworkInterfaceResponse(eni *ec2.NetworkInterface) *Ec2NetworkInterface {
+	out := &Ec2NetworkInterface{
+		Id:              aws.StringValue(eni.NetworkInterfaceId),
+		Description:     aws.StringValue(eni.Description),
+		InterfaceType:   aws.StringValue(eni.InterfaceType),
+		Status:          aws.StringValue(eni.Status),
+		SubnetId:        aws.StringValue(eni.SubnetId),
+		VpcId:           aws.StringValue(eni.VpcId),
+		Az:              aws.StringValue(eni.AvailabilityZone),
+		MacAddress:      aws.StringValue(eni.MacAddress),
+		PrivateIp:       aws.StringValue(eni.PrivateIpAddress),
+		PrivateIps:      make([]*Ec2NetworkInterfacePrivateIp, 0, len(eni.PrivateIpAddresses)),
+		Ipv6Addresses:   make([]string, 0, len(eni.Ipv6Addresses)),
+		Sgs:             make([]map[string]string, 0, len(eni.Groups)),
+		SourceDestCheck: aws.BoolValue(eni.SourceDestCheck),
+		Tags:            tagsList(eni.TagSet),
+	}
+
+	if eni.Attachment != nil {
+		out.InstanceId = aws.StringValue(eni.Attachment.InstanceId)
+		out.AttachmentId = aws.StringValue(eni.Attachment.AttachmentId)
+		out.DeviceIndex = eni.Attachment.DeviceIndex
+	}
+
+	if eni.Association != nil {
+		out.PublicIp = aws.StringValue(eni.Association.PublicIp)
+	}
+
+	for _, ip := range eni.PrivateIpAddresses {
+		privateIp := &Ec2NetworkInterfacePrivateIp{
+			PrivateIp: aws.StringValue(ip.PrivateIpAddress),
+			Primary:   aws.BoolValue(ip.Primary),
+		}
+		if ip.Association != nil {
+			privateIp.PublicIp = aws.StringValue(ip.Association.PublicIp)
+		}
+		out.PrivateIps = append(out.PrivateIps, privateIp)
+	}
+
+	for _, ip := range eni.Ipv6Addresses {
+		out.Ipv6Addresses = append(out.Ipv6Addresses, aws.StringValue(ip.Ipv6Address))
+	}
+
+	for _, g := range eni.Groups {
+		out.Sgs = append(out.Sgs, map[string]string{
+			aws.StringValue(g.GroupId): aws.StringValue(g.GroupName),
+		})
+	}
+
+	return out
+}
+
+// toEc2InstanceNetworkInterfaces converts the network interfaces of an instance to the json response format, ordered
+// by device index
+func toEc2InstanceNetworkInterfaces(enis []*ec2.InstanceNetworkInterface) []*Ec2NetworkInterface {
+	out := make([]*Ec2NetworkInterface, 0, len(enis))
+	for _, eni := range enis {
+		out = append(out, toEc2NetworkInterfaceResponse(instanceNetworkInterface(eni)))
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return aws.Int64Value(out[i].DeviceIndex) < aws.Int64Value(out[j].DeviceIndex)
+	})
+
+	return out
+}
+
+// instanceNetworkInterface returns the fields of an instance network interface that are in the json response format
+// as a network interface
+func instanceNetworkInterface(eni *ec2.InstanceNetworkInterface) *ec2.NetworkInterface {
+	out := &ec2.NetworkInterface{
+		NetworkInterfaceId: eni.NetworkInterfaceId,
+		Description:        eni.Description,
+		InterfaceType:      eni.InterfaceType,
+		Status:             eni.Status,
+		SubnetId:           eni.SubnetId,
+		VpcId:              eni.VpcId,
+		MacAddress:         eni.MacAddress,
+		PrivateIpAddress:   eni.PrivateIpAddress,
+		SourceDestCheck:    eni.SourceDestCheck,
+		Groups:             eni.Groups,
+	}
+
+	if eni.Attachment != nil {
+		out.Attachment = &ec2.NetworkInterfaceAttachment{
+			AttachmentId: eni.Attachment.AttachmentId,
+			DeviceIndex:  eni.Attachment.DeviceIndex,
+		}
+	}
+
+	if eni.Association != nil {
+		out.Association = &ec2.NetworkInterfaceAssociation{PublicIp: eni.Association.PublicIp}
+	}
+
+	for _, ip := range eni.PrivateIpAddresses {
+		privateIp := &ec2.NetworkInterfacePrivateIpAddress{
+			PrivateIpAddress: ip.PrivateIpAddress,
+			Primary:          ip.Primary,
+		}
+		if ip.Association != nil {
+			privateIp.Association = &ec2.NetworkInterfaceAssociation{PublicIp: ip.Association.PublicIp}
+		}
+		out.PrivateIpAddresses = append(out.PrivateIpAddresses, privateIp)
+	}
+
+	for _, ip := range eni.Ipv6Addresses {
+		out.Ipv6Addresses = append(out.Ipv6Addresses, &ec2.NetworkInterfaceIpv6Address{Ipv6Address: ip.Ipv6Address})
+	}
+
+	return out
+}
+
+// Ec2KeyPairCreateRequest creates a key pair, it's always tagged with the spinup:org of the api.  When an ssm
+// parameter is given, the private key is also stored in it as a SecureString.
+type Ec2KeyPairCreateRequest struct {
+	Name         string              `json:"name"`
+	KeyType      string              `json:"key_type"`   // rsa or ed25519, defaults to rsa
+	KeyFormat    string              `json:"key_format"` // pem or ppk, defaults to pem
+	SsmParameter string              `json:"ssm_parameter"`
+	SsmKmsKeyId  string              `json:"ssm_kms_key_id"` // Defaults to the aws managed key
+	Tags         []map[string]string `json:"tags"`
+}
+
+// Ec2KeyPairImportRequest imports the public key of a key pair, it's always tagged with the spinup:org of the api
+type Ec2KeyPairImportRequest struct {
+	Name      string              `json:"name"`
+	PublicKey string              `json:"public_key"`
+	Tags      []map[string]string `json:"tags"`
+}
+
+type Ec2KeyPairUpdateRequest struct {
+	Tags map[string]string `json:"tags"`
+}
+
+type Ec2KeyPairResponse struct {
+	Id           string              `json:"id"`
+	Name         string              `json:"name"`
+	Type         string              `json:"type"`
+	Fingerprint  string              `json:"fingerprint"`
+	CreatedAt    string              `json:"created_at,omitempty"`
+	PublicKey    string              `json:"public_key,omitempty"`
+	PrivateKey   string              `json:"private_key,omitempty"` // Only returned when the key pair is created
+	SsmParameter string              `json:"ssm_parameter,omitempty"`
+	Tags         []map[string]string `json:"tags"`
+}
+
+// toEc2KeyPairResponse converts a key pair to the json response format
+func toEc2KeyPairResponse(kp *ec2.KeyPairInfo) *Ec2KeyPairResponse {
+	tags := make([]map[string]string, 0, len(kp.Tags))
+	for _, t := range kp.Tags {
+		tags = append(tags, map[string]string{
+			aws.StringValue(t.Key): aws.StringValue(t.Value),
+		})
+	}
+
+	return &Ec2KeyPairResponse{
+		Id:          aws.StringValue(kp.KeyPairId),
+		Name:        aws.StringValue(kp.KeyName),
+		Type:        aws.StringValue(kp.KeyType),
+		Fingerprint: aws.StringValue(kp.KeyFingerprint),
+		CreatedAt:   timeFormat(kp.CreateTime),
+		PublicKey:   aws.StringValue(kp.PublicKey),
+		Tags:        tags,
+	}
+}
+
+// Ec2InstanceConsoleResponse is the decoded system console output of an instance
+type Ec2InstanceConsoleResponse struct {
+	InstanceId string `json:"instance_id"`
+	Latest     bool   `json:"latest"`
+	Timestamp  string `json:"timestamp,omitempty"`
+	Output     string `json:"output"`
+}
+
+// Ec2InstanceStatusResponse is the status checks, ebs status and scheduled events of an instance
+type Ec2InstanceStatusResponse struct {
+	InstanceId       string              `json:"instance_id"`
+	AvailabilityZone string              `json:"availability_zone"`
+	State            string              `json:"state"`
+	InstanceStatus   *Ec2StatusCheck     `json:"instance_status"`
+	SystemStatus     *Ec2StatusCheck     `json:"system_status"`
+	EbsStatus        []*Ec2VolumeStatus  `json:"ebs_status"`
+	Events           []*Ec2InstanceEvent `json:"events"`
+}
+
+// Ec2StatusCheck is the result of an instance, system or volume status check
+type Ec2StatusCheck struct {
+	Status  string                  `json:"status"`
+	Details []*Ec2StatusCheckDetail `json:"details,omitempty"`
+}
+
+// Ec2StatusCheckDetail is the result of a single status check
+type Ec2StatusCheckDetail struct {
+	Name          string `json:"name"`
+	Status        string `json:"status"`
+	ImpairedSince string `json:"impaired_since,omitempty"`
+}
+
+// Ec2VolumeStatus is the status check of a volume attached to an instance
+type Ec2VolumeStatus struct {
+	VolumeId string          `json:"volume_id"`
+	Status   *Ec2StatusCheck `json:"status"`
+}
+
+// Ec2InstanceEvent is a scheduled maintenance event of an instance, like a reboot or a retirement
+type Ec2InstanceEvent struct {
+	Id                string `json:"id"`
+	InstanceId        string `json:"instance_id"`
+	Code              string `json:"code"`
+	Description       string `json:"description"`
+	NotBefore         string `json:"not_before,omitempty"`
+	NotAfter          string `json:"not_after,omitempty"`
+	NotBeforeDeadline string `json:"not_before_deadline,omitempty"`
+	Reschedulable     bool   `json:"reschedulable"`
+}
+
+// Ec2InstanceEventRescheduleRequest reschedules a scheduled event of an instance
+type Ec2InstanceEventRescheduleRequest struct {
+	NotBefore string `json:"not_before"` // RFC3339, must be before the not_before_deadline of the event
+}
+
+// Ec2InstanceSchedule is the power schedule of an instance
+type Ec2InstanceSchedule struct {
+	InstanceId string                      `json:"instance_id"`
+	Timezone   string                      `json:"timezone,omitempty"`
+	Rules      []*Ec2InstanceScheduleRule  `json:"rules"`
+	SkipUntil  string                      `json:"skip_until,omitempty"`
+	NextAction *Ec2InstanceScheduledAction `json:"next_action,omitempty"`
+}
+
+// Ec2InstanceScheduleRule starts or stops an instance at the times matching a cron expression
+type Ec2InstanceScheduleRule struct {
+	Action string `json:"action"` // start or stop
+	Cron   string `json:"cron"`   // minute hour day-of-month month day-of-week, in the time zone of the schedule
+}
+
+// Ec2InstanceScheduledAction is the next action of an instance schedule
+type Ec2InstanceScheduledAction struct {
+	Action  string `json:"action"`
+	Time    string `json:"time"` // RFC3339, in the time zone of the schedule
+	Skipped bool   `json:"skipped,omitempty"`
+}
+
+// Ec2InstanceScheduleRequest replaces the power schedule of an instance.  When rules are omitted the rules and the
+// time zone are kept and only the skip is changed, an empty list of rules removes the schedule.
+type Ec2InstanceScheduleRequest struct {
+	Timezone  string                     `json:"timezone"` // IANA time zone, defaults to UTC
+	Rules     []*Ec2InstanceScheduleRule `json:"rules"`
+	SkipUntil *string                    `json:"skip_until"` // RFC3339, actions before it are skipped, empty clears it
+	SkipNext  bool                       `json:"skip_next"`  // skips the next action only
+}
+
+// Ec2IdleInstanceReport lists the running instances in the org whose cpu and network use stayed under the idle
+// thresholds for the window
+type Ec2IdleInstanceReport struct {
+	Window           string             `json:"window"`
+	CpuThreshold     float64            `json:"cpu_threshold"`
+	NetworkThreshold float64            `json:"network_threshold"`
+	AutoStop         bool               `json:"auto_stop"`
+	GracePeriod      string             `json:"grace_period,omitempty"`
+	Instances        []*Ec2IdleInstance `json:"instances"`
+}
+
+// Ec2IdleInstance is an idle instance and its average use over the window
+type Ec2IdleInstance struct {
+	InstanceId            string  `json:"instance_id"`
+	Name                  string  `json:"name,omitempty"`
+	InstanceType          string  `json:"instance_type"`
+	LaunchTime            string  `json:"launch_time"`
+	CpuAverage            float64 `json:"cpu_average"`
+	NetworkBytesPerSecond float64 `json:"network_bytes_per_second"`
+	IdleSince             string  `json:"idle_since,omitempty"`
+	StopAfter             string  `json:"stop_after,omitempty"`
+	OptedOut              bool    `json:"opted_out"`
+}
+
+// Ec2EstimateRequest is a request to estimate the cost of an instance and volumes before creating them
+type Ec2EstimateRequest struct {
+	Region   *string                   `json:"region"` // defaults to the region of the api
+	Instance *Ec2InstanceCreateRequest `json:"instance"`
+	Volumes  []*Ec2VolumeCreateRequest `json:"volumes"`
+}
+
+// Ec2EstimateResponse is the estimated on-demand cost of the resources in an estimate request
+type Ec2EstimateResponse struct {
+	Region      string             `json:"region"`
+	Currency    string             `json:"currency"`
+	PricesDate  string             `json:"prices_date,omitempty"`
+	HourlyCost  float64            `json:"hourly_cost"`
+	MonthlyCost float64            `json:"monthly_cost"`
+	Items       []*Ec2EstimateItem `json:"items"`
+	Warnings    []string           `json:"warnings,omitempty"` // resources or parts of resources that aren't included
+}
+
+// Ec2EstimateItem is the estimated cost of a single resource
+type Ec2EstimateItem struct {
+	Resource    string  `json:"resource"`         // instance or volume
+	Device      string  `json:"device,omitempty"` // device name of the volumes of an instance
+	Type        string  `json:"type"`             // instance or volume type
+	Size        int64   `json:"size,omitempty"`   // GB
+	Iops        int64   `json:"iops,omitempty"`   // provisioned iops
+	HourlyCost  float64 `json:"hourly_cost"`
+	MonthlyCost float64 `json:"monthly_cost"`
+}
+
+// Ec2InstanceTypePrice is an instance type returned by the instance selector with its on-demand price
+type Ec2InstanceTypePrice struct {
+	InstanceType string   `json:"instance_type"`
+	HourlyCost   *float64 `json:"hourly_cost,omitempty"` // not set for types missing from the price catalog
+	MonthlyCost  *float64 `json:"monthly_cost,omitempty"`
+}
+
+// Ec2SummaryResponse is the inventory and estimated monthly cost of the resources in the org, grouped by the value
+// of a tag
+type Ec2SummaryResponse struct {
+	GroupBy     string             `json:"group_by,omitempty"` // tag key, resources aren't grouped when empty
+	Region      string             `json:"region"`
+	Currency    string             `json:"currency"`
+	MonthlyCost float64            `json:"monthly_cost"`
+	Groups      []*Ec2SummaryGroup `json:"groups"`
+	Warnings    []string           `json:"warnings,omitempty"` // resources left out of the costs
+}
+
+// Ec2SummaryGroup is the inventory of the resources with the same tag value, resources without the tag have an
+// empty value
+type Ec2SummaryGroup struct {
+	Value       string              `json:"value"`
+	MonthlyCost float64             `json:"monthly_cost"`
+	Instances   *Ec2InstanceSummary `json:"instances"`
+	Volumes     *Ec2ResourceSummary `json:"volumes"`
+	Snapshots   *Ec2ResourceSummary `json:"snapshots"`
+	Images      *Ec2ResourceSummary `json:"images"` // images are stored in snapshots, their size isn't costed again
+}
+
+// Ec2ResourceSummary is the count, size and estimated monthly cost of a type of resource
+type Ec2ResourceSummary struct {
+	Count       int                        `json:"count"`
+	SizeGB      int64                      `json:"size_gb,omitempty"`
+	MonthlyCost float64                    `json:"monthly_cost"`
+	ByType      map[string]*Ec2SummaryItem `json:"by_type,omitempty"` // instance or volume type
+}
+
+// Ec2InstanceSummary is the resource summary of instances, only running instances are costed
+type Ec2InstanceSummary struct {
+	Ec2ResourceSummary
+	ByState map[string]int `json:"by_state"`
+}
+
+// Ec2SummaryItem is the count, size and estimated monthly cost of an instance or volume type
+type Ec2SummaryItem struct {
+	Count       int     `json:"count"`
+	SizeGB      int64   `json:"size_gb,omitempty"`
+	MonthlyCost float64 `json:"monthly_cost"`
+}
+
+// Ec2MetricsResponse is the cloudwatch metrics of an instance or a volume between start and end
+type Ec2MetricsResponse struct {
+	Id      string             `json:"id"`
+	Start   string             `json:"start"`
+	End     string             `json:"end"`
+	Period  int64              `json:"period"` // seconds each datapoint is aggregated over
+	Metrics []*Ec2MetricSeries `json:"metrics"`
+}
+
+// Ec2MetricSeries is the datapoints of a metric, oldest first
+type Ec2MetricSeries struct {
+	Name       string                `json:"name"`
+	Namespace  string                `json:"namespace"`
+	Metric     string                `json:"metric"`
+	Statistic  string                `json:"statistic"`
+	Unit       string                `json:"unit,omitempty"`
+	Datapoints []*Ec2MetricDatapoint `json:"datapoints"`
+}
+
+// Ec2MetricDatapoint is the value of a metric for the period starting at the timestamp
+type Ec2MetricDatapoint struct {
+	Timestamp string  `json:"timestamp"`
+	Value     float64 `json:"value"`
+}
+
+// Ec2ResourceEvent is a change of a resource sent to the subscribers of the event stream of an account
+type Ec2ResourceEvent struct {
+	Id         uint64 `json:"id"`
+	Type       string `json:"type"`
+	Account    string `json:"account"`
+	ResourceId string `json:"resource_id"`
+	Previous   string `json:"previous,omitempty"`
+	Current    string `json:"current"`
+	Source     string `json:"source"`
+	Time       string `json:"time"`
+}
+
+// Ec2LifecycleEvent is sent to webhook endpoints when a resource is created or deleted with the api
+type Ec2LifecycleEvent struct {
+	Id         string `json:"id"`
+	Type       string `json:"type"`
+	Account    string `json:"account"`
+	Org        string `json:"org"`
+	ResourceId string `json:"resource_id"`
+	Time       string `json:"time"`
+}
+
+// Ec2InstanceConnectRequest pushes a short-lived ssh public key to an instance with ec2 instance connect
+type Ec2InstanceConnectRequest struct {
+	OsUser      string `json:"os_user"`
+	PublicKey   string `json:"public_key"`
+	RequestedBy string `json:"requested_by"` // Identity of the user requesting access, recorded in the logs
+}
+
+type Ec2InstanceConnectResponse struct {
+	InstanceId string `json:"instance_id"`
+	OsUser     string `json:"os_user"`
+	Host       string `json:"host"`
+	RequestId  string `json:"request_id"`
+	ExpiresAt  string `json:"expires_at"`
+}
+
+// Ec2InstanceSessionRequest starts an ssm session manager session on an instance
+type Ec2InstanceSessionRequest struct {
+	DocumentName string              `json:"document_name"` // Defaults to a shell session, only shell and local port forwarding are allowed
+	Parameters   map[string][]string `json:"parameters"`
+	Reason       string              `json:"reason"`
+	RequestedBy  string              `json:"requested_by"` // Identity of the user requesting access, recorded in the logs
+}
+
+type Ec2InstanceSessionResponse struct {
+	InstanceId string `json:"instance_id"`
+	SessionId  string `json:"session_id"`
+	StreamUrl  string `json:"stream_url"`
+	TokenValue string `json:"token_value"`
+}
+
 type Ec2VpcResponse struct {
 	Id                   string                     `json:"id"`
 	CIDRBlock            string                     `json:"cidr_block"`
@@ -855,3 +1649,130 @@ type Ec2VolumeUpdateRequest struct {
 	Iops *int64             `json:"iops"`
 	Tags *map[string]string `json:"tags,omitempty"`
 }
+
+type Ec2VolumeMigrationRequest struct {
+	SourceType  *string           `json:"source_type"` // Volume type to migrate from: [gp2|io1]
+	TargetType  *string           `json:"target_type"` // Volume type to migrate to: [gp3|io2]
+	VolumeIds   []string          `json:"volume_ids"`  // Optional list of volume ids to limit the migration to
+	Tags        map[string]string `json:"tags"`        // Optional tags the selected volumes must have
+	Org         *string           `json:"org"`         // Optional org the selected volumes must belong to
+	Concurrency *int              `json:"concurrency"` // Maximum number of concurrent ModifyVolume calls
+	DryRun      *bool             `json:"dry_run"`     // Only compute the migration plan and estimated savings
+}
+
+type Ec2VolumeMigrationItem struct {
+	VolumeId           string  `json:"volume_id"`
+	Size               int64   `json:"size"`
+	OriginalType       string  `json:"original_type"`
+	OriginalIops       int64   `json:"original_iops"`
+	OriginalThroughput int64   `json:"original_throughput"`
+	TargetType         string  `json:"target_type"`
+	TargetIops         int64   `json:"target_iops"`
+	TargetThroughput   int64   `json:"target_throughput"`
+	CurrentMonthlyCost float64 `json:"current_monthly_cost"`
+	TargetMonthlyCost  float64 `json:"target_monthly_cost"`
+	ModificationState  string  `json:"modification_state,omitempty"`
+	Progress           int64   `json:"progress"`
+	StatusMessage      string  `json:"status_message,omitempty"`
+	Error              string  `json:"error,omitempty"`
+}
+
+type Ec2VolumeMigrationSummary struct {
+	Total                   int     `json:"total"`
+	Submitted               int     `json:"submitted"`
+	Completed               int     `json:"completed"`
+	Failed                  int     `json:"failed"`
+	CurrentMonthlyCost      float64 `json:"current_monthly_cost"`
+	TargetMonthlyCost       float64 `json:"target_monthly_cost"`
+	EstimatedMonthlySavings float64 `json:"estimated_monthly_savings"`
+}
+
+type Ec2VolumeMigrationResponse struct {
+	ID         string                    `json:"id"`
+	Status     string                    `json:"status"`
+	DryRun     bool                      `json:"dry_run"`
+	SourceType string                    `json:"source_type"`
+	TargetType string                    `json:"target_type"`
+	CreatedAt  string                    `json:"created_at"`
+	UpdatedAt  string                    `json:"updated_at"`
+	Summary    Ec2VolumeMigrationSummary `json:"summary"`
+	Volumes    []*Ec2VolumeMigrationItem `json:"volumes"`
+}
+
+type Ec2VolumeResizeRequest struct {
+	Size        *int64  `json:"size"`         // New size of the volume in GiB, must be larger than the current size
+	MountPoint  *string `json:"mount_point"`  // Optional mount point of the filesystem to grow (linux)
+	DriveLetter *string `json:"drive_letter"` // Optional drive letter of the partition to grow (windows)
+}
+
+type Ec2VolumeResizeResponse struct {
+	ID                string `json:"id"`
+	VolumeId          string `json:"volume_id"`
+	InstanceId        string `json:"instance_id"`
+	Device            string `json:"device"`
+	Platform          string `json:"platform"`
+	Status            string `json:"status"`
+	OriginalSize      int64  `json:"original_size"`
+	TargetSize        int64  `json:"target_size"`
+	MountPoint        string `json:"mount_point,omitempty"`
+	DriveLetter       string `json:"drive_letter,omitempty"`
+	ModificationState string `json:"modification_state,omitempty"`
+	CommandId         string `json:"command_id,omitempty"`
+	CommandStatus     string `json:"command_status,omitempty"`
+	FilesystemSize    int64  `json:"filesystem_size,omitempty"`
+	Output            string `json:"output,omitempty"`
+	Error             string `json:"error,omitempty"`
+	CreatedAt         string `json:"created_at"`
+	UpdatedAt         string `json:"updated_at"`
+}
+
+type OrphanResource struct {
+	Type                 string            `json:"type"`
+	ID                   string            `json:"id"`
+	Name                 string            `json:"name,omitempty"`
+	Reason               string            `json:"reason"`
+	CreatedAt            string            `json:"created_at,omitempty"`
+	AgeDays              int               `json:"age_days"`
+	Size                 int64             `json:"size,omitempty"`
+	EstimatedMonthlyCost float64           `json:"estimated_monthly_cost"`
+	Protected            bool              `json:"protected"`
+	Tags                 map[string]string `json:"tags,omitempty"`
+}
+
+type OrphanSummary struct {
+	Total                int            `json:"total"`
+	Protected            int            `json:"protected"`
+	EstimatedMonthlyCost float64        `json:"estimated_monthly_cost"` // Estimated monthly cost of the unprotected orphans
+	ByType               map[string]int `json:"by_type"`
+}
+
+type OrphanListResponse struct {
+	Summary   OrphanSummary     `json:"summary"`
+	Resources []*OrphanResource `json:"resources"`
+}
+
+type OrphanSelection struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+type OrphanDeleteRequest struct {
+	Resources []*OrphanSelection `json:"resources"` // Orphaned resources to delete
+	Org       *string            `json:"org"`       // Optional org the resources must belong to, defaults to the org of the api
+	AllOrgs   *bool              `json:"all_orgs"`  // Select resources in any org of the account, org must not be given
+	DryRun    *bool              `json:"dry_run"`   // Only report what would be deleted, defaults to true
+}
+
+type OrphanDeleteResult struct {
+	Type                 string  `json:"type"`
+	ID                   string  `json:"id"`
+	Status               string  `json:"status"`
+	Reason               string  `json:"reason,omitempty"`
+	Error                string  `json:"error,omitempty"`
+	EstimatedMonthlyCost float64 `json:"estimated_monthly_cost,omitempty"`
+}
+
+type OrphanDeleteResponse struct {
+	DryRun  bool                  `json:"dry_run"`
+	Results []*OrphanDeleteResult `json:"results"`
+}
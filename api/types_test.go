@@ -205,6 +205,27 @@ func Test_toEc2InstanceResponse(t *testing.T) {
 						"sg-00112233445566": "spdev-000575",
 					},
 				},
+				Enis: []*Ec2NetworkInterface{
+					{
+						Id:            "eni-aaaaxxxxvvvv",
+						InterfaceType: "interface",
+						Status:        "in-use",
+						SubnetId:      "subnet-aabbccddee",
+						VpcId:         "vpc-0987654321",
+						MacAddress:    "11:11:11:11:11:11",
+						AttachmentId:  "eni-attach-aaaaaaaaaaavvvvvvvvv",
+						DeviceIndex:   aws.Int64(0),
+						PrivateIp:     "10.1.2.34",
+						PrivateIps: []*Ec2NetworkInterfacePrivateIp{
+							{PrivateIp: "10.1.2.34", Primary: true},
+						},
+						Ipv6Addresses: []string{},
+						Sgs: []map[string]string{
+							{"sg-00112233445566": "spdev-000575"},
+						},
+						SourceDestCheck: true,
+					},
+				},
 				State:  "running",
 				Subnet: "subnet-aabbccddee",
 				Tags: []map[string]string{
@@ -252,6 +273,7 @@ func Test_toEc2InstanceResponse(t *testing.T) {
 				CreatedBy: "ab123",
 				Platform:  "winders",
 				Sgs:       []map[string]string{},
+				Enis:      []*Ec2NetworkInterface{},
 				Tags: []map[string]string{
 					{"CreatedBy": "ab123"},
 				},
@@ -274,12 +296,76 @@ func Test_toEc2InstanceResponse(t *testing.T) {
 				CreatedBy: "ba321",
 				Platform:  "linux",
 				Sgs:       []map[string]string{},
+				Enis:      []*Ec2NetworkInterface{},
 				Tags: []map[string]string{
 					{"yale:created_by": "ba321"},
 				},
 				Volumes: map[string]*Volume{},
 			},
 		},
+		{
+			name: "elastic ip",
+			args: args{
+				instance: &ec2.Instance{
+					PublicIpAddress: aws.String("203.0.113.10"),
+					NetworkInterfaces: []*ec2.InstanceNetworkInterface{
+						{
+							Association: &ec2.InstanceNetworkInterfaceAssociation{
+								IpOwnerId: aws.String("012345678901"),
+								PublicIp:  aws.String("203.0.113.10"),
+							},
+						},
+					},
+				},
+			},
+			want: &Ec2InstanceResponse{
+				PublicIp:  "203.0.113.10",
+				ElasticIp: "203.0.113.10",
+				Platform:  "linux",
+				Sgs:       []map[string]string{},
+				Enis: []*Ec2NetworkInterface{
+					{
+						PublicIp:      "203.0.113.10",
+						PrivateIps:    []*Ec2NetworkInterfacePrivateIp{},
+						Ipv6Addresses: []string{},
+						Sgs:           []map[string]string{},
+					},
+				},
+				Tags:    []map[string]string{},
+				Volumes: map[string]*Volume{},
+			},
+		},
+		{
+			name: "public ip",
+			args: args{
+				instance: &ec2.Instance{
+					PublicIpAddress: aws.String("198.51.100.10"),
+					NetworkInterfaces: []*ec2.InstanceNetworkInterface{
+						{
+							Association: &ec2.InstanceNetworkInterfaceAssociation{
+								IpOwnerId: aws.String("amazon"),
+								PublicIp:  aws.String("198.51.100.10"),
+							},
+						},
+					},
+				},
+			},
+			want: &Ec2InstanceResponse{
+				PublicIp: "198.51.100.10",
+				Platform: "linux",
+				Sgs:      []map[string]string{},
+				Enis: []*Ec2NetworkInterface{
+					{
+						PublicIp:      "198.51.100.10",
+						PrivateIps:    []*Ec2NetworkInterfacePrivateIp{},
+						Ipv6Addresses: []string{},
+						Sgs:           []map[string]string{},
+					},
+				},
+				Tags:    []map[string]string{},
+				Volumes: map[string]*Volume{},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,38 @@
+package cloudwatch
+
+import (
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	log "github.com/sirupsen/logrus"
+)
+
+// CloudWatch is a wrapper around the aws CloudWatch service
+type CloudWatch struct {
+	session *session.Session
+	Service cloudwatchiface.CloudWatchAPI
+}
+
+type CloudWatchOption func(*CloudWatch)
+
+// New creates a new CloudWatch
+func New(opts ...CloudWatchOption) *CloudWatch {
+	c := CloudWatch{}
+
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	if c.session != nil {
+		c.Service = cloudwatch.New(c.session)
+	}
+
+	return &c
+}
+
+func WithSession(sess *session.Session) CloudWatchOption {
+	return func(c *CloudWatch) {
+		log.Debug("using aws session")
+		c.session = sess
+	}
+}
@@ -0,0 +1,30 @@
+package cloudwatch
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+)
+
+// mockCloudWatchClient is a fake cloudwatch client
+type mockCloudWatchClient struct {
+	cloudwatchiface.CloudWatchAPI
+	t   *testing.T
+	err error
+}
+
+func newMockCloudWatchClient(t *testing.T, err error) cloudwatchiface.CloudWatchAPI {
+	return &mockCloudWatchClient{
+		t:   t,
+		err: err,
+	}
+}
+
+func TestNewSession(t *testing.T) {
+	c := New()
+	to := reflect.TypeOf(c).String()
+	if to != "*cloudwatch.CloudWatch" {
+		t.Errorf("expected type to be '*cloudwatch.CloudWatch', got %s", to)
+	}
+}
@@ -0,0 +1,99 @@
+package cloudwatch
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/ec2-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	log "github.com/sirupsen/logrus"
+)
+
+// MaxMetricDataQueries is the most queries in a single GetMetricData call
+const MaxMetricDataQueries = 500
+
+// MetricQuery returns a query for a statistic of a metric with the given dimensions, aggregated over period seconds
+func MetricQuery(id, namespace, metric, stat string, period int64, dimensions map[string]string) *cloudwatch.MetricDataQuery {
+	keys := make([]string, 0, len(dimensions))
+	for k := range dimensions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	dims := make([]*cloudwatch.Dimension, 0, len(keys))
+	for _, k := range keys {
+		dims = append(dims, &cloudwatch.Dimension{
+			Name:  aws.String(k),
+			Value: aws.String(dimensions[k]),
+		})
+	}
+
+	return &cloudwatch.MetricDataQuery{
+		Id: aws.String(id),
+		MetricStat: &cloudwatch.MetricStat{
+			Metric: &cloudwatch.Metric{
+				Namespace:  aws.String(namespace),
+				MetricName: aws.String(metric),
+				Dimensions: dims,
+			},
+			Period: aws.Int64(period),
+			Stat:   aws.String(stat),
+		},
+		ReturnData: aws.Bool(true),
+	}
+}
+
+// GetMetricData gets the datapoints of the queries between start and end, oldest first.  Results split across
+// pages are merged, so there's one result per query id.
+func (c *CloudWatch) GetMetricData(ctx context.Context, start, end time.Time, queries ...*cloudwatch.MetricDataQuery) ([]*cloudwatch.MetricDataResult, error) {
+	if len(queries) == 0 || !start.Before(end) {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	if len(queries) > MaxMetricDataQueries {
+		msg := fmt.Sprintf("too many metric queries, at most %d are allowed", MaxMetricDataQueries)
+		return nil, apierror.New(apierror.ErrBadRequest, msg, nil)
+	}
+
+	log.Infof("getting metric data for %d queries from %s to %s", len(queries), start.Format(time.RFC3339), end.Format(time.RFC3339))
+
+	input := &cloudwatch.GetMetricDataInput{
+		StartTime:         aws.Time(start),
+		EndTime:           aws.Time(end),
+		MetricDataQueries: queries,
+		ScanBy:            aws.String(cloudwatch.ScanByTimestampAscending),
+	}
+
+	results := []*cloudwatch.MetricDataResult{}
+	byId := map[string]*cloudwatch.MetricDataResult{}
+	for {
+		out, err := c.Service.GetMetricDataWithContext(ctx, input)
+		if err != nil {
+			return nil, common.ErrCode("failed to get metric data", err)
+		}
+
+		for _, r := range out.MetricDataResults {
+			id := aws.StringValue(r.Id)
+			if prev, ok := byId[id]; ok {
+				prev.Timestamps = append(prev.Timestamps, r.Timestamps...)
+				prev.Values = append(prev.Values, r.Values...)
+				prev.StatusCode = r.StatusCode
+				continue
+			}
+
+			byId[id] = r
+			results = append(results, r)
+		}
+
+		if aws.StringValue(out.NextToken) == "" {
+			break
+		}
+		input.NextToken = out.NextToken
+	}
+
+	return results, nil
+}
@@ -0,0 +1,159 @@
+package cloudwatch
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+)
+
+var (
+	testStart = time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+	testEnd   = time.Date(2023, 1, 2, 3, 0, 0, 0, time.UTC)
+)
+
+// GetMetricDataWithContext returns one datapoint per hour for each query, one hour per page
+func (m *mockCloudWatchClient) GetMetricDataWithContext(ctx context.Context, input *cloudwatch.GetMetricDataInput, opts ...request.Option) (*cloudwatch.GetMetricDataOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	page := 0
+	if input.NextToken != nil {
+		page = int(aws.StringValue(input.NextToken)[0] - '0')
+	}
+
+	ts := aws.TimeValue(input.StartTime).Add(time.Duration(page) * time.Hour)
+
+	out := &cloudwatch.GetMetricDataOutput{}
+	for _, q := range input.MetricDataQueries {
+		out.MetricDataResults = append(out.MetricDataResults, &cloudwatch.MetricDataResult{
+			Id:         q.Id,
+			Timestamps: []*time.Time{aws.Time(ts)},
+			Values:     []*float64{aws.Float64(float64(page))},
+			StatusCode: aws.String(cloudwatch.StatusCodePartialData),
+		})
+	}
+
+	if next := ts.Add(time.Hour); next.Before(aws.TimeValue(input.EndTime)) {
+		out.NextToken = aws.String(string(rune('0' + page + 1)))
+	} else {
+		for _, r := range out.MetricDataResults {
+			r.StatusCode = aws.String(cloudwatch.StatusCodeComplete)
+		}
+	}
+
+	return out, nil
+}
+
+func TestMetricQuery(t *testing.T) {
+	want := &cloudwatch.MetricDataQuery{
+		Id: aws.String("cpu"),
+		MetricStat: &cloudwatch.MetricStat{
+			Metric: &cloudwatch.Metric{
+				Namespace:  aws.String("AWS/EC2"),
+				MetricName: aws.String("CPUUtilization"),
+				Dimensions: []*cloudwatch.Dimension{
+					{Name: aws.String("AutoScalingGroupName"), Value: aws.String("asg")},
+					{Name: aws.String("InstanceId"), Value: aws.String("i-1")},
+				},
+			},
+			Period: aws.Int64(300),
+			Stat:   aws.String("Average"),
+		},
+		ReturnData: aws.Bool(true),
+	}
+
+	got := MetricQuery("cpu", "AWS/EC2", "CPUUtilization", "Average", 300, map[string]string{"InstanceId": "i-1", "AutoScalingGroupName": "asg"})
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MetricQuery() = %v, want %v", got, want)
+	}
+}
+
+func TestCloudWatch_GetMetricData(t *testing.T) {
+	queries := []*cloudwatch.MetricDataQuery{
+		MetricQuery("cpu", "AWS/EC2", "CPUUtilization", "Average", 3600, map[string]string{"InstanceId": "i-1"}),
+		MetricQuery("netin", "AWS/EC2", "NetworkIn", "Sum", 3600, map[string]string{"InstanceId": "i-1"}),
+	}
+
+	want := []*cloudwatch.MetricDataResult{}
+	for _, id := range []string{"cpu", "netin"} {
+		want = append(want, &cloudwatch.MetricDataResult{
+			Id:         aws.String(id),
+			Timestamps: []*time.Time{aws.Time(testStart), aws.Time(testStart.Add(time.Hour)), aws.Time(testStart.Add(2 * time.Hour))},
+			Values:     aws.Float64Slice([]float64{0, 1, 2}),
+			StatusCode: aws.String(cloudwatch.StatusCodeComplete),
+		})
+	}
+
+	tooMany := make([]*cloudwatch.MetricDataQuery, MaxMetricDataQueries+1)
+
+	tests := []struct {
+		name    string
+		service cloudwatchiface.CloudWatchAPI
+		start   time.Time
+		end     time.Time
+		queries []*cloudwatch.MetricDataQuery
+		want    []*cloudwatch.MetricDataResult
+		wantErr bool
+	}{
+		{
+			name:    "success case",
+			service: newMockCloudWatchClient(t, nil),
+			start:   testStart,
+			end:     testEnd,
+			queries: queries,
+			want:    want,
+		},
+		{
+			name:    "no queries",
+			service: newMockCloudWatchClient(t, nil),
+			start:   testStart,
+			end:     testEnd,
+			wantErr: true,
+		},
+		{
+			name:    "too many queries",
+			service: newMockCloudWatchClient(t, nil),
+			start:   testStart,
+			end:     testEnd,
+			queries: tooMany,
+			wantErr: true,
+		},
+		{
+			name:    "end before start",
+			service: newMockCloudWatchClient(t, nil),
+			start:   testEnd,
+			end:     testStart,
+			queries: queries,
+			wantErr: true,
+		},
+		{
+			name:    "aws error",
+			service: newMockCloudWatchClient(t, awserr.New("Bad Request", "boom.", nil)),
+			start:   testStart,
+			end:     testEnd,
+			queries: queries,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &CloudWatch{Service: tt.service}
+			got, err := c.GetMetricData(context.TODO(), tt.start, tt.end, tt.queries...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CloudWatch.GetMetricData() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CloudWatch.GetMetricData() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -26,14 +26,20 @@ import (
 
 // Config is representation of the configuration data
 type Config struct {
-	AccountsMap   map[string]string
-	ProxyBackend  *ProxyBackend
-	ListenAddress string
-	Account       Account
-	Token         string
-	LogLevel      string
-	Version       Version
-	Org           string
+	AccountsMap       map[string]string
+	ProxyBackend      *ProxyBackend
+	ListenAddress     string
+	Account           Account
+	Token             string
+	LogLevel          string
+	Version           Version
+	Org               string
+	SecurityGroupLint *SecurityGroupLint
+	EventStream       *EventStream
+	Webhooks          *Webhooks
+	InstanceSchedules *InstanceSchedules
+	IdleInstances     *IdleInstances
+	Pricing           *Pricing
 }
 
 // Account is the configuration for an individual account
@@ -46,6 +52,93 @@ type Account struct {
 	Role       string
 }
 
+// SecurityGroupLint is the configuration for security group risk findings
+type SecurityGroupLint struct {
+	// Strict rejects new rules with findings at or above StrictSeverity when creating or updating security groups
+	Strict bool
+	// StrictSeverity is the minimum severity rejected in strict mode [low|medium|high|critical], defaults to high
+	StrictSeverity string
+	// MaxPortRange is the widest port range allowed without a finding, defaults to 1000
+	MaxPortRange int64
+	// AllowedPublicPorts are ports that may be open to 0.0.0.0/0 or ::/0 without a finding
+	AllowedPublicPorts []int64
+	// AllowedAccounts are other accounts whose security groups may be referenced without a finding
+	AllowedAccounts []string
+	// AllowedGroups are security groups that are never reported
+	AllowedGroups []string
+	// DisabledChecks are checks that are never reported
+	DisabledChecks []string
+}
+
+// EventStream is the configuration for the stream of resource change events
+type EventStream struct {
+	// PollInterval is how often the resources of an account are polled while it has subscribers, defaults to 30s
+	PollInterval string
+	// QueueUrl is an optional SQS queue receiving EC2 events from an EventBridge rule, used along with polling
+	QueueUrl string
+}
+
+// InstanceSchedules is the configuration for the executor of instance power schedules
+type InstanceSchedules struct {
+	// Interval is how often the schedules are checked, defaults to 1m
+	Interval string
+	// Accounts are the names or numbers of the accounts whose schedules are run, defaults to the accounts in AccountsMap
+	Accounts []string
+}
+
+// IdleInstances is the configuration for detecting and stopping idle instances
+type IdleInstances struct {
+	// Window is how far back the cpu and network use of an instance is averaged, defaults to 24h
+	Window string
+	// CpuThreshold is the average cpu utilization in percent under which an instance is idle, defaults to 5
+	CpuThreshold float64
+	// NetworkThreshold is the average network traffic in and out in bytes per second under which an instance is
+	// idle, defaults to 5120
+	NetworkThreshold float64
+	// AutoStop stops instances that stay idle for the grace period
+	AutoStop bool
+	// GracePeriod is how long an instance is idle before it's stopped, defaults to 24h
+	GracePeriod string
+	// Interval is how often instances are checked, defaults to 1h
+	Interval string
+	// Accounts are the names or numbers of the accounts checked, defaults to the accounts in AccountsMap
+	Accounts []string
+}
+
+// Pricing is the configuration for cost estimates
+type Pricing struct {
+	// CatalogFile is an optional JSON price catalog replacing the catalog bundled with the api
+	CatalogFile string
+	// Region is the region prices are estimated in, defaults to the region of the account
+	Region string
+}
+
+// Webhooks is the configuration for notifying other systems when resources are created or deleted with the api
+type Webhooks struct {
+	// Endpoints receive the events matching their filters
+	Endpoints []*WebhookEndpoint
+	// MaxRetries is how many times a failed delivery is retried, defaults to 5
+	MaxRetries *int
+	// Backoff is the delay before the first retry, it doubles with every retry, defaults to 1s
+	Backoff string
+	// MaxBackoff is the longest delay between retries, defaults to 5m
+	MaxBackoff string
+	// Timeout is the timeout of a single delivery attempt, defaults to 10s
+	Timeout string
+	// DeadLetterFile is an optional file where deliveries are appended as JSON lines when all attempts fail
+	DeadLetterFile string
+}
+
+// WebhookEndpoint is an endpoint receiving webhook events
+type WebhookEndpoint struct {
+	// Url the events are posted to
+	Url string
+	// Secret used to sign the events with HMAC-SHA256
+	Secret string
+	// Events are the event types sent to the endpoint, exact types or prefixes like 'instance.*', defaults to all events
+	Events []string
+}
+
 type ProxyBackend struct {
 	BaseUrl       string
 	Token         string
@@ -34,7 +34,13 @@ var testConfig = []byte(
 		},
 		"token": "SEKRET",
 		"logLevel": "info",
-		"org": "test"
+		"org": "test",
+		"securityGroupLint": {
+			"strict": true,
+			"strictSeverity": "critical",
+			"allowedPublicPorts": [22],
+			"allowedAccounts": ["012345678901"]
+		}
 	}`)
 
 var brokenConfig = []byte(`{ "foobar": { "baz": "biz" }`)
@@ -52,6 +58,12 @@ func TestReadConfig(t *testing.T) {
 		Token:    "SEKRET",
 		LogLevel: "info",
 		Org:      "test",
+		SecurityGroupLint: &SecurityGroupLint{
+			Strict:             true,
+			StrictSeverity:     "critical",
+			AllowedPublicPorts: []int64{22},
+			AllowedAccounts:    []string{"012345678901"},
+		},
 	}
 
 	actualConfig, err := ReadConfig(bytes.NewReader(testConfig))
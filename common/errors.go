@@ -7,6 +7,9 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+// ErrTimeout indicates a wait for a resource to reach a state timed out
+const ErrTimeout = "Timeout"
+
 // ErrCode converts ec2 errors to apierror errors
 // TODO fill out with EC2 standard error types
 func ErrCode(msg string, err error) error {
@@ -19,12 +22,16 @@ func ErrCode(msg string, err error) error {
 			return apierror.New(apierror.ErrForbidden, msg, aerr)
 		case
 			// Conflict
-			"Conflict":
+			"Conflict",
+			// A key pair with the same name already exists
+			"InvalidKeyPair.Duplicate":
 
 			return apierror.New(apierror.ErrConflict, msg, aerr)
 		case
 			// Not found.
-			"NotFound":
+			"NotFound",
+			// The key pair doesn't exist
+			"InvalidKeyPair.NotFound":
 
 			return apierror.New(apierror.ErrNotFound, msg, aerr)
 
@@ -0,0 +1,56 @@
+package ec2
+
+import (
+	"context"
+	"encoding/base64"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/ec2-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	log "github.com/sirupsen/logrus"
+)
+
+// GetConsoleOutput gets the base64 encoded system console output of an instance, either the latest output (only
+// supported on nitro instances) or the buffered output from the last boot
+func (e *Ec2) GetConsoleOutput(ctx context.Context, id string, latest bool) (*ec2.GetConsoleOutputOutput, error) {
+	if id == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("getting console output for instance %s (latest: %t)", id, latest)
+
+	out, err := e.Service.GetConsoleOutputWithContext(ctx, &ec2.GetConsoleOutputInput{
+		InstanceId: aws.String(id),
+		Latest:     aws.Bool(latest),
+	})
+	if err != nil {
+		return nil, common.ErrCode("getting console output", err)
+	}
+
+	return out, nil
+}
+
+// GetConsoleScreenshot gets a jpeg screenshot of the console of an instance
+func (e *Ec2) GetConsoleScreenshot(ctx context.Context, id string, wakeUp bool) ([]byte, error) {
+	if id == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("getting console screenshot for instance %s (wake up: %t)", id, wakeUp)
+
+	out, err := e.Service.GetConsoleScreenshotWithContext(ctx, &ec2.GetConsoleScreenshotInput{
+		InstanceId: aws.String(id),
+		WakeUp:     aws.Bool(wakeUp),
+	})
+	if err != nil {
+		return nil, common.ErrCode("getting console screenshot", err)
+	}
+
+	image, err := base64.StdEncoding.DecodeString(aws.StringValue(out.ImageData))
+	if err != nil {
+		return nil, apierror.New(apierror.ErrInternalError, "failed to decode console screenshot", err)
+	}
+
+	return image, nil
+}
@@ -0,0 +1,163 @@
+package ec2
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+)
+
+var consoleTimestamp = time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func (m *mockEC2Client) GetConsoleOutputWithContext(ctx context.Context, input *ec2.GetConsoleOutputInput, opts ...request.Option) (*ec2.GetConsoleOutputOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	output := "Zm9vCg==" // foo
+	if aws.BoolValue(input.Latest) {
+		output = "YmFyCg==" // bar
+	}
+
+	return &ec2.GetConsoleOutputOutput{
+		InstanceId: input.InstanceId,
+		Output:     aws.String(output),
+		Timestamp:  &consoleTimestamp,
+	}, nil
+}
+
+func (m *mockEC2Client) GetConsoleScreenshotWithContext(ctx context.Context, input *ec2.GetConsoleScreenshotInput, opts ...request.Option) (*ec2.GetConsoleScreenshotOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	image := "/9j/4A==" // jpeg magic number
+	if aws.StringValue(input.InstanceId) == "i-corrupt" {
+		image = "not base64"
+	}
+
+	return &ec2.GetConsoleScreenshotOutput{
+		InstanceId: input.InstanceId,
+		ImageData:  aws.String(image),
+	}, nil
+}
+
+func TestEc2_GetConsoleOutput(t *testing.T) {
+	type fields struct {
+		Service ec2iface.EC2API
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		id      string
+		latest  bool
+		want    *ec2.GetConsoleOutputOutput
+		wantErr bool
+	}{
+		{
+			name:   "full output",
+			fields: fields{Service: newmockEC2Client(t, nil)},
+			id:     "i-0123456789abcdef0",
+			want: &ec2.GetConsoleOutputOutput{
+				InstanceId: aws.String("i-0123456789abcdef0"),
+				Output:     aws.String("Zm9vCg=="),
+				Timestamp:  &consoleTimestamp,
+			},
+		},
+		{
+			name:   "latest output",
+			fields: fields{Service: newmockEC2Client(t, nil)},
+			id:     "i-0123456789abcdef0",
+			latest: true,
+			want: &ec2.GetConsoleOutputOutput{
+				InstanceId: aws.String("i-0123456789abcdef0"),
+				Output:     aws.String("YmFyCg=="),
+				Timestamp:  &consoleTimestamp,
+			},
+		},
+		{
+			name:    "missing id",
+			fields:  fields{Service: newmockEC2Client(t, nil)},
+			wantErr: true,
+		},
+		{
+			name:    "aws error",
+			fields:  fields{Service: newmockEC2Client(t, awserr.New("Bad Request", "boom.", nil))},
+			id:      "i-0123456789abcdef0",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Ec2{
+				Service: tt.fields.Service,
+			}
+			got, err := e.GetConsoleOutput(context.TODO(), tt.id, tt.latest)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Ec2.GetConsoleOutput() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Ec2.GetConsoleOutput() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEc2_GetConsoleScreenshot(t *testing.T) {
+	type fields struct {
+		Service ec2iface.EC2API
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		id      string
+		want    []byte
+		wantErr bool
+	}{
+		{
+			name:   "success case",
+			fields: fields{Service: newmockEC2Client(t, nil)},
+			id:     "i-0123456789abcdef0",
+			want:   []byte{0xff, 0xd8, 0xff, 0xe0},
+		},
+		{
+			name:    "invalid image data",
+			fields:  fields{Service: newmockEC2Client(t, nil)},
+			id:      "i-corrupt",
+			wantErr: true,
+		},
+		{
+			name:    "missing id",
+			fields:  fields{Service: newmockEC2Client(t, nil)},
+			wantErr: true,
+		},
+		{
+			name:    "aws error",
+			fields:  fields{Service: newmockEC2Client(t, awserr.New("Bad Request", "boom.", nil))},
+			id:      "i-0123456789abcdef0",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Ec2{
+				Service: tt.fields.Service,
+			}
+			got, err := e.GetConsoleScreenshot(context.TODO(), tt.id, false)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Ec2.GetConsoleScreenshot() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Ec2.GetConsoleScreenshot() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
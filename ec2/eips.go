@@ -0,0 +1,129 @@
+package ec2
+
+import (
+	"context"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/ec2-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	log "github.com/sirupsen/logrus"
+)
+
+// ListAddresses returns the elastic ips matching the given filters, limited to the org when one is given
+func (e *Ec2) ListAddresses(ctx context.Context, org string, filters ...*ec2.Filter) ([]*ec2.Address, error) {
+	log.Infof("listing elastic ips (org: '%s')", org)
+
+	if org != "" {
+		filters = append(filters, inSpinupOrg(org))
+	}
+
+	out, err := e.Service.DescribeAddressesWithContext(ctx, &ec2.DescribeAddressesInput{
+		Filters: filters,
+	})
+	if err != nil {
+		return nil, common.ErrCode("listing elastic ips", err)
+	}
+
+	log.Debugf("got describe addresses output with %d addresses", len(out.Addresses))
+
+	return out.Addresses, nil
+}
+
+// GetAddress gets an elastic ip by its allocation id
+func (e *Ec2) GetAddress(ctx context.Context, id string) (*ec2.Address, error) {
+	if id == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("getting details about elastic ip %s", id)
+
+	out, err := e.Service.DescribeAddressesWithContext(ctx, &ec2.DescribeAddressesInput{
+		AllocationIds: aws.StringSlice([]string{id}),
+	})
+	if err != nil {
+		return nil, common.ErrCode("getting elastic ip", err)
+	}
+
+	if len(out.Addresses) == 0 {
+		return nil, apierror.New(apierror.ErrNotFound, "elastic ip not found", nil)
+	}
+
+	if len(out.Addresses) > 1 {
+		return nil, apierror.New(apierror.ErrBadRequest, "unexpected elastic ip count returned", nil)
+	}
+
+	return out.Addresses[0], nil
+}
+
+// AllocateAddress allocates an elastic ip
+func (e *Ec2) AllocateAddress(ctx context.Context, input *ec2.AllocateAddressInput) (*ec2.AllocateAddressOutput, error) {
+	if input == nil {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("allocating elastic ip")
+
+	out, err := e.Service.AllocateAddressWithContext(ctx, input)
+	if err != nil {
+		return nil, common.ErrCode("failed to allocate elastic ip", err)
+	}
+
+	log.Debugf("got output allocating elastic ip %+v", out)
+
+	return out, nil
+}
+
+// AssociateAddress associates an elastic ip with an instance or network interface and returns the association id
+func (e *Ec2) AssociateAddress(ctx context.Context, input *ec2.AssociateAddressInput) (string, error) {
+	if input == nil || aws.StringValue(input.AllocationId) == "" {
+		return "", apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	if (aws.StringValue(input.InstanceId) == "") == (aws.StringValue(input.NetworkInterfaceId) == "") {
+		return "", apierror.New(apierror.ErrBadRequest, "instance id or network interface id is required", nil)
+	}
+
+	log.Infof("associating elastic ip %s with %s%s", aws.StringValue(input.AllocationId), aws.StringValue(input.InstanceId), aws.StringValue(input.NetworkInterfaceId))
+
+	out, err := e.Service.AssociateAddressWithContext(ctx, input)
+	if err != nil {
+		return "", common.ErrCode("failed to associate elastic ip", err)
+	}
+
+	return aws.StringValue(out.AssociationId), nil
+}
+
+// DisassociateAddress removes the association of an elastic ip
+func (e *Ec2) DisassociateAddress(ctx context.Context, associationId string) error {
+	if associationId == "" {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("disassociating elastic ip association %s", associationId)
+
+	if _, err := e.Service.DisassociateAddressWithContext(ctx, &ec2.DisassociateAddressInput{
+		AssociationId: aws.String(associationId),
+	}); err != nil {
+		return common.ErrCode("failed to disassociate elastic ip", err)
+	}
+
+	return nil
+}
+
+// ReleaseAddress releases an elastic ip
+func (e *Ec2) ReleaseAddress(ctx context.Context, id string) error {
+	if id == "" {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("releasing elastic ip %s", id)
+
+	if _, err := e.Service.ReleaseAddressWithContext(ctx, &ec2.ReleaseAddressInput{
+		AllocationId: aws.String(id),
+	}); err != nil {
+		return common.ErrCode("failed to release elastic ip", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,399 @@
+package ec2
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+)
+
+var addresses = []*ec2.Address{
+	{
+		AllocationId:  aws.String("eipalloc-0000000001"),
+		PublicIp:      aws.String("203.0.113.10"),
+		Domain:        aws.String("vpc"),
+		AssociationId: aws.String("eipassoc-0000000001"),
+		InstanceId:    aws.String("i-0000000001"),
+		Tags: []*ec2.Tag{
+			{Key: aws.String("spinup:org"), Value: aws.String("foo")},
+		},
+	},
+	{
+		AllocationId: aws.String("eipalloc-0000000002"),
+		PublicIp:     aws.String("203.0.113.11"),
+		Domain:       aws.String("vpc"),
+		Tags: []*ec2.Tag{
+			{Key: aws.String("spinup:org"), Value: aws.String("bar")},
+		},
+	},
+}
+
+func (m *mockEC2Client) DescribeAddressesWithContext(ctx context.Context, input *ec2.DescribeAddressesInput, opts ...request.Option) (*ec2.DescribeAddressesOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	out := []*ec2.Address{}
+	for _, a := range addresses {
+		if len(input.AllocationIds) > 0 && aws.StringValue(input.AllocationIds[0]) != aws.StringValue(a.AllocationId) {
+			continue
+		}
+
+		match := true
+		for _, f := range input.Filters {
+			if aws.StringValue(f.Name) == "tag:spinup:org" && aws.StringValue(f.Values[0]) != aws.StringValue(a.Tags[0].Value) {
+				match = false
+			}
+		}
+
+		if match {
+			out = append(out, a)
+		}
+	}
+
+	return &ec2.DescribeAddressesOutput{Addresses: out}, nil
+}
+
+func (m *mockEC2Client) AllocateAddressWithContext(ctx context.Context, input *ec2.AllocateAddressInput, opts ...request.Option) (*ec2.AllocateAddressOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	return &ec2.AllocateAddressOutput{
+		AllocationId: aws.String("eipalloc-0000000003"),
+		PublicIp:     aws.String("203.0.113.12"),
+		Domain:       input.Domain,
+	}, nil
+}
+
+func (m *mockEC2Client) AssociateAddressWithContext(ctx context.Context, input *ec2.AssociateAddressInput, opts ...request.Option) (*ec2.AssociateAddressOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	return &ec2.AssociateAddressOutput{AssociationId: aws.String("eipassoc-0000000002")}, nil
+}
+
+func (m *mockEC2Client) DisassociateAddressWithContext(ctx context.Context, input *ec2.DisassociateAddressInput, opts ...request.Option) (*ec2.DisassociateAddressOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	return &ec2.DisassociateAddressOutput{}, nil
+}
+
+func (m *mockEC2Client) ReleaseAddressWithContext(ctx context.Context, input *ec2.ReleaseAddressInput, opts ...request.Option) (*ec2.ReleaseAddressOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	return &ec2.ReleaseAddressOutput{}, nil
+}
+
+func TestEc2_ListAddresses(t *testing.T) {
+	type fields struct {
+		Service ec2iface.EC2API
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		org     string
+		want    []*ec2.Address
+		wantErr bool
+	}{
+		{
+			name:   "success case",
+			fields: fields{Service: newmockEC2Client(t, nil)},
+			want:   addresses,
+		},
+		{
+			name:   "success case with org",
+			fields: fields{Service: newmockEC2Client(t, nil)},
+			org:    "bar",
+			want:   addresses[1:],
+		},
+		{
+			name:    "aws error",
+			fields:  fields{Service: newmockEC2Client(t, awserr.New("Bad Request", "boom.", nil))},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Ec2{
+				Service: tt.fields.Service,
+			}
+			got, err := e.ListAddresses(context.TODO(), tt.org)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Ec2.ListAddresses() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Ec2.ListAddresses() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEc2_GetAddress(t *testing.T) {
+	type fields struct {
+		Service ec2iface.EC2API
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		id      string
+		want    *ec2.Address
+		wantErr bool
+	}{
+		{
+			name:   "success case",
+			fields: fields{Service: newmockEC2Client(t, nil)},
+			id:     "eipalloc-0000000001",
+			want:   addresses[0],
+		},
+		{
+			name:    "not found",
+			fields:  fields{Service: newmockEC2Client(t, nil)},
+			id:      "eipalloc-0000000009",
+			wantErr: true,
+		},
+		{
+			name:    "missing id",
+			fields:  fields{Service: newmockEC2Client(t, nil)},
+			wantErr: true,
+		},
+		{
+			name:    "aws error",
+			fields:  fields{Service: newmockEC2Client(t, awserr.New("Bad Request", "boom.", nil))},
+			id:      "eipalloc-0000000001",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Ec2{
+				Service: tt.fields.Service,
+			}
+			got, err := e.GetAddress(context.TODO(), tt.id)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Ec2.GetAddress() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Ec2.GetAddress() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEc2_AllocateAddress(t *testing.T) {
+	type fields struct {
+		Service ec2iface.EC2API
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		input   *ec2.AllocateAddressInput
+		want    *ec2.AllocateAddressOutput
+		wantErr bool
+	}{
+		{
+			name:   "success case",
+			fields: fields{Service: newmockEC2Client(t, nil)},
+			input:  &ec2.AllocateAddressInput{Domain: aws.String("vpc")},
+			want: &ec2.AllocateAddressOutput{
+				AllocationId: aws.String("eipalloc-0000000003"),
+				PublicIp:     aws.String("203.0.113.12"),
+				Domain:       aws.String("vpc"),
+			},
+		},
+		{
+			name:    "nil input",
+			fields:  fields{Service: newmockEC2Client(t, nil)},
+			wantErr: true,
+		},
+		{
+			name:    "aws error",
+			fields:  fields{Service: newmockEC2Client(t, awserr.New("Bad Request", "boom.", nil))},
+			input:   &ec2.AllocateAddressInput{Domain: aws.String("vpc")},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Ec2{
+				Service: tt.fields.Service,
+			}
+			got, err := e.AllocateAddress(context.TODO(), tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Ec2.AllocateAddress() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Ec2.AllocateAddress() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEc2_AssociateAddress(t *testing.T) {
+	type fields struct {
+		Service ec2iface.EC2API
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		input   *ec2.AssociateAddressInput
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "instance",
+			fields: fields{Service: newmockEC2Client(t, nil)},
+			input: &ec2.AssociateAddressInput{
+				AllocationId: aws.String("eipalloc-0000000002"),
+				InstanceId:   aws.String("i-0000000002"),
+			},
+			want: "eipassoc-0000000002",
+		},
+		{
+			name:   "network interface",
+			fields: fields{Service: newmockEC2Client(t, nil)},
+			input: &ec2.AssociateAddressInput{
+				AllocationId:       aws.String("eipalloc-0000000002"),
+				NetworkInterfaceId: aws.String("eni-0000000002"),
+			},
+			want: "eipassoc-0000000002",
+		},
+		{
+			name:    "missing target",
+			fields:  fields{Service: newmockEC2Client(t, nil)},
+			input:   &ec2.AssociateAddressInput{AllocationId: aws.String("eipalloc-0000000002")},
+			wantErr: true,
+		},
+		{
+			name:   "both targets",
+			fields: fields{Service: newmockEC2Client(t, nil)},
+			input: &ec2.AssociateAddressInput{
+				AllocationId:       aws.String("eipalloc-0000000002"),
+				InstanceId:         aws.String("i-0000000002"),
+				NetworkInterfaceId: aws.String("eni-0000000002"),
+			},
+			wantErr: true,
+		},
+		{
+			name:    "missing allocation id",
+			fields:  fields{Service: newmockEC2Client(t, nil)},
+			input:   &ec2.AssociateAddressInput{InstanceId: aws.String("i-0000000002")},
+			wantErr: true,
+		},
+		{
+			name:   "aws error",
+			fields: fields{Service: newmockEC2Client(t, awserr.New("Bad Request", "boom.", nil))},
+			input: &ec2.AssociateAddressInput{
+				AllocationId: aws.String("eipalloc-0000000002"),
+				InstanceId:   aws.String("i-0000000002"),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Ec2{
+				Service: tt.fields.Service,
+			}
+			got, err := e.AssociateAddress(context.TODO(), tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Ec2.AssociateAddress() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Ec2.AssociateAddress() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEc2_DisassociateAddress(t *testing.T) {
+	type fields struct {
+		Service ec2iface.EC2API
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		id      string
+		wantErr bool
+	}{
+		{
+			name:   "success case",
+			fields: fields{Service: newmockEC2Client(t, nil)},
+			id:     "eipassoc-0000000001",
+		},
+		{
+			name:    "missing id",
+			fields:  fields{Service: newmockEC2Client(t, nil)},
+			wantErr: true,
+		},
+		{
+			name:    "aws error",
+			fields:  fields{Service: newmockEC2Client(t, awserr.New("Bad Request", "boom.", nil))},
+			id:      "eipassoc-0000000001",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Ec2{
+				Service: tt.fields.Service,
+			}
+			if err := e.DisassociateAddress(context.TODO(), tt.id); (err != nil) != tt.wantErr {
+				t.Errorf("Ec2.DisassociateAddress() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEc2_ReleaseAddress(t *testing.T) {
+	type fields struct {
+		Service ec2iface.EC2API
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		id      string
+		wantErr bool
+	}{
+		{
+			name:   "success case",
+			fields: fields{Service: newmockEC2Client(t, nil)},
+			id:     "eipalloc-0000000002",
+		},
+		{
+			name:    "missing id",
+			fields:  fields{Service: newmockEC2Client(t, nil)},
+			wantErr: true,
+		},
+		{
+			name:    "aws error",
+			fields:  fields{Service: newmockEC2Client(t, awserr.New("Bad Request", "boom.", nil))},
+			id:      "eipalloc-0000000002",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Ec2{
+				Service: tt.fields.Service,
+			}
+			if err := e.ReleaseAddress(context.TODO(), tt.id); (err != nil) != tt.wantErr {
+				t.Errorf("Ec2.ReleaseAddress() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
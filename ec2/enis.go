@@ -0,0 +1,179 @@
+package ec2
+
+import (
+	"context"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/ec2-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	log "github.com/sirupsen/logrus"
+)
+
+// ListNetworkInterfaces returns the full details of all network interfaces matching the given filters, limited to the
+// org when one is given, following pagination
+func (e *Ec2) ListNetworkInterfaces(ctx context.Context, org string, filters ...*ec2.Filter) ([]*ec2.NetworkInterface, error) {
+	log.Infof("listing network interfaces (org: '%s')", org)
+
+	if org != "" {
+		filters = append(filters, inSpinupOrg(org))
+	}
+
+	input := ec2.DescribeNetworkInterfacesInput{
+		Filters:    filters,
+		MaxResults: aws.Int64(1000),
+	}
+
+	enis := []*ec2.NetworkInterface{}
+	for {
+		out, err := e.Service.DescribeNetworkInterfacesWithContext(ctx, &input)
+		if err != nil {
+			return nil, common.ErrCode("listing network interfaces", err)
+		}
+
+		log.Debugf("got describe network interfaces output with %d network interfaces", len(out.NetworkInterfaces))
+
+		enis = append(enis, out.NetworkInterfaces...)
+
+		if out.NextToken != nil {
+			input.NextToken = out.NextToken
+			continue
+		}
+
+		break
+	}
+
+	return enis, nil
+}
+
+// UpdateNetworkInterfaceGroups replaces the security groups attached to a network interface
+func (e *Ec2) UpdateNetworkInterfaceGroups(ctx context.Context, id string, groups []string) error {
+	if id == "" || len(groups) == 0 {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("updating security groups for network interface %s to %v", id, groups)
+
+	if _, err := e.Service.ModifyNetworkInterfaceAttributeWithContext(ctx, &ec2.ModifyNetworkInterfaceAttributeInput{
+		NetworkInterfaceId: aws.String(id),
+		Groups:             aws.StringSlice(groups),
+	}); err != nil {
+		return common.ErrCode("updating network interface security groups", err)
+	}
+
+	return nil
+}
+
+// GetNetworkInterface gets the details of a network interface
+func (e *Ec2) GetNetworkInterface(ctx context.Context, id string) (*ec2.NetworkInterface, error) {
+	if id == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("getting details about network interface %s", id)
+
+	out, err := e.Service.DescribeNetworkInterfacesWithContext(ctx, &ec2.DescribeNetworkInterfacesInput{
+		NetworkInterfaceIds: aws.StringSlice([]string{id}),
+	})
+	if err != nil {
+		return nil, common.ErrCode("getting network interface", err)
+	}
+
+	if len(out.NetworkInterfaces) == 0 {
+		return nil, apierror.New(apierror.ErrNotFound, "network interface not found", nil)
+	}
+
+	if len(out.NetworkInterfaces) > 1 {
+		return nil, apierror.New(apierror.ErrBadRequest, "unexpected network interface count returned", nil)
+	}
+
+	return out.NetworkInterfaces[0], nil
+}
+
+// CreateNetworkInterface creates a network interface in a subnet
+func (e *Ec2) CreateNetworkInterface(ctx context.Context, input *ec2.CreateNetworkInterfaceInput) (*ec2.NetworkInterface, error) {
+	if input == nil || aws.StringValue(input.SubnetId) == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("creating network interface in subnet %s", aws.StringValue(input.SubnetId))
+
+	out, err := e.Service.CreateNetworkInterfaceWithContext(ctx, input)
+	if err != nil {
+		return nil, common.ErrCode("failed to create network interface", err)
+	}
+
+	log.Debugf("got output creating network interface %+v", out)
+
+	return out.NetworkInterface, nil
+}
+
+// DeleteNetworkInterface deletes a network interface
+func (e *Ec2) DeleteNetworkInterface(ctx context.Context, id string) error {
+	if id == "" {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("deleting network interface %s", id)
+
+	if _, err := e.Service.DeleteNetworkInterfaceWithContext(ctx, &ec2.DeleteNetworkInterfaceInput{
+		NetworkInterfaceId: aws.String(id),
+	}); err != nil {
+		return common.ErrCode("failed to delete network interface", err)
+	}
+
+	return nil
+}
+
+// AttachNetworkInterface attaches a network interface to an instance at the device index and returns the attachment id
+func (e *Ec2) AttachNetworkInterface(ctx context.Context, id, instanceId string, deviceIndex int64) (string, error) {
+	if id == "" || instanceId == "" || deviceIndex < 0 {
+		return "", apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("attaching network interface %s to instance %s at device index %d", id, instanceId, deviceIndex)
+
+	out, err := e.Service.AttachNetworkInterfaceWithContext(ctx, &ec2.AttachNetworkInterfaceInput{
+		NetworkInterfaceId: aws.String(id),
+		InstanceId:         aws.String(instanceId),
+		DeviceIndex:        aws.Int64(deviceIndex),
+	})
+	if err != nil {
+		return "", common.ErrCode("failed to attach network interface", err)
+	}
+
+	return aws.StringValue(out.AttachmentId), nil
+}
+
+// DetachNetworkInterface detaches a network interface by its attachment id
+func (e *Ec2) DetachNetworkInterface(ctx context.Context, attachmentId string, force bool) error {
+	if attachmentId == "" {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("detaching network interface attachment %s (force: %t)", attachmentId, force)
+
+	if _, err := e.Service.DetachNetworkInterfaceWithContext(ctx, &ec2.DetachNetworkInterfaceInput{
+		AttachmentId: aws.String(attachmentId),
+		Force:        aws.Bool(force),
+	}); err != nil {
+		return common.ErrCode("failed to detach network interface", err)
+	}
+
+	return nil
+}
+
+// UpdateNetworkInterfaceAttribute modifies a single attribute of a network interface
+func (e *Ec2) UpdateNetworkInterfaceAttribute(ctx context.Context, input *ec2.ModifyNetworkInterfaceAttributeInput) error {
+	if input == nil || aws.StringValue(input.NetworkInterfaceId) == "" {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("updating network interface %s attribute", aws.StringValue(input.NetworkInterfaceId))
+
+	if _, err := e.Service.ModifyNetworkInterfaceAttributeWithContext(ctx, input); err != nil {
+		return common.ErrCode("updating network interface attribute", err)
+	}
+
+	return nil
+}
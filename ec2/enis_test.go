@@ -0,0 +1,484 @@
+package ec2
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+)
+
+var networkInterfaces = []*ec2.NetworkInterface{
+	{
+		NetworkInterfaceId: aws.String("eni-0000000001"),
+		Status:             aws.String("in-use"),
+		Groups: []*ec2.GroupIdentifier{
+			{GroupId: aws.String("sg-0000000001")},
+		},
+	},
+	{
+		NetworkInterfaceId: aws.String("eni-0000000002"),
+		Status:             aws.String("available"),
+	},
+}
+
+func (m *mockEC2Client) DescribeNetworkInterfacesWithContext(ctx context.Context, input *ec2.DescribeNetworkInterfacesInput, opts ...request.Option) (*ec2.DescribeNetworkInterfacesOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	if len(input.NetworkInterfaceIds) > 0 {
+		out := []*ec2.NetworkInterface{}
+		for _, eni := range networkInterfaces {
+			if aws.StringValue(eni.NetworkInterfaceId) == aws.StringValue(input.NetworkInterfaceIds[0]) {
+				out = append(out, eni)
+			}
+		}
+		return &ec2.DescribeNetworkInterfacesOutput{NetworkInterfaces: out}, nil
+	}
+
+	// return the first page with a token, then the rest
+	if input.NextToken == nil {
+		return &ec2.DescribeNetworkInterfacesOutput{
+			NetworkInterfaces: networkInterfaces[:1],
+			NextToken:         aws.String("next"),
+		}, nil
+	}
+
+	return &ec2.DescribeNetworkInterfacesOutput{NetworkInterfaces: networkInterfaces[1:]}, nil
+}
+
+func TestEc2_ListNetworkInterfaces(t *testing.T) {
+	type fields struct {
+		Service ec2iface.EC2API
+	}
+	type args struct {
+		ctx     context.Context
+		org     string
+		filters []*ec2.Filter
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    []*ec2.NetworkInterface
+		wantErr bool
+	}{
+		{
+			name:   "success case",
+			args:   args{ctx: context.TODO()},
+			fields: fields{Service: newmockEC2Client(t, nil)},
+			want:   networkInterfaces,
+		},
+		{
+			name:    "aws error",
+			args:    args{ctx: context.TODO()},
+			fields:  fields{Service: newmockEC2Client(t, awserr.New("Bad Request", "boom.", nil))},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Ec2{
+				Service: tt.fields.Service,
+			}
+			got, err := e.ListNetworkInterfaces(tt.args.ctx, tt.args.org, tt.args.filters...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Ec2.ListNetworkInterfaces() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Ec2.ListNetworkInterfaces() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func (m *mockEC2Client) ModifyNetworkInterfaceAttributeWithContext(ctx context.Context, input *ec2.ModifyNetworkInterfaceAttributeInput, opts ...request.Option) (*ec2.ModifyNetworkInterfaceAttributeOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	return &ec2.ModifyNetworkInterfaceAttributeOutput{}, nil
+}
+
+func TestEc2_UpdateNetworkInterfaceGroups(t *testing.T) {
+	type fields struct {
+		Service ec2iface.EC2API
+	}
+	type args struct {
+		ctx    context.Context
+		id     string
+		groups []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		wantErr bool
+	}{
+		{
+			name:   "success case",
+			args:   args{ctx: context.TODO(), id: "eni-0000000001", groups: []string{"sg-0000000002"}},
+			fields: fields{Service: newmockEC2Client(t, nil)},
+		},
+		{
+			name:    "missing id",
+			args:    args{ctx: context.TODO(), groups: []string{"sg-0000000002"}},
+			fields:  fields{Service: newmockEC2Client(t, nil)},
+			wantErr: true,
+		},
+		{
+			name:    "missing groups",
+			args:    args{ctx: context.TODO(), id: "eni-0000000001"},
+			fields:  fields{Service: newmockEC2Client(t, nil)},
+			wantErr: true,
+		},
+		{
+			name:    "aws error",
+			args:    args{ctx: context.TODO(), id: "eni-0000000001", groups: []string{"sg-0000000002"}},
+			fields:  fields{Service: newmockEC2Client(t, awserr.New("Bad Request", "boom.", nil))},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Ec2{
+				Service: tt.fields.Service,
+			}
+			if err := e.UpdateNetworkInterfaceGroups(tt.args.ctx, tt.args.id, tt.args.groups); (err != nil) != tt.wantErr {
+				t.Errorf("Ec2.UpdateNetworkInterfaceGroups() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEc2_GetNetworkInterface(t *testing.T) {
+	type fields struct {
+		Service ec2iface.EC2API
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		id      string
+		want    *ec2.NetworkInterface
+		wantErr bool
+	}{
+		{
+			name:   "success case",
+			fields: fields{Service: newmockEC2Client(t, nil)},
+			id:     "eni-0000000002",
+			want:   networkInterfaces[1],
+		},
+		{
+			name:    "not found",
+			fields:  fields{Service: newmockEC2Client(t, nil)},
+			id:      "eni-0000000009",
+			wantErr: true,
+		},
+		{
+			name:    "missing id",
+			fields:  fields{Service: newmockEC2Client(t, nil)},
+			wantErr: true,
+		},
+		{
+			name:    "aws error",
+			fields:  fields{Service: newmockEC2Client(t, awserr.New("Bad Request", "boom.", nil))},
+			id:      "eni-0000000001",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Ec2{
+				Service: tt.fields.Service,
+			}
+			got, err := e.GetNetworkInterface(context.TODO(), tt.id)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Ec2.GetNetworkInterface() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Ec2.GetNetworkInterface() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func (m *mockEC2Client) CreateNetworkInterfaceWithContext(ctx context.Context, input *ec2.CreateNetworkInterfaceInput, opts ...request.Option) (*ec2.CreateNetworkInterfaceOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	return &ec2.CreateNetworkInterfaceOutput{
+		NetworkInterface: &ec2.NetworkInterface{
+			NetworkInterfaceId: aws.String("eni-0000000003"),
+			SubnetId:           input.SubnetId,
+			Status:             aws.String("pending"),
+		},
+	}, nil
+}
+
+func TestEc2_CreateNetworkInterface(t *testing.T) {
+	type fields struct {
+		Service ec2iface.EC2API
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		input   *ec2.CreateNetworkInterfaceInput
+		want    *ec2.NetworkInterface
+		wantErr bool
+	}{
+		{
+			name:   "success case",
+			fields: fields{Service: newmockEC2Client(t, nil)},
+			input:  &ec2.CreateNetworkInterfaceInput{SubnetId: aws.String("subnet-0000000001")},
+			want: &ec2.NetworkInterface{
+				NetworkInterfaceId: aws.String("eni-0000000003"),
+				SubnetId:           aws.String("subnet-0000000001"),
+				Status:             aws.String("pending"),
+			},
+		},
+		{
+			name:    "missing subnet",
+			fields:  fields{Service: newmockEC2Client(t, nil)},
+			input:   &ec2.CreateNetworkInterfaceInput{},
+			wantErr: true,
+		},
+		{
+			name:    "aws error",
+			fields:  fields{Service: newmockEC2Client(t, awserr.New("Bad Request", "boom.", nil))},
+			input:   &ec2.CreateNetworkInterfaceInput{SubnetId: aws.String("subnet-0000000001")},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Ec2{
+				Service: tt.fields.Service,
+			}
+			got, err := e.CreateNetworkInterface(context.TODO(), tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Ec2.CreateNetworkInterface() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Ec2.CreateNetworkInterface() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func (m *mockEC2Client) DeleteNetworkInterfaceWithContext(ctx context.Context, input *ec2.DeleteNetworkInterfaceInput, opts ...request.Option) (*ec2.DeleteNetworkInterfaceOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	return &ec2.DeleteNetworkInterfaceOutput{}, nil
+}
+
+func TestEc2_DeleteNetworkInterface(t *testing.T) {
+	type fields struct {
+		Service ec2iface.EC2API
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		id      string
+		wantErr bool
+	}{
+		{
+			name:   "success case",
+			fields: fields{Service: newmockEC2Client(t, nil)},
+			id:     "eni-0000000002",
+		},
+		{
+			name:    "missing id",
+			fields:  fields{Service: newmockEC2Client(t, nil)},
+			wantErr: true,
+		},
+		{
+			name:    "aws error",
+			fields:  fields{Service: newmockEC2Client(t, awserr.New("Bad Request", "boom.", nil))},
+			id:      "eni-0000000002",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Ec2{
+				Service: tt.fields.Service,
+			}
+			if err := e.DeleteNetworkInterface(context.TODO(), tt.id); (err != nil) != tt.wantErr {
+				t.Errorf("Ec2.DeleteNetworkInterface() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func (m *mockEC2Client) AttachNetworkInterfaceWithContext(ctx context.Context, input *ec2.AttachNetworkInterfaceInput, opts ...request.Option) (*ec2.AttachNetworkInterfaceOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	return &ec2.AttachNetworkInterfaceOutput{AttachmentId: aws.String("eni-attach-0000000001")}, nil
+}
+
+func TestEc2_AttachNetworkInterface(t *testing.T) {
+	type fields struct {
+		Service ec2iface.EC2API
+	}
+	tests := []struct {
+		name        string
+		fields      fields
+		id          string
+		instanceId  string
+		deviceIndex int64
+		want        string
+		wantErr     bool
+	}{
+		{
+			name:        "success case",
+			fields:      fields{Service: newmockEC2Client(t, nil)},
+			id:          "eni-0000000002",
+			instanceId:  "i-0000000001",
+			deviceIndex: 1,
+			want:        "eni-attach-0000000001",
+		},
+		{
+			name:       "missing id",
+			fields:     fields{Service: newmockEC2Client(t, nil)},
+			instanceId: "i-0000000001",
+			wantErr:    true,
+		},
+		{
+			name:        "invalid device index",
+			fields:      fields{Service: newmockEC2Client(t, nil)},
+			id:          "eni-0000000002",
+			instanceId:  "i-0000000001",
+			deviceIndex: -1,
+			wantErr:     true,
+		},
+		{
+			name:        "aws error",
+			fields:      fields{Service: newmockEC2Client(t, awserr.New("Bad Request", "boom.", nil))},
+			id:          "eni-0000000002",
+			instanceId:  "i-0000000001",
+			deviceIndex: 1,
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Ec2{
+				Service: tt.fields.Service,
+			}
+			got, err := e.AttachNetworkInterface(context.TODO(), tt.id, tt.instanceId, tt.deviceIndex)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Ec2.AttachNetworkInterface() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Ec2.AttachNetworkInterface() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func (m *mockEC2Client) DetachNetworkInterfaceWithContext(ctx context.Context, input *ec2.DetachNetworkInterfaceInput, opts ...request.Option) (*ec2.DetachNetworkInterfaceOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	return &ec2.DetachNetworkInterfaceOutput{}, nil
+}
+
+func TestEc2_DetachNetworkInterface(t *testing.T) {
+	type fields struct {
+		Service ec2iface.EC2API
+	}
+	tests := []struct {
+		name         string
+		fields       fields
+		attachmentId string
+		wantErr      bool
+	}{
+		{
+			name:         "success case",
+			fields:       fields{Service: newmockEC2Client(t, nil)},
+			attachmentId: "eni-attach-0000000001",
+		},
+		{
+			name:    "missing attachment id",
+			fields:  fields{Service: newmockEC2Client(t, nil)},
+			wantErr: true,
+		},
+		{
+			name:         "aws error",
+			fields:       fields{Service: newmockEC2Client(t, awserr.New("Bad Request", "boom.", nil))},
+			attachmentId: "eni-attach-0000000001",
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Ec2{
+				Service: tt.fields.Service,
+			}
+			if err := e.DetachNetworkInterface(context.TODO(), tt.attachmentId, false); (err != nil) != tt.wantErr {
+				t.Errorf("Ec2.DetachNetworkInterface() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEc2_UpdateNetworkInterfaceAttribute(t *testing.T) {
+	type fields struct {
+		Service ec2iface.EC2API
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		input   *ec2.ModifyNetworkInterfaceAttributeInput
+		wantErr bool
+	}{
+		{
+			name:   "success case",
+			fields: fields{Service: newmockEC2Client(t, nil)},
+			input: &ec2.ModifyNetworkInterfaceAttributeInput{
+				NetworkInterfaceId: aws.String("eni-0000000001"),
+				SourceDestCheck:    &ec2.AttributeBooleanValue{Value: aws.Bool(false)},
+			},
+		},
+		{
+			name:    "missing id",
+			fields:  fields{Service: newmockEC2Client(t, nil)},
+			input:   &ec2.ModifyNetworkInterfaceAttributeInput{},
+			wantErr: true,
+		},
+		{
+			name:   "aws error",
+			fields: fields{Service: newmockEC2Client(t, awserr.New("Bad Request", "boom.", nil))},
+			input: &ec2.ModifyNetworkInterfaceAttributeInput{
+				NetworkInterfaceId: aws.String("eni-0000000001"),
+				SourceDestCheck:    &ec2.AttributeBooleanValue{Value: aws.Bool(false)},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Ec2{
+				Service: tt.fields.Service,
+			}
+			if err := e.UpdateNetworkInterfaceAttribute(context.TODO(), tt.input); (err != nil) != tt.wantErr {
+				t.Errorf("Ec2.UpdateNetworkInterfaceAttribute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
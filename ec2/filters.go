@@ -1,10 +1,141 @@
 package ec2
 
 import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
 )
 
+// ListFilter filters a list of resources by tag, state, type, availability zone and creation time.  Tags, states, types
+// and availability zones are translated to EC2 filters and take * and ? wildcards.  EC2 can't filter on a range of
+// creation times, so those are filtered client side with Created.
+type ListFilter struct {
+	// Tags are the values of tags by key, a tag without values only has to exist
+	Tags         map[string][]string
+	State        []string
+	Type         []string
+	Az           []string
+	CreatedAfter *time.Time
+}
+
+// listFilterNames are the EC2 filter names of the state, type and availability zone of each resource, resources can't
+// be filtered on fields without a name
+var listFilterNames = map[string]map[string]string{
+	"instance": {
+		"state": "instance-state-name",
+		"type":  "instance-type",
+		"az":    "availability-zone",
+	},
+	"volume": {
+		"state": "status",
+		"type":  "volume-type",
+		"az":    "availability-zone",
+	},
+	"snapshot": {
+		"state": "status",
+	},
+	"image": {
+		"state": "state",
+		"type":  "image-type",
+	},
+	"security-group": {},
+}
+
+// listFilterCreated are the resources with a creation time that can be filtered client side
+var listFilterCreated = map[string]bool{
+	"instance": true,
+	"volume":   true,
+	"snapshot": true,
+	"image":    true,
+}
+
+// Filters returns the EC2 filters of the list filter for a resource (instance, volume, snapshot, image or
+// security-group), or a bad request error if the resource can't be filtered on one of its fields
+func (f *ListFilter) Filters(resource string) ([]*ec2.Filter, error) {
+	names, ok := listFilterNames[resource]
+	if !ok {
+		return nil, apierror.New(apierror.ErrBadRequest, fmt.Sprintf("unknown resource %s", resource), nil)
+	}
+
+	filters := []*ec2.Filter{}
+	if f == nil {
+		return filters, nil
+	}
+
+	for _, field := range []struct {
+		name   string
+		values []string
+	}{
+		{"state", f.State},
+		{"type", f.Type},
+		{"az", f.Az},
+	} {
+		if len(field.values) == 0 {
+			continue
+		}
+
+		name, ok := names[field.name]
+		if !ok {
+			return nil, apierror.New(apierror.ErrBadRequest, fmt.Sprintf("%s list can't be filtered by %s", resource, field.name), nil)
+		}
+
+		filters = append(filters, &ec2.Filter{
+			Name:   aws.String(name),
+			Values: aws.StringSlice(field.values),
+		})
+	}
+
+	if f.CreatedAfter != nil && !listFilterCreated[resource] {
+		return nil, apierror.New(apierror.ErrBadRequest, fmt.Sprintf("%s list can't be filtered by creation time", resource), nil)
+	}
+
+	keys := make([]string, 0, len(f.Tags))
+	for k := range f.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if len(f.Tags[k]) == 0 {
+			filters = append(filters, &ec2.Filter{
+				Name:   aws.String("tag-key"),
+				Values: aws.StringSlice([]string{k}),
+			})
+			continue
+		}
+
+		filters = append(filters, &ec2.Filter{
+			Name:   aws.String("tag:" + k),
+			Values: aws.StringSlice(f.Tags[k]),
+		})
+	}
+
+	return filters, nil
+}
+
+// Created returns true if a resource created at the given time passes the creation time filter
+func (f *ListFilter) Created(t *time.Time) bool {
+	if f == nil || f.CreatedAfter == nil {
+		return true
+	}
+
+	return t != nil && t.After(*f.CreatedAfter)
+}
+
+// hasFilter returns true if there's a filter with the name
+func hasFilter(filters []*ec2.Filter, name string) bool {
+	for _, f := range filters {
+		if aws.StringValue(f.Name) == name {
+			return true
+		}
+	}
+	return false
+}
+
 func notTerminated() *ec2.Filter {
 	return &ec2.Filter{
 		Name: aws.String("instance-state-name"),
@@ -31,6 +162,16 @@ func inOrg(org string) *ec2.Filter {
 	}
 }
 
+// inSpinupOrg filters on the spinup:org tag, used for resources that are only tagged with the new org tag
+func inSpinupOrg(org string) *ec2.Filter {
+	return &ec2.Filter{
+		Name: aws.String("tag:spinup:org"),
+		Values: aws.StringSlice(
+			[]string{org},
+		),
+	}
+}
+
 func inVpc(vpc string) *ec2.Filter {
 	return &ec2.Filter{
 		Name: aws.String("vpc-id"),
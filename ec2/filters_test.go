@@ -3,6 +3,7 @@ package ec2
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
@@ -67,6 +68,35 @@ func Test_inOrg(t *testing.T) {
 	}
 }
 
+func Test_inSpinupOrg(t *testing.T) {
+	type args struct {
+		org string
+	}
+	tests := []struct {
+		name string
+		args args
+		want *ec2.Filter
+	}{
+		{
+			name: "in foo org",
+			args: args{org: "foo"},
+			want: &ec2.Filter{
+				Name: aws.String("tag:spinup:org"),
+				Values: aws.StringSlice(
+					[]string{"foo"},
+				),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inSpinupOrg(tt.args.org); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("inSpinupOrg() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func Test_withInstanceId(t *testing.T) {
 	type args struct {
 		id string
@@ -153,3 +183,81 @@ func Test_inVpc(t *testing.T) {
 		})
 	}
 }
+
+func TestListFilter_Filters(t *testing.T) {
+	after := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		filter   *ListFilter
+		resource string
+		want     []*ec2.Filter
+		wantErr  bool
+	}{
+		{name: "nil filter", resource: "instance", want: []*ec2.Filter{}},
+		{
+			name: "instance filters",
+			filter: &ListFilter{
+				Tags:         map[string][]string{"Owner": {}, "Name": {"web*", "db"}},
+				State:        []string{"running"},
+				Type:         []string{"t3.*"},
+				Az:           []string{"us-east-1a"},
+				CreatedAfter: &after,
+			},
+			resource: "instance",
+			want: []*ec2.Filter{
+				{Name: aws.String("instance-state-name"), Values: aws.StringSlice([]string{"running"})},
+				{Name: aws.String("instance-type"), Values: aws.StringSlice([]string{"t3.*"})},
+				{Name: aws.String("availability-zone"), Values: aws.StringSlice([]string{"us-east-1a"})},
+				{Name: aws.String("tag:Name"), Values: aws.StringSlice([]string{"web*", "db"})},
+				{Name: aws.String("tag-key"), Values: aws.StringSlice([]string{"Owner"})},
+			},
+		},
+		{
+			name:     "volume state",
+			filter:   &ListFilter{State: []string{"available"}},
+			resource: "volume",
+			want:     []*ec2.Filter{{Name: aws.String("status"), Values: aws.StringSlice([]string{"available"})}},
+		},
+		{name: "snapshot az", filter: &ListFilter{Az: []string{"us-east-1a"}}, resource: "snapshot", wantErr: true},
+		{name: "security group creation time", filter: &ListFilter{CreatedAfter: &after}, resource: "security-group", wantErr: true},
+		{name: "unknown resource", filter: &ListFilter{}, resource: "bucket", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.filter.Filters(tt.resource)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ListFilter.Filters() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ListFilter.Filters() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListFilter_Created(t *testing.T) {
+	after := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	before, later := after.Add(-time.Hour), after.Add(time.Hour)
+
+	tests := []struct {
+		name   string
+		filter *ListFilter
+		t      *time.Time
+		want   bool
+	}{
+		{name: "no filter", t: &before, want: true},
+		{name: "no creation time filter", filter: &ListFilter{}, want: true},
+		{name: "created after", filter: &ListFilter{CreatedAfter: &after}, t: &later, want: true},
+		{name: "created before", filter: &ListFilter{CreatedAfter: &after}, t: &before, want: false},
+		{name: "unknown creation time", filter: &ListFilter{CreatedAfter: &after}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Created(tt.t); got != tt.want {
+				t.Errorf("ListFilter.Created() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -56,6 +56,51 @@ func (e *Ec2) ListImages(ctx context.Context, org, name string) ([]map[string]*s
 	return list, nil
 }
 
+// ListImagesPage returns the full details of a page of images owned by the account matching the name and given filters.
+// Only available images are listed unless filtering on the image state.
+func (e *Ec2) ListImagesPage(ctx context.Context, org, name string, per int64, next *string, filters ...*ec2.Filter) ([]*ec2.Image, *string, error) {
+	log.Infof("listing ec2 images (name: '%s', org: '%s')", name, org)
+
+	filters = append(filters, &ec2.Filter{
+		Name:   aws.String("is-public"),
+		Values: aws.StringSlice([]string{"false"}),
+	})
+
+	if !hasFilter(filters, "state") {
+		filters = append(filters, isAvailable())
+	}
+
+	if org != "" {
+		filters = append(filters, inOrg(org))
+	}
+
+	if name != "" {
+		filters = append(filters, &ec2.Filter{
+			Name:   aws.String("name"),
+			Values: aws.StringSlice([]string{name}),
+		})
+	}
+
+	input := ec2.DescribeImagesInput{
+		Owners:    aws.StringSlice([]string{"self"}),
+		Filters:   filters,
+		NextToken: next,
+	}
+
+	if per != 0 {
+		input.MaxResults = aws.Int64(per)
+	}
+
+	out, err := e.Service.DescribeImagesWithContext(ctx, &input)
+	if err != nil {
+		return nil, nil, common.ErrCode("listing images", err)
+	}
+
+	log.Debugf("returning list of %d images", len(out.Images))
+
+	return out.Images, out.NextToken, nil
+}
+
 func (e *Ec2) GetImage(ctx context.Context, ids ...string) ([]*ec2.Image, error) {
 	if len(ids) == 0 {
 		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
@@ -115,3 +160,24 @@ func (e *Ec2) DeregisterImage(ctx context.Context, input *ec2.DeregisterImageInp
 
 	return nil
 }
+
+// ListImageDetails returns the full details of all images owned by the account matching the given filters
+func (e *Ec2) ListImageDetails(ctx context.Context, org string, filters ...*ec2.Filter) ([]*ec2.Image, error) {
+	log.Infof("listing image details (org: '%s')", org)
+
+	if org != "" {
+		filters = append(filters, inOrg(org))
+	}
+
+	out, err := e.Service.DescribeImagesWithContext(ctx, &ec2.DescribeImagesInput{
+		Filters: filters,
+		Owners:  aws.StringSlice([]string{"self"}),
+	})
+	if err != nil {
+		return nil, common.ErrCode("listing image details", err)
+	}
+
+	log.Debugf("got describe images output with %d images", len(out.Images))
+
+	return out.Images, nil
+}
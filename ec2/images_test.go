@@ -675,3 +675,68 @@ func TestEc2_DeregisterImage(t *testing.T) {
 		})
 	}
 }
+
+func TestEc2_ListImageDetails(t *testing.T) {
+	type fields struct {
+		Service ec2iface.EC2API
+	}
+	type args struct {
+		ctx     context.Context
+		org     string
+		filters []*ec2.Filter
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    []*ec2.Image
+		wantErr bool
+	}{
+		{
+			name:   "success case",
+			args:   args{ctx: context.TODO()},
+			fields: fields{Service: newmockEC2Client(t, nil)},
+			want:   []*ec2.Image{images[0], images[1], images[2], images[3], images[5], images[6]},
+		},
+		{
+			name:   "success case with org",
+			args:   args{ctx: context.TODO(), org: "tst"},
+			fields: fields{Service: newmockEC2Client(t, nil)},
+			want:   []*ec2.Image{images[2], images[3]},
+		},
+		{
+			name: "success case with filters",
+			args: args{
+				ctx: context.TODO(),
+				org: "dev",
+				filters: []*ec2.Filter{
+					{Name: aws.String("state"), Values: aws.StringSlice([]string{"available"})},
+					{Name: aws.String("is-public"), Values: aws.StringSlice([]string{"false"})},
+				},
+			},
+			fields: fields{Service: newmockEC2Client(t, nil)},
+			want:   []*ec2.Image{images[0], images[1]},
+		},
+		{
+			name:    "aws error",
+			args:    args{ctx: context.TODO()},
+			fields:  fields{Service: newmockEC2Client(t, awserr.New("Bad Request", "boom.", nil))},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Ec2{
+				Service: tt.fields.Service,
+			}
+			got, err := e.ListImageDetails(tt.args.ctx, tt.args.org, tt.args.filters...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Ec2.ListImageDetails() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Ec2.ListImageDetails() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,10 +2,14 @@ package ec2
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/YaleSpinup/apierror"
 	"github.com/YaleSpinup/ec2-api/common"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	log "github.com/sirupsen/logrus"
 )
@@ -121,36 +125,35 @@ func (e *Ec2) ListInstanceTypeOfferings(ctx context.Context, azs []string, per i
 	return out.InstanceTypeOfferings, out.NextToken, nil
 }
 
-// ListInstances lists the instances that are not terminated and not spot
-func (e *Ec2) ListInstances(ctx context.Context, org string, per int64, next *string) ([]map[string]*string, *string, error) {
+// ListInstancesPage returns the full details of a page of instances that are not spot matching the given filters.  Terminated
+// instances are only listed when filtering on the instance state.
+func (e *Ec2) ListInstancesPage(ctx context.Context, org string, per int64, next *string, filters ...*ec2.Filter) ([]*ec2.Instance, *string, error) {
 	log.Infof("listing ec2 instances")
 
-	var nextToken *string
-	if next != nil {
-		nextToken = next
+	if !hasFilter(filters, "instance-state-name") {
+		filters = append(filters, notTerminated())
 	}
-
-	filters := []*ec2.Filter{
-		notTerminated(),
-	}
-
 	if org != "" {
 		filters = append(filters, inOrg(org))
 	}
 
-	out, err := e.Service.DescribeInstancesWithContext(ctx, &ec2.DescribeInstancesInput{
-		Filters:    filters,
-		MaxResults: aws.Int64(per),
-		NextToken:  nextToken,
-	})
+	input := ec2.DescribeInstancesInput{
+		Filters:   filters,
+		NextToken: next,
+	}
+
+	if per != 0 {
+		input.MaxResults = aws.Int64(per)
+	}
 
+	out, err := e.Service.DescribeInstancesWithContext(ctx, &input)
 	if err != nil {
 		return nil, nil, common.ErrCode("listing instances", err)
 	}
 
 	log.Debugf("got output from instance list %+v", out)
 
-	list := []map[string]*string{}
+	instances := []*ec2.Instance{}
 	for _, r := range out.Reservations {
 		log.Debugf("reserveration: %s", aws.StringValue(r.ReservationId))
 		for _, i := range r.Instances {
@@ -160,41 +163,81 @@ func (e *Ec2) ListInstances(ctx context.Context, org string, per int64, next *st
 				continue
 			}
 
-			var instanceName *string
+			instances = append(instances, i)
+		}
+	}
 
-			for _, tag := range i.Tags {
-				if *tag.Key == "Name" {
-					instanceName = tag.Value
-					break
-				}
-			}
+	return instances, out.NextToken, nil
+}
 
-			list = append(list, map[string]*string{
-				"id":    i.InstanceId,
-				"name":  instanceName,
-				"state": i.State.Name,
-			})
+// ListInstanceDetails returns the full details of all instances that are not terminated matching the given filters, following pagination
+func (e *Ec2) ListInstanceDetails(ctx context.Context, org string, filters ...*ec2.Filter) ([]*ec2.Instance, error) {
+	log.Infof("listing instance details (org: '%s')", org)
+
+	filters = append(filters, notTerminated())
+	if org != "" {
+		filters = append(filters, inOrg(org))
+	}
+
+	input := ec2.DescribeInstancesInput{
+		Filters:    filters,
+		MaxResults: aws.Int64(1000),
+	}
+
+	instances := []*ec2.Instance{}
+	for {
+		out, err := e.Service.DescribeInstancesWithContext(ctx, &input)
+		if err != nil {
+			return nil, common.ErrCode("listing instance details", err)
+		}
+
+		if out == nil {
+			break
+		}
+
+		log.Debugf("got describe instances output with %d reservations", len(out.Reservations))
+
+		for _, r := range out.Reservations {
+			instances = append(instances, r.Instances...)
+		}
+
+		if out.NextToken != nil {
+			input.NextToken = out.NextToken
+			continue
 		}
+
+		break
 	}
 
-	return list, out.NextToken, nil
+	return instances, nil
 }
 
-// GetInstance gets details about an instance by ID
+// GetInstance gets details about an instance by ID, terminated instances aren't found
 func (e *Ec2) GetInstance(ctx context.Context, id string) (*ec2.Instance, error) {
+	return e.getInstance(ctx, id, notTerminated())
+}
+
+// GetInstanceAnyState gets details about an instance by ID in any state, including terminated
+func (e *Ec2) GetInstanceAnyState(ctx context.Context, id string) (*ec2.Instance, error) {
+	return e.getInstance(ctx, id)
+}
+
+func (e *Ec2) getInstance(ctx context.Context, id string, filters ...*ec2.Filter) (*ec2.Instance, error) {
 	if id == "" {
 		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
 	}
 
 	log.Infof("getting details about ec2 instance %s/%s", e.org, id)
 
-	out, err := e.Service.DescribeInstancesWithContext(ctx, &ec2.DescribeInstancesInput{
+	input := &ec2.DescribeInstancesInput{
 		InstanceIds: aws.StringSlice([]string{id}),
-		Filters: []*ec2.Filter{
-			notTerminated(),
-		},
-	})
+	}
+
+	if len(filters) > 0 {
+		input.Filters = filters
+	}
 
+	out, err := e.Service.DescribeInstancesWithContext(ctx, input)
 	if err != nil {
 		return nil, common.ErrCode("getting instance", err)
 	}
@@ -366,3 +409,57 @@ func (e *Ec2) RebootInstance(ctx context.Context, ids ...string) error {
 	}
 	return nil
 }
+
+// instanceWaiterDelay is the delay between checks of the state of an instance while waiting for it
+const instanceWaiterDelay = 5 * time.Second
+
+// WaitUntilInstanceState waits until an instance is running, stopped or terminated.  It keeps waiting until the
+// context is done and fails early when the instance gets to a state it can't reach the desired state from, like
+// terminated while waiting for running.
+func (e *Ec2) WaitUntilInstanceState(ctx context.Context, id, state string) error {
+	if id == "" || state == "" {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("waiting for instance %s/%s to be %s", e.org, id, state)
+
+	input := &ec2.DescribeInstancesInput{
+		InstanceIds: aws.StringSlice([]string{id}),
+	}
+
+	opts := []request.WaiterOption{
+		request.WithWaiterDelay(request.ConstantWaiterDelay(instanceWaiterDelay)),
+		// no limit on attempts, the context ends the wait
+		request.WithWaiterMaxAttempts(0),
+	}
+
+	var err error
+	switch state {
+	case ec2.InstanceStateNameRunning:
+		err = e.Service.WaitUntilInstanceRunningWithContext(ctx, input, opts...)
+	case ec2.InstanceStateNameStopped:
+		err = e.Service.WaitUntilInstanceStoppedWithContext(ctx, input, opts...)
+	case ec2.InstanceStateNameTerminated:
+		err = e.Service.WaitUntilInstanceTerminatedWithContext(ctx, input, opts...)
+	default:
+		msg := fmt.Sprintf("can't wait for instance state %q, must be running, stopped or terminated", state)
+		return apierror.New(apierror.ErrBadRequest, msg, nil)
+	}
+
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok {
+			switch aerr.Code() {
+			case request.CanceledErrorCode:
+				msg := fmt.Sprintf("timed out waiting for instance %s to be %s", id, state)
+				return apierror.New(common.ErrTimeout, msg, err)
+			case request.WaiterResourceNotReadyErrorCode:
+				msg := fmt.Sprintf("instance %s can't get to %s from its current state", id, state)
+				return apierror.New(apierror.ErrConflict, msg, err)
+			}
+		}
+
+		return common.ErrCode("waiting for instance state", err)
+	}
+
+	return nil
+}
@@ -5,6 +5,8 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/ec2-api/common"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/request"
@@ -18,7 +20,25 @@ func (m *mockEC2Client) DescribeInstancesWithContext(ctx context.Context, input
 		return nil, m.err
 	}
 
-	if len(input.InstanceIds) != 0 && aws.StringValue(input.InstanceIds[0]) == "i-notfound" {
+	if len(input.InstanceIds) != 0 && aws.StringValue(input.InstanceIds[0]) == "i-terminated" {
+		// terminated instances are only found without the state filter
+		if len(input.Filters) != 0 {
+			return &ec2.DescribeInstancesOutput{}, nil
+		}
+
+		return &ec2.DescribeInstancesOutput{
+			Reservations: []*ec2.Reservation{
+				{
+					Instances: []*ec2.Instance{
+						{
+							InstanceId: aws.String("i-terminated"),
+							State:      &ec2.InstanceState{Name: aws.String("terminated")},
+						},
+					},
+				},
+			},
+		}, nil
+	} else if len(input.InstanceIds) != 0 && aws.StringValue(input.InstanceIds[0]) == "i-notfound" {
 		return &ec2.DescribeInstancesOutput{}, nil
 	} else if len(input.InstanceIds) != 0 && aws.StringValue(input.InstanceIds[0]) == "i-multiple" {
 		return &ec2.DescribeInstancesOutput{
@@ -26,6 +46,20 @@ func (m *mockEC2Client) DescribeInstancesWithContext(ctx context.Context, input
 		}, nil
 	}
 
+	if aws.StringValue(input.NextToken) == "t-page" {
+		return &ec2.DescribeInstancesOutput{
+			Reservations: []*ec2.Reservation{
+				{
+					Instances: []*ec2.Instance{
+						{InstanceId: aws.String("i-0123456789abcdef0")},
+						{InstanceId: aws.String("i-0123456789abcdef1"), InstanceLifecycle: aws.String("spot")},
+					},
+				},
+			},
+			NextToken: aws.String("t-next"),
+		}, nil
+	}
+
 	return nil, nil
 }
 
@@ -269,6 +303,15 @@ func TestEc2_GetInstance(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name:   "terminated",
+			fields: fields{Service: newmockEC2Client(t, nil)},
+			args: args{
+				ctx: context.TODO(),
+				id:  "i-terminated",
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -292,6 +335,46 @@ func TestEc2_GetInstance(t *testing.T) {
 	}
 }
 
+func TestEc2_GetInstanceAnyState(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		want    *ec2.Instance
+		wantErr bool
+	}{
+		{
+			name:    "empty id",
+			wantErr: true,
+		},
+		{
+			name: "terminated",
+			id:   "i-terminated",
+			want: &ec2.Instance{
+				InstanceId: aws.String("i-terminated"),
+				State:      &ec2.InstanceState{Name: aws.String("terminated")},
+			},
+		},
+		{
+			name:    "not found",
+			id:      "i-notfound",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Ec2{Service: newmockEC2Client(t, nil)}
+			got, err := e.GetInstanceAnyState(context.TODO(), tt.id)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Ec2.GetInstanceAnyState() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Ec2.GetInstanceAnyState() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestEc2_StartInstance(t *testing.T) {
 	type fields struct {
 		session         *session.Session
@@ -503,3 +586,216 @@ func TestEc2_RebootInstance(t *testing.T) {
 		})
 	}
 }
+
+func TestEc2_ListInstanceDetails(t *testing.T) {
+	type fields struct {
+		Service ec2iface.EC2API
+	}
+	type args struct {
+		ctx     context.Context
+		org     string
+		filters []*ec2.Filter
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    []*ec2.Instance
+		wantErr bool
+	}{
+		{
+			name:   "success case",
+			args:   args{ctx: context.TODO(), org: "testorg"},
+			fields: fields{Service: newmockEC2Client(t, nil)},
+			want:   []*ec2.Instance{},
+		},
+		{
+			name:    "aws error",
+			args:    args{ctx: context.TODO()},
+			fields:  fields{Service: newmockEC2Client(t, awserr.New("Bad Request", "boom.", nil))},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Ec2{
+				Service: tt.fields.Service,
+			}
+			got, err := e.ListInstanceDetails(tt.args.ctx, tt.args.org, tt.args.filters...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Ec2.ListInstanceDetails() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Ec2.ListInstanceDetails() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEc2_ListInstancesPage(t *testing.T) {
+	type fields struct {
+		Service ec2iface.EC2API
+	}
+	type args struct {
+		ctx  context.Context
+		org  string
+		per  int64
+		next *string
+	}
+	tests := []struct {
+		name     string
+		fields   fields
+		args     args
+		want     []*ec2.Instance
+		wantNext *string
+		wantErr  bool
+	}{
+		{
+			name:     "success case skips spot instances",
+			args:     args{ctx: context.TODO(), org: "testorg", per: 10, next: aws.String("t-page")},
+			fields:   fields{Service: newmockEC2Client(t, nil)},
+			want:     []*ec2.Instance{{InstanceId: aws.String("i-0123456789abcdef0")}},
+			wantNext: aws.String("t-next"),
+		},
+		{
+			name:    "aws error",
+			args:    args{ctx: context.TODO()},
+			fields:  fields{Service: newmockEC2Client(t, awserr.New("Bad Request", "boom.", nil))},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Ec2{
+				Service: tt.fields.Service,
+			}
+			got, next, err := e.ListInstancesPage(tt.args.ctx, tt.args.org, tt.args.per, tt.args.next)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Ec2.ListInstancesPage() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Ec2.ListInstancesPage() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(next, tt.wantNext) {
+				t.Errorf("Ec2.ListInstancesPage() next = %v, want %v", next, tt.wantNext)
+			}
+		})
+	}
+}
+
+func (m *mockEC2Client) waitUntilInstance(input *ec2.DescribeInstancesInput) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	switch aws.StringValue(input.InstanceIds[0]) {
+	case "i-timeout":
+		return awserr.New(request.CanceledErrorCode, "waiter context canceled", context.DeadlineExceeded)
+	case "i-notready":
+		return awserr.New(request.WaiterResourceNotReadyErrorCode, "failed waiting for successful resource state", nil)
+	}
+
+	return nil
+}
+
+func (m *mockEC2Client) WaitUntilInstanceRunningWithContext(ctx context.Context, input *ec2.DescribeInstancesInput, opts ...request.WaiterOption) error {
+	return m.waitUntilInstance(input)
+}
+
+func (m *mockEC2Client) WaitUntilInstanceStoppedWithContext(ctx context.Context, input *ec2.DescribeInstancesInput, opts ...request.WaiterOption) error {
+	return m.waitUntilInstance(input)
+}
+
+func (m *mockEC2Client) WaitUntilInstanceTerminatedWithContext(ctx context.Context, input *ec2.DescribeInstancesInput, opts ...request.WaiterOption) error {
+	return m.waitUntilInstance(input)
+}
+
+func TestEc2_WaitUntilInstanceState(t *testing.T) {
+	type fields struct {
+		Service ec2iface.EC2API
+	}
+	tests := []struct {
+		name     string
+		fields   fields
+		id       string
+		state    string
+		wantCode string
+		wantErr  bool
+	}{
+		{
+			name:   "running",
+			fields: fields{Service: newmockEC2Client(t, nil)},
+			id:     "i-0123456789abcdef0",
+			state:  "running",
+		},
+		{
+			name:   "stopped",
+			fields: fields{Service: newmockEC2Client(t, nil)},
+			id:     "i-0123456789abcdef0",
+			state:  "stopped",
+		},
+		{
+			name:   "terminated",
+			fields: fields{Service: newmockEC2Client(t, nil)},
+			id:     "i-0123456789abcdef0",
+			state:  "terminated",
+		},
+		{
+			name:     "unsupported state",
+			fields:   fields{Service: newmockEC2Client(t, nil)},
+			id:       "i-0123456789abcdef0",
+			state:    "stopping",
+			wantCode: apierror.ErrBadRequest,
+			wantErr:  true,
+		},
+		{
+			name:     "missing id",
+			fields:   fields{Service: newmockEC2Client(t, nil)},
+			state:    "running",
+			wantCode: apierror.ErrBadRequest,
+			wantErr:  true,
+		},
+		{
+			name:     "timeout",
+			fields:   fields{Service: newmockEC2Client(t, nil)},
+			id:       "i-timeout",
+			state:    "running",
+			wantCode: common.ErrTimeout,
+			wantErr:  true,
+		},
+		{
+			name:     "unreachable state",
+			fields:   fields{Service: newmockEC2Client(t, nil)},
+			id:       "i-notready",
+			state:    "running",
+			wantCode: apierror.ErrConflict,
+			wantErr:  true,
+		},
+		{
+			name:     "aws error",
+			fields:   fields{Service: newmockEC2Client(t, awserr.New("Bad Request", "boom.", nil))},
+			id:       "i-0123456789abcdef0",
+			state:    "running",
+			wantCode: apierror.ErrBadRequest,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Ec2{
+				Service: tt.fields.Service,
+			}
+			err := e.WaitUntilInstanceState(context.TODO(), tt.id, tt.state)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Ec2.WaitUntilInstanceState() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if aerr, ok := err.(apierror.Error); ok && aerr.Code != tt.wantCode {
+				t.Errorf("Ec2.WaitUntilInstanceState() error code = %s, want %s", aerr.Code, tt.wantCode)
+			}
+		})
+	}
+}
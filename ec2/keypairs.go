@@ -0,0 +1,111 @@
+package ec2
+
+import (
+	"context"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/ec2-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	log "github.com/sirupsen/logrus"
+)
+
+// ListKeyPairs returns the key pairs matching the given filters, limited to the org when one is given
+func (e *Ec2) ListKeyPairs(ctx context.Context, org string, filters ...*ec2.Filter) ([]*ec2.KeyPairInfo, error) {
+	log.Infof("listing key pairs (org: '%s')", org)
+
+	if org != "" {
+		filters = append(filters, inSpinupOrg(org))
+	}
+
+	out, err := e.Service.DescribeKeyPairsWithContext(ctx, &ec2.DescribeKeyPairsInput{
+		Filters: filters,
+	})
+	if err != nil {
+		return nil, common.ErrCode("listing key pairs", err)
+	}
+
+	log.Debugf("got describe key pairs output with %d key pairs", len(out.KeyPairs))
+
+	return out.KeyPairs, nil
+}
+
+// GetKeyPair gets a key pair by its name, including the public key
+func (e *Ec2) GetKeyPair(ctx context.Context, name string) (*ec2.KeyPairInfo, error) {
+	if name == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("getting details about key pair %s", name)
+
+	out, err := e.Service.DescribeKeyPairsWithContext(ctx, &ec2.DescribeKeyPairsInput{
+		KeyNames:         aws.StringSlice([]string{name}),
+		IncludePublicKey: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, common.ErrCode("getting key pair", err)
+	}
+
+	if len(out.KeyPairs) == 0 {
+		return nil, apierror.New(apierror.ErrNotFound, "key pair not found", nil)
+	}
+
+	if len(out.KeyPairs) > 1 {
+		return nil, apierror.New(apierror.ErrBadRequest, "unexpected key pair count returned", nil)
+	}
+
+	return out.KeyPairs[0], nil
+}
+
+// CreateKeyPair creates a key pair, the private key is only returned in the output of this call
+func (e *Ec2) CreateKeyPair(ctx context.Context, input *ec2.CreateKeyPairInput) (*ec2.CreateKeyPairOutput, error) {
+	if input == nil || aws.StringValue(input.KeyName) == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("creating key pair %s", aws.StringValue(input.KeyName))
+
+	out, err := e.Service.CreateKeyPairWithContext(ctx, input)
+	if err != nil {
+		return nil, common.ErrCode("failed to create key pair", err)
+	}
+
+	log.Debugf("created key pair %s (%s)", aws.StringValue(out.KeyName), aws.StringValue(out.KeyPairId))
+
+	return out, nil
+}
+
+// ImportKeyPair imports the public key of a key pair
+func (e *Ec2) ImportKeyPair(ctx context.Context, input *ec2.ImportKeyPairInput) (*ec2.ImportKeyPairOutput, error) {
+	if input == nil || aws.StringValue(input.KeyName) == "" || len(input.PublicKeyMaterial) == 0 {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("importing key pair %s", aws.StringValue(input.KeyName))
+
+	out, err := e.Service.ImportKeyPairWithContext(ctx, input)
+	if err != nil {
+		return nil, common.ErrCode("failed to import key pair", err)
+	}
+
+	log.Debugf("imported key pair %s (%s)", aws.StringValue(out.KeyName), aws.StringValue(out.KeyPairId))
+
+	return out, nil
+}
+
+// DeleteKeyPair deletes a key pair by its id
+func (e *Ec2) DeleteKeyPair(ctx context.Context, id string) error {
+	if id == "" {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("deleting key pair %s", id)
+
+	if _, err := e.Service.DeleteKeyPairWithContext(ctx, &ec2.DeleteKeyPairInput{
+		KeyPairId: aws.String(id),
+	}); err != nil {
+		return common.ErrCode("failed to delete key pair", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,180 @@
+package ec2
+
+import (
+	"context"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/ec2-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	log "github.com/sirupsen/logrus"
+)
+
+// ListManagedPrefixLists returns the managed prefix lists matching the given filters, following pagination
+func (e *Ec2) ListManagedPrefixLists(ctx context.Context, filters ...*ec2.Filter) ([]*ec2.ManagedPrefixList, error) {
+	log.Infof("listing managed prefix lists")
+
+	input := ec2.DescribeManagedPrefixListsInput{
+		Filters:    filters,
+		MaxResults: aws.Int64(100),
+	}
+
+	lists := []*ec2.ManagedPrefixList{}
+	for {
+		out, err := e.Service.DescribeManagedPrefixListsWithContext(ctx, &input)
+		if err != nil {
+			return nil, common.ErrCode("listing managed prefix lists", err)
+		}
+
+		log.Debugf("got describe managed prefix lists output with %d prefix lists", len(out.PrefixLists))
+
+		lists = append(lists, out.PrefixLists...)
+
+		if out.NextToken != nil {
+			input.NextToken = out.NextToken
+			continue
+		}
+
+		break
+	}
+
+	return lists, nil
+}
+
+// GetManagedPrefixList gets the details of a managed prefix list
+func (e *Ec2) GetManagedPrefixList(ctx context.Context, id string) (*ec2.ManagedPrefixList, error) {
+	if id == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("getting details about managed prefix list %s", id)
+
+	out, err := e.Service.DescribeManagedPrefixListsWithContext(ctx, &ec2.DescribeManagedPrefixListsInput{
+		PrefixListIds: aws.StringSlice([]string{id}),
+	})
+	if err != nil {
+		return nil, common.ErrCode("getting details for managed prefix list", err)
+	}
+
+	if len(out.PrefixLists) == 0 {
+		return nil, apierror.New(apierror.ErrNotFound, "prefix list not found", nil)
+	}
+
+	if len(out.PrefixLists) > 1 {
+		return nil, apierror.New(apierror.ErrBadRequest, "unexpected prefix list count returned", nil)
+	}
+
+	return out.PrefixLists[0], nil
+}
+
+// GetManagedPrefixListEntries returns the entries of a managed prefix list, following pagination.  If version is
+// given, the entries of that version of the prefix list are returned.
+func (e *Ec2) GetManagedPrefixListEntries(ctx context.Context, id string, version *int64) ([]*ec2.PrefixListEntry, error) {
+	if id == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("getting entries for managed prefix list %s (version: %d)", id, aws.Int64Value(version))
+
+	input := ec2.GetManagedPrefixListEntriesInput{
+		PrefixListId:  aws.String(id),
+		TargetVersion: version,
+		MaxResults:    aws.Int64(100),
+	}
+
+	entries := []*ec2.PrefixListEntry{}
+	for {
+		out, err := e.Service.GetManagedPrefixListEntriesWithContext(ctx, &input)
+		if err != nil {
+			return nil, common.ErrCode("getting managed prefix list entries", err)
+		}
+
+		log.Debugf("got managed prefix list entries output with %d entries", len(out.Entries))
+
+		entries = append(entries, out.Entries...)
+
+		if out.NextToken != nil {
+			input.NextToken = out.NextToken
+			continue
+		}
+
+		break
+	}
+
+	return entries, nil
+}
+
+// CreateManagedPrefixList creates a managed prefix list
+func (e *Ec2) CreateManagedPrefixList(ctx context.Context, input *ec2.CreateManagedPrefixListInput) (*ec2.ManagedPrefixList, error) {
+	if input == nil {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("creating managed prefix list %s", aws.StringValue(input.PrefixListName))
+
+	out, err := e.Service.CreateManagedPrefixListWithContext(ctx, input)
+	if err != nil {
+		return nil, common.ErrCode("failed to create managed prefix list", err)
+	}
+
+	log.Debugf("got output creating managed prefix list %+v", out)
+
+	return out.PrefixList, nil
+}
+
+// ModifyManagedPrefixList modifies the name, max entries or entries of a managed prefix list
+func (e *Ec2) ModifyManagedPrefixList(ctx context.Context, input *ec2.ModifyManagedPrefixListInput) (*ec2.ManagedPrefixList, error) {
+	if input == nil || aws.StringValue(input.PrefixListId) == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("modifying managed prefix list %s", aws.StringValue(input.PrefixListId))
+
+	out, err := e.Service.ModifyManagedPrefixListWithContext(ctx, input)
+	if err != nil {
+		return nil, common.ErrCode("failed to modify managed prefix list", err)
+	}
+
+	log.Debugf("got output modifying managed prefix list %+v", out)
+
+	return out.PrefixList, nil
+}
+
+// RestoreManagedPrefixListVersion restores the entries of a managed prefix list to a previous version
+func (e *Ec2) RestoreManagedPrefixListVersion(ctx context.Context, id string, currentVersion, previousVersion int64) (*ec2.ManagedPrefixList, error) {
+	if id == "" || currentVersion < 1 || previousVersion < 1 {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("restoring managed prefix list %s from version %d to version %d", id, currentVersion, previousVersion)
+
+	out, err := e.Service.RestoreManagedPrefixListVersionWithContext(ctx, &ec2.RestoreManagedPrefixListVersionInput{
+		PrefixListId:    aws.String(id),
+		CurrentVersion:  aws.Int64(currentVersion),
+		PreviousVersion: aws.Int64(previousVersion),
+	})
+	if err != nil {
+		return nil, common.ErrCode("failed to restore managed prefix list version", err)
+	}
+
+	log.Debugf("got output restoring managed prefix list version %+v", out)
+
+	return out.PrefixList, nil
+}
+
+// DeleteManagedPrefixList deletes a managed prefix list
+func (e *Ec2) DeleteManagedPrefixList(ctx context.Context, id string) error {
+	if id == "" {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("deleting managed prefix list %s", id)
+
+	if _, err := e.Service.DeleteManagedPrefixListWithContext(ctx, &ec2.DeleteManagedPrefixListInput{
+		PrefixListId: aws.String(id),
+	}); err != nil {
+		return common.ErrCode("failed to delete managed prefix list", err)
+	}
+
+	return nil
+}
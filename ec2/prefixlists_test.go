@@ -0,0 +1,489 @@
+package ec2
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+)
+
+var prefixLists = []*ec2.ManagedPrefixList{
+	{
+		PrefixListId:   aws.String("pl-0000000001"),
+		PrefixListName: aws.String("campus"),
+		AddressFamily:  aws.String("IPv4"),
+		MaxEntries:     aws.Int64(10),
+		Version:        aws.Int64(2),
+		State:          aws.String("create-complete"),
+		OwnerId:        aws.String("012345678901"),
+	},
+	{
+		PrefixListId:   aws.String("pl-0000000002"),
+		PrefixListName: aws.String("vpn"),
+		AddressFamily:  aws.String("IPv4"),
+		MaxEntries:     aws.Int64(5),
+		Version:        aws.Int64(1),
+		State:          aws.String("create-complete"),
+		OwnerId:        aws.String("012345678901"),
+	},
+}
+
+var prefixListEntries = []*ec2.PrefixListEntry{
+	{Cidr: aws.String("10.0.0.0/8"), Description: aws.String("campus")},
+	{Cidr: aws.String("172.16.0.0/12"), Description: aws.String("vpn")},
+}
+
+func (m *mockEC2Client) DescribeManagedPrefixListsWithContext(ctx context.Context, input *ec2.DescribeManagedPrefixListsInput, opts ...request.Option) (*ec2.DescribeManagedPrefixListsOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	if len(input.PrefixListIds) > 0 {
+		out := []*ec2.ManagedPrefixList{}
+		for _, pl := range prefixLists {
+			for _, id := range input.PrefixListIds {
+				if aws.StringValue(pl.PrefixListId) == aws.StringValue(id) {
+					out = append(out, pl)
+				}
+			}
+		}
+		return &ec2.DescribeManagedPrefixListsOutput{PrefixLists: out}, nil
+	}
+
+	// return the first page with a token, then the rest
+	if input.NextToken == nil {
+		return &ec2.DescribeManagedPrefixListsOutput{
+			PrefixLists: prefixLists[:1],
+			NextToken:   aws.String("next"),
+		}, nil
+	}
+
+	return &ec2.DescribeManagedPrefixListsOutput{PrefixLists: prefixLists[1:]}, nil
+}
+
+func (m *mockEC2Client) GetManagedPrefixListEntriesWithContext(ctx context.Context, input *ec2.GetManagedPrefixListEntriesInput, opts ...request.Option) (*ec2.GetManagedPrefixListEntriesOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	// return the first page with a token, then the rest
+	if input.NextToken == nil {
+		return &ec2.GetManagedPrefixListEntriesOutput{
+			Entries:   prefixListEntries[:1],
+			NextToken: aws.String("next"),
+		}, nil
+	}
+
+	return &ec2.GetManagedPrefixListEntriesOutput{Entries: prefixListEntries[1:]}, nil
+}
+
+func (m *mockEC2Client) CreateManagedPrefixListWithContext(ctx context.Context, input *ec2.CreateManagedPrefixListInput, opts ...request.Option) (*ec2.CreateManagedPrefixListOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	return &ec2.CreateManagedPrefixListOutput{
+		PrefixList: &ec2.ManagedPrefixList{
+			PrefixListId:   aws.String("pl-0000000003"),
+			PrefixListName: input.PrefixListName,
+			AddressFamily:  input.AddressFamily,
+			MaxEntries:     input.MaxEntries,
+			Version:        aws.Int64(1),
+			State:          aws.String("create-in-progress"),
+		},
+	}, nil
+}
+
+func (m *mockEC2Client) ModifyManagedPrefixListWithContext(ctx context.Context, input *ec2.ModifyManagedPrefixListInput, opts ...request.Option) (*ec2.ModifyManagedPrefixListOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	return &ec2.ModifyManagedPrefixListOutput{
+		PrefixList: &ec2.ManagedPrefixList{
+			PrefixListId: input.PrefixListId,
+			Version:      aws.Int64(aws.Int64Value(input.CurrentVersion) + 1),
+			State:        aws.String("modify-in-progress"),
+		},
+	}, nil
+}
+
+func (m *mockEC2Client) RestoreManagedPrefixListVersionWithContext(ctx context.Context, input *ec2.RestoreManagedPrefixListVersionInput, opts ...request.Option) (*ec2.RestoreManagedPrefixListVersionOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	return &ec2.RestoreManagedPrefixListVersionOutput{
+		PrefixList: &ec2.ManagedPrefixList{
+			PrefixListId: input.PrefixListId,
+			Version:      aws.Int64(aws.Int64Value(input.CurrentVersion) + 1),
+			State:        aws.String("restore-in-progress"),
+		},
+	}, nil
+}
+
+func (m *mockEC2Client) DeleteManagedPrefixListWithContext(ctx context.Context, input *ec2.DeleteManagedPrefixListInput, opts ...request.Option) (*ec2.DeleteManagedPrefixListOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	return &ec2.DeleteManagedPrefixListOutput{}, nil
+}
+
+func TestEc2_ListManagedPrefixLists(t *testing.T) {
+	type fields struct {
+		Service ec2iface.EC2API
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		want    []*ec2.ManagedPrefixList
+		wantErr bool
+	}{
+		{
+			name:   "success case",
+			fields: fields{Service: newmockEC2Client(t, nil)},
+			want:   prefixLists,
+		},
+		{
+			name:    "aws error",
+			fields:  fields{Service: newmockEC2Client(t, awserr.New("Bad Request", "boom.", nil))},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Ec2{
+				Service: tt.fields.Service,
+			}
+			got, err := e.ListManagedPrefixLists(context.TODO())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Ec2.ListManagedPrefixLists() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Ec2.ListManagedPrefixLists() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEc2_GetManagedPrefixList(t *testing.T) {
+	type fields struct {
+		Service ec2iface.EC2API
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		id      string
+		want    *ec2.ManagedPrefixList
+		wantErr bool
+	}{
+		{
+			name:   "success case",
+			fields: fields{Service: newmockEC2Client(t, nil)},
+			id:     "pl-0000000002",
+			want:   prefixLists[1],
+		},
+		{
+			name:    "not found",
+			fields:  fields{Service: newmockEC2Client(t, nil)},
+			id:      "pl-0000000009",
+			wantErr: true,
+		},
+		{
+			name:    "missing id",
+			fields:  fields{Service: newmockEC2Client(t, nil)},
+			wantErr: true,
+		},
+		{
+			name:    "aws error",
+			fields:  fields{Service: newmockEC2Client(t, awserr.New("Bad Request", "boom.", nil))},
+			id:      "pl-0000000001",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Ec2{
+				Service: tt.fields.Service,
+			}
+			got, err := e.GetManagedPrefixList(context.TODO(), tt.id)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Ec2.GetManagedPrefixList() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Ec2.GetManagedPrefixList() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEc2_GetManagedPrefixListEntries(t *testing.T) {
+	type fields struct {
+		Service ec2iface.EC2API
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		id      string
+		version *int64
+		want    []*ec2.PrefixListEntry
+		wantErr bool
+	}{
+		{
+			name:   "success case",
+			fields: fields{Service: newmockEC2Client(t, nil)},
+			id:     "pl-0000000001",
+			want:   prefixListEntries,
+		},
+		{
+			name:    "success case with version",
+			fields:  fields{Service: newmockEC2Client(t, nil)},
+			id:      "pl-0000000001",
+			version: aws.Int64(1),
+			want:    prefixListEntries,
+		},
+		{
+			name:    "missing id",
+			fields:  fields{Service: newmockEC2Client(t, nil)},
+			wantErr: true,
+		},
+		{
+			name:    "aws error",
+			fields:  fields{Service: newmockEC2Client(t, awserr.New("Bad Request", "boom.", nil))},
+			id:      "pl-0000000001",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Ec2{
+				Service: tt.fields.Service,
+			}
+			got, err := e.GetManagedPrefixListEntries(context.TODO(), tt.id, tt.version)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Ec2.GetManagedPrefixListEntries() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Ec2.GetManagedPrefixListEntries() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEc2_CreateManagedPrefixList(t *testing.T) {
+	type fields struct {
+		Service ec2iface.EC2API
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		input   *ec2.CreateManagedPrefixListInput
+		want    *ec2.ManagedPrefixList
+		wantErr bool
+	}{
+		{
+			name:   "success case",
+			fields: fields{Service: newmockEC2Client(t, nil)},
+			input: &ec2.CreateManagedPrefixListInput{
+				PrefixListName: aws.String("campus"),
+				AddressFamily:  aws.String("IPv4"),
+				MaxEntries:     aws.Int64(10),
+			},
+			want: &ec2.ManagedPrefixList{
+				PrefixListId:   aws.String("pl-0000000003"),
+				PrefixListName: aws.String("campus"),
+				AddressFamily:  aws.String("IPv4"),
+				MaxEntries:     aws.Int64(10),
+				Version:        aws.Int64(1),
+				State:          aws.String("create-in-progress"),
+			},
+		},
+		{
+			name:    "nil input",
+			fields:  fields{Service: newmockEC2Client(t, nil)},
+			wantErr: true,
+		},
+		{
+			name:    "aws error",
+			fields:  fields{Service: newmockEC2Client(t, awserr.New("Bad Request", "boom.", nil))},
+			input:   &ec2.CreateManagedPrefixListInput{PrefixListName: aws.String("campus")},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Ec2{
+				Service: tt.fields.Service,
+			}
+			got, err := e.CreateManagedPrefixList(context.TODO(), tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Ec2.CreateManagedPrefixList() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Ec2.CreateManagedPrefixList() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEc2_ModifyManagedPrefixList(t *testing.T) {
+	type fields struct {
+		Service ec2iface.EC2API
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		input   *ec2.ModifyManagedPrefixListInput
+		want    *ec2.ManagedPrefixList
+		wantErr bool
+	}{
+		{
+			name:   "success case",
+			fields: fields{Service: newmockEC2Client(t, nil)},
+			input: &ec2.ModifyManagedPrefixListInput{
+				PrefixListId:   aws.String("pl-0000000001"),
+				CurrentVersion: aws.Int64(2),
+				AddEntries:     []*ec2.AddPrefixListEntry{{Cidr: aws.String("192.168.0.0/16")}},
+			},
+			want: &ec2.ManagedPrefixList{
+				PrefixListId: aws.String("pl-0000000001"),
+				Version:      aws.Int64(3),
+				State:        aws.String("modify-in-progress"),
+			},
+		},
+		{
+			name:    "missing id",
+			fields:  fields{Service: newmockEC2Client(t, nil)},
+			input:   &ec2.ModifyManagedPrefixListInput{},
+			wantErr: true,
+		},
+		{
+			name:    "aws error",
+			fields:  fields{Service: newmockEC2Client(t, awserr.New("Bad Request", "boom.", nil))},
+			input:   &ec2.ModifyManagedPrefixListInput{PrefixListId: aws.String("pl-0000000001")},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Ec2{
+				Service: tt.fields.Service,
+			}
+			got, err := e.ModifyManagedPrefixList(context.TODO(), tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Ec2.ModifyManagedPrefixList() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Ec2.ModifyManagedPrefixList() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEc2_RestoreManagedPrefixListVersion(t *testing.T) {
+	type fields struct {
+		Service ec2iface.EC2API
+	}
+	tests := []struct {
+		name     string
+		fields   fields
+		id       string
+		current  int64
+		previous int64
+		want     *ec2.ManagedPrefixList
+		wantErr  bool
+	}{
+		{
+			name:     "success case",
+			fields:   fields{Service: newmockEC2Client(t, nil)},
+			id:       "pl-0000000001",
+			current:  2,
+			previous: 1,
+			want: &ec2.ManagedPrefixList{
+				PrefixListId: aws.String("pl-0000000001"),
+				Version:      aws.Int64(3),
+				State:        aws.String("restore-in-progress"),
+			},
+		},
+		{
+			name:    "invalid version",
+			fields:  fields{Service: newmockEC2Client(t, nil)},
+			id:      "pl-0000000001",
+			current: 2,
+			wantErr: true,
+		},
+		{
+			name:     "aws error",
+			fields:   fields{Service: newmockEC2Client(t, awserr.New("Bad Request", "boom.", nil))},
+			id:       "pl-0000000001",
+			current:  2,
+			previous: 1,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Ec2{
+				Service: tt.fields.Service,
+			}
+			got, err := e.RestoreManagedPrefixListVersion(context.TODO(), tt.id, tt.current, tt.previous)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Ec2.RestoreManagedPrefixListVersion() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Ec2.RestoreManagedPrefixListVersion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEc2_DeleteManagedPrefixList(t *testing.T) {
+	type fields struct {
+		Service ec2iface.EC2API
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		id      string
+		wantErr bool
+	}{
+		{
+			name:   "success case",
+			fields: fields{Service: newmockEC2Client(t, nil)},
+			id:     "pl-0000000001",
+		},
+		{
+			name:    "missing id",
+			fields:  fields{Service: newmockEC2Client(t, nil)},
+			wantErr: true,
+		},
+		{
+			name:    "aws error",
+			fields:  fields{Service: newmockEC2Client(t, awserr.New("Bad Request", "boom.", nil))},
+			id:      "pl-0000000001",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Ec2{
+				Service: tt.fields.Service,
+			}
+			if err := e.DeleteManagedPrefixList(context.TODO(), tt.id); (err != nil) != tt.wantErr {
+				t.Errorf("Ec2.DeleteManagedPrefixList() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/YaleSpinup/apierror"
 	"github.com/YaleSpinup/ec2-api/common"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	log "github.com/sirupsen/logrus"
 )
@@ -66,6 +67,68 @@ func (e *Ec2) ListSecurityGroups(ctx context.Context, org string) ([]map[string]
 	return list, err
 }
 
+// ListSecurityGroupsPage returns the full details of a page of security groups matching the given filters
+func (e *Ec2) ListSecurityGroupsPage(ctx context.Context, org string, per int64, next *string, filters ...*ec2.Filter) ([]*ec2.SecurityGroup, *string, error) {
+	log.Infof("listing ec2 security groups (org: '%s')", org)
+
+	if org != "" {
+		filters = append(filters, inOrg(org))
+	}
+
+	input := ec2.DescribeSecurityGroupsInput{
+		Filters:   filters,
+		NextToken: next,
+	}
+
+	if per != 0 {
+		input.MaxResults = aws.Int64(per)
+	}
+
+	out, err := e.Service.DescribeSecurityGroupsWithContext(ctx, &input)
+	if err != nil {
+		return nil, nil, common.ErrCode("listing security groups", err)
+	}
+
+	log.Debugf("returning list of %d security groups", len(out.SecurityGroups))
+
+	return out.SecurityGroups, out.NextToken, nil
+}
+
+// ListSecurityGroupDetails returns the full details of all security groups matching the given filters, following pagination
+func (e *Ec2) ListSecurityGroupDetails(ctx context.Context, org string, filters ...*ec2.Filter) ([]*ec2.SecurityGroup, error) {
+	log.Infof("listing security group details (org: '%s')", org)
+
+	if org != "" {
+		filters = append(filters, inOrg(org))
+	}
+
+	input := ec2.DescribeSecurityGroupsInput{
+		Filters:    filters,
+		MaxResults: aws.Int64(1000),
+	}
+
+	sgs := []*ec2.SecurityGroup{}
+	for {
+		out, err := e.Service.DescribeSecurityGroupsWithContext(ctx, &input)
+		if err != nil {
+			return nil, common.ErrCode("listing security group details", err)
+		}
+
+		log.Debugf("got describe security groups output with %d security groups", len(out.SecurityGroups))
+
+		sgs = append(sgs, out.SecurityGroups...)
+
+		if out.NextToken != nil {
+			input.NextToken = out.NextToken
+			continue
+		}
+
+		break
+	}
+
+	return sgs, nil
+}
+
 // GetSecurityGroup Get the given security groups by a list of ids
 func (e *Ec2) GetSecurityGroup(ctx context.Context, ids ...string) ([]*ec2.SecurityGroup, error) {
 	if len(ids) == 0 {
@@ -97,6 +160,11 @@ func (e *Ec2) DeleteSecurityGroup(ctx context.Context, id string) error {
 	if _, err := e.Service.DeleteSecurityGroupWithContext(ctx, &ec2.DeleteSecurityGroupInput{
 		GroupId: aws.String(id),
 	}); err != nil {
+		// a security group that's in use by another resource can't be deleted
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == "DependencyViolation" {
+			return apierror.New(apierror.ErrConflict, "deleting security group: "+aerr.Message(), err)
+		}
+
 		return common.ErrCode("deleting security group", err)
 	}
 
@@ -206,3 +274,47 @@ func (e *Ec2) RevokeSecurityGroup(ctx context.Context, direction, sg string, per
 
 	return nil
 }
+
+// UpdateSecurityGroupRuleDescriptions updates the descriptions of existing security group rules
+func (e *Ec2) UpdateSecurityGroupRuleDescriptions(ctx context.Context, direction, sg string, permissions []*ec2.IpPermission) error {
+	if direction == "" || sg == "" || permissions == nil {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("updating %s security group rule descriptions for %s", direction, sg)
+
+	switch direction {
+	case "outbound":
+		out, err := e.Service.UpdateSecurityGroupRuleDescriptionsEgressWithContext(ctx, &ec2.UpdateSecurityGroupRuleDescriptionsEgressInput{
+			GroupId:       aws.String(sg),
+			IpPermissions: permissions,
+		})
+		if err != nil {
+			return common.ErrCode("failed updating egress rule descriptions", err)
+		}
+
+		log.Debugf("got output updating security group egress rule descriptions: %+v", out)
+
+		if !aws.BoolValue(out.Return) {
+			return apierror.New(apierror.ErrBadRequest, "security group rule description update failed", nil)
+		}
+	case "inbound":
+		out, err := e.Service.UpdateSecurityGroupRuleDescriptionsIngressWithContext(ctx, &ec2.UpdateSecurityGroupRuleDescriptionsIngressInput{
+			GroupId:       aws.String(sg),
+			IpPermissions: permissions,
+		})
+		if err != nil {
+			return common.ErrCode("failed updating ingress rule descriptions", err)
+		}
+
+		log.Debugf("got output updating security group ingress rule descriptions: %+v", out)
+
+		if !aws.BoolValue(out.Return) {
+			return apierror.New(apierror.ErrBadRequest, "security group rule description update failed", nil)
+		}
+	default:
+		return apierror.New(apierror.ErrBadRequest, "direction is required to be [outbound|inbound]", nil)
+	}
+
+	return nil
+}
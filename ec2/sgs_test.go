@@ -6,6 +6,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/YaleSpinup/apierror"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/request"
@@ -436,6 +437,34 @@ func (m mockEC2Client) RevokeSecurityGroupEgressWithContext(ctx context.Context,
 	return nil, awserr.New("NotFound", "Security group not found", nil)
 }
 
+func (m mockEC2Client) UpdateSecurityGroupRuleDescriptionsIngressWithContext(ctx context.Context, input *ec2.UpdateSecurityGroupRuleDescriptionsIngressInput, opts ...request.Option) (*ec2.UpdateSecurityGroupRuleDescriptionsIngressOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	for _, securityGroup := range securityGroups {
+		if aws.StringValue(input.GroupId) == aws.StringValue(securityGroup.GroupId) {
+			return &ec2.UpdateSecurityGroupRuleDescriptionsIngressOutput{Return: aws.Bool(true)}, nil
+		}
+	}
+
+	return nil, awserr.New("NotFound", "Security group not found", nil)
+}
+
+func (m mockEC2Client) UpdateSecurityGroupRuleDescriptionsEgressWithContext(ctx context.Context, input *ec2.UpdateSecurityGroupRuleDescriptionsEgressInput, opts ...request.Option) (*ec2.UpdateSecurityGroupRuleDescriptionsEgressOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	for _, securityGroup := range securityGroups {
+		if aws.StringValue(input.GroupId) == aws.StringValue(securityGroup.GroupId) {
+			return &ec2.UpdateSecurityGroupRuleDescriptionsEgressOutput{Return: aws.Bool(true)}, nil
+		}
+	}
+
+	return nil, awserr.New("NotFound", "Security group not found", nil)
+}
+
 func TestEc2_ListSecurityGroups(t *testing.T) {
 	type fields struct {
 		session *session.Session
@@ -1029,10 +1058,11 @@ func TestEc2_DeleteSecurityGroup(t *testing.T) {
 		id  string
 	}
 	tests := []struct {
-		name    string
-		fields  fields
-		args    args
-		wantErr bool
+		name     string
+		fields   fields
+		args     args
+		wantCode string
+		wantErr  bool
 	}{
 		{
 			name:    "empty id",
@@ -1086,8 +1116,19 @@ func TestEc2_DeleteSecurityGroup(t *testing.T) {
 				ctx: context.TODO(),
 				id:  "sg-0000000001",
 			},
-			fields:  fields{Service: newmockEC2Client(t, awserr.New("Bad Request", "boom.", nil))},
-			wantErr: true,
+			fields:   fields{Service: newmockEC2Client(t, awserr.New("Bad Request", "boom.", nil))},
+			wantCode: apierror.ErrBadRequest,
+			wantErr:  true,
+		},
+		{
+			name: "in use",
+			args: args{
+				ctx: context.TODO(),
+				id:  "sg-0000000001",
+			},
+			fields:   fields{Service: newmockEC2Client(t, awserr.New("DependencyViolation", "resource sg-0000000001 has a dependent object", nil))},
+			wantCode: apierror.ErrConflict,
+			wantErr:  true,
 		},
 	}
 	for _, tt := range tests {
@@ -1100,8 +1141,14 @@ func TestEc2_DeleteSecurityGroup(t *testing.T) {
 				DefaultSubnets:  tt.fields.DefaultSubnets,
 				org:             tt.fields.org,
 			}
-			if err := e.DeleteSecurityGroup(tt.args.ctx, tt.args.id); (err != nil) != tt.wantErr {
+			err := e.DeleteSecurityGroup(tt.args.ctx, tt.args.id)
+			if (err != nil) != tt.wantErr {
 				t.Errorf("Ec2.DeleteSecurityGroup() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if aerr, ok := err.(apierror.Error); ok && tt.wantCode != "" && aerr.Code != tt.wantCode {
+				t.Errorf("Ec2.DeleteSecurityGroup() error code = %s, want %s", aerr.Code, tt.wantCode)
 			}
 		})
 	}
@@ -1697,3 +1744,138 @@ func TestEc2_RevokeSecurityGroup(t *testing.T) {
 		})
 	}
 }
+
+func TestEc2_ListSecurityGroupDetails(t *testing.T) {
+	type fields struct {
+		Service ec2iface.EC2API
+	}
+	type args struct {
+		ctx     context.Context
+		org     string
+		filters []*ec2.Filter
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    []*ec2.SecurityGroup
+		wantErr bool
+	}{
+		{
+			name:   "success case",
+			args:   args{ctx: context.TODO(), org: "testorg"},
+			fields: fields{Service: newmockEC2Client(t, nil)},
+			want:   securityGroups,
+		},
+		{
+			name:    "aws error",
+			args:    args{ctx: context.TODO()},
+			fields:  fields{Service: newmockEC2Client(t, awserr.New("Bad Request", "boom.", nil))},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Ec2{
+				Service: tt.fields.Service,
+			}
+			got, err := e.ListSecurityGroupDetails(tt.args.ctx, tt.args.org, tt.args.filters...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Ec2.ListSecurityGroupDetails() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Ec2.ListSecurityGroupDetails() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEc2_UpdateSecurityGroupRuleDescriptions(t *testing.T) {
+	permissions := []*ec2.IpPermission{
+		{
+			IpProtocol: aws.String("tcp"),
+			FromPort:   aws.Int64(22),
+			ToPort:     aws.Int64(22),
+			IpRanges: []*ec2.IpRange{
+				{
+					CidrIp:      aws.String("192.168.0.0/24"),
+					Description: aws.String("ssh"),
+				},
+			},
+		},
+	}
+
+	type fields struct {
+		Service ec2iface.EC2API
+	}
+	type args struct {
+		ctx         context.Context
+		direction   string
+		sg          string
+		permissions []*ec2.IpPermission
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		wantErr bool
+	}{
+		{
+			name:    "empty direction",
+			fields:  fields{Service: newmockEC2Client(t, nil)},
+			args:    args{ctx: context.TODO(), sg: "sg-0000000001", permissions: permissions},
+			wantErr: true,
+		},
+		{
+			name:    "empty sg",
+			fields:  fields{Service: newmockEC2Client(t, nil)},
+			args:    args{ctx: context.TODO(), direction: "inbound", permissions: permissions},
+			wantErr: true,
+		},
+		{
+			name:    "empty permissions",
+			fields:  fields{Service: newmockEC2Client(t, nil)},
+			args:    args{ctx: context.TODO(), direction: "inbound", sg: "sg-0000000001"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid direction",
+			fields:  fields{Service: newmockEC2Client(t, nil)},
+			args:    args{ctx: context.TODO(), direction: "sideways", sg: "sg-0000000001", permissions: permissions},
+			wantErr: true,
+		},
+		{
+			name:   "inbound",
+			fields: fields{Service: newmockEC2Client(t, nil)},
+			args:   args{ctx: context.TODO(), direction: "inbound", sg: "sg-0000000001", permissions: permissions},
+		},
+		{
+			name:   "outbound",
+			fields: fields{Service: newmockEC2Client(t, nil)},
+			args:   args{ctx: context.TODO(), direction: "outbound", sg: "sg-0000000001", permissions: permissions},
+		},
+		{
+			name:    "missing sg",
+			fields:  fields{Service: newmockEC2Client(t, nil)},
+			args:    args{ctx: context.TODO(), direction: "inbound", sg: "sg-missing", permissions: permissions},
+			wantErr: true,
+		},
+		{
+			name:    "aws error",
+			fields:  fields{Service: newmockEC2Client(t, awserr.New("Bad Request", "boom.", nil))},
+			args:    args{ctx: context.TODO(), direction: "outbound", sg: "sg-0000000001", permissions: permissions},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Ec2{
+				Service: tt.fields.Service,
+			}
+			if err := e.UpdateSecurityGroupRuleDescriptions(tt.args.ctx, tt.args.direction, tt.args.sg, tt.args.permissions); (err != nil) != tt.wantErr {
+				t.Errorf("Ec2.UpdateSecurityGroupRuleDescriptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
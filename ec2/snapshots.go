@@ -81,3 +81,39 @@ func (e *Ec2) DeleteSnapshot(ctx context.Context, input *ec2.DeleteSnapshotInput
 	}
 	return nil
 }
+
+// ListSnapshotDetails returns the full details of all snapshots owned by the account matching the given filters, following pagination
+func (e *Ec2) ListSnapshotDetails(ctx context.Context, org string, filters ...*ec2.Filter) ([]*ec2.Snapshot, error) {
+	log.Infof("listing snapshot details (org: '%s')", org)
+
+	if org != "" {
+		filters = append(filters, inOrg(org))
+	}
+
+	input := ec2.DescribeSnapshotsInput{
+		Filters:    filters,
+		MaxResults: aws.Int64(1000),
+		OwnerIds:   aws.StringSlice([]string{"self"}),
+	}
+
+	snapshots := []*ec2.Snapshot{}
+	for {
+		out, err := e.Service.DescribeSnapshotsWithContext(ctx, &input)
+		if err != nil {
+			return nil, common.ErrCode("listing snapshot details", err)
+		}
+
+		log.Debugf("got describe snapshots output with %d snapshots", len(out.Snapshots))
+
+		snapshots = append(snapshots, out.Snapshots...)
+
+		if out.NextToken != nil {
+			input.NextToken = out.NextToken
+			continue
+		}
+
+		break
+	}
+
+	return snapshots, nil
+}
@@ -186,3 +186,49 @@ func TestEc2_ListSnapshots(t *testing.T) {
 		})
 	}
 }
+
+func TestEc2_ListSnapshotDetails(t *testing.T) {
+	type fields struct {
+		Service ec2iface.EC2API
+	}
+	type args struct {
+		ctx     context.Context
+		org     string
+		filters []*ec2.Filter
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    []*ec2.Snapshot
+		wantErr bool
+	}{
+		{
+			name:   "success case",
+			args:   args{ctx: context.TODO(), org: "testorg"},
+			fields: fields{Service: newmockEC2Client(t, nil)},
+			want:   []*ec2.Snapshot{{SnapshotId: aws.String("snap-123")}, {SnapshotId: aws.String("snap-456")}},
+		},
+		{
+			name:    "aws error",
+			args:    args{ctx: context.TODO()},
+			fields:  fields{Service: newmockEC2Client(t, awserr.New("Bad Request", "boom.", nil))},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Ec2{
+				Service: tt.fields.Service,
+			}
+			got, err := e.ListSnapshotDetails(tt.args.ctx, tt.args.org, tt.args.filters...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Ec2.ListSnapshotDetails() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Ec2.ListSnapshotDetails() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
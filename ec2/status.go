@@ -0,0 +1,126 @@
+package ec2
+
+import (
+	"context"
+	"time"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/ec2-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	log "github.com/sirupsen/logrus"
+)
+
+// GetInstanceStatus gets the status checks and scheduled events of an instance, including stopped instances
+func (e *Ec2) GetInstanceStatus(ctx context.Context, id string) (*ec2.InstanceStatus, error) {
+	if id == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("getting status of instance %s", id)
+
+	out, err := e.Service.DescribeInstanceStatusWithContext(ctx, &ec2.DescribeInstanceStatusInput{
+		InstanceIds:         aws.StringSlice([]string{id}),
+		IncludeAllInstances: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, common.ErrCode("getting instance status", err)
+	}
+
+	if len(out.InstanceStatuses) == 0 {
+		return nil, apierror.New(apierror.ErrNotFound, "instance status not found", nil)
+	}
+
+	if len(out.InstanceStatuses) > 1 {
+		return nil, apierror.New(apierror.ErrBadRequest, "unexpected instance status count returned", nil)
+	}
+
+	return out.InstanceStatuses[0], nil
+}
+
+// ListInstanceStatuses returns the status of all instances matching the given filters, including stopped instances,
+// following pagination
+func (e *Ec2) ListInstanceStatuses(ctx context.Context, filters ...*ec2.Filter) ([]*ec2.InstanceStatus, error) {
+	log.Infof("listing instance statuses")
+
+	input := ec2.DescribeInstanceStatusInput{
+		Filters:             filters,
+		IncludeAllInstances: aws.Bool(true),
+		MaxResults:          aws.Int64(1000),
+	}
+
+	statuses := []*ec2.InstanceStatus{}
+	for {
+		out, err := e.Service.DescribeInstanceStatusWithContext(ctx, &input)
+		if err != nil {
+			return nil, common.ErrCode("listing instance statuses", err)
+		}
+
+		log.Debugf("got describe instance status output with %d statuses", len(out.InstanceStatuses))
+
+		statuses = append(statuses, out.InstanceStatuses...)
+
+		if out.NextToken != nil {
+			input.NextToken = out.NextToken
+			continue
+		}
+
+		break
+	}
+
+	return statuses, nil
+}
+
+// ListVolumeStatuses returns the status checks of the given volumes, following pagination
+func (e *Ec2) ListVolumeStatuses(ctx context.Context, ids ...string) ([]*ec2.VolumeStatusItem, error) {
+	if len(ids) == 0 {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("listing status of volumes %v", ids)
+
+	input := ec2.DescribeVolumeStatusInput{
+		VolumeIds: aws.StringSlice(ids),
+	}
+
+	statuses := []*ec2.VolumeStatusItem{}
+	for {
+		out, err := e.Service.DescribeVolumeStatusWithContext(ctx, &input)
+		if err != nil {
+			return nil, common.ErrCode("listing volume statuses", err)
+		}
+
+		log.Debugf("got describe volume status output with %d statuses", len(out.VolumeStatuses))
+
+		statuses = append(statuses, out.VolumeStatuses...)
+
+		if out.NextToken != nil {
+			input.NextToken = out.NextToken
+			continue
+		}
+
+		break
+	}
+
+	return statuses, nil
+}
+
+// ModifyInstanceEventStartTime reschedules a scheduled event of an instance to start after the given time
+func (e *Ec2) ModifyInstanceEventStartTime(ctx context.Context, id, eventId string, notBefore time.Time) (*ec2.InstanceStatusEvent, error) {
+	if id == "" || eventId == "" || notBefore.IsZero() {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("rescheduling event %s of instance %s to %s", eventId, id, notBefore.UTC().Format(time.RFC3339))
+
+	out, err := e.Service.ModifyInstanceEventStartTimeWithContext(ctx, &ec2.ModifyInstanceEventStartTimeInput{
+		InstanceId:      aws.String(id),
+		InstanceEventId: aws.String(eventId),
+		NotBefore:       aws.Time(notBefore),
+	})
+	if err != nil {
+		return nil, common.ErrCode("failed to reschedule instance event", err)
+	}
+
+	return out.Event, nil
+}
@@ -0,0 +1,302 @@
+package ec2
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+)
+
+var eventNotBefore = time.Date(2023, 2, 1, 6, 0, 0, 0, time.UTC)
+
+var instanceStatuses = []*ec2.InstanceStatus{
+	{
+		InstanceId:     aws.String("i-0000000001"),
+		InstanceState:  &ec2.InstanceState{Name: aws.String("running")},
+		InstanceStatus: &ec2.InstanceStatusSummary{Status: aws.String("ok")},
+		SystemStatus:   &ec2.InstanceStatusSummary{Status: aws.String("ok")},
+		Events: []*ec2.InstanceStatusEvent{
+			{
+				InstanceEventId: aws.String("instance-event-0000000001"),
+				Code:            aws.String("system-reboot"),
+				NotBefore:       &eventNotBefore,
+			},
+		},
+	},
+	{
+		InstanceId:     aws.String("i-0000000002"),
+		InstanceState:  &ec2.InstanceState{Name: aws.String("stopped")},
+		InstanceStatus: &ec2.InstanceStatusSummary{Status: aws.String("not-applicable")},
+		SystemStatus:   &ec2.InstanceStatusSummary{Status: aws.String("not-applicable")},
+	},
+}
+
+func (m *mockEC2Client) DescribeInstanceStatusWithContext(ctx context.Context, input *ec2.DescribeInstanceStatusInput, opts ...request.Option) (*ec2.DescribeInstanceStatusOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	if input.NextToken == nil && len(input.InstanceIds) == 0 {
+		return &ec2.DescribeInstanceStatusOutput{
+			InstanceStatuses: instanceStatuses[:1],
+			NextToken:        aws.String("next"),
+		}, nil
+	}
+
+	out := []*ec2.InstanceStatus{}
+	for _, s := range instanceStatuses {
+		if len(input.InstanceIds) > 0 && aws.StringValue(input.InstanceIds[0]) != aws.StringValue(s.InstanceId) {
+			continue
+		}
+
+		if len(input.InstanceIds) == 0 && s == instanceStatuses[0] {
+			continue
+		}
+
+		out = append(out, s)
+	}
+
+	return &ec2.DescribeInstanceStatusOutput{InstanceStatuses: out}, nil
+}
+
+func (m *mockEC2Client) DescribeVolumeStatusWithContext(ctx context.Context, input *ec2.DescribeVolumeStatusInput, opts ...request.Option) (*ec2.DescribeVolumeStatusOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	out := []*ec2.VolumeStatusItem{}
+	for _, id := range input.VolumeIds {
+		out = append(out, &ec2.VolumeStatusItem{
+			VolumeId:     id,
+			VolumeStatus: &ec2.VolumeStatusInfo{Status: aws.String("ok")},
+		})
+	}
+
+	return &ec2.DescribeVolumeStatusOutput{VolumeStatuses: out}, nil
+}
+
+func (m *mockEC2Client) ModifyInstanceEventStartTimeWithContext(ctx context.Context, input *ec2.ModifyInstanceEventStartTimeInput, opts ...request.Option) (*ec2.ModifyInstanceEventStartTimeOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	return &ec2.ModifyInstanceEventStartTimeOutput{
+		Event: &ec2.InstanceStatusEvent{
+			InstanceEventId: input.InstanceEventId,
+			Code:            aws.String("system-reboot"),
+			NotBefore:       input.NotBefore,
+		},
+	}, nil
+}
+
+func TestEc2_GetInstanceStatus(t *testing.T) {
+	type fields struct {
+		Service ec2iface.EC2API
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		id      string
+		want    *ec2.InstanceStatus
+		wantErr bool
+	}{
+		{
+			name:   "success case",
+			fields: fields{Service: newmockEC2Client(t, nil)},
+			id:     "i-0000000002",
+			want:   instanceStatuses[1],
+		},
+		{
+			name:    "not found",
+			fields:  fields{Service: newmockEC2Client(t, nil)},
+			id:      "i-0000000009",
+			wantErr: true,
+		},
+		{
+			name:    "missing id",
+			fields:  fields{Service: newmockEC2Client(t, nil)},
+			wantErr: true,
+		},
+		{
+			name:    "aws error",
+			fields:  fields{Service: newmockEC2Client(t, awserr.New("Bad Request", "boom.", nil))},
+			id:      "i-0000000001",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Ec2{
+				Service: tt.fields.Service,
+			}
+			got, err := e.GetInstanceStatus(context.TODO(), tt.id)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Ec2.GetInstanceStatus() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Ec2.GetInstanceStatus() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEc2_ListInstanceStatuses(t *testing.T) {
+	type fields struct {
+		Service ec2iface.EC2API
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		want    []*ec2.InstanceStatus
+		wantErr bool
+	}{
+		{
+			name:   "success case with pagination",
+			fields: fields{Service: newmockEC2Client(t, nil)},
+			want:   instanceStatuses,
+		},
+		{
+			name:    "aws error",
+			fields:  fields{Service: newmockEC2Client(t, awserr.New("Bad Request", "boom.", nil))},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Ec2{
+				Service: tt.fields.Service,
+			}
+			got, err := e.ListInstanceStatuses(context.TODO())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Ec2.ListInstanceStatuses() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Ec2.ListInstanceStatuses() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEc2_ListVolumeStatuses(t *testing.T) {
+	type fields struct {
+		Service ec2iface.EC2API
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		ids     []string
+		want    []*ec2.VolumeStatusItem
+		wantErr bool
+	}{
+		{
+			name:   "success case",
+			fields: fields{Service: newmockEC2Client(t, nil)},
+			ids:    []string{"vol-0000000001"},
+			want: []*ec2.VolumeStatusItem{
+				{
+					VolumeId:     aws.String("vol-0000000001"),
+					VolumeStatus: &ec2.VolumeStatusInfo{Status: aws.String("ok")},
+				},
+			},
+		},
+		{
+			name:    "missing ids",
+			fields:  fields{Service: newmockEC2Client(t, nil)},
+			wantErr: true,
+		},
+		{
+			name:    "aws error",
+			fields:  fields{Service: newmockEC2Client(t, awserr.New("Bad Request", "boom.", nil))},
+			ids:     []string{"vol-0000000001"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Ec2{
+				Service: tt.fields.Service,
+			}
+			got, err := e.ListVolumeStatuses(context.TODO(), tt.ids...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Ec2.ListVolumeStatuses() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Ec2.ListVolumeStatuses() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEc2_ModifyInstanceEventStartTime(t *testing.T) {
+	type fields struct {
+		Service ec2iface.EC2API
+	}
+	tests := []struct {
+		name      string
+		fields    fields
+		id        string
+		eventId   string
+		notBefore time.Time
+		want      *ec2.InstanceStatusEvent
+		wantErr   bool
+	}{
+		{
+			name:      "success case",
+			fields:    fields{Service: newmockEC2Client(t, nil)},
+			id:        "i-0000000001",
+			eventId:   "instance-event-0000000001",
+			notBefore: eventNotBefore,
+			want: &ec2.InstanceStatusEvent{
+				InstanceEventId: aws.String("instance-event-0000000001"),
+				Code:            aws.String("system-reboot"),
+				NotBefore:       &eventNotBefore,
+			},
+		},
+		{
+			name:    "missing time",
+			fields:  fields{Service: newmockEC2Client(t, nil)},
+			id:      "i-0000000001",
+			eventId: "instance-event-0000000001",
+			wantErr: true,
+		},
+		{
+			name:      "missing event id",
+			fields:    fields{Service: newmockEC2Client(t, nil)},
+			id:        "i-0000000001",
+			notBefore: eventNotBefore,
+			wantErr:   true,
+		},
+		{
+			name:      "aws error",
+			fields:    fields{Service: newmockEC2Client(t, awserr.New("Bad Request", "boom.", nil))},
+			id:        "i-0000000001",
+			eventId:   "instance-event-0000000001",
+			notBefore: eventNotBefore,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Ec2{
+				Service: tt.fields.Service,
+			}
+			got, err := e.ModifyInstanceEventStartTime(context.TODO(), tt.id, tt.eventId, tt.notBefore)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Ec2.ModifyInstanceEventStartTime() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Ec2.ModifyInstanceEventStartTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
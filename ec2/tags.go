@@ -46,3 +46,28 @@ func (e *Ec2) UpdateTags(ctx context.Context, input *ec2.CreateTagsInput) error
 
 	return nil
 }
+
+// DeleteTags removes the tags with the given keys from the resources, whatever their values
+func (e *Ec2) DeleteTags(ctx context.Context, keys []string, ids ...string) error {
+	if len(ids) == 0 || len(keys) == 0 {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("deleting tags %v from resources: %v", keys, ids)
+
+	tags := make([]*ec2.Tag, 0, len(keys))
+	for _, k := range keys {
+		tags = append(tags, &ec2.Tag{Key: aws.String(k)})
+	}
+
+	input := ec2.DeleteTagsInput{
+		Resources: aws.StringSlice(ids),
+		Tags:      tags,
+	}
+
+	if _, err := e.Service.DeleteTagsWithContext(ctx, &input); err != nil {
+		return common.ErrCode("deleting tags", err)
+	}
+
+	return nil
+}
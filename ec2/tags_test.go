@@ -29,6 +29,16 @@ func (m *mockEC2Client) CreateTagsWithContext(ctx context.Context, input *ec2.Cr
 	return &ec2.CreateTagsOutput{}, nil
 }
 
+func (m *mockEC2Client) DeleteTagsWithContext(ctx context.Context, input *ec2.DeleteTagsInput, opts ...request.Option) (*ec2.DeleteTagsOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	if !reflect.DeepEqual(input.Resources, aws.StringSlice(inpIds)) || !reflect.DeepEqual(input.Tags, []*ec2.Tag{{Key: aws.String("foo")}}) {
+		return nil, errors.New("input does not match")
+	}
+	return &ec2.DeleteTagsOutput{}, nil
+}
+
 func (m *mockEC2Client) DescribeVolumesWithContext(aws aws.Context, inp *ec2.DescribeVolumesInput, opt ...request.Option) (*ec2.DescribeVolumesOutput, error) {
 	if m.err != nil {
 		return nil, m.err
@@ -154,3 +164,55 @@ func TestEc2_UpdateTags(t *testing.T) {
 		})
 	}
 }
+
+func TestEc2_DeleteTags(t *testing.T) {
+	type fields struct {
+		Service ec2iface.EC2API
+	}
+	type args struct {
+		ctx  context.Context
+		keys []string
+		ids  []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		wantErr bool
+	}{
+		{
+			name:    "success case",
+			args:    args{ctx: context.TODO(), keys: []string{"foo"}, ids: inpIds},
+			fields:  fields{Service: newmockEC2Client(t, nil)},
+			wantErr: false,
+		},
+		{
+			name:    "aws error",
+			args:    args{ctx: context.TODO(), keys: []string{"foo"}, ids: inpIds},
+			fields:  fields{Service: newmockEC2Client(t, awserr.New("Bad Request", "boom.", nil))},
+			wantErr: true,
+		},
+		{
+			name:    "no keys",
+			fields:  fields{Service: newmockEC2Client(t, nil)},
+			args:    args{ctx: context.TODO(), keys: nil, ids: inpIds},
+			wantErr: true,
+		},
+		{
+			name:    "no ids",
+			fields:  fields{Service: newmockEC2Client(t, nil)},
+			args:    args{ctx: context.TODO(), keys: []string{"foo"}, ids: []string{}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Ec2{
+				Service: tt.fields.Service,
+			}
+			if err := e.DeleteTags(tt.args.ctx, tt.args.keys, tt.args.ids...); (err != nil) != tt.wantErr {
+				t.Errorf("Ec2.DeleteTags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
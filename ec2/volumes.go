@@ -51,20 +51,17 @@ func (e *Ec2) DeleteVolume(ctx context.Context, id string) error {
 	return nil
 }
 
-func (e *Ec2) ListVolumes(ctx context.Context, org string, per int64, next *string) ([]map[string]*string, *string, error) {
+// ListVolumesPage returns the full details of a page of volumes matching the given filters
+func (e *Ec2) ListVolumesPage(ctx context.Context, org string, per int64, next *string, filters ...*ec2.Filter) ([]*ec2.Volume, *string, error) {
 	log.Infof("listing volumes")
 
-	var filters []*ec2.Filter
 	if org != "" {
-		filters = []*ec2.Filter{inOrg(org)}
+		filters = append(filters, inOrg(org))
 	}
 
 	input := ec2.DescribeVolumesInput{
-		Filters: filters,
-	}
-
-	if next != nil {
-		input.NextToken = next
+		Filters:   filters,
+		NextToken: next,
 	}
 
 	if per != 0 {
@@ -78,14 +75,42 @@ func (e *Ec2) ListVolumes(ctx context.Context, org string, per int64, next *stri
 
 	log.Debugf("returning list of %d volumes", len(out.Volumes))
 
-	list := make([]map[string]*string, len(out.Volumes))
-	for i, v := range out.Volumes {
-		list[i] = map[string]*string{
-			"id": v.VolumeId,
+	return out.Volumes, out.NextToken, nil
+}
+
+// ListVolumeDetails returns the full details of all volumes matching the given filters, following pagination
+func (e *Ec2) ListVolumeDetails(ctx context.Context, org string, filters ...*ec2.Filter) ([]*ec2.Volume, error) {
+	log.Infof("listing volume details (org: '%s')", org)
+
+	if org != "" {
+		filters = append(filters, inOrg(org))
+	}
+
+	input := ec2.DescribeVolumesInput{
+		Filters:    filters,
+		MaxResults: aws.Int64(500),
+	}
+
+	volumes := []*ec2.Volume{}
+	for {
+		out, err := e.Service.DescribeVolumesWithContext(ctx, &input)
+		if err != nil {
+			return nil, common.ErrCode("listing volume details", err)
 		}
+
+		log.Debugf("got describe volumes output %+v", out)
+
+		volumes = append(volumes, out.Volumes...)
+
+		if out.NextToken != nil {
+			input.NextToken = out.NextToken
+			continue
+		}
+
+		break
 	}
 
-	return list, out.NextToken, nil
+	return volumes, nil
 }
 
 func (e *Ec2) GetVolume(ctx context.Context, ids ...string) ([]*ec2.Volume, error) {
@@ -378,3 +378,60 @@ func TestEc2_AttachVolume(t *testing.T) {
 		})
 	}
 }
+
+func TestEc2_ListVolumeDetails(t *testing.T) {
+	type fields struct {
+		Service ec2iface.EC2API
+	}
+	type args struct {
+		ctx     context.Context
+		org     string
+		filters []*ec2.Filter
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    []*ec2.Volume
+		wantErr bool
+	}{
+		{
+			name:   "success case",
+			args:   args{ctx: context.TODO(), org: "testorg"},
+			fields: fields{Service: newmockEC2Client(t, nil)},
+			want:   []*ec2.Volume{},
+		},
+		{
+			name: "success case with filters",
+			args: args{
+				ctx: context.TODO(),
+				filters: []*ec2.Filter{
+					{Name: aws.String("volume-type"), Values: aws.StringSlice([]string{"gp2"})},
+				},
+			},
+			fields: fields{Service: newmockEC2Client(t, nil)},
+			want:   []*ec2.Volume{},
+		},
+		{
+			name:    "aws error",
+			args:    args{ctx: context.TODO()},
+			fields:  fields{Service: newmockEC2Client(t, awserr.New("Bad Request", "boom.", nil))},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Ec2{
+				Service: tt.fields.Service,
+			}
+			got, err := e.ListVolumeDetails(tt.args.ctx, tt.args.org, tt.args.filters...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Ec2.ListVolumeDetails() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Ec2.ListVolumeDetails() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
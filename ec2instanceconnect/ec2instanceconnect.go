@@ -0,0 +1,38 @@
+package ec2instanceconnect
+
+import (
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2instanceconnect"
+	"github.com/aws/aws-sdk-go/service/ec2instanceconnect/ec2instanceconnectiface"
+	log "github.com/sirupsen/logrus"
+)
+
+// EC2InstanceConnect is a wrapper around the aws EC2 Instance Connect service
+type EC2InstanceConnect struct {
+	session *session.Session
+	Service ec2instanceconnectiface.EC2InstanceConnectAPI
+}
+
+type EC2InstanceConnectOption func(*EC2InstanceConnect)
+
+// New creates a new EC2InstanceConnect
+func New(opts ...EC2InstanceConnectOption) *EC2InstanceConnect {
+	e := EC2InstanceConnect{}
+
+	for _, opt := range opts {
+		opt(&e)
+	}
+
+	if e.session != nil {
+		e.Service = ec2instanceconnect.New(e.session)
+	}
+
+	return &e
+}
+
+func WithSession(sess *session.Session) EC2InstanceConnectOption {
+	return func(e *EC2InstanceConnect) {
+		log.Debug("using aws session")
+		e.session = sess
+	}
+}
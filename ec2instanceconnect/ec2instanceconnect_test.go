@@ -0,0 +1,30 @@
+package ec2instanceconnect
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/ec2instanceconnect/ec2instanceconnectiface"
+)
+
+// mockEC2InstanceConnectClient is a fake ec2 instance connect client
+type mockEC2InstanceConnectClient struct {
+	ec2instanceconnectiface.EC2InstanceConnectAPI
+	t   *testing.T
+	err error
+}
+
+func newMockEC2InstanceConnectClient(t *testing.T, err error) ec2instanceconnectiface.EC2InstanceConnectAPI {
+	return &mockEC2InstanceConnectClient{
+		t:   t,
+		err: err,
+	}
+}
+
+func TestNewSession(t *testing.T) {
+	e := New()
+	to := reflect.TypeOf(e).String()
+	if to != "*ec2instanceconnect.EC2InstanceConnect" {
+		t.Errorf("expected type to be '*ec2instanceconnect.EC2InstanceConnect', got %s", to)
+	}
+}
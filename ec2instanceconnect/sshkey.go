@@ -0,0 +1,36 @@
+package ec2instanceconnect
+
+import (
+	"context"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/ec2-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2instanceconnect"
+	log "github.com/sirupsen/logrus"
+)
+
+// SendSSHPublicKey pushes an ssh public key to an instance for the os user.  The key is only valid for 60
+// seconds, long enough to open a connection.
+func (e *EC2InstanceConnect) SendSSHPublicKey(ctx context.Context, instanceId, osUser, publicKey string) (string, error) {
+	if instanceId == "" || osUser == "" || publicKey == "" {
+		return "", apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("sending ssh public key for %s to instance %s", osUser, instanceId)
+
+	out, err := e.Service.SendSSHPublicKeyWithContext(ctx, &ec2instanceconnect.SendSSHPublicKeyInput{
+		InstanceId:     aws.String(instanceId),
+		InstanceOSUser: aws.String(osUser),
+		SSHPublicKey:   aws.String(publicKey),
+	})
+	if err != nil {
+		return "", common.ErrCode("failed to send ssh public key", err)
+	}
+
+	if !aws.BoolValue(out.Success) {
+		return "", apierror.New(apierror.ErrBadRequest, "ssh public key wasn't accepted", nil)
+	}
+
+	return aws.StringValue(out.RequestId), nil
+}
@@ -0,0 +1,75 @@
+package ec2instanceconnect
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2instanceconnect"
+	"github.com/aws/aws-sdk-go/service/ec2instanceconnect/ec2instanceconnectiface"
+)
+
+func (m *mockEC2InstanceConnectClient) SendSSHPublicKeyWithContext(ctx context.Context, input *ec2instanceconnect.SendSSHPublicKeyInput, opts ...request.Option) (*ec2instanceconnect.SendSSHPublicKeyOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	return &ec2instanceconnect.SendSSHPublicKeyOutput{
+		RequestId: aws.String("req-123"),
+		Success:   aws.Bool(aws.StringValue(input.InstanceOSUser) != "nobody"),
+	}, nil
+}
+
+func TestEC2InstanceConnect_SendSSHPublicKey(t *testing.T) {
+	tests := []struct {
+		name      string
+		service   ec2instanceconnectiface.EC2InstanceConnectAPI
+		osUser    string
+		publicKey string
+		want      string
+		wantErr   bool
+	}{
+		{
+			name:      "success case",
+			service:   newMockEC2InstanceConnectClient(t, nil),
+			osUser:    "ec2-user",
+			publicKey: "ssh-ed25519 AAAA",
+			want:      "req-123",
+		},
+		{
+			name:      "not accepted",
+			service:   newMockEC2InstanceConnectClient(t, nil),
+			osUser:    "nobody",
+			publicKey: "ssh-ed25519 AAAA",
+			wantErr:   true,
+		},
+		{
+			name:    "missing public key",
+			service: newMockEC2InstanceConnectClient(t, nil),
+			osUser:  "ec2-user",
+			wantErr: true,
+		},
+		{
+			name:      "aws error",
+			service:   newMockEC2InstanceConnectClient(t, awserr.New("Bad Request", "boom.", nil)),
+			osUser:    "ec2-user",
+			publicKey: "ssh-ed25519 AAAA",
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &EC2InstanceConnect{Service: tt.service}
+			got, err := e.SendSSHPublicKey(context.TODO(), "i-123", tt.osUser, tt.publicKey)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("EC2InstanceConnect.SendSSHPublicKey() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("EC2InstanceConnect.SendSSHPublicKey() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
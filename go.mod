@@ -15,6 +15,7 @@ require (
 	github.com/prometheus/client_golang v1.20.5
 	github.com/sirupsen/logrus v1.9.3
 	golang.org/x/crypto v0.31.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -138,3 +138,48 @@ func (i *Iam) DeleteInstanceProfile(ctx context.Context, input *iam.DeleteInstan
 
 	return nil
 }
+
+// ListInstanceProfiles lists all of the instance profiles in the account, following pagination
+func (i *Iam) ListInstanceProfiles(ctx context.Context) ([]*iam.InstanceProfile, error) {
+	log.Info("listing instanceprofiles")
+
+	input := iam.ListInstanceProfilesInput{}
+
+	profiles := []*iam.InstanceProfile{}
+	for {
+		out, err := i.Service.ListInstanceProfilesWithContext(ctx, &input)
+		if err != nil {
+			return nil, common.ErrCode("failed to list instanceprofiles", err)
+		}
+		log.Debugf("got output list of instanceprofiles with %d instanceprofiles", len(out.InstanceProfiles))
+
+		profiles = append(profiles, out.InstanceProfiles...)
+
+		if aws.BoolValue(out.IsTruncated) {
+			input.Marker = out.Marker
+			continue
+		}
+
+		break
+	}
+
+	return profiles, nil
+}
+
+// ListInstanceProfileTags lists the tags for an instance profile
+func (i *Iam) ListInstanceProfileTags(ctx context.Context, name string) ([]*iam.Tag, error) {
+	if name == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+	log.Infof("listing tags for instanceprofile %s", name)
+
+	out, err := i.Service.ListInstanceProfileTagsWithContext(ctx, &iam.ListInstanceProfileTagsInput{
+		InstanceProfileName: aws.String(name),
+	})
+	if err != nil {
+		return nil, common.ErrCode("failed to list instanceprofile tags", err)
+	}
+	log.Debugf("got output list of instanceprofile tags: %+v", out)
+
+	return out.Tags, nil
+}
@@ -68,6 +68,33 @@ func (m *mockIAMClient) DeleteInstanceProfileWithContext(ctx aws.Context, input
 	return &iam.DeleteInstanceProfileOutput{}, nil
 }
 
+func (m *mockIAMClient) ListInstanceProfilesWithContext(ctx aws.Context, input *iam.ListInstanceProfilesInput, opts ...request.Option) (*iam.ListInstanceProfilesOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	// return the first page truncated, then the rest
+	if input.Marker == nil {
+		return &iam.ListInstanceProfilesOutput{
+			InstanceProfiles: []*iam.InstanceProfile{{InstanceProfileName: aws.String("profile1")}},
+			IsTruncated:      aws.Bool(true),
+			Marker:           aws.String("next"),
+		}, nil
+	}
+
+	return &iam.ListInstanceProfilesOutput{
+		InstanceProfiles: []*iam.InstanceProfile{{InstanceProfileName: aws.String("profile2")}},
+		IsTruncated:      aws.Bool(false),
+	}, nil
+}
+
+func (m *mockIAMClient) ListInstanceProfileTagsWithContext(ctx aws.Context, input *iam.ListInstanceProfileTagsInput, opts ...request.Option) (*iam.ListInstanceProfileTagsOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &iam.ListInstanceProfileTagsOutput{Tags: []*iam.Tag{{Key: aws.String("foo"), Value: aws.String("bar")}}}, nil
+}
+
 func TestIam_GetInstanceProfile(t *testing.T) {
 	type fields struct {
 		Service iamiface.IAMAPI
@@ -472,3 +499,101 @@ func TestIam_DeleteInstanceProfile(t *testing.T) {
 		})
 	}
 }
+
+func TestIam_ListInstanceProfiles(t *testing.T) {
+	type fields struct {
+		Service iamiface.IAMAPI
+	}
+	type args struct {
+		ctx context.Context
+	}
+	tests := []struct {
+		name    string
+		args    args
+		fields  fields
+		want    []*iam.InstanceProfile
+		wantErr bool
+	}{
+		{
+			name:   "success case",
+			args:   args{ctx: context.TODO()},
+			fields: fields{Service: newmockIAMClient(t, nil)},
+			want: []*iam.InstanceProfile{
+				{InstanceProfileName: aws.String("profile1")},
+				{InstanceProfileName: aws.String("profile2")},
+			},
+		},
+		{
+			name:    "aws error",
+			args:    args{ctx: context.TODO()},
+			fields:  fields{Service: newmockIAMClient(t, awserr.New("Bad Request", "boom.", nil))},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := &Iam{
+				Service: tt.fields.Service,
+			}
+			got, err := i.ListInstanceProfiles(tt.args.ctx)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Iam.ListInstanceProfiles() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Iam.ListInstanceProfiles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIam_ListInstanceProfileTags(t *testing.T) {
+	type fields struct {
+		Service iamiface.IAMAPI
+	}
+	type args struct {
+		ctx  context.Context
+		name string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		fields  fields
+		want    []*iam.Tag
+		wantErr bool
+	}{
+		{
+			name:   "success case",
+			args:   args{ctx: context.TODO(), name: "profile1"},
+			fields: fields{Service: newmockIAMClient(t, nil)},
+			want:   []*iam.Tag{{Key: aws.String("foo"), Value: aws.String("bar")}},
+		},
+		{
+			name:    "empty name",
+			args:    args{ctx: context.TODO()},
+			fields:  fields{Service: newmockIAMClient(t, nil)},
+			wantErr: true,
+		},
+		{
+			name:    "aws error",
+			args:    args{ctx: context.TODO(), name: "profile1"},
+			fields:  fields{Service: newmockIAMClient(t, awserr.New("Bad Request", "boom.", nil))},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := &Iam{
+				Service: tt.fields.Service,
+			}
+			got, err := i.ListInstanceProfileTags(tt.args.ctx, tt.args.name)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Iam.ListInstanceProfileTags() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Iam.ListInstanceProfileTags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package pricing
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// HoursPerMonth is the number of hours in a month used for monthly estimates
+const HoursPerMonth = 730
+
+// bundledCatalog is the price catalog used when no catalog file is configured
+//
+//go:embed catalog.json
+var bundledCatalog []byte
+
+// Catalog is a list of on-demand prices by region
+type Catalog struct {
+	Currency string                   `json:"currency"`
+	Updated  string                   `json:"updated"`
+	Regions  map[string]*RegionPrices `json:"regions"`
+}
+
+// RegionPrices are the prices of a region
+type RegionPrices struct {
+	// Instances are the on-demand hourly rates of linux instances by instance type
+	Instances map[string]float64 `json:"instances"`
+	// Volumes are the prices of EBS volumes by volume type
+	Volumes map[string]*VolumePrices `json:"volumes"`
+	// SnapshotGBMonth is the monthly price of a GB of snapshot storage
+	SnapshotGBMonth float64 `json:"snapshot_gb_month"`
+}
+
+// VolumePrices are the monthly prices of an EBS volume type
+type VolumePrices struct {
+	// GBMonth is the price of a provisioned GB
+	GBMonth float64 `json:"gb_month"`
+	// IopsMonth is the price of a provisioned iops above the included iops
+	IopsMonth float64 `json:"iops_month"`
+	// IncludedIops are provisioned at no additional cost
+	IncludedIops int64 `json:"included_iops"`
+	// IopsTiers price provisioned iops in tiers instead of IopsMonth
+	IopsTiers []*IopsTier `json:"iops_tiers"`
+	// ThroughputMonth is the price of a provisioned MiB/s above the included throughput
+	ThroughputMonth float64 `json:"throughput_month"`
+	// IncludedThroughput is provisioned at no additional cost
+	IncludedThroughput int64 `json:"included_throughput"`
+}
+
+// IopsTier is the price of the provisioned iops up to a limit, a tier without a limit prices all the remaining iops
+type IopsTier struct {
+	UpTo  int64   `json:"up_to"`
+	Price float64 `json:"price"`
+}
+
+// Bundled returns the price catalog bundled with the api
+func Bundled() (*Catalog, error) {
+	return Parse(bundledCatalog)
+}
+
+// Load reads a price catalog from a JSON file
+func Load(path string) (*Catalog, error) {
+	log.Infof("loading price catalog from %s", path)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read price catalog")
+	}
+
+	return Parse(data)
+}
+
+// Parse parses and validates a JSON price catalog
+func Parse(data []byte) (*Catalog, error) {
+	c := &Catalog{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, errors.Wrap(err, "failed to parse price catalog")
+	}
+
+	if len(c.Regions) == 0 {
+		return nil, errors.New("price catalog has no regions")
+	}
+
+	if c.Currency == "" {
+		c.Currency = "USD"
+	}
+
+	for name, r := range c.Regions {
+		if r == nil {
+			return nil, fmt.Errorf("price catalog region %s has no prices", name)
+		}
+
+		for t, p := range r.Instances {
+			if p < 0 {
+				return nil, fmt.Errorf("price catalog has a negative price for %s in %s", t, name)
+			}
+		}
+
+		for t, v := range r.Volumes {
+			if v == nil {
+				return nil, fmt.Errorf("price catalog volume type %s in %s has no prices", t, name)
+			}
+
+			for i, tier := range v.IopsTiers {
+				if tier.UpTo == 0 && i != len(v.IopsTiers)-1 {
+					return nil, fmt.Errorf("only the last iops tier of volume type %s in %s can be unlimited", t, name)
+				}
+
+				if i > 0 && tier.UpTo != 0 && tier.UpTo <= v.IopsTiers[i-1].UpTo {
+					return nil, fmt.Errorf("iops tiers of volume type %s in %s must be in increasing order", t, name)
+				}
+			}
+		}
+	}
+
+	log.Debugf("loaded price catalog updated %s with regions %v", c.Updated, c.RegionNames())
+
+	return c, nil
+}
+
+// RegionNames returns the sorted names of the regions in the catalog
+func (c *Catalog) RegionNames() []string {
+	names := make([]string, 0, len(c.Regions))
+	for r := range c.Regions {
+		names = append(names, r)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// InstanceHourly returns the on-demand hourly rate of an instance type in a region, and false if it isn't in the catalog
+func (c *Catalog) InstanceHourly(region, instanceType string) (float64, bool) {
+	r, ok := c.Regions[region]
+	if !ok {
+		return 0, false
+	}
+
+	p, ok := r.Instances[instanceType]
+	return p, ok
+}
+
+// VolumeMonthly returns the monthly price of an EBS volume in a region, and false if the volume type isn't in the catalog
+func (c *Catalog) VolumeMonthly(region, volumeType string, size, iops, throughput int64) (float64, bool) {
+	r, ok := c.Regions[region]
+	if !ok {
+		return 0, false
+	}
+
+	v, ok := r.Volumes[volumeType]
+	if !ok {
+		return 0, false
+	}
+
+	cost := float64(size) * v.GBMonth
+
+	if iops > v.IncludedIops {
+		if len(v.IopsTiers) > 0 {
+			cost += v.tieredIops(iops - v.IncludedIops)
+		} else {
+			cost += float64(iops-v.IncludedIops) * v.IopsMonth
+		}
+	}
+
+	if throughput > v.IncludedThroughput {
+		cost += float64(throughput-v.IncludedThroughput) * v.ThroughputMonth
+	}
+
+	return cost, true
+}
+
+// SnapshotMonthly returns the monthly price of storing a snapshot of the given size in a region
+func (c *Catalog) SnapshotMonthly(region string, size int64) (float64, bool) {
+	r, ok := c.Regions[region]
+	if !ok {
+		return 0, false
+	}
+
+	return float64(size) * r.SnapshotGBMonth, true
+}
+
+// tieredIops returns the price of provisioned iops priced in tiers
+func (v *VolumePrices) tieredIops(iops int64) float64 {
+	var cost float64
+	var lower int64
+	for _, t := range v.IopsTiers {
+		n := iops
+		if t.UpTo != 0 && n > t.UpTo {
+			n = t.UpTo
+		}
+
+		if n > lower {
+			cost += float64(n-lower) * t.Price
+		}
+
+		if t.UpTo == 0 || iops <= t.UpTo {
+			break
+		}
+		lower = t.UpTo
+	}
+
+	return cost
+}
@@ -0,0 +1,168 @@
+package pricing
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var testCatalog = []byte(`{
+  "currency": "USD",
+  "updated": "2023-01-01",
+  "regions": {
+    "us-east-1": {
+      "instances": { "t3.micro": 0.0104, "m5.large": 0.096 },
+      "volumes": {
+        "gp2": { "gb_month": 0.10 },
+        "gp3": { "gb_month": 0.08, "iops_month": 0.005, "included_iops": 3000, "throughput_month": 0.04, "included_throughput": 125 },
+        "io2": { "gb_month": 0.125, "iops_tiers": [{ "up_to": 32000, "price": 0.065 }, { "up_to": 64000, "price": 0.0455 }, { "price": 0.032 }] }
+      },
+      "snapshot_gb_month": 0.05
+    },
+    "us-west-2": {
+      "instances": { "t3.micro": 0.0104 }
+    }
+  }
+}`)
+
+func round(f float64) float64 {
+	return math.Round(f*10000) / 10000
+}
+
+func TestBundled(t *testing.T) {
+	c, err := Bundled()
+	if err != nil {
+		t.Fatalf("Bundled() error = %v", err)
+	}
+
+	if _, ok := c.InstanceHourly("us-east-1", "t3.micro"); !ok {
+		t.Error("expected t3.micro in the bundled catalog")
+	}
+
+	if _, ok := c.VolumeMonthly("us-east-1", "gp3", 100, 3000, 125); !ok {
+		t.Error("expected gp3 in the bundled catalog")
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{name: "valid", data: string(testCatalog)},
+		{name: "invalid json", data: `{"regions":`, wantErr: true},
+		{name: "no regions", data: `{"currency":"USD"}`, wantErr: true},
+		{name: "empty region", data: `{"regions":{"us-east-1":null}}`, wantErr: true},
+		{name: "negative price", data: `{"regions":{"us-east-1":{"instances":{"t3.micro":-1}}}}`, wantErr: true},
+		{name: "unlimited tier first", data: `{"regions":{"us-east-1":{"volumes":{"io2":{"iops_tiers":[{"price":1},{"up_to":10,"price":1}]}}}}}`, wantErr: true},
+		{name: "decreasing tiers", data: `{"regions":{"us-east-1":{"volumes":{"io2":{"iops_tiers":[{"up_to":10,"price":1},{"up_to":5,"price":1}]}}}}}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "catalog.json")
+	if err := os.WriteFile(path, testCatalog, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if want := []string{"us-east-1", "us-west-2"}; !reflect.DeepEqual(c.RegionNames(), want) {
+		t.Errorf("Load() regions = %v, want %v", c.RegionNames(), want)
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("expected error loading a missing catalog")
+	}
+}
+
+func TestCatalog_InstanceHourly(t *testing.T) {
+	c, err := Parse(testCatalog)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		region       string
+		instanceType string
+		want         float64
+		wantOk       bool
+	}{
+		{name: "priced", region: "us-east-1", instanceType: "m5.large", want: 0.096, wantOk: true},
+		{name: "unknown type", region: "us-east-1", instanceType: "x9.huge"},
+		{name: "unknown region", region: "eu-west-1", instanceType: "m5.large"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := c.InstanceHourly(tt.region, tt.instanceType)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("Catalog.InstanceHourly() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestCatalog_VolumeMonthly(t *testing.T) {
+	c, err := Parse(testCatalog)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		region     string
+		volumeType string
+		size       int64
+		iops       int64
+		throughput int64
+		want       float64
+		wantOk     bool
+	}{
+		{name: "gp2", region: "us-east-1", volumeType: "gp2", size: 100, want: 10, wantOk: true},
+		{name: "gp3 baseline", region: "us-east-1", volumeType: "gp3", size: 100, iops: 3000, throughput: 125, want: 8, wantOk: true},
+		{name: "gp3 provisioned", region: "us-east-1", volumeType: "gp3", size: 100, iops: 4000, throughput: 250, want: 18, wantOk: true},
+		{name: "io2 first tier", region: "us-east-1", volumeType: "io2", size: 100, iops: 1000, want: 77.5, wantOk: true},
+		{name: "io2 second tier", region: "us-east-1", volumeType: "io2", size: 100, iops: 40000, want: 2456.5, wantOk: true},
+		{name: "io2 last tier", region: "us-east-1", volumeType: "io2", size: 100, iops: 70000, want: 3740.5, wantOk: true},
+		{name: "unknown type", region: "us-east-1", volumeType: "gp9", size: 100},
+		{name: "region without volumes", region: "us-west-2", volumeType: "gp2", size: 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := c.VolumeMonthly(tt.region, tt.volumeType, tt.size, tt.iops, tt.throughput)
+			if round(got) != tt.want || ok != tt.wantOk {
+				t.Errorf("Catalog.VolumeMonthly() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestCatalog_SnapshotMonthly(t *testing.T) {
+	c, err := Parse(testCatalog)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, ok := c.SnapshotMonthly("us-east-1", 100); round(got) != 5 || !ok {
+		t.Errorf("Catalog.SnapshotMonthly() = %v, %v, want 5, true", got, ok)
+	}
+
+	if _, ok := c.SnapshotMonthly("eu-west-1", 100); ok {
+		t.Error("expected no snapshot price in an unknown region")
+	}
+}
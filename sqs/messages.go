@@ -0,0 +1,49 @@
+package sqs
+
+import (
+	"context"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/ec2-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	log "github.com/sirupsen/logrus"
+)
+
+// ReceiveMessages long polls a queue for up to max messages, waiting up to wait seconds for messages to arrive
+func (s *SQS) ReceiveMessages(ctx context.Context, queueUrl string, max, wait int64) ([]*sqs.Message, error) {
+	if queueUrl == "" || max < 1 {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Debugf("receiving up to %d messages from %s", max, queueUrl)
+
+	out, err := s.Service.ReceiveMessageWithContext(ctx, &sqs.ReceiveMessageInput{
+		QueueUrl:            aws.String(queueUrl),
+		MaxNumberOfMessages: aws.Int64(max),
+		WaitTimeSeconds:     aws.Int64(wait),
+	})
+	if err != nil {
+		return nil, common.ErrCode("failed to receive messages", err)
+	}
+
+	return out.Messages, nil
+}
+
+// DeleteMessage deletes a received message from a queue
+func (s *SQS) DeleteMessage(ctx context.Context, queueUrl, receiptHandle string) error {
+	if queueUrl == "" || receiptHandle == "" {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Debugf("deleting message from %s", queueUrl)
+
+	if _, err := s.Service.DeleteMessageWithContext(ctx, &sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(queueUrl),
+		ReceiptHandle: aws.String(receiptHandle),
+	}); err != nil {
+		return common.ErrCode("failed to delete message", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package sqs
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+)
+
+var testMessages = []*sqs.Message{
+	{MessageId: aws.String("m-1"), ReceiptHandle: aws.String("r-1"), Body: aws.String(`{"id":"1"}`)},
+	{MessageId: aws.String("m-2"), ReceiptHandle: aws.String("r-2"), Body: aws.String(`{"id":"2"}`)},
+}
+
+func (m *mockSQSClient) ReceiveMessageWithContext(ctx context.Context, input *sqs.ReceiveMessageInput, opts ...request.Option) (*sqs.ReceiveMessageOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	max := int(aws.Int64Value(input.MaxNumberOfMessages))
+	if max > len(testMessages) {
+		max = len(testMessages)
+	}
+
+	return &sqs.ReceiveMessageOutput{Messages: testMessages[:max]}, nil
+}
+
+func (m *mockSQSClient) DeleteMessageWithContext(ctx context.Context, input *sqs.DeleteMessageInput, opts ...request.Option) (*sqs.DeleteMessageOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	return &sqs.DeleteMessageOutput{}, nil
+}
+
+func TestSQS_ReceiveMessages(t *testing.T) {
+	tests := []struct {
+		name     string
+		service  sqsiface.SQSAPI
+		queueUrl string
+		max      int64
+		want     []*sqs.Message
+		wantErr  bool
+	}{
+		{
+			name:     "success case",
+			service:  newMockSQSClient(t, nil),
+			queueUrl: "https://sqs.us-east-1.amazonaws.com/012345678901/events",
+			max:      10,
+			want:     testMessages,
+		},
+		{
+			name:     "one message",
+			service:  newMockSQSClient(t, nil),
+			queueUrl: "https://sqs.us-east-1.amazonaws.com/012345678901/events",
+			max:      1,
+			want:     testMessages[:1],
+		},
+		{
+			name:    "missing queue url",
+			service: newMockSQSClient(t, nil),
+			max:     10,
+			wantErr: true,
+		},
+		{
+			name:     "aws error",
+			service:  newMockSQSClient(t, awserr.New("Bad Request", "boom.", nil)),
+			queueUrl: "https://sqs.us-east-1.amazonaws.com/012345678901/events",
+			max:      10,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &SQS{Service: tt.service}
+			got, err := s.ReceiveMessages(context.TODO(), tt.queueUrl, tt.max, 20)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SQS.ReceiveMessages() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SQS.ReceiveMessages() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSQS_DeleteMessage(t *testing.T) {
+	tests := []struct {
+		name          string
+		service       sqsiface.SQSAPI
+		queueUrl      string
+		receiptHandle string
+		wantErr       bool
+	}{
+		{
+			name:          "success case",
+			service:       newMockSQSClient(t, nil),
+			queueUrl:      "https://sqs.us-east-1.amazonaws.com/012345678901/events",
+			receiptHandle: "r-1",
+		},
+		{
+			name:     "missing receipt handle",
+			service:  newMockSQSClient(t, nil),
+			queueUrl: "https://sqs.us-east-1.amazonaws.com/012345678901/events",
+			wantErr:  true,
+		},
+		{
+			name:          "aws error",
+			service:       newMockSQSClient(t, awserr.New("Bad Request", "boom.", nil)),
+			queueUrl:      "https://sqs.us-east-1.amazonaws.com/012345678901/events",
+			receiptHandle: "r-1",
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &SQS{Service: tt.service}
+			if err := s.DeleteMessage(context.TODO(), tt.queueUrl, tt.receiptHandle); (err != nil) != tt.wantErr {
+				t.Errorf("SQS.DeleteMessage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package sqs
+
+import (
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	log "github.com/sirupsen/logrus"
+)
+
+// SQS is a wrapper around the aws SQS service
+type SQS struct {
+	session *session.Session
+	Service sqsiface.SQSAPI
+}
+
+type SQSOption func(*SQS)
+
+// New creates a new SQS
+func New(opts ...SQSOption) *SQS {
+	s := SQS{}
+
+	for _, opt := range opts {
+		opt(&s)
+	}
+
+	if s.session != nil {
+		s.Service = sqs.New(s.session)
+	}
+
+	return &s
+}
+
+func WithSession(sess *session.Session) SQSOption {
+	return func(s *SQS) {
+		log.Debug("using aws session")
+		s.session = sess
+	}
+}
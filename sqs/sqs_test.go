@@ -0,0 +1,30 @@
+package sqs
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+)
+
+// mockSQSClient is a fake sqs client
+type mockSQSClient struct {
+	sqsiface.SQSAPI
+	t   *testing.T
+	err error
+}
+
+func newMockSQSClient(t *testing.T, err error) sqsiface.SQSAPI {
+	return &mockSQSClient{
+		t:   t,
+		err: err,
+	}
+}
+
+func TestNewSession(t *testing.T) {
+	s := New()
+	to := reflect.TypeOf(s).String()
+	if to != "*sqs.SQS" {
+		t.Errorf("expected type to be '*sqs.SQS', got %s", to)
+	}
+}
@@ -0,0 +1,75 @@
+package ssm
+
+import (
+	"context"
+
+	"github.com/YaleSpinup/apierror"
+	"github.com/YaleSpinup/ec2-api/common"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	log "github.com/sirupsen/logrus"
+)
+
+// StartSession starts a session manager session on an instance and returns the session id, the stream url and
+// the token used to open the stream
+func (s *SSM) StartSession(ctx context.Context, input *ssm.StartSessionInput) (*ssm.StartSessionOutput, error) {
+	if input == nil || aws.StringValue(input.Target) == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("starting session on %s with doc name: '%s'", aws.StringValue(input.Target), aws.StringValue(input.DocumentName))
+
+	out, err := s.Service.StartSessionWithContext(ctx, input)
+	if err != nil {
+		return nil, common.ErrCode("failed to start session", err)
+	}
+
+	log.Debugf("started session %s on %s", aws.StringValue(out.SessionId), aws.StringValue(input.Target))
+
+	return out, nil
+}
+
+// GetSession gets an active session manager session by its id
+func (s *SSM) GetSession(ctx context.Context, sessionId string) (*ssm.Session, error) {
+	if sessionId == "" {
+		return nil, apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("getting session %s", sessionId)
+
+	out, err := s.Service.DescribeSessionsWithContext(ctx, &ssm.DescribeSessionsInput{
+		State: aws.String(ssm.SessionStateActive),
+		Filters: []*ssm.SessionFilter{
+			{
+				Key:   aws.String(ssm.SessionFilterKeySessionId),
+				Value: aws.String(sessionId),
+			},
+		},
+	})
+	if err != nil {
+		return nil, common.ErrCode("failed to describe session", err)
+	}
+
+	if len(out.Sessions) == 0 {
+		return nil, apierror.New(apierror.ErrNotFound, "session not found", nil)
+	}
+
+	return out.Sessions[0], nil
+}
+
+// TerminateSession terminates a session manager session
+func (s *SSM) TerminateSession(ctx context.Context, sessionId string) error {
+	if sessionId == "" {
+		return apierror.New(apierror.ErrBadRequest, "invalid input", nil)
+	}
+
+	log.Infof("terminating session %s", sessionId)
+
+	if _, err := s.Service.TerminateSessionWithContext(ctx, &ssm.TerminateSessionInput{
+		SessionId: aws.String(sessionId),
+	}); err != nil {
+		return common.ErrCode("failed to terminate session", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,188 @@
+package ssm
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+)
+
+func (m *mockSSMClient) StartSessionWithContext(ctx context.Context, inp *ssm.StartSessionInput, _ ...request.Option) (*ssm.StartSessionOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	return &ssm.StartSessionOutput{
+		SessionId:  aws.String("session-123"),
+		StreamUrl:  aws.String("wss://ssmmessages.us-east-1.amazonaws.com/v1/data-channel/session-123"),
+		TokenValue: aws.String("token"),
+	}, nil
+}
+
+func (m *mockSSMClient) TerminateSessionWithContext(ctx context.Context, inp *ssm.TerminateSessionInput, _ ...request.Option) (*ssm.TerminateSessionOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	return &ssm.TerminateSessionOutput{SessionId: inp.SessionId}, nil
+}
+
+func (m *mockSSMClient) DescribeSessionsWithContext(ctx context.Context, inp *ssm.DescribeSessionsInput, _ ...request.Option) (*ssm.DescribeSessionsOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	out := &ssm.DescribeSessionsOutput{Sessions: []*ssm.Session{}}
+	for _, f := range inp.Filters {
+		if aws.StringValue(f.Key) == ssm.SessionFilterKeySessionId && aws.StringValue(f.Value) == "session-123" {
+			out.Sessions = append(out.Sessions, &ssm.Session{
+				SessionId: aws.String("session-123"),
+				Target:    aws.String("i-123"),
+				Status:    aws.String(ssm.SessionStatusConnected),
+			})
+		}
+	}
+
+	return out, nil
+}
+
+func TestSSM_StartSession(t *testing.T) {
+	tests := []struct {
+		name    string
+		service ssmiface.SSMAPI
+		input   *ssm.StartSessionInput
+		want    *ssm.StartSessionOutput
+		wantErr bool
+	}{
+		{
+			name:    "success case",
+			service: newMockSSMClient(t, nil),
+			input:   &ssm.StartSessionInput{Target: aws.String("i-123")},
+			want: &ssm.StartSessionOutput{
+				SessionId:  aws.String("session-123"),
+				StreamUrl:  aws.String("wss://ssmmessages.us-east-1.amazonaws.com/v1/data-channel/session-123"),
+				TokenValue: aws.String("token"),
+			},
+		},
+		{
+			name:    "nil input",
+			service: newMockSSMClient(t, nil),
+			wantErr: true,
+		},
+		{
+			name:    "missing target",
+			service: newMockSSMClient(t, nil),
+			input:   &ssm.StartSessionInput{},
+			wantErr: true,
+		},
+		{
+			name:    "aws error",
+			service: newMockSSMClient(t, awserr.New("Bad Request", "boom.", nil)),
+			input:   &ssm.StartSessionInput{Target: aws.String("i-123")},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &SSM{Service: tt.service}
+			got, err := s.StartSession(context.TODO(), tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SSM.StartSession() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SSM.StartSession() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSSM_TerminateSession(t *testing.T) {
+	tests := []struct {
+		name      string
+		service   ssmiface.SSMAPI
+		sessionId string
+		wantErr   bool
+	}{
+		{
+			name:      "success case",
+			service:   newMockSSMClient(t, nil),
+			sessionId: "session-123",
+		},
+		{
+			name:    "missing session id",
+			service: newMockSSMClient(t, nil),
+			wantErr: true,
+		},
+		{
+			name:      "aws error",
+			service:   newMockSSMClient(t, awserr.New("Bad Request", "boom.", nil)),
+			sessionId: "session-123",
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &SSM{Service: tt.service}
+			if err := s.TerminateSession(context.TODO(), tt.sessionId); (err != nil) != tt.wantErr {
+				t.Errorf("SSM.TerminateSession() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSSM_GetSession(t *testing.T) {
+	tests := []struct {
+		name      string
+		service   ssmiface.SSMAPI
+		sessionId string
+		want      *ssm.Session
+		wantErr   bool
+	}{
+		{
+			name:      "success case",
+			service:   newMockSSMClient(t, nil),
+			sessionId: "session-123",
+			want: &ssm.Session{
+				SessionId: aws.String("session-123"),
+				Target:    aws.String("i-123"),
+				Status:    aws.String(ssm.SessionStatusConnected),
+			},
+		},
+		{
+			name:    "missing session id",
+			service: newMockSSMClient(t, nil),
+			wantErr: true,
+		},
+		{
+			name:      "not found",
+			service:   newMockSSMClient(t, nil),
+			sessionId: "session-456",
+			wantErr:   true,
+		},
+		{
+			name:      "aws error",
+			service:   newMockSSMClient(t, awserr.New("Bad Request", "boom.", nil)),
+			sessionId: "session-123",
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &SSM{Service: tt.service}
+			got, err := s.GetSession(context.TODO(), tt.sessionId)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SSM.GetSession() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SSM.GetSession() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}